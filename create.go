@@ -51,6 +51,14 @@ command(s) that get executed on start, edit the args parameter of the spec. See
 			Name:  "no-new-keyring",
 			Usage: "do not create a new session keyring for the container.  This will cause the container to inherit the calling processes session key",
 		},
+		cli.StringSliceFlag{
+			Name:  "netdev",
+			Usage: "add or override a network device, given as comma separated KEY=VALUE pairs (e.g. name=eth1,type=sriov,parent=ens1f0,ip=10.0.0.2/24)",
+		},
+		cli.BoolFlag{
+			Name:  "netdev-seccomp",
+			Usage: "allow the syscalls needed to manage network devices in the seccomp profile, if the container has network devices or CAP_NET_ADMIN",
+		},
 		cli.IntFlag{
 			Name:  "preserve-fds",
 			Usage: "Pass N additional file descriptors to the container (stdio + $LISTEN_FDS + N in total)",
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
@@ -28,6 +29,7 @@ information is displayed once every 5 seconds.`,
 	Flags: []cli.Flag{
 		cli.DurationFlag{Name: "interval", Value: 5 * time.Second, Usage: "set the stats collection interval"},
 		cli.BoolFlag{Name: "stats", Usage: "display the container's stats then exit"},
+		cli.StringSliceFlag{Name: "filter", Usage: "only display the events of the given types (mtu, network_monitor, oom, quota, stats), can be repeated or comma separated"},
 	},
 	Action: func(context *cli.Context) error {
 		if err := checkArgs(context, 1, exactArgs); err != nil {
@@ -41,6 +43,13 @@ information is displayed once every 5 seconds.`,
 		if duration <= 0 {
 			return errors.New("duration interval must be greater than 0")
 		}
+		filter, err := parseEventFilter(context.StringSlice("filter"))
+		if err != nil {
+			return err
+		}
+		if context.Bool("stats") && !filter["stats"] {
+			return errors.New("--stats can not be used with a filter excluding stats events")
+		}
 		status, err := container.Status()
 		if err != nil {
 			return err
@@ -73,16 +82,75 @@ information is displayed once every 5 seconds.`,
 			group.Wait()
 			return nil
 		}
-		go func() {
-			for range time.Tick(context.Duration("interval")) {
-				s, err := container.Stats()
-				if err != nil {
-					logrus.Error(err)
-					continue
+		// Stats are not collected at all when filtered out, as collecting
+		// them is the costly part.
+		if filter["stats"] {
+			go func() {
+				for range time.Tick(context.Duration("interval")) {
+					s, err := container.Stats()
+					if err != nil {
+						logrus.Error(err)
+						continue
+					}
+					stats <- s
 				}
-				stats <- s
+			}()
+		}
+		// The MTU mismatches are only checked once, as the network of the
+		// container is set up before it starts. Checking them requires
+		// entering the container network namespace, which unprivileged
+		// callers can not do, so the other events are still reported.
+		if filter["mtu"] {
+			mismatches, err := container.CheckNetworkMTU()
+			if err != nil {
+				logrus.Warnf("unable to check the network MTU: %v", err)
 			}
-		}()
+			for _, m := range mismatches {
+				events <- &types.Event{Type: "mtu", ID: container.ID(), Data: m}
+			}
+		}
+		// The network monitors record their status and the exceeded quotas
+		// in the container state directory, which is polled for changes.
+		var monitorTick <-chan time.Time
+		monitors := map[string]libcontainer.NetworkMonitorStatus{}
+		reportMonitors := func() {
+			current, err := container.NetworkMonitors()
+			if err != nil {
+				logrus.Error(err)
+				return
+			}
+			for _, m := range current {
+				if old, ok := monitors[m.Name]; !ok || old.Suspended != m.Suspended || !old.Since.Equal(m.Since) {
+					events <- &types.Event{Type: "network_monitor", ID: container.ID(), Data: m}
+				}
+				monitors[m.Name] = m
+			}
+		}
+		quotas := map[string]bool{}
+		reportQuotas := func() {
+			exceeded, err := container.NetworkQuotasExceeded()
+			if err != nil {
+				logrus.Error(err)
+				return
+			}
+			for _, e := range exceeded {
+				if !quotas[e.Interface] {
+					events <- &types.Event{Type: "quota", ID: container.ID(), Data: e}
+					quotas[e.Interface] = true
+				}
+			}
+		}
+		if filter["network_monitor"] || filter["quota"] {
+			monitorTick = time.Tick(context.Duration("interval"))
+		}
+		if filter["network_monitor"] {
+			reportMonitors()
+		}
+		if filter["quota"] {
+			reportQuotas()
+		}
+		// The OOM notifications are still needed when filtered out, as
+		// their channel is closed when the container stops.
 		n, err := container.NotifyOOM()
 		if err != nil {
 			return err
@@ -90,16 +158,22 @@ information is displayed once every 5 seconds.`,
 		for {
 			select {
 			case _, ok := <-n:
-				if ok {
-					// this means an oom event was received, if it is !ok then
-					// the channel was closed because the container stopped and
-					// the cgroups no longer exist.
-					events <- &types.Event{Type: "oom", ID: container.ID()}
-				} else {
+				if !ok {
+					// The channel was closed because the container stopped
+					// and the cgroups no longer exist.
 					n = nil
+				} else if filter["oom"] {
+					events <- &types.Event{Type: "oom", ID: container.ID()}
 				}
 			case s := <-stats:
 				events <- &types.Event{Type: "stats", ID: container.ID(), Data: convertLibcontainerStats(s)}
+			case <-monitorTick:
+				if filter["network_monitor"] {
+					reportMonitors()
+				}
+				if filter["quota"] {
+					reportQuotas()
+				}
 			}
 			if n == nil {
 				close(events)
@@ -111,6 +185,28 @@ information is displayed once every 5 seconds.`,
 	},
 }
 
+// eventTypes are the types of the events reported by the events command.
+var eventTypes = map[string]bool{"mtu": true, "network_monitor": true, "oom": true, "quota": true, "stats": true}
+
+// parseEventFilter returns the set of event types to report, given the
+// values of the --filter option. All types are reported if there are none.
+func parseEventFilter(values []string) (map[string]bool, error) {
+	filter := make(map[string]bool, len(eventTypes))
+	for _, v := range values {
+		for _, t := range strings.Split(v, ",") {
+			t = strings.TrimSpace(t)
+			if !eventTypes[t] {
+				return nil, fmt.Errorf("invalid event type %q in filter", t)
+			}
+			filter[t] = true
+		}
+	}
+	if len(filter) == 0 {
+		return eventTypes, nil
+	}
+	return filter, nil
+}
+
 func convertLibcontainerStats(ls *libcontainer.Stats) *types.Stats {
 	cg := ls.CgroupStats
 	if cg == nil {
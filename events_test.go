@@ -0,0 +1,33 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseEventFilter(t *testing.T) {
+	filter, err := parseEventFilter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !filter["mtu"] || !filter["network_monitor"] || !filter["oom"] || !filter["quota"] || !filter["stats"] {
+		t.Errorf("expected all event types without filter, got %v", filter)
+	}
+	filter, err = parseEventFilter([]string{"oom"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(filter, map[string]bool{"oom": true}) {
+		t.Errorf("expected only oom events, got %v", filter)
+	}
+	filter, err = parseEventFilter([]string{"stats, oom"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filter) != 2 {
+		t.Errorf("expected comma separated types, got %v", filter)
+	}
+	if _, err := parseEventFilter([]string{"oom,cpu"}); err == nil {
+		t.Error("expected error for an unknown event type")
+	}
+}
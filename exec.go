@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"os/exec"
 	"strconv"
 	"strings"
 
@@ -99,6 +100,10 @@ following will output a list of processes running in the container:
 			Name:  "ignore-paused",
 			Usage: "allow exec in a paused container",
 		},
+		cli.BoolFlag{
+			Name:  "netns-only",
+			Usage: "run a host binary joined only to the container's network namespace",
+		},
 	},
 	Action: func(context *cli.Context) error {
 		if err := checkArgs(context, 1, minArgs); err != nil {
@@ -162,6 +167,15 @@ func execProcess(context *cli.Context) (int, error) {
 	if path == "" && len(context.Args()) == 1 {
 		return -1, errors.New("process args cannot be empty")
 	}
+	if context.Bool("netns-only") {
+		// The host binary is not a container process.
+		for _, name := range netnsOnlyConflicts {
+			if context.IsSet(name) {
+				return -1, fmt.Errorf("--netns-only can't be used with --%s", name)
+			}
+		}
+		return execInNetNS(context, container)
+	}
 	state, err := container.State()
 	if err != nil {
 		return -1, err
@@ -196,6 +210,44 @@ func execProcess(context *cli.Context) (int, error) {
 	return r.run(p)
 }
 
+// netnsOnlyConflicts are the options configuring the container process,
+// which can not be used with --netns-only.
+var netnsOnlyConflicts = []string{
+	"process", "console-socket", "pidfd-socket", "cwd", "tty", "user", "additional-gids",
+	"process-label", "apparmor", "no-new-privs", "cap", "preserve-fds", "cgroup",
+}
+
+// execInNetNS runs a host binary that only joins the network namespace of
+// the container, and returns its exit status.
+func execInNetNS(context *cli.Context, container *libcontainer.Container) (int, error) {
+	args := context.Args()[1:]
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Env = append(os.Environ(), context.StringSlice("env")...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := container.StartInNetNS(cmd); err != nil {
+		return -1, err
+	}
+	if pidFile := context.String("pid-file"); pidFile != "" {
+		if err := writePidFile(pidFile, cmd.Process.Pid); err != nil {
+			_ = cmd.Process.Kill()
+			_ = cmd.Wait()
+			return -1, err
+		}
+	}
+	if context.Bool("detach") {
+		return 0, nil
+	}
+	if err := cmd.Wait(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return -1, err
+		}
+	}
+	return cmd.ProcessState.ExitCode(), nil
+}
+
 func getProcess(context *cli.Context, bundle string) (*specs.Process, error) {
 	if path := context.String("process"); path != "" {
 		f, err := os.Open(path)
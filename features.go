@@ -3,7 +3,10 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"runtime/debug"
+	"strings"
 
+	"github.com/opencontainers/runc/libcontainer"
 	"github.com/opencontainers/runc/libcontainer/capabilities"
 	"github.com/opencontainers/runc/libcontainer/configs"
 	"github.com/opencontainers/runc/libcontainer/seccomp"
@@ -36,6 +39,7 @@ var featuresCommand = cli.Command{
 				runcfeatures.AnnotationRuncVersion:           version,
 				runcfeatures.AnnotationRuncCommit:            gitCommit,
 				runcfeatures.AnnotationRuncCheckpointEnabled: "true",
+				runcfeatures.AnnotationNetworkTypes:          strings.Join(libcontainer.KnownNetworkTypes(), ","),
 			},
 			Hooks:        configs.KnownHookNames(),
 			MountOptions: specconv.KnownMountOptions(),
@@ -79,8 +83,33 @@ var featuresCommand = cli.Command{
 			feat.Annotations[runcfeatures.AnnotationLibseccompVersion] = fmt.Sprintf("%d.%d.%d", major, minor, patch)
 		}
 
+		if v := moduleVersion("github.com/vishvananda/netlink"); v != "" {
+			feat.Annotations[runcfeatures.AnnotationNetlinkVersion] = v
+		}
+		if types, err := libcontainer.SupportedNetworkTypes(); err == nil {
+			feat.Annotations[runcfeatures.AnnotationNetworkSupportedTypes] = strings.Join(types, ",")
+		}
+
 		enc := json.NewEncoder(context.App.Writer)
 		enc.SetIndent("", "    ")
 		return enc.Encode(feat)
 	},
 }
+
+// moduleVersion returns the version of the module path runc is built with, or
+// an empty string if unknown.
+func moduleVersion(path string) string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == path {
+			if dep.Replace != nil {
+				return dep.Replace.Version
+			}
+			return dep.Version
+		}
+	}
+	return ""
+}
@@ -0,0 +1,157 @@
+// Package nettest provides helpers to exercise network code against
+// throwaway network namespaces. The helpers require root, and skip the
+// calling test otherwise.
+package nettest
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// NS is a throwaway network namespace, which is kept alive until the test
+// that created it finishes.
+type NS struct {
+	// Path is the path to the namespace, which can be opened and passed to
+	// setns(2) for as long as the test runs.
+	Path string
+}
+
+// NewNS creates a new network namespace, with its loopback interface up.
+func NewNS(t testing.TB) *NS {
+	t.Helper()
+	if os.Geteuid() != 0 {
+		t.Skip("creating network namespaces requires root")
+	}
+	pathCh := make(chan string)
+	errCh := make(chan error)
+	done := make(chan struct{})
+	go func() {
+		// Never unlocked, the thread is terminated when the test ends.
+		runtime.LockOSThread()
+		if err := unix.Unshare(unix.CLONE_NEWNET); err != nil {
+			errCh <- err
+			return
+		}
+		pathCh <- fmt.Sprintf("/proc/%d/task/%d/ns/net", os.Getpid(), unix.Gettid())
+		<-done
+	}()
+	t.Cleanup(func() { close(done) })
+
+	var ns *NS
+	select {
+	case path := <-pathCh:
+		ns = &NS{Path: path}
+	case err := <-errCh:
+		t.Fatalf("unable to create network namespace: %v", err)
+	}
+	ns.Do(t, func() error {
+		return netlink.LinkSetUp(&netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: "lo"}})
+	})
+	return ns
+}
+
+// Run runs fn on a thread that joined the namespace, and returns its error.
+func (ns *NS) Run(fn func() error) error {
+	f, err := os.Open(ns.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	errCh := make(chan error, 1)
+	go func() {
+		// Never unlocked, so the thread is not reused by the runtime.
+		runtime.LockOSThread()
+		if err := unix.Setns(int(f.Fd()), unix.CLONE_NEWNET); err != nil {
+			errCh <- fmt.Errorf("setns %s: %w", ns.Path, err)
+			return
+		}
+		errCh <- fn()
+	}()
+	return <-errCh
+}
+
+// Do runs fn in the namespace, and fails the test if it returns an error.
+func (ns *NS) Do(t testing.TB, fn func() error) {
+	t.Helper()
+	if err := ns.Run(fn); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Fd opens the namespace, the file is closed when the test finishes.
+func (ns *NS) Fd(t testing.TB) int {
+	t.Helper()
+	f, err := os.Open(ns.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return int(f.Fd())
+}
+
+// AddVeth creates a veth pair in the namespace, and moves the peer to the
+// peerNS namespace if not nil. Both ends are left down.
+func (ns *NS) AddVeth(t testing.TB, name, peer string, peerNS *NS) {
+	t.Helper()
+	peerFd := -1
+	if peerNS != nil {
+		peerFd = peerNS.Fd(t)
+	}
+	ns.Do(t, func() error {
+		veth := &netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: name}, PeerName: peer}
+		if err := netlink.LinkAdd(veth); err != nil {
+			return fmt.Errorf("unable to create veth pair %s/%s: %w", name, peer, err)
+		}
+		if peerFd == -1 {
+			return nil
+		}
+		link, err := netlink.LinkByName(peer)
+		if err != nil {
+			return err
+		}
+		return netlink.LinkSetNsFd(link, peerFd)
+	})
+}
+
+// Link returns the link with the given name in the namespace, or nil if it
+// does not exist.
+func (ns *NS) Link(t testing.TB, name string) netlink.Link {
+	t.Helper()
+	var link netlink.Link
+	ns.Do(t, func() (err error) {
+		link, err = netlink.LinkByName(name)
+		var notFound netlink.LinkNotFoundError
+		if errors.As(err, &notFound) {
+			return nil
+		}
+		return err
+	})
+	return link
+}
+
+// Addrs returns the addresses of the link with the given name, in CIDR form.
+func (ns *NS) Addrs(t testing.TB, name string) []string {
+	t.Helper()
+	var addrs []string
+	ns.Do(t, func() error {
+		link, err := netlink.LinkByName(name)
+		if err != nil {
+			return err
+		}
+		list, err := netlink.AddrList(link, netlink.FAMILY_ALL)
+		if err != nil {
+			return err
+		}
+		for _, a := range list {
+			addrs = append(addrs, a.IPNet.String())
+		}
+		return nil
+	})
+	return addrs
+}
@@ -0,0 +1,109 @@
+package libcontainer
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+)
+
+// bondMiimon is the default link monitoring interval of bonds, in
+// milliseconds.
+const bondMiimon = 100
+
+// bond is a network strategy that creates a bond interface inside the
+// container, and enslaves the interfaces of other networks of the container
+// to it, so the container fails over between them without the NET_ADMIN
+// capability.
+type bond struct{}
+
+func (b *bond) create(ctx context.Context, n *network, nspid int) error {
+	// The bond is created by initialize, once its slaves are in the
+	// container.
+	return nil
+}
+
+func (b *bond) initialize(ctx context.Context, n *network) (retErr error) {
+	link, err := bondLink(&n.Network)
+	if err != nil {
+		return err
+	}
+	if n.Bond.Primary != "" {
+		// The kernel makes the interface the primary slave once enslaved.
+		primary, err := netlink.LinkByName(n.Bond.Primary)
+		if err != nil {
+			return err
+		}
+		link.Primary = primary.Attrs().Index
+	}
+	if err := netlink.LinkAdd(link); err != nil {
+		return fmt.Errorf("unable to create bond %s: %w", n.Name, err)
+	}
+	master, err := netlink.LinkByName(n.Name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if retErr != nil {
+			_ = netlink.LinkDel(master)
+		}
+	}()
+	// The bond brings its slaves up, but they must be down to be enslaved.
+	for _, name := range n.Bond.Slaves {
+		slave, err := netlink.LinkByName(name)
+		if err != nil {
+			return err
+		}
+		if err := netlink.LinkSetDown(slave); err != nil {
+			return err
+		}
+		if err := netlink.LinkSetMasterByIndex(slave, master.Attrs().Index); err != nil {
+			return fmt.Errorf("unable to enslave %s to bond %s: %w", name, n.Name, err)
+		}
+	}
+	return configureLink(master, &n.Network)
+}
+
+func (b *bond) attach(n *configs.Network) error {
+	return nil
+}
+
+func (b *bond) detach(n *configs.Network) error {
+	return nil
+}
+
+// bondLink returns the bond link of the bond network n.
+func bondLink(n *configs.Network) (*netlink.Bond, error) {
+	attrs := netlink.NewLinkAttrs()
+	attrs.Name = n.Name
+	attrs.MTU = n.Mtu
+	if n.TxQueueLen != 0 {
+		attrs.TxQLen = n.TxQueueLen
+	}
+	if n.MacAddress != "" {
+		mac, err := net.ParseMAC(n.MacAddress)
+		if err != nil {
+			return nil, err
+		}
+		attrs.HardwareAddr = mac
+	}
+	s := n.Bond
+	link := netlink.NewLinkBond(attrs)
+	link.Mode = netlink.StringToBondMode(s.Mode)
+	if link.Mode == netlink.BOND_MODE_UNKNOWN {
+		return nil, fmt.Errorf("invalid bonding mode %q", s.Mode)
+	}
+	link.Miimon = bondMiimon
+	if s.Miimon != 0 {
+		link.Miimon = int(s.Miimon)
+	}
+	if s.LACPRate != "" {
+		link.LacpRate = netlink.StringToBondLacpRate(s.LACPRate)
+	}
+	if s.XmitHashPolicy != "" {
+		link.XmitHashPolicy = netlink.StringToBondXmitHashPolicy(s.XmitHashPolicy)
+	}
+	return link, nil
+}
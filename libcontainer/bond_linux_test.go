@@ -0,0 +1,91 @@
+package libcontainer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/opencontainers/runc/internal/testutil/nettest"
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+func TestBond(t *testing.T) {
+	host := nettest.NewNS(t)
+	ctr := nettest.NewNS(t)
+	host.AddVeth(t, "rveth0", "eth0", ctr)
+	host.AddVeth(t, "rveth1", "eth1", ctr)
+	n := &network{Network: configs.Network{
+		Type:    "bond",
+		Name:    "bond0",
+		Address: "10.0.0.2/24",
+		Bond: &configs.BondSettings{
+			Mode:    configs.BondActiveBackup,
+			Slaves:  []string{"eth0", "eth1"},
+			Primary: "eth1",
+		},
+	}}
+	if err := ctr.Run(func() error { return (&bond{}).initialize(context.Background(), n) }); err != nil {
+		if errors.Is(err, unix.EOPNOTSUPP) {
+			t.Skip("bonding is not supported by the kernel")
+		}
+		t.Fatal(err)
+	}
+	link, ok := ctr.Link(t, "bond0").(*netlink.Bond)
+	if !ok {
+		t.Fatal("expected a bond interface in the container")
+	}
+	if link.Mode != netlink.BOND_MODE_ACTIVE_BACKUP || link.Miimon != bondMiimon {
+		t.Errorf("expected active-backup mode and miimon %d, got %v and %d", bondMiimon, link.Mode, link.Miimon)
+	}
+	if link.Primary != ctr.Link(t, "eth1").Attrs().Index {
+		t.Errorf("expected eth1 to be the primary slave, got index %d", link.Primary)
+	}
+	for _, name := range n.Bond.Slaves {
+		if master := ctr.Link(t, name).Attrs().MasterIndex; master != link.Index {
+			t.Errorf("expected %s to be enslaved to bond0, got master %d", name, master)
+		}
+	}
+	if addrs := ctr.Addrs(t, "bond0"); len(addrs) != 1 || addrs[0] != "10.0.0.2/24" {
+		t.Errorf("expected the address on the bond, got %v", addrs)
+	}
+
+	// The bond is deleted if a slave can not be enslaved.
+	n.Name = "bond1"
+	n.Bond.Primary = ""
+	n.Bond.Slaves = []string{"eth2"}
+	if err := ctr.Run(func() error { return (&bond{}).initialize(context.Background(), n) }); err == nil {
+		t.Fatal("expected error enslaving a missing interface")
+	}
+	if ctr.Link(t, "bond1") != nil {
+		t.Error("expected the bond to be deleted on failure")
+	}
+}
+
+func TestBondLink(t *testing.T) {
+	n := &configs.Network{
+		Type: "bond",
+		Name: "bond0",
+		Mtu:  9000,
+		Bond: &configs.BondSettings{
+			Mode:           configs.Bond8023AD,
+			Slaves:         []string{"eth0", "eth1"},
+			Miimon:         50,
+			LACPRate:       "fast",
+			XmitHashPolicy: "layer3+4",
+		},
+	}
+	link, err := bondLink(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link.Mode != netlink.BOND_MODE_802_3AD || link.Miimon != 50 || link.LacpRate != netlink.BOND_LACP_RATE_FAST ||
+		link.XmitHashPolicy != netlink.BOND_XMIT_HASH_POLICY_LAYER3_4 || link.MTU != 9000 || link.Primary != -1 {
+		t.Errorf("unexpected bond link %+v", link)
+	}
+	n.Bond.Mode = "round-robin"
+	if _, err := bondLink(n); err == nil {
+		t.Error("expected error for an unknown mode")
+	}
+}
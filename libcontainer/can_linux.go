@@ -0,0 +1,181 @@
+package libcontainer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+// vxcanInfoPeer is the VXCAN_INFO_PEER attribute, which holds the settings
+// of the peer interface like VETH_INFO_PEER.
+const vxcanInfoPeer = 1
+
+// canCtrlModes maps the controller mode names to the CAN_CTRLMODE_* flags.
+var canCtrlModes = map[string]uint32{
+	"loopback":        unix.CAN_CTRLMODE_LOOPBACK,
+	"listen-only":     unix.CAN_CTRLMODE_LISTENONLY,
+	"triple-sampling": unix.CAN_CTRLMODE_3_SAMPLES,
+	"one-shot":        unix.CAN_CTRLMODE_ONE_SHOT,
+	"berr-reporting":  unix.CAN_CTRLMODE_BERR_REPORTING,
+	"fd":              unix.CAN_CTRLMODE_FD,
+	"fd-non-iso":      unix.CAN_CTRLMODE_FD_NON_ISO,
+	"presume-ack":     unix.CAN_CTRLMODE_PRESUME_ACK,
+	"cc-len8-dlc":     unix.CAN_CTRLMODE_CC_LEN8_DLC,
+}
+
+// can is a network strategy that moves a SocketCAN interface of the host,
+// named by HostInterfaceName, to the container.
+type can struct{}
+
+func (c *can) create(ctx context.Context, n *network, nspid int) error {
+	link, err := netlink.LinkByName(n.HostInterfaceName)
+	if err != nil {
+		return fmt.Errorf("unable to find can interface %s: %w", n.HostInterfaceName, err)
+	}
+	ns, err := os.Open("/proc/" + strconv.Itoa(nspid) + "/ns/net")
+	if err != nil {
+		return err
+	}
+	defer ns.Close()
+	// The link is brought up by initialize, once the controller is set up.
+	return moveLink(link, int(ns.Fd()), &linkMove{
+		name:     n.Name,
+		mtu:      n.Mtu,
+		down:     n.DownBeforeMove,
+		altNames: n.AltNames,
+		raw:      n.RawLinkAttributes,
+	})
+}
+
+func (c *can) initialize(ctx context.Context, n *network) error {
+	return setupCANLink(&n.Network)
+}
+
+func (c *can) attach(n *configs.Network) error {
+	return nil
+}
+
+func (c *can) detach(n *configs.Network) error {
+	return nil
+}
+
+// vcan is a network strategy that creates a virtual SocketCAN interface in
+// the container.
+type vcan struct{}
+
+func (v *vcan) create(ctx context.Context, n *network, nspid int) error {
+	attrs := netlink.NewLinkAttrs()
+	attrs.Name = n.Name
+	attrs.Namespace = netlink.NsPid(nspid)
+	link := &netlink.GenericLink{LinkAttrs: attrs, LinkType: "vcan"}
+	if err := netlink.LinkAdd(link); err != nil {
+		return fmt.Errorf("unable to create vcan interface %s: %w", n.Name, err)
+	}
+	return nil
+}
+
+func (v *vcan) initialize(ctx context.Context, n *network) error {
+	return setupCANLink(&n.Network)
+}
+
+func (v *vcan) attach(n *configs.Network) error {
+	return nil
+}
+
+func (v *vcan) detach(n *configs.Network) error {
+	return nil
+}
+
+// vxcan is a network strategy that creates a SocketCAN tunnel, with the
+// HostInterfaceName end in the host and the Name end in the container.
+type vxcan struct{}
+
+func (v *vxcan) create(ctx context.Context, n *network, nspid int) error {
+	req := nl.NewNetlinkRequest(unix.RTM_NEWLINK, unix.NLM_F_CREATE|unix.NLM_F_EXCL|unix.NLM_F_ACK)
+	req.AddData(nl.NewIfInfomsg(unix.AF_UNSPEC))
+	req.AddData(nl.NewRtAttr(unix.IFLA_IFNAME, nl.ZeroTerminated(n.HostInterfaceName)))
+	linkInfo := nl.NewRtAttr(unix.IFLA_LINKINFO, nil)
+	linkInfo.AddRtAttr(nl.IFLA_INFO_KIND, nl.NonZeroTerminated("vxcan"))
+	data := linkInfo.AddRtAttr(nl.IFLA_INFO_DATA, nil)
+	peer := data.AddRtAttr(vxcanInfoPeer, nil)
+	nl.NewIfInfomsgChild(peer, unix.AF_UNSPEC)
+	peer.AddRtAttr(unix.IFLA_IFNAME, nl.ZeroTerminated(n.Name))
+	peer.AddRtAttr(unix.IFLA_NET_NS_PID, nl.Uint32Attr(uint32(nspid)))
+	req.AddData(linkInfo)
+	if _, err := req.Execute(unix.NETLINK_ROUTE, 0); err != nil {
+		return fmt.Errorf("unable to create vxcan tunnel %s: %w", n.HostInterfaceName, err)
+	}
+	host, err := netlink.LinkByName(n.HostInterfaceName)
+	if err != nil {
+		return err
+	}
+	return netlink.LinkSetUp(host)
+}
+
+func (v *vxcan) initialize(ctx context.Context, n *network) error {
+	return setupCANLink(&n.Network)
+}
+
+func (v *vxcan) attach(n *configs.Network) error {
+	return nil
+}
+
+func (v *vxcan) detach(n *configs.Network) error {
+	return nil
+}
+
+// setupCANLink configures the SocketCAN interface of n, which must be down,
+// and brings it up.
+func setupCANLink(n *configs.Network) error {
+	link, err := netlink.LinkByName(n.Name)
+	if err != nil {
+		return err
+	}
+	if n.CAN != nil {
+		req := nl.NewNetlinkRequest(unix.RTM_NEWLINK, unix.NLM_F_ACK)
+		msg := nl.NewIfInfomsg(unix.AF_UNSPEC)
+		msg.Index = int32(link.Attrs().Index)
+		req.AddData(msg)
+		req.AddData(canLinkInfo(n.CAN))
+		if _, err := req.Execute(unix.NETLINK_ROUTE, 0); err != nil {
+			return fmt.Errorf("unable to configure can interface %s: %w", n.Name, err)
+		}
+	}
+	return configureLink(link, n)
+}
+
+// canLinkInfo returns the IFLA_LINKINFO attribute setting the bit rate and
+// controller modes of a can interface.
+func canLinkInfo(s *configs.CANSettings) *nl.RtAttr {
+	linkInfo := nl.NewRtAttr(unix.IFLA_LINKINFO, nil)
+	linkInfo.AddRtAttr(nl.IFLA_INFO_KIND, nl.NonZeroTerminated("can"))
+	data := linkInfo.AddRtAttr(nl.IFLA_INFO_DATA, nil)
+	if s.Bitrate != 0 {
+		// struct can_bittiming, where the kernel computes the other
+		// fields from the bit rate when they are all zero.
+		bittiming := make([]byte, 8*4)
+		nl.NativeEndian().PutUint32(bittiming, s.Bitrate)
+		data.AddRtAttr(unix.IFLA_CAN_BITTIMING, bittiming)
+	}
+	if len(s.CtrlMode) > 0 {
+		// struct can_ctrlmode.
+		var mask, flags uint32
+		for mode, enabled := range s.CtrlMode {
+			mask |= canCtrlModes[mode]
+			if enabled {
+				flags |= canCtrlModes[mode]
+			}
+		}
+		ctrlmode := make([]byte, 8)
+		nl.NativeEndian().PutUint32(ctrlmode, mask)
+		nl.NativeEndian().PutUint32(ctrlmode[4:], flags)
+		data.AddRtAttr(unix.IFLA_CAN_CTRLMODE, ctrlmode)
+	}
+	return linkInfo
+}
@@ -0,0 +1,43 @@
+package libcontainer
+
+import (
+	"testing"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+func TestCANLinkInfo(t *testing.T) {
+	linkInfo := canLinkInfo(&configs.CANSettings{
+		Bitrate:  500000,
+		CtrlMode: map[string]bool{"fd": true, "listen-only": false},
+	})
+	attrs, err := nl.ParseRouteAttr(linkInfo.Serialize()[unix.SizeofRtAttr:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(attrs) != 2 || string(attrs[0].Value) != "can" {
+		t.Fatalf("unexpected link info %+v", attrs)
+	}
+	data, err := nl.ParseRouteAttr(attrs[1].Value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := map[uint16][]byte{}
+	for _, a := range data {
+		values[a.Attr.Type] = a.Value
+	}
+	bittiming := values[unix.IFLA_CAN_BITTIMING]
+	if len(bittiming) != 32 || nl.NativeEndian().Uint32(bittiming) != 500000 {
+		t.Errorf("unexpected bit timing %v", bittiming)
+	}
+	ctrlmode := values[unix.IFLA_CAN_CTRLMODE]
+	if len(ctrlmode) != 8 {
+		t.Fatalf("unexpected controller mode %v", ctrlmode)
+	}
+	mask, flags := nl.NativeEndian().Uint32(ctrlmode), nl.NativeEndian().Uint32(ctrlmode[4:])
+	if mask != unix.CAN_CTRLMODE_FD|unix.CAN_CTRLMODE_LISTENONLY || flags != unix.CAN_CTRLMODE_FD {
+		t.Errorf("unexpected controller mode mask %#x flags %#x", mask, flags)
+	}
+}
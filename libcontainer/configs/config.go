@@ -137,6 +137,14 @@ type Config struct {
 	// Routes can be specified to create entries in the route table as the container is started
 	Routes []*Route `json:"routes"`
 
+	// NetworkOptions specifies settings for the container's network namespace as a whole
+	NetworkOptions *NetworkOptions `json:"network_options,omitempty"`
+
+	// ActivationSockets are listening sockets created inside the container
+	// before the container process is started, and passed to it using the
+	// systemd socket activation protocol.
+	ActivationSockets []*ActivationSocket `json:"activation_sockets,omitempty"`
+
 	// Cgroups specifies specific cgroup settings for the various subsystems that the container is
 	// placed into to limit the resources the container has available
 	Cgroups *Cgroup `json:"cgroups"`
@@ -460,12 +468,17 @@ func (c Command) Run(s *specs.State) error {
 	if err != nil {
 		return err
 	}
+	return c.RunWithInput(b)
+}
+
+// RunWithInput executes the command with input as its standard input.
+func (c Command) RunWithInput(input []byte) error {
 	var stdout, stderr bytes.Buffer
 	cmd := exec.Cmd{
 		Path:   c.Path,
 		Args:   c.Args,
 		Env:    c.Env,
-		Stdin:  bytes.NewReader(b),
+		Stdin:  bytes.NewReader(input),
 		Stdout: &stdout,
 		Stderr: &stderr,
 	}
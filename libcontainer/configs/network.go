@@ -1,5 +1,10 @@
 package configs
 
+import (
+	"encoding/json"
+	"time"
+)
+
 // Network defines configuration for a container's networking stack
 //
 // The network configuration can be omitted from a container causing the
@@ -8,10 +13,14 @@ type Network struct {
 	// Type sets the networks type, commonly veth and loopback
 	Type string `json:"type"`
 
-	// Name of the network interface
+	// Name of the network interface in the container. If empty for a
+	// network with a host interface, a name derived from the host interface
+	// name and the container id is given when the container is created, so
+	// it does not collide with the names expected by the image, like eth0.
 	Name string `json:"name"`
 
-	// The bridge to use.
+	// Bridge is the bridge the host end of the pair is added to.
+	// Note: This only applies to veth networks.
 	Bridge string `json:"bridge"`
 
 	// MacAddress contains the MAC address to set on the network interface
@@ -29,6 +38,12 @@ type Network struct {
 	// IPv6Gateway sets the ipv6 gateway address that is used as the default for the interface
 	IPv6Gateway string `json:"ipv6_gateway"`
 
+	// GatewayMetric is the metric of the default routes through Gateway and
+	// IPv6Gateway, arbitrating between the default routes of several
+	// interfaces, the lowest being preferred. Activating a standby network
+	// only replaces the default routes with the same metric.
+	GatewayMetric int `json:"gateway_metric,omitempty"`
+
 	// Mtu sets the mtu value for the interface and will be mirrored on both the host and
 	// container's interfaces if a pair is created, specifically in the case of type veth
 	// Note: This does not apply to loopback interfaces.
@@ -43,11 +58,1245 @@ type Network struct {
 	// container.
 	HostInterfaceName string `json:"host_interface_name"`
 
+	// AltNames are alternative names given to the interface, which can be
+	// longer than regular interface names.
+	// Note: This only applies to interfaces moved from the host, such as can
+	// and sriov.
+	AltNames []string `json:"alt_names,omitempty"`
+
+	// DownBeforeMove sets the interface down before it is moved to the
+	// container, if it is up, for the drivers which do not cope with the
+	// move closing it. It is not needed otherwise, and only adds a round
+	// trip and a carrier change, so interfaces are moved as they are by
+	// default.
+	// Note: This only applies to interfaces moved from the host, such as can
+	// and sriov, wireless ones included, and to interfaces moved between
+	// containers with "runc netdev move".
+	DownBeforeMove bool `json:"down_before_move,omitempty"`
+
+	// Parent is the host interface the container interface is created on,
+	// for types creating child interfaces such as ipoib, or the physical
+	// function the virtual function of sriov networks is taken from.
+	Parent string `json:"parent,omitempty"`
+
 	// HairpinMode specifies if hairpin NAT should be enabled on the virtual interface
 	// bridge port in the case of type veth
 	// Note: This is unsupported on some systems.
-	// Note: This does not apply to loopback interfaces.
+	// Note: This only applies to veth networks with a bridge.
 	HairpinMode bool `json:"hairpin_mode"`
+
+	// CreateBridge creates Bridge, and brings it up, if it does not exist.
+	// The bridge is not removed with the container, as other containers
+	// may use it.
+	// Note: This only applies to veth networks with a bridge.
+	CreateBridge bool `json:"create_bridge,omitempty"`
+
+	// ApplyPolicy defines what happens when a tuning setting of the network,
+	// such as a sysctl, host shaping or gateway pinning, can not be applied.
+	// By default, the optional settings of the profile are skipped if they
+	// are not supported, and any other failure aborts the container creation.
+	ApplyPolicy ApplyPolicy `json:"apply_policy,omitempty"`
+
+	// AddressConflict defines what happens when Address or IPv6Address is
+	// already present on the interface, as when a network is attached
+	// again after a partial failure. It defaults to AddressConflictFail.
+	AddressConflict AddressConflictPolicy `json:"address_conflict,omitempty"`
+
+	// FlushOnDetach removes the addresses and routes of the interface in
+	// the container when the network is detached, before the device goes
+	// back to the host, so it does not bring container addresses that
+	// conflict with the host ones. Kernel assigned IPv6 link-local
+	// addresses are kept. Virtual interfaces, which are deleted when
+	// detached, are not flushed.
+	// Note: This does not apply to loopback interfaces.
+	FlushOnDetach bool `json:"flush_on_detach,omitempty"`
+
+	// PreUpHook is run in the container network namespace once the
+	// interface has been moved to the container and configured, right
+	// before it is brought up, with the network settings as JSON on its
+	// standard input. It can be used for steps that must happen at this
+	// exact point, such as vendor device tools. A failure of the hook fails
+	// the network setup.
+	// Note: This does not apply to loopback interfaces.
+	PreUpHook *Command `json:"pre_up_hook,omitempty"`
+
+	// Standby configures the interface in the container but leaves it down,
+	// without its gateway, static and container routes, so it can be
+	// activated later on with ActivateNetwork for a fast failover.
+	// Note: This does not apply to loopback interfaces.
+	Standby bool `json:"standby,omitempty"`
+
+	// Optional skips the network, instead of failing the container
+	// creation, if its device or parent device is missing or busy, as for
+	// an acceleration NIC the container can run without. Skipped networks
+	// are removed from the container configuration.
+	// Note: This does not apply to loopback interfaces.
+	Optional bool `json:"optional,omitempty"`
+
+	// Profile is the name of a set of settings applied to the network, one
+	// of "default", "lowlatency" or "router". The settings of the network
+	// take precedence over the ones of the profile.
+	Profile string `json:"profile,omitempty"`
+
+	// HostFirewallMark, if not zero, is set as the firewall mark of all the
+	// traffic coming from the container through the host side interface, so
+	// host level tc or policy rules can match it without knowing interface
+	// names. It is implemented with an nftables rule installed in the host.
+	// Note: This only applies to types with a host side interface, such as veth.
+	HostFirewallMark uint32 `json:"host_firewall_mark,omitempty"`
+
+	// HostShaping limits the bandwidth between the host and the container.
+	// It is enforced on the host side interface, so it can not be altered
+	// from inside the container.
+	// Note: This only applies to types with a host side interface, such as veth.
+	HostShaping *Shaping `json:"host_shaping,omitempty"`
+
+	// HostRoutes installs routes in the host to Address and IPv6Address
+	// through the host side interface, and enables proxy ARP on it, for
+	// routed setups where the container is reached without a bridge or NAT.
+	// The container then routes its traffic through any gateway, such as a
+	// link-local address, which the host answers for. The routes are
+	// removed with the container.
+	// Note: This only applies to veth networks.
+	HostRoutes bool `json:"host_routes,omitempty"`
+
+	// Isolate removes the interfaces found in the container network
+	// namespace, other than loopback, before the other networks are set up,
+	// so the container has a clean network view even when it joins an
+	// existing network namespace. It is one of IsolateDown or
+	// IsolateDelete, and the loopback network must be listed first.
+	// Note: This only applies to loopback interfaces.
+	Isolate IsolateMode `json:"isolate,omitempty"`
+
+	// PortForwards lists TCP ports of the container's loopback interface that
+	// are made reachable from the host's loopback interface.
+	// Note: This only applies to loopback interfaces.
+	PortForwards []*PortForward `json:"port_forwards,omitempty"`
+
+	// RPFilter sets the reverse path filtering mode of the interface: 0
+	// disables it, 1 enables strict mode and 2 enables loose mode. The kernel
+	// uses the maximum of this value and the namespace wide setting.
+	// If nil, the kernel default is kept.
+	RPFilter *int `json:"rp_filter,omitempty"`
+
+	// AcceptLocal allows accepting packets with a local source address on the
+	// interface, as needed for NAT to localhost and direct server return load
+	// balancers. If nil, the kernel default is kept.
+	AcceptLocal *bool `json:"accept_local,omitempty"`
+
+	// RouteLocalnet allows routing packets with 127.0.0.0/8 source or
+	// destination addresses through the interface. If nil, the kernel default
+	// is kept.
+	RouteLocalnet *bool `json:"route_localnet,omitempty"`
+
+	// AutoIPv4LinkLocal assigns an IPv4 link-local address (169.254.0.0/16)
+	// to the interface, selected as described in RFC 3927 so it does not
+	// conflict with other hosts on the link. It can not be used together
+	// with Address. Note that conflict detection delays the container start
+	// by several seconds.
+	// Note: This only applies to ethernet interfaces.
+	AutoIPv4LinkLocal bool `json:"auto_ipv4_link_local,omitempty"`
+
+	// Unnumbered sets the interface up with its IPv6 link-local address
+	// only, for routing daemons peering over unnumbered links, as with BGP
+	// unnumbered. IPv6 is enabled on the interface, and router
+	// advertisements are accepted even if forwarding is enabled, so the
+	// link-local next hop of the peer and the default route are learned
+	// from them, but no global address is configured from their prefixes.
+	// Note: This does not apply to loopback interfaces.
+	Unnumbered bool `json:"unnumbered,omitempty"`
+
+	// DropRouterAdvertisements drops the IPv6 router advertisements received
+	// on the interface, so a rogue router on a shared link can not configure
+	// addresses or routes in the container, whatever the accept_ra sysctls.
+	// It is implemented with an nftables rule on the ingress of the
+	// interface, installed in the container network namespace.
+	// Note: This does not apply to loopback interfaces.
+	DropRouterAdvertisements bool `json:"drop_router_advertisements,omitempty"`
+
+	// Quota limits the traffic of the interface, applying its action once
+	// exceeded. It is implemented with an nftables quota object counting
+	// the bytes received, sent and forwarded through the interface, and a
+	// counter object counting its packets, installed in the container
+	// network namespace.
+	// Note: This does not apply to loopback interfaces.
+	Quota *InterfaceQuota `json:"quota,omitempty"`
+
+	// PrefixDelegation requests an IPv6 prefix on the interface with
+	// DHCPv6 prefix delegation (RFC 8415) once it is up, and assigns a
+	// sub-prefix of it to another interface of the container, for
+	// containers routing a downstream network.
+	// Note: This does not apply to loopback interfaces.
+	PrefixDelegation *PrefixDelegationSettings `json:"prefix_delegation,omitempty"`
+
+	// PinGateway installs permanent neighbor entries for Gateway and
+	// IPv6Gateway on the interface, so the container traffic is not
+	// disrupted by ARP or NDP storms, or gateway flaps. The gateway MAC
+	// address is resolved when the interface is set up, unless
+	// GatewayMacAddress is set.
+	// Note: This does not apply to loopback interfaces.
+	PinGateway bool `json:"pin_gateway,omitempty"`
+
+	// GatewayMacAddress is the MAC address of the gateways pinned by
+	// PinGateway.
+	GatewayMacAddress string `json:"gateway_mac_address,omitempty"`
+
+	// Neighbors are known peers added to the neighbor table of the
+	// interface when it is set up, so the first packets sent to them do not
+	// wait for ARP or NDP resolution. The entries are added as stale, so the
+	// kernel uses them right away but still confirms them, and replaces them
+	// if a peer changes.
+	// Note: This does not apply to loopback interfaces.
+	Neighbors []*Neighbor `json:"neighbors,omitempty"`
+
+	// StaticRoutes are added through the interface once it has its
+	// addresses, so destinations beyond its subnet are reachable without a
+	// hook. The routes of a standby network are added when it is activated.
+	// Note: This does not apply to loopback interfaces.
+	StaticRoutes []*NetworkRoute `json:"static_routes,omitempty"`
+
+	// TrafficFilters are tc filters installed on the interface, inside the
+	// container network namespace, for simple dataplane policies such as
+	// dropping, rate limiting or redirecting some of its traffic without a
+	// CNI chain. The filters of a direction are evaluated in the order
+	// listed, the first matching one applying its action.
+	// Note: This does not apply to loopback interfaces.
+	TrafficFilters []*TrafficFilter `json:"traffic_filters,omitempty"`
+
+	// RawLinkAttributes are added as is to the netlink request moving the
+	// interface to the container, for driver attributes runc does not model.
+	// Attributes runc sets itself, such as the name or the target namespace,
+	// are rejected. This requires the Experimental network option.
+	RawLinkAttributes []*LinkAttribute `json:"raw_link_attributes,omitempty"`
+
+	// CAN configures the controller of a SocketCAN interface moved to the
+	// container.
+	// Note: This only applies to can networks.
+	CAN *CANSettings `json:"can,omitempty"`
+
+	// IPoIB configures the IP over InfiniBand child interface created on
+	// Parent.
+	// Note: This only applies to ipoib networks.
+	IPoIB *IPoIBSettings `json:"ipoib,omitempty"`
+
+	// SRIOV configures how the virtual function moved to the container is
+	// taken from its physical function, which is Parent unless a PCI
+	// address is given.
+	// Note: This only applies to sriov networks.
+	SRIOV *SRIOVSettings `json:"sriov,omitempty"`
+
+	// RDMA limits the resources of the RDMA device backing the interface in
+	// the container cgroup, so they are bounded consistently with the
+	// device assignment. The RDMA device is found when the interface is
+	// created, and the limits are applied as the RDMA limits of the cgroup
+	// resources, which take precedence for the same device.
+	// Note: This only applies to sriov and ipoib networks.
+	RDMA *LinuxRdma `json:"rdma,omitempty"`
+
+	// VDPA configures the vDPA device created for the container, whose
+	// virtio interface is moved to the container.
+	// Note: This only applies to vdpa networks.
+	VDPA *VDPASettings `json:"vdpa,omitempty"`
+
+	// Tunnel configures the endpoints of the tunnel interface created in
+	// the container.
+	// Note: This only applies to ipip, sit, gre and gretap networks.
+	Tunnel *TunnelSettings `json:"tunnel,omitempty"`
+
+	// L2TP configures the L2TPv3 tunnel and session whose ethernet
+	// pseudowire interface is moved to the container.
+	// Note: This only applies to l2tpeth networks.
+	L2TP *L2TPSettings `json:"l2tp,omitempty"`
+
+	// Macvlan configures how the macvlan or macvtap interface created on
+	// Parent shares it.
+	// Note: This only applies to macvlan and macvtap networks.
+	Macvlan *MacvlanSettings `json:"macvlan,omitempty"`
+
+	// Ipvlan configures how the ipvlan interface created on Parent shares
+	// it.
+	// Note: This only applies to ipvlan networks.
+	Ipvlan *IpvlanSettings `json:"ipvlan,omitempty"`
+
+	// VLAN configures the 802.1Q subinterface created on Parent.
+	// Note: This only applies to vlan networks.
+	VLAN *VLANSettings `json:"vlan,omitempty"`
+
+	// Tap configures the tap device created in the container.
+	// Note: This only applies to tap networks.
+	Tap *TapSettings `json:"tap,omitempty"`
+
+	// WireGuard configures the keys and peers of the WireGuard interface
+	// moved to the container.
+	// Note: This only applies to wireguard networks.
+	WireGuard *WireGuardSettings `json:"wireguard,omitempty"`
+
+	// VXLAN configures the VXLAN interface moved to the container. Parent,
+	// if set, is the host interface the tunnel traffic is sent through.
+	// Note: This only applies to vxlan networks.
+	VXLAN *VXLANSettings `json:"vxlan,omitempty"`
+
+	// Geneve configures the Geneve interface moved to the container.
+	// Note: This only applies to geneve networks.
+	Geneve *GeneveSettings `json:"geneve,omitempty"`
+
+	// Bond configures the bond interface created in the container, and the
+	// interfaces of the other networks of the container enslaved to it.
+	// Note: This only applies to bond networks.
+	Bond *BondSettings `json:"bond,omitempty"`
+
+	// Team configures the team interface created in the container, and the
+	// interfaces of the other networks of the container added to it as
+	// ports. It is an alternative to Bond for the hosts standardized on the
+	// team driver.
+	// Note: This only applies to team networks.
+	Team *TeamSettings `json:"team,omitempty"`
+
+	// LLDP announces the container on the link of the interface with LLDP
+	// frames, so the fabric tooling can map switch ports to workloads. The
+	// frames are sent by "runc netdev lldp", or by "runc run" while it is
+	// attached to the container: they are not sent for a container started
+	// with "runc create" or "runc run --detach" unless "runc netdev lldp"
+	// runs.
+	// Note: This only applies to the networks giving the container a
+	// physical link: sriov networks and passthru macvlan networks.
+	LLDP *LLDPSettings `json:"lldp,omitempty"`
+
+	// MACsec configures the MACsec interface created in the container, and
+	// its secure associations.
+	// Note: This only applies to macsec networks.
+	MACsec *MACsecSettings `json:"macsec,omitempty"`
+
+	// Plugin is the external program creating the interface of the network
+	// in the container.
+	// Note: This only applies to plugin networks.
+	Plugin *PluginSettings `json:"plugin,omitempty"`
+}
+
+// ApplyPolicy is the policy applied when a network setting fails.
+type ApplyPolicy string
+
+const (
+	// ApplyPolicyStrict fails on any setting which can not be applied,
+	// including the optional settings of the network profile.
+	ApplyPolicyStrict ApplyPolicy = "strict"
+	// ApplyPolicyBestEffort skips the settings which can not be applied,
+	// and reports them.
+	ApplyPolicyBestEffort ApplyPolicy = "best-effort"
+)
+
+// AddressConflictPolicy is the policy applied when an address to add to an
+// interface is already present.
+type AddressConflictPolicy string
+
+const (
+	// AddressConflictFail fails the network setup.
+	AddressConflictFail AddressConflictPolicy = "fail"
+	// AddressConflictIgnore keeps the existing address as is.
+	AddressConflictIgnore AddressConflictPolicy = "ignore"
+	// AddressConflictReplace replaces the existing address, resetting its
+	// flags and lifetimes.
+	AddressConflictReplace AddressConflictPolicy = "replace"
+)
+
+// IsolateMode is how the interfaces found in the network namespace of a
+// container are removed.
+type IsolateMode string
+
+const (
+	// IsolateDown brings the interfaces down, and flushes their addresses
+	// and routes.
+	IsolateDown IsolateMode = "down"
+	// IsolateDelete deletes the interfaces. The interfaces which can not be
+	// deleted, such as physical devices, are brought down and flushed.
+	IsolateDelete IsolateMode = "delete"
+)
+
+// LinkAttribute is a raw netlink route attribute of a link (IFLA_*).
+type LinkAttribute struct {
+	// Type is the attribute type, including the NLA_F_NESTED flag for
+	// nested attributes.
+	Type uint16 `json:"type"`
+
+	// Value is the attribute payload, in host byte order for integers. It
+	// is base64 encoded in JSON.
+	Value []byte `json:"value,omitempty"`
+}
+
+// Shaping defines bandwidth limits for a network. Rates are given in bytes
+// per second and bursts in bytes. A zero rate means no limit.
+type Shaping struct {
+	// IngressRate limits the traffic received by the container.
+	IngressRate uint64 `json:"ingress_rate,omitempty"`
+
+	// IngressBurst is the amount of traffic which can be received at once
+	// above IngressRate.
+	IngressBurst uint32 `json:"ingress_burst,omitempty"`
+
+	// EgressRate limits the traffic sent by the container.
+	EgressRate uint64 `json:"egress_rate,omitempty"`
+
+	// EgressBurst is the amount of traffic which can be sent at once above
+	// EgressRate.
+	EgressBurst uint32 `json:"egress_burst,omitempty"`
+}
+
+// Neighbor is a peer reachable on the link of a network interface.
+type Neighbor struct {
+	// IP is the IPv4 or IPv6 address of the peer.
+	IP string `json:"ip"`
+
+	// MacAddress is the MAC address of the peer.
+	MacAddress string `json:"mac_address"`
+}
+
+// InterfaceQuota defines the traffic quota of an interface.
+type InterfaceQuota struct {
+	// Bytes is the amount of traffic allowed through the interface.
+	Bytes uint64 `json:"bytes,omitempty"`
+
+	// Packets is the number of packets allowed through the interface. As
+	// nftables has no quota of packets, the action is applied once runc
+	// run or runc netdev quota finds it exceeded, like QuotaLinkDown.
+	Packets uint64 `json:"packets,omitempty"`
+
+	// Action is applied once the quota is exceeded, QuotaDropNew by
+	// default.
+	Action QuotaAction `json:"action,omitempty"`
+}
+
+// QuotaAction is the action applied to an interface once its quota is
+// exceeded. Exceeded quotas are reported as events once found by runc run
+// or runc netdev quota, which also apply QuotaLinkDown, the other actions
+// being applied by the kernel. As runc run only checks the quotas while it
+// is attached to the container, a container started with runc create or
+// runc run --detach needs runc netdev quota for those.
+type QuotaAction string
+
+const (
+	// QuotaDropNew drops the packets of new connections, letting the
+	// established ones go on.
+	QuotaDropNew QuotaAction = "drop-new"
+	// QuotaDrop drops all the traffic of the interface.
+	QuotaDrop QuotaAction = "drop"
+	// QuotaLinkDown brings the interface down.
+	QuotaLinkDown QuotaAction = "link-down"
+	// QuotaEvent only reports the quota as exceeded.
+	QuotaEvent QuotaAction = "event"
+)
+
+// NetworkRoute is a route through the interface of a network.
+type NetworkRoute struct {
+	// Destination is the destination IP address and mask in the CIDR form.
+	Destination string `json:"destination"`
+
+	// Gateway is the next hop, of the same family as Destination. If
+	// empty, the destination is reachable directly on the link.
+	Gateway string `json:"gateway,omitempty"`
+
+	// Metric is the priority of the route, the lowest being preferred.
+	Metric int `json:"metric,omitempty"`
+
+	// Scope is the scope of the route, "universe", "link" or "host". It
+	// defaults to "link" for routes without a gateway, as in "ip route",
+	// and to "universe" otherwise.
+	Scope string `json:"scope,omitempty"`
+
+	// Table is the routing table the route is added to, the main table by
+	// default. Routes in other tables are only used for the packets
+	// selected by the routing rules of the network options, for example to
+	// separate the management and data plane traffic of a container.
+	Table int `json:"table,omitempty"`
+}
+
+// TrafficFilter is a tc u32 or flower filter of an interface, applying its
+// action to the packets matching all of its match fields. A filter without
+// match fields matches all packets.
+type TrafficFilter struct {
+	// Direction is the traffic filtered, FilterIngress by default.
+	Direction FilterDirection `json:"direction,omitempty"`
+
+	// Classifier is the tc classifier matching the packets, FilterU32 by
+	// default.
+	Classifier FilterClassifier `json:"classifier,omitempty"`
+
+	// Source matches the source address of IPv4 or IPv6 packets, in the
+	// CIDR form.
+	Source string `json:"source,omitempty"`
+
+	// Destination matches the destination address of IPv4 or IPv6 packets,
+	// in the CIDR form.
+	Destination string `json:"destination,omitempty"`
+
+	// Protocol matches the IP protocol of the packets, one of "tcp", "udp",
+	// "sctp", "icmp" or "icmpv6".
+	Protocol string `json:"protocol,omitempty"`
+
+	// SourcePort matches the source port of tcp, udp or sctp packets.
+	// The u32 classifier matches ports at a fixed offset, so IPv4 packets
+	// with options and IPv6 packets with extension headers are not matched.
+	SourcePort uint16 `json:"source_port,omitempty"`
+
+	// DestinationPort matches the destination port of tcp, udp or sctp
+	// packets, as SourcePort.
+	DestinationPort uint16 `json:"destination_port,omitempty"`
+
+	// Action is applied to the matching packets.
+	Action FilterAction `json:"action"`
+
+	// Rate limits the matching traffic in bytes per second, for the police
+	// action.
+	Rate uint32 `json:"rate,omitempty"`
+
+	// Burst is the amount of matching traffic which can go through at once
+	// above Rate, for the police action.
+	Burst uint32 `json:"burst,omitempty"`
+
+	// Target is the container interface the matching packets are sent
+	// through, for the mirred action. It must be the interface of a network
+	// listed before.
+	Target string `json:"target,omitempty"`
+}
+
+// FilterDirection is the traffic of an interface a traffic filter applies
+// to.
+type FilterDirection string
+
+const (
+	// FilterIngress filters the packets received by the interface.
+	FilterIngress FilterDirection = "ingress"
+	// FilterEgress filters the packets sent by the interface.
+	FilterEgress FilterDirection = "egress"
+)
+
+// FilterClassifier is the tc classifier of a traffic filter.
+type FilterClassifier string
+
+const (
+	// FilterU32 matches the fields of the packets at fixed offsets.
+	FilterU32 FilterClassifier = "u32"
+	// FilterFlower matches the fields of the packets as parsed by the
+	// kernel, so ports are found whatever the size of the network header.
+	// It requires the cls_flower kernel module.
+	FilterFlower FilterClassifier = "flower"
+)
+
+// FilterAction is the action a traffic filter applies to the matching
+// packets.
+type FilterAction string
+
+const (
+	// FilterDrop drops the packets.
+	FilterDrop FilterAction = "drop"
+	// FilterPolice drops the packets beyond the filter rate.
+	FilterPolice FilterAction = "police"
+	// FilterMirred redirects the packets to the egress of the filter
+	// target.
+	FilterMirred FilterAction = "mirred"
+)
+
+// CANSettings defines the controller settings of a SocketCAN interface.
+type CANSettings struct {
+	// Bitrate is the bus bit rate in bits per second, from which the kernel
+	// computes the bit timing. If zero, the bit timing is not changed.
+	Bitrate uint32 `json:"bitrate,omitempty"`
+
+	// CtrlMode enables (true) or disables (false) controller modes, named
+	// as in "ip link": "loopback", "listen-only", "triple-sampling",
+	// "one-shot", "berr-reporting", "fd", "fd-non-iso", "presume-ack" and
+	// "cc-len8-dlc". Modes which are not listed are not changed.
+	CtrlMode map[string]bool `json:"ctrlmode,omitempty"`
+}
+
+// IPoIBSettings defines an IP over InfiniBand child interface.
+type IPoIBSettings struct {
+	// Pkey is the partition key of the child interface. The full
+	// membership bit (0x8000) is set by the kernel.
+	Pkey uint16 `json:"pkey"`
+
+	// Mode is the IPoIB transport mode, "datagram" (the default) or
+	// "connected".
+	Mode string `json:"mode,omitempty"`
+
+	// Umcast allows user space multicast on the interface.
+	Umcast bool `json:"umcast,omitempty"`
+}
+
+// SRIOVSettings defines how virtual functions are managed for sriov networks.
+// The virtual functions given to containers are recorded in a registry shared
+// by the containers of the same root directory, and released when the
+// container is deleted.
+type SRIOVSettings struct {
+	// NumVFs is the number of virtual functions enabled on the physical
+	// function if none are. If zero, the virtual functions must have been
+	// enabled beforehand.
+	NumVFs int `json:"num_vfs,omitempty"`
+
+	// PCIAddress is the PCI address of the physical function, such as
+	// "0000:65:00.0", used instead of its name in Parent, which may not be
+	// stable across reboots.
+	PCIAddress string `json:"pci_address,omitempty"`
+
+	// Vlan is the VLAN ID the physical function sets on the frames of the
+	// virtual function, transparently to the container. If zero, the frames
+	// are not tagged.
+	Vlan int `json:"vlan,omitempty"`
+
+	// Qos is the 802.1p priority of the frames tagged with Vlan.
+	Qos int `json:"qos,omitempty"`
+
+	// Spoofchk enables or disables the checking by the physical function of
+	// the source address of the frames sent by the virtual function. If
+	// nil, the current setting is kept.
+	Spoofchk *bool `json:"spoofchk,omitempty"`
+
+	// Trust allows or denies the virtual function to change its address or
+	// enable promiscuous modes. If nil, the current setting is kept.
+	Trust *bool `json:"trust,omitempty"`
+
+	// AdminMAC sets MacAddress on the physical function, as the
+	// administrative address of the virtual function, which the container
+	// can not change unless the virtual function is trusted.
+	AdminMAC bool `json:"admin_mac,omitempty"`
+
+	// ClaimTTL, if not zero, makes the claim of the virtual function in the
+	// registry expire unless it is renewed within ClaimTTL, so a virtual
+	// function is freed even if the container is never deleted, as when its
+	// host or engine crashes. The claims of a running container are renewed
+	// by "runc netdev renew", or by "runc run" while it is attached to the
+	// container: the claims of a container started with "runc create" or
+	// "runc run --detach" expire unless "runc netdev renew" runs. A virtual
+	// function still in a container network namespace is never claimed
+	// again, even once expired.
+	ClaimTTL time.Duration `json:"claim_ttl,omitempty"`
+}
+
+// VDPASettings defines a vDPA device, created on a management device of a
+// virtio offload capable NIC and deleted when the container is deleted.
+type VDPASettings struct {
+	// MgmtDev is the management device the vDPA device is created on, as
+	// in "vdpa mgmtdev show", such as "pci/0000:65:00.2" or "vdpasim_net".
+	MgmtDev string `json:"mgmtdev"`
+
+	// Device is the name of the vDPA device.
+	Device string `json:"device"`
+}
+
+// TunnelSettings defines an IP encapsulation tunnel, over IPv6 for ip6tnl
+// networks and over IPv4 for the other ones. The tunnel interface is in the
+// container, but the encapsulated packets are sent and received in the host
+// network namespace, so the endpoints are host addresses.
+type TunnelSettings struct {
+	// Local is the source address of the encapsulated packets. If empty,
+	// it is chosen by the host routing.
+	Local string `json:"local,omitempty"`
+
+	// Remote is the address of the other end of the tunnel.
+	Remote string `json:"remote"`
+
+	// TTL is the time to live of the encapsulated packets. If zero, it is
+	// inherited from the inner packets.
+	TTL uint8 `json:"ttl,omitempty"`
+
+	// InputKey and OutputKey are the GRE keys of the received and sent
+	// packets, usually the same. Packets are sent and expected without a
+	// key if zero.
+	// Note: This only applies to gre and gretap tunnels.
+	InputKey  uint32 `json:"input_key,omitempty"`
+	OutputKey uint32 `json:"output_key,omitempty"`
+
+	// Mode is the encapsulated protocol of ip6tnl tunnels, "ipip6" for
+	// IPv4, "ip6ip6" for IPv6 or "any" (the default) for both.
+	// Note: This only applies to ip6tnl tunnels.
+	Mode string `json:"mode,omitempty"`
+}
+
+// L2TPSettings defines a static L2TPv3 tunnel with a single ethernet
+// pseudowire session. As for TunnelSettings, the tunnel endpoints are host
+// addresses. Frames are carried as is, VLAN tags included.
+type L2TPSettings struct {
+	// Encap is the encapsulation of the tunnel, "udp" (the default) or
+	// "ip".
+	Encap string `json:"encap,omitempty"`
+
+	// Local and Remote are the addresses of the tunnel endpoints, of the
+	// same family.
+	Local  string `json:"local"`
+	Remote string `json:"remote"`
+
+	// LocalPort and RemotePort are the UDP ports of the tunnel endpoints.
+	// They are required with the udp encapsulation only.
+	LocalPort  uint16 `json:"local_port,omitempty"`
+	RemotePort uint16 `json:"remote_port,omitempty"`
+
+	// TunnelID and PeerTunnelID are the tunnel identifiers on both ends.
+	TunnelID     uint32 `json:"tunnel_id"`
+	PeerTunnelID uint32 `json:"peer_tunnel_id"`
+
+	// SessionID and PeerSessionID are the session identifiers on both ends.
+	SessionID     uint32 `json:"session_id"`
+	PeerSessionID uint32 `json:"peer_session_id"`
+}
+
+// MacvlanSettings defines how a macvlan interface shares its parent device.
+type MacvlanSettings struct {
+	// Mode is the macvlan mode, one of "bridge" (the default), "private",
+	// "vepa" or "passthru". A passthru macvlan takes over the parent device,
+	// so only one can be created on a parent. Macvtap interfaces do not
+	// support the passthru mode.
+	Mode string `json:"mode,omitempty"`
+}
+
+// IpvlanSettings defines how an ipvlan interface shares its parent device.
+// Unlike macvlan interfaces, ipvlan interfaces use the hardware address of
+// the parent.
+type IpvlanSettings struct {
+	// Mode is the ipvlan mode, one of "l2" (the default), "l3" or "l3s".
+	Mode string `json:"mode,omitempty"`
+
+	// Flag defines how the ipvlan interfaces of a parent communicate with
+	// each other, one of "bridge" (the default), "private" or "vepa".
+	Flag string `json:"flag,omitempty"`
+}
+
+// VLANSettings defines a VLAN subinterface, which sends and receives the
+// frames of its parent device tagged with ID.
+type VLANSettings struct {
+	// ID is the VLAN ID, from 1 to 4094.
+	ID int `json:"id"`
+
+	// Protocol is the tag protocol, "802.1q" (the default) or "802.1ad".
+	Protocol string `json:"protocol,omitempty"`
+
+	// IngressQosMap maps the priority of the received frames, from 0 to 7,
+	// to the priority of their packets in the container.
+	IngressQosMap map[uint32]uint32 `json:"ingress_qos_map,omitempty"`
+
+	// EgressQosMap maps the priority of the packets sent by the container
+	// to the priority of their frames, from 0 to 7.
+	EgressQosMap map[uint32]uint32 `json:"egress_qos_map,omitempty"`
+}
+
+// VXLANSettings defines a VXLAN interface. The interface is created in the
+// host and moved to the container, so the tunnel traffic is sent and
+// received in the host network namespace.
+type VXLANSettings struct {
+	// VNI is the VXLAN network identifier, lower than 16777216.
+	VNI uint32 `json:"vni"`
+
+	// Local is the source address of the tunnel traffic. If empty, it is
+	// chosen by the host routing.
+	Local string `json:"local,omitempty"`
+
+	// Remote is the address the tunnel traffic is sent to, either a
+	// unicast address or a multicast group joined on Parent.
+	Remote string `json:"remote,omitempty"`
+
+	// Port is the UDP destination port of the tunnel traffic, 4789 by
+	// default.
+	Port uint16 `json:"port,omitempty"`
+
+	// TTL is the time to live of the tunnel traffic. If zero, the default
+	// TTL of the host is used.
+	TTL uint8 `json:"ttl,omitempty"`
+
+	// Learning fills the forwarding database of the interface with the
+	// source addresses of the received traffic.
+	Learning bool `json:"learning,omitempty"`
+
+	// SrcPortLow and SrcPortHigh are the range the UDP source port of the
+	// tunnel traffic is picked in, from a hash of the inner flow. Both must
+	// be set, or neither to use the ephemeral port range of the host.
+	SrcPortLow  uint16 `json:"srcport_low,omitempty"`
+	SrcPortHigh uint16 `json:"srcport_high,omitempty"`
+
+	TunnelTuning
+}
+
+// Values of the don't fragment bit of the tunnel traffic.
+const (
+	TunnelDFUnset   = "unset"
+	TunnelDFSet     = "set"
+	TunnelDFInherit = "inherit"
+)
+
+// TunnelTuning holds the settings of the outer headers shared by the UDP
+// tunnel networks.
+type TunnelTuning struct {
+	// TOS is the type of service of the tunnel traffic, or 1 to inherit it
+	// from the inner packets.
+	TOS uint8 `json:"tos,omitempty"`
+
+	// DF sets the don't fragment bit of the IPv4 tunnel traffic, to
+	// TunnelDFUnset, TunnelDFSet, or TunnelDFInherit to copy it from the
+	// inner packets. The kernel default is TunnelDFUnset.
+	DF string `json:"df,omitempty"`
+
+	// UDPChecksum computes the UDP checksum of the IPv4 tunnel traffic.
+	// The IPv6 tunnel traffic always carries one.
+	UDPChecksum bool `json:"udp_checksum,omitempty"`
+}
+
+// GeneveSettings defines a Geneve interface. The interface is created in the
+// host and moved to the container, so the tunnel traffic is sent and
+// received in the host network namespace.
+type GeneveSettings struct {
+	// VNI is the Geneve virtual network identifier, lower than 16777216.
+	VNI uint32 `json:"vni,omitempty"`
+
+	// Remote is the address the tunnel traffic is sent to.
+	Remote string `json:"remote,omitempty"`
+
+	// Port is the UDP destination port of the tunnel traffic, 6081 by
+	// default.
+	Port uint16 `json:"port,omitempty"`
+
+	// TTL is the time to live of the tunnel traffic. If zero, the default
+	// TTL of the host is used.
+	TTL uint8 `json:"ttl,omitempty"`
+
+	TunnelTuning
+
+	// External creates the interface in collect metadata mode: the VNI,
+	// remote address and option TLVs of each packet are passed through to
+	// and from the tunnel metadata, as set and matched by tc or an OVN
+	// controller, instead of being fixed. VNI and Remote must not be set.
+	External bool `json:"external,omitempty"`
+}
+
+// MACsecSettings defines a MACsec interface, created in the container over
+// the interface of another network of the container, such as a physical
+// port, so the traffic on the link is encrypted without an agent in the
+// container. The keys are read from files, so they are not saved in the
+// container state.
+type MACsecSettings struct {
+	// Link is the name of the interface the MACsec interface is created
+	// over, the interface of a network listed before.
+	Link string `json:"link"`
+
+	// Port is the port number of the secure channel identifier of the
+	// interface, 1 by default. The identifier is made of the MAC address of
+	// the interface and of the port.
+	Port uint16 `json:"port,omitempty"`
+
+	// CipherSuite is "gcm-aes-128" (the default) or "gcm-aes-256".
+	CipherSuite string `json:"cipher_suite,omitempty"`
+
+	// IntegrityOnly authenticates the frames without encrypting them.
+	IntegrityOnly bool `json:"integrity_only,omitempty"`
+
+	// ReplayWindow, if not zero, enables the replay protection, accepting
+	// frames received up to ReplayWindow packet numbers out of order.
+	ReplayWindow uint32 `json:"replay_window,omitempty"`
+
+	// TxSA is the secure association the frames are sent with.
+	TxSA *MACsecSA `json:"tx_sa"`
+
+	// RxSCs are the secure channels the frames are received on, one per
+	// peer.
+	RxSCs []*MACsecRxSC `json:"rx_scs,omitempty"`
+}
+
+// MACsecSA defines a MACsec secure association.
+type MACsecSA struct {
+	// AN is the association number, from 0 to 3.
+	AN uint8 `json:"an"`
+
+	// PN is the first packet number, 1 by default.
+	PN uint32 `json:"pn,omitempty"`
+
+	// KeyID is the hex encoded 16 bytes identifier of the key.
+	KeyID string `json:"key_id"`
+
+	// KeyFile is the path to the file holding the hex encoded key, of 16
+	// bytes for gcm-aes-128 and 32 bytes for gcm-aes-256.
+	KeyFile string `json:"key_file"`
+}
+
+// MACsecRxSC defines the secure channel of a MACsec peer.
+type MACsecRxSC struct {
+	// Address and Port are the MAC address and port number making the
+	// secure channel identifier of the peer. Port is 1 by default.
+	Address string `json:"address"`
+	Port    uint16 `json:"port,omitempty"`
+
+	// SAs are the secure associations of the channel.
+	SAs []*MACsecSA `json:"sas"`
+}
+
+// LLDPSettings defines the LLDP frames sent on the interface of a network.
+// The chassis ID is the container ID and the port ID the name of the
+// interface in the container.
+type LLDPSettings struct {
+	// Interval is the time between two frames, 30 seconds by default. The
+	// frames are valid for four intervals.
+	Interval time.Duration `json:"interval,omitempty"`
+
+	// SystemName, SystemDescription and PortDescription are the optional
+	// TLVs of the same names. The system name defaults to the container ID.
+	SystemName        string `json:"system_name,omitempty"`
+	SystemDescription string `json:"system_description,omitempty"`
+	PortDescription   string `json:"port_description,omitempty"`
+
+	// TLVs are additional TLVs added as is to the frames, such as
+	// organizationally specific ones.
+	TLVs []*LLDPTLV `json:"tlvs,omitempty"`
+}
+
+// LLDPTLV is a raw LLDP TLV.
+type LLDPTLV struct {
+	// Type is the TLV type, from 8 to 127. The lower types are set by
+	// runc.
+	Type uint8 `json:"type"`
+
+	// Value is the TLV information string, of at most 511 bytes.
+	Value []byte `json:"value"`
+}
+
+// PrefixDelegationSettings defines how a prefix is delegated to a container
+// and assigned to its interfaces. The first address of the sub-prefix is
+// added to the interface, with the lifetimes of the delegated prefix. runc
+// does not renew the lease, so a DHCPv6 client run in the container must take
+// it over to keep the prefix beyond its lifetimes. The client is identified
+// by a DUID based on the MAC address of the interface the prefix is
+// requested on.
+type PrefixDelegationSettings struct {
+	// Interface is the interface the sub-prefix is assigned to, "lo" or
+	// the interface of a network listed before.
+	Interface string `json:"interface"`
+
+	// PrefixLength is the length of the prefix hinted to the server. If
+	// zero, the server picks it.
+	PrefixLength int `json:"prefix_length,omitempty"`
+
+	// SubnetLength is the length of the sub-prefix, 64 by default. It
+	// must not be shorter than the delegated prefix.
+	SubnetLength int `json:"subnet_length,omitempty"`
+
+	// SubnetID is the index of the sub-prefix in the delegated prefix, 0
+	// by default.
+	SubnetID uint64 `json:"subnet_id,omitempty"`
+
+	// Timeout bounds the time waiting for the delegated prefix, 10 seconds
+	// by default. The container creation fails if no prefix is delegated
+	// in time.
+	Timeout time.Duration `json:"timeout,omitempty"`
+}
+
+// Bonding modes supported by bond networks.
+const (
+	BondActiveBackup = "active-backup"
+	Bond8023AD       = "802.3ad"
+)
+
+// BondSettings defines a bond interface created in the container.
+type BondSettings struct {
+	// Mode is the bonding mode, BondActiveBackup or Bond8023AD.
+	Mode string `json:"mode"`
+
+	// Slaves are the names of the interfaces enslaved to the bond, which
+	// belong to networks listed before the bond network. At least two are
+	// required, and the networks must have no addresses or gateways.
+	Slaves []string `json:"slaves"`
+
+	// Primary is the slave used whenever it is available, in active-backup
+	// mode.
+	Primary string `json:"primary,omitempty"`
+
+	// Miimon is the link monitoring interval, in milliseconds, 100 by
+	// default. The slaves are failed over when their carrier goes down.
+	Miimon uint32 `json:"miimon,omitempty"`
+
+	// LACPRate is the rate LACPDUs are requested at from the partner,
+	// "slow" (the default) or "fast", in 802.3ad mode.
+	LACPRate string `json:"lacp_rate,omitempty"`
+
+	// XmitHashPolicy is the policy selecting the slave of the sent
+	// packets, for example "layer2" (the default) or "layer3+4", in 802.3ad
+	// mode.
+	XmitHashPolicy string `json:"xmit_hash_policy,omitempty"`
+}
+
+// Runners supported by team networks, which are run by the kernel. The
+// runners implemented by teamd, such as lacp, are not supported.
+const (
+	TeamRunnerActiveBackup = "activebackup"
+	TeamRunnerRoundRobin   = "roundrobin"
+	TeamRunnerBroadcast    = "broadcast"
+	TeamRunnerRandom       = "random"
+)
+
+// TeamSettings defines a team interface created in the container.
+type TeamSettings struct {
+	// Runner is the team runner, TeamRunnerActiveBackup,
+	// TeamRunnerRoundRobin, TeamRunnerBroadcast or TeamRunnerRandom.
+	Runner string `json:"runner"`
+
+	// Ports are the names of the interfaces added to the team, which
+	// belong to networks listed before the team network. At least two are
+	// required, and the networks must have no addresses or gateways.
+	Ports []string `json:"ports"`
+
+	// ActivePort is the port used by the activebackup runner, the first
+	// port by default. The kernel does not monitor the ports, so the
+	// active port is only changed by teamd, if run in the container.
+	ActivePort string `json:"active_port,omitempty"`
+}
+
+// PluginSettings defines an external program setting up a network, in the
+// manner of a CNI plugin, so existing IPAM and device provisioning tools can
+// be reused while runc configures the interface and keeps its lifecycle.
+//
+// The program is run in the host with a JSON request on its standard input,
+// with the fields "command", "container_id", "interface" and "config". When
+// the container is created, the command is "add" and the request also holds
+// "netns", the path of the container network namespace. The program creates
+// the interface in it, and writes a JSON result on its standard output, with
+// the optional fields "mac_address", "address", "gateway", "ipv6_address",
+// "ipv6_gateway" and "routes", which take precedence over the settings of the
+// network. runc then configures the interface as for other networks. When
+// the container is destroyed, the command is "del" and the request holds the
+// result of the "add" command in "result", if there was one. The program
+// must succeed even if the resources were already released.
+type PluginSettings struct {
+	// Command is the program run, its path being absolute. The request
+	// fails if it runs past its timeout.
+	Command *Command `json:"command"`
+
+	// Config is passed as is to the program.
+	Config json.RawMessage `json:"config,omitempty"`
+}
+
+// TapSettings defines a persistent tap device, used by virtual machines run
+// in the container.
+type TapSettings struct {
+	// MultiQueue creates a multi-queue device, whose queues are attached by
+	// opening it several times.
+	MultiQueue bool `json:"multi_queue,omitempty"`
+
+	// VnetHdr prepends a virtio-net header to the frames, as expected by
+	// virtio-net backends such as vhost-net.
+	VnetHdr bool `json:"vnet_hdr,omitempty"`
+
+	// Owner is the user ID, as seen from the host, allowed to attach to the
+	// device. If nil, and Group is nil too, CAP_NET_ADMIN is needed.
+	Owner *uint32 `json:"owner,omitempty"`
+
+	// Group is the group ID, as seen from the host, allowed to attach to
+	// the device.
+	Group *uint32 `json:"group,omitempty"`
+}
+
+// WireGuardSettings defines a WireGuard interface. The interface is created
+// in the host and moved to the container, so its encrypted traffic is sent
+// and received from the host network namespace, while the container only
+// sees the tunneled traffic. The keys are read from files, so they are not
+// saved in the container state.
+type WireGuardSettings struct {
+	// PrivateKeyFile is the path to the file holding the base64 encoded
+	// private key of the interface, as generated by "wg genkey".
+	PrivateKeyFile string `json:"private_key_file"`
+
+	// ListenPort is the UDP port the interface listens on in the host. If
+	// zero, a port is chosen by the kernel.
+	ListenPort uint16 `json:"listen_port,omitempty"`
+
+	// FirewallMark is set on the encrypted packets sent by the interface.
+	FirewallMark uint32 `json:"firewall_mark,omitempty"`
+
+	// Peers are the peers of the interface.
+	Peers []*WireGuardPeer `json:"peers,omitempty"`
+}
+
+// WireGuardPeer defines a peer of a WireGuard interface.
+type WireGuardPeer struct {
+	// PublicKey is the base64 encoded public key of the peer.
+	PublicKey string `json:"public_key"`
+
+	// PresharedKeyFile is the path to the file holding a base64 encoded
+	// symmetric key shared with the peer, as generated by "wg genpsk".
+	PresharedKeyFile string `json:"preshared_key_file,omitempty"`
+
+	// Endpoint is the host and UDP port of the peer. Host names are
+	// resolved when the interface is created.
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// AllowedIPs are the addresses, in CIDR notation, the peer is allowed
+	// to send from, and the traffic to which is sent to the peer. Routes to
+	// them through the interface are not added, use Routes for this.
+	AllowedIPs []string `json:"allowed_ips,omitempty"`
+
+	// PersistentKeepalive is the interval, in seconds, between the packets
+	// sent to the peer to keep stateful firewalls or NAT mappings open. If
+	// zero, no packet is sent.
+	PersistentKeepalive uint16 `json:"persistent_keepalive,omitempty"`
+}
+
+// NetworkOptions defines settings applying to the container's network
+// namespace as a whole, rather than to a single network interface.
+type NetworkOptions struct {
+	// RPFilter sets the reverse path filtering mode for all the interfaces
+	// of the namespace (net.ipv4.conf.all.rp_filter), and the default for
+	// interfaces created later (net.ipv4.conf.default.rp_filter).
+	// If nil, the kernel default is kept.
+	RPFilter *int `json:"rp_filter,omitempty"`
+
+	// MaskedProcNet defines what to do when /proc/net, or a file below it,
+	// is masked while network interfaces other than loopback are configured,
+	// which confuses consumers reading interface statistics from inside the
+	// container. Valid values are "warn" (the default), "error" and "ignore".
+	MaskedProcNet MaskedProcNetPolicy `json:"masked_proc_net,omitempty"`
+
+	// ReadyFile enables writing a readiness notification, including the
+	// status of every configured interface, to the container state directory
+	// once the network setup is completed.
+	ReadyFile bool `json:"ready_file,omitempty"`
+
+	// ReadySocket is the path to a unix datagram socket which receives an
+	// sd_notify style "NETWORK_READY=1" message, followed by the status of
+	// every configured interface, once the network setup is completed.
+	ReadySocket string `json:"ready_socket,omitempty"`
+
+	// Experimental enables network settings whose format and behavior may
+	// change in future releases, such as raw link attributes.
+	Experimental bool `json:"experimental,omitempty"`
+
+	// StatsHistory enables keeping the recent history of the interface
+	// counters in the container state directory, so it is still available
+	// once the container has stopped. The counters are only sampled by
+	// "runc run" while it is attached to the container: the history stays
+	// empty with "runc create" or "runc run --detach".
+	StatsHistory *StatsHistory `json:"stats_history,omitempty"`
+
+	// SetupTimeout bounds the time spent setting up all the networks of the
+	// container, in the host and in the container. If the setup does not
+	// complete in time, the container creation fails and what was set up is
+	// rolled back with the container, even if a driver does not respond. If
+	// zero, there is no limit.
+	SetupTimeout time.Duration `json:"setup_timeout,omitempty"`
+
+	// NetlinkRateLimit throttles the creation of the networks of all the
+	// containers sharing the same root directory, so that starting many
+	// containers at once does not flood the network drivers with netlink
+	// requests. Each network created takes a token from a bucket shared by
+	// the containers.
+	NetlinkRateLimit *RateLimit `json:"netlink_rate_limit,omitempty"`
+
+	// ExportEnv exports the addresses assigned to the container interfaces
+	// to the environment of the container init process, as variables like
+	// RUNC_NETDEV_ETH0_IPV4. Variables set in the process environment are
+	// not overridden.
+	ExportEnv bool `json:"export_env,omitempty"`
+
+	// IsolateMounts runs the creation of the networks by runc in the host
+	// in a private mount namespace, so that it is not affected by the
+	// mounts made while the container rootfs is prepared, and the mounts
+	// made by the plugins it runs do not leak to the host. Only the thread
+	// creating the networks, and the processes it starts, are isolated: the
+	// steps it runs on other threads, such as those entering the container
+	// network namespace, use the host mount namespace, and the setup done
+	// by runc init, including the pre-up hooks, is not covered.
+	IsolateMounts bool `json:"isolate_mounts,omitempty"`
+
+	// StatsBackend selects how the interface counters reported in the
+	// container stats are collected. Valid values are "sysfs" (the default),
+	// "netlink" and "ebpf".
+	StatsBackend NetworkStatsBackend `json:"stats_backend,omitempty"`
+
+	// RoutingRules are the policy routing rules of the namespace, as added
+	// by "ip rule", selecting the routing table of the packets they match,
+	// for example by source address in containers with several interfaces.
+	// They are added before the interfaces are set up.
+	RoutingRules []*RoutingRule `json:"routing_rules,omitempty"`
+}
+
+// RoutingRule is a policy routing rule, selecting the routing table looked
+// up for the packets it matches. A rule without From or To applies to both
+// IPv4 and IPv6.
+type RoutingRule struct {
+	// Priority orders the rules, the lowest first. If zero, the kernel
+	// gives the rule the priority before the last rule added.
+	Priority int `json:"priority,omitempty"`
+
+	// From matches the source address of the packets, in the CIDR form.
+	From string `json:"from,omitempty"`
+
+	// To matches the destination address of the packets, in the CIDR form.
+	To string `json:"to,omitempty"`
+
+	// Mark matches the firewall mark of the packets, under Mask if not
+	// zero. The mark is not matched if both are zero.
+	Mark uint32 `json:"mark,omitempty"`
+	Mask uint32 `json:"mask,omitempty"`
+
+	// Iif and Oif match the interfaces the packets are received from and
+	// sent to.
+	Iif string `json:"iif,omitempty"`
+	Oif string `json:"oif,omitempty"`
+
+	// Table is the routing table looked up, 254 being the main table.
+	Table int `json:"table"`
+}
+
+// RateLimit defines a token bucket.
+type RateLimit struct {
+	// Rate is the number of tokens added to the bucket per second.
+	Rate float64 `json:"rate"`
+
+	// Burst is the size of the bucket, which is the number of tokens which
+	// can be taken at once.
+	Burst int `json:"burst"`
+}
+
+// StatsHistory defines how the interface counters of a container are sampled.
+type StatsHistory struct {
+	// Interval is the time between two samples.
+	Interval time.Duration `json:"interval"`
+
+	// Samples is the number of samples kept, older samples are discarded.
+	Samples int `json:"samples"`
+}
+
+// MaskedProcNetPolicy is the policy applied when /proc/net is masked in a
+// container with network interfaces.
+type MaskedProcNetPolicy string
+
+const (
+	MaskedProcNetWarn   MaskedProcNetPolicy = "warn"
+	MaskedProcNetError  MaskedProcNetPolicy = "error"
+	MaskedProcNetIgnore MaskedProcNetPolicy = "ignore"
+)
+
+// NetworkStatsBackend is the way the interface counters of a container are
+// collected.
+type NetworkStatsBackend string
+
+const (
+	// NetworkStatsSysfs reads the counters of the host side of veth pairs
+	// from sysfs. It is the cheapest, but only covers veth networks.
+	NetworkStatsSysfs NetworkStatsBackend = "sysfs"
+	// NetworkStatsNetlink reads the counters of all the container interfaces
+	// from inside the container network namespace.
+	NetworkStatsNetlink NetworkStatsBackend = "netlink"
+	// NetworkStatsEBPF counts the packets and bytes of all the container
+	// interfaces with eBPF programs attached to their traffic control hooks
+	// once the networks are set up. The counts include the packets the
+	// driver counters miss, such as those dropped by other filters, at the
+	// cost of running a program for every packet. Errors and drops are not
+	// reported.
+	NetworkStatsEBPF NetworkStatsBackend = "ebpf"
+)
+
+// PortForward defines a TCP port listening on the container's loopback
+// interface that is forwarded to a port on the host's loopback interface,
+// so applications binding 127.0.0.1 inside the container are reachable
+// from the host.
+type PortForward struct {
+	// HostPort is the port to listen on 127.0.0.1 in the host.
+	HostPort uint16 `json:"host_port"`
+
+	// ContainerPort is the port to connect to on 127.0.0.1 in the container.
+	ContainerPort uint16 `json:"container_port"`
 }
 
 // Route defines a routing table entry.
@@ -73,3 +1322,20 @@ type Route struct {
 	// InterfaceName specifies the device to set this route up for, for example eth0.
 	InterfaceName string `json:"interface_name"`
 }
+
+// ActivationSocket defines a socket which is created inside the container
+// namespaces before the container process is started. The sockets are passed
+// to the container process starting at file descriptor 3, and announced with
+// the LISTEN_FDS, LISTEN_PID and LISTEN_FDNAMES environment variables.
+type ActivationSocket struct {
+	// Name is passed to the process in LISTEN_FDNAMES.
+	Name string `json:"name,omitempty"`
+
+	// Network is one of "tcp", "tcp4", "tcp6", "udp", "udp4", "udp6", "unix"
+	// or "unixgram".
+	Network string `json:"network"`
+
+	// Address is the address to listen on, for example ":8080" for inet
+	// sockets, or an absolute path inside the container for unix sockets.
+	Address string `json:"address"`
+}
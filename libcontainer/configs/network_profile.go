@@ -0,0 +1,45 @@
+package configs
+
+// NetworkProfile bundles network settings which are commonly used together,
+// so they can be selected with Network.Profile instead of being repeated for
+// every network. The settings of the network take precedence over the ones
+// of its profile.
+type NetworkProfile struct {
+	// TxQueueLen is used if the network does not set one.
+	TxQueueLen int
+
+	// Sysctls are per interface sysctls. Keys prefixed with "ipv4." are
+	// relative to net.ipv4.conf.<interface>, and keys prefixed with "ipv6."
+	// to net.ipv6.conf.<interface>.
+	Sysctls map[string]string
+}
+
+var networkProfiles = map[string]NetworkProfile{
+	"default": {},
+	// lowlatency keeps the transmit queue short, and makes the interface
+	// usable as soon as it is up by skipping IPv6 duplicate address
+	// detection and announcing its addresses right away.
+	"lowlatency": {
+		TxQueueLen: 128,
+		Sysctls: map[string]string{
+			"ipv4.arp_notify":   "1",
+			"ipv6.accept_dad":   "0",
+			"ipv6.ndisc_notify": "1",
+		},
+	},
+	// router is for containers forwarding traffic between interfaces.
+	"router": {
+		Sysctls: map[string]string{
+			"ipv4.forwarding": "1",
+			"ipv4.rp_filter":  "0",
+			"ipv6.forwarding": "1",
+		},
+	},
+}
+
+// GetNetworkProfile returns the network profile with the given name. The
+// returned profile must not be modified.
+func GetNetworkProfile(name string) (NetworkProfile, bool) {
+	p, ok := networkProfiles[name]
+	return p, ok
+}
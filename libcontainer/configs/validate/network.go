@@ -0,0 +1,1645 @@
+package validate
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// minStatsInterval and maxStatsSamples bound the cost of keeping the
+	// interface counters history, which is rewritten on every sample.
+	minStatsInterval = 100 * time.Millisecond
+	maxStatsSamples  = 3600
+
+	// altIfNameSize is ALTIFNAMSIZ, the size of alternative interface names
+	// including the terminating NUL byte.
+	altIfNameSize = 128
+
+	// maxTrafficFilters is the maximum number of traffic filters of a
+	// network.
+	maxTrafficFilters = 1024
+)
+
+// networkDevice validates the settings of a single network of the container.
+func networkDevice(n *configs.Network) error {
+	switch n.ApplyPolicy {
+	case "", configs.ApplyPolicyStrict, configs.ApplyPolicyBestEffort:
+	default:
+		return fmt.Errorf("invalid apply policy %q", n.ApplyPolicy)
+	}
+	switch n.AddressConflict {
+	case "", configs.AddressConflictFail, configs.AddressConflictIgnore, configs.AddressConflictReplace:
+	default:
+		return fmt.Errorf("invalid address conflict policy %q", n.AddressConflict)
+	}
+	if n.Profile != "" {
+		if _, ok := configs.GetNetworkProfile(n.Profile); !ok {
+			return fmt.Errorf("unknown network profile %q", n.Profile)
+		}
+		if n.Type == "loopback" {
+			return errors.New("network profiles are not supported on loopback networks")
+		}
+	}
+	if n.Name != "" {
+		if err := interfaceName(n.Name); err != nil {
+			return err
+		}
+	}
+	if n.HostInterfaceName != "" {
+		if err := interfaceName(n.HostInterfaceName); err != nil {
+			return fmt.Errorf("invalid host interface name: %w", err)
+		}
+	}
+	if err := altNames(n); err != nil {
+		return err
+	}
+	if err := portForwards(n); err != nil {
+		return err
+	}
+	if err := canNetwork(n); err != nil {
+		return err
+	}
+	if err := ipoibNetwork(n); err != nil {
+		return err
+	}
+	if err := sriovNetwork(n); err != nil {
+		return err
+	}
+	if err := rdmaLimits(n); err != nil {
+		return err
+	}
+	if err := vdpaNetwork(n); err != nil {
+		return err
+	}
+	if err := tunnelNetwork(n); err != nil {
+		return err
+	}
+	if err := vethNetwork(n); err != nil {
+		return err
+	}
+	if err := l2tpNetwork(n); err != nil {
+		return err
+	}
+	if err := macvlanNetwork(n); err != nil {
+		return err
+	}
+	if err := ipvlanNetwork(n); err != nil {
+		return err
+	}
+	if err := vlanNetwork(n); err != nil {
+		return err
+	}
+	if err := tapNetwork(n); err != nil {
+		return err
+	}
+	if err := wireguardNetwork(n); err != nil {
+		return err
+	}
+	if err := vxlanNetwork(n); err != nil {
+		return err
+	}
+	if err := geneveNetwork(n); err != nil {
+		return err
+	}
+	if err := bondNetwork(n); err != nil {
+		return err
+	}
+	if err := teamNetwork(n); err != nil {
+		return err
+	}
+	if err := macsecNetwork(n); err != nil {
+		return err
+	}
+	if err := pluginNetwork(n); err != nil {
+		return err
+	}
+	if err := lldp(n); err != nil {
+		return err
+	}
+	if n.Type == "dummy" {
+		if n.Name == "" {
+			return errors.New("dummy networks require a name")
+		}
+		if n.HostInterfaceName != "" || n.HostFirewallMark != 0 || n.HostShaping != nil {
+			return errors.New("host interface settings are not supported on dummy networks")
+		}
+	}
+	if n.FlushOnDetach && n.Type == "loopback" {
+		return errors.New("flushing on detach is not supported on loopback networks")
+	}
+	if n.Standby && n.Type == "loopback" {
+		return errors.New("standby is not supported on loopback networks")
+	}
+	if n.Optional && n.Type == "loopback" {
+		return errors.New("optional is not supported on loopback networks")
+	}
+	if n.PreUpHook != nil {
+		if n.Type == "loopback" {
+			return errors.New("pre-up hooks are not supported on loopback networks")
+		}
+		if !filepath.IsAbs(n.PreUpHook.Path) {
+			return fmt.Errorf("pre-up hook path %q is not absolute", n.PreUpHook.Path)
+		}
+	}
+	switch n.Isolate {
+	case "":
+	case configs.IsolateDown, configs.IsolateDelete:
+		if n.Type != "loopback" {
+			return fmt.Errorf("isolation is not supported on %s networks", n.Type)
+		}
+	default:
+		return fmt.Errorf("invalid isolate mode %q", n.Isolate)
+	}
+	if n.HostFirewallMark != 0 && n.Type == "loopback" {
+		return errors.New("host firewall mark is not supported on loopback networks")
+	}
+	if err := hostShaping(n); err != nil {
+		return err
+	}
+	if n.HostRoutes {
+		if n.Type != "veth" {
+			return fmt.Errorf("host routes are not supported on %s networks", n.Type)
+		}
+		if n.Address == "" && n.IPv6Address == "" {
+			return errors.New("host routes require an address")
+		}
+	}
+	if n.GatewayMetric != 0 {
+		if n.GatewayMetric < 0 {
+			return fmt.Errorf("invalid gateway metric %d", n.GatewayMetric)
+		}
+		if n.Gateway == "" && n.IPv6Gateway == "" {
+			return errors.New("gateway metric requires a gateway")
+		}
+	}
+	if err := pinGateway(n); err != nil {
+		return err
+	}
+	if err := neighbors(n); err != nil {
+		return err
+	}
+	if err := staticRoutes(n); err != nil {
+		return err
+	}
+	if err := interfaceQuota(n); err != nil {
+		return err
+	}
+	if err := trafficFilters(n); err != nil {
+		return err
+	}
+	if n.AutoIPv4LinkLocal {
+		if n.Type == "loopback" {
+			return errors.New("IPv4 link-local configuration is not supported on loopback networks")
+		}
+		if n.Address != "" {
+			return errors.New("IPv4 link-local configuration can not be used with a static address")
+		}
+	}
+	if n.Unnumbered {
+		if n.Type == "loopback" {
+			return errors.New("unnumbered mode is not supported on loopback networks")
+		}
+		if n.Address != "" || n.IPv6Address != "" || n.Gateway != "" || n.IPv6Gateway != "" || n.AutoIPv4LinkLocal {
+			return errors.New("unnumbered mode can not be used with addresses or gateways")
+		}
+	}
+	if n.DropRouterAdvertisements {
+		if n.Type == "loopback" {
+			return errors.New("dropping router advertisements is not supported on loopback networks")
+		}
+		if n.Unnumbered {
+			return errors.New("unnumbered mode requires router advertisements")
+		}
+	}
+	if err := prefixDelegation(n); err != nil {
+		return err
+	}
+	return rpFilter(n.RPFilter)
+}
+
+// prefixDelegation validates the DHCPv6 prefix delegation settings of a
+// network. The interface the sub-prefix is assigned to is checked against the
+// other networks by prefixDelegationInterfaces.
+func prefixDelegation(n *configs.Network) error {
+	pd := n.PrefixDelegation
+	if pd == nil {
+		return nil
+	}
+	if n.Type == "loopback" {
+		return errors.New("prefix delegation is not supported on loopback networks")
+	}
+	if n.Standby {
+		return errors.New("prefix delegation requires the interface to be up, it can not be used with standby")
+	}
+	if pd.Interface == "" {
+		return errors.New("prefix delegation requires an interface to assign the prefix to")
+	}
+	if pd.PrefixLength < 0 || pd.PrefixLength > 128 {
+		return fmt.Errorf("invalid delegated prefix length %d", pd.PrefixLength)
+	}
+	subnet := pd.SubnetLength
+	if subnet == 0 {
+		subnet = 64
+	}
+	if subnet < 1 || subnet > 128 {
+		return fmt.Errorf("invalid delegated subnet length %d", pd.SubnetLength)
+	}
+	if pd.PrefixLength > subnet {
+		return fmt.Errorf("delegated subnet length %d is shorter than the requested prefix length %d", subnet, pd.PrefixLength)
+	}
+	if pd.Timeout < 0 {
+		return errors.New("prefix delegation timeout must not be negative")
+	}
+	return nil
+}
+
+// prefixDelegationInterfaces checks that the delegated prefixes are assigned
+// to the loopback interface, or to the interface of a network set up before
+// the one requesting the prefix, which is not optional.
+func prefixDelegationInterfaces(networks []*configs.Network) error {
+	before := make(map[string]*configs.Network, len(networks))
+	for _, n := range networks {
+		if pd := n.PrefixDelegation; pd != nil && pd.Interface != "lo" {
+			d, ok := before[pd.Interface]
+			if !ok {
+				return fmt.Errorf("invalid network %q: delegated prefix interface %q must be lo or the interface of a network listed before", n.Name, pd.Interface)
+			}
+			if d.Optional {
+				return fmt.Errorf("invalid network %q: delegated prefix can not be assigned to optional network %q", n.Name, pd.Interface)
+			}
+		}
+		if n.Name != "" {
+			before[n.Name] = n
+		}
+	}
+	return nil
+}
+
+// networkOptions validates the settings for the network namespace as a whole.
+func networkOptions(opts *configs.NetworkOptions) error {
+	if opts == nil {
+		return nil
+	}
+	if err := rpFilter(opts.RPFilter); err != nil {
+		return fmt.Errorf("invalid network options: %w", err)
+	}
+	if opts.SetupTimeout < 0 {
+		return errors.New("invalid network options: setup timeout must not be negative")
+	}
+	if l := opts.NetlinkRateLimit; l != nil && (l.Rate <= 0 || l.Burst < 1) {
+		return errors.New("invalid network options: netlink rate limit requires a positive rate and burst")
+	}
+	if h := opts.StatsHistory; h != nil {
+		if h.Interval < minStatsInterval {
+			return fmt.Errorf("invalid network options: stats history interval must be at least %s", minStatsInterval)
+		}
+		if h.Samples < 1 || h.Samples > maxStatsSamples {
+			return fmt.Errorf("invalid network options: stats history samples must be between 1 and %d", maxStatsSamples)
+		}
+	}
+	switch opts.StatsBackend {
+	case "", configs.NetworkStatsSysfs, configs.NetworkStatsNetlink, configs.NetworkStatsEBPF:
+	default:
+		return fmt.Errorf("invalid network options: unknown stats backend %q", opts.StatsBackend)
+	}
+	for _, r := range opts.RoutingRules {
+		if err := routingRule(r); err != nil {
+			return fmt.Errorf("invalid network options: %w", err)
+		}
+	}
+	return nil
+}
+
+func routingRule(r *configs.RoutingRule) error {
+	if r.Table <= 0 || r.Table == unix.RT_TABLE_LOCAL {
+		return fmt.Errorf("invalid routing rule table %d", r.Table)
+	}
+	if r.Priority < 0 {
+		return fmt.Errorf("invalid routing rule priority %d", r.Priority)
+	}
+	var families []bool
+	for _, prefix := range []string{r.From, r.To} {
+		if prefix == "" {
+			continue
+		}
+		_, ipnet, err := net.ParseCIDR(prefix)
+		if err != nil {
+			return fmt.Errorf("invalid routing rule: %w", err)
+		}
+		families = append(families, ipnet.IP.To4() != nil)
+	}
+	if len(families) == 2 && families[0] != families[1] {
+		return fmt.Errorf("routing rule from %s to %s mixes address families", r.From, r.To)
+	}
+	for _, name := range []string{r.Iif, r.Oif} {
+		if name == "" {
+			continue
+		}
+		if err := interfaceName(name); err != nil {
+			return fmt.Errorf("invalid routing rule: %w", err)
+		}
+	}
+	return nil
+}
+
+// maskedProcNet checks that /proc/net is not masked when network interfaces
+// other than loopback are configured, according to the configured policy.
+func maskedProcNet(config *configs.Config) error {
+	policy := configs.MaskedProcNetWarn
+	if config.NetworkOptions != nil && config.NetworkOptions.MaskedProcNet != "" {
+		policy = config.NetworkOptions.MaskedProcNet
+	}
+	switch policy {
+	case configs.MaskedProcNetWarn, configs.MaskedProcNetError, configs.MaskedProcNetIgnore:
+	default:
+		return fmt.Errorf("invalid masked_proc_net policy %q", policy)
+	}
+	if policy == configs.MaskedProcNetIgnore {
+		return nil
+	}
+	hasInterfaces := false
+	for _, n := range config.Networks {
+		if n.Type != "loopback" {
+			hasInterfaces = true
+			break
+		}
+	}
+	if !hasInterfaces {
+		return nil
+	}
+	for _, p := range config.MaskPaths {
+		p = filepath.Clean(p)
+		if p != "/proc/net" && !strings.HasPrefix(p, "/proc/net/") {
+			continue
+		}
+		err := fmt.Errorf("%s is masked, network statistics will not be available inside the container", p)
+		if policy == configs.MaskedProcNetError {
+			return err
+		}
+		logrus.WithError(err).Warn("configuration")
+	}
+	return nil
+}
+
+// interfaceName checks name is a valid network interface name, using the
+// same rules as the kernel. Interface names end up in sysfs and procfs paths
+// and in nftables rulesets, so they must be checked before being used.
+func interfaceName(name string) error {
+	return checkInterfaceName(name, unix.IFNAMSIZ)
+}
+
+// checkInterfaceName validates name as an interface name of at most size-1
+// characters.
+func checkInterfaceName(name string, size int) error {
+	if name == "" || len(name) >= size {
+		return fmt.Errorf("interface name %q must be between 1 and %d characters", name, size-1)
+	}
+	if name == "." || name == ".." || strings.ContainsAny(name, "/:\"\x00") {
+		return fmt.Errorf("invalid interface name %q", name)
+	}
+	for _, r := range name {
+		if unicode.IsSpace(r) {
+			return fmt.Errorf("invalid interface name %q", name)
+		}
+	}
+	return nil
+}
+
+// altNames validates the alternative names of the interface, which can be
+// up to ALTIFNAMSIZ-1 characters long.
+func altNames(n *configs.Network) error {
+	if len(n.AltNames) == 0 {
+		return nil
+	}
+	if n.Type != "can" && n.Type != "sriov" {
+		return fmt.Errorf("alternative names are not supported on %s networks", n.Type)
+	}
+	seen := make(map[string]bool, len(n.AltNames))
+	for _, name := range n.AltNames {
+		if err := checkInterfaceName(name, altIfNameSize); err != nil {
+			return fmt.Errorf("invalid alternative name: %w", err)
+		}
+		if name == n.Name || seen[name] {
+			return fmt.Errorf("duplicate alternative name %q", name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
+// rawLinkAttributes checks the raw link attributes of a network are enabled,
+// and do not conflict with the attributes set by runc.
+func rawLinkAttributes(n *configs.Network, opts *configs.NetworkOptions) error {
+	if len(n.RawLinkAttributes) == 0 {
+		return nil
+	}
+	if opts == nil || !opts.Experimental {
+		return errors.New("raw link attributes require the experimental network option")
+	}
+	if n.Type == "loopback" {
+		return errors.New("raw link attributes are not supported on loopback networks")
+	}
+	for _, attr := range n.RawLinkAttributes {
+		switch attr.Type &^ (unix.NLA_F_NESTED | unix.NLA_F_NET_BYTEORDER) {
+		case unix.IFLA_UNSPEC, unix.IFLA_IFNAME, unix.IFLA_NET_NS_PID, unix.IFLA_NET_NS_FD,
+			unix.IFLA_LINK_NETNSID, unix.IFLA_NEW_NETNSID, unix.IFLA_TARGET_NETNSID:
+			return fmt.Errorf("raw link attribute %d is reserved", attr.Type)
+		}
+	}
+	return nil
+}
+
+func pinGateway(n *configs.Network) error {
+	if n.GatewayMacAddress != "" {
+		if !n.PinGateway {
+			return errors.New("gateway MAC address is only used with pin_gateway")
+		}
+		if _, err := net.ParseMAC(n.GatewayMacAddress); err != nil {
+			return fmt.Errorf("invalid gateway MAC address: %w", err)
+		}
+	}
+	if !n.PinGateway {
+		return nil
+	}
+	if n.Type == "loopback" {
+		return errors.New("pinning the gateway is not supported on loopback networks")
+	}
+	if n.Gateway == "" && n.IPv6Gateway == "" {
+		return errors.New("pinning the gateway requires a gateway")
+	}
+	for _, gw := range []string{n.Gateway, n.IPv6Gateway} {
+		if gw != "" && net.ParseIP(gw) == nil {
+			return fmt.Errorf("invalid gateway %q", gw)
+		}
+	}
+	return nil
+}
+
+func neighbors(n *configs.Network) error {
+	if len(n.Neighbors) == 0 {
+		return nil
+	}
+	if n.Type == "loopback" {
+		return errors.New("neighbors are not supported on loopback networks")
+	}
+	for _, neigh := range n.Neighbors {
+		if net.ParseIP(neigh.IP) == nil {
+			return fmt.Errorf("invalid neighbor address %q", neigh.IP)
+		}
+		if _, err := net.ParseMAC(neigh.MacAddress); err != nil {
+			return fmt.Errorf("invalid MAC address of neighbor %s: %w", neigh.IP, err)
+		}
+	}
+	return nil
+}
+
+func interfaceQuota(n *configs.Network) error {
+	q := n.Quota
+	if q == nil {
+		return nil
+	}
+	if n.Type == "loopback" {
+		return errors.New("quotas are not supported on loopback networks")
+	}
+	if q.Bytes == 0 && q.Packets == 0 {
+		return errors.New("quota requires a number of bytes or packets")
+	}
+	switch q.Action {
+	case "", configs.QuotaDropNew, configs.QuotaDrop, configs.QuotaLinkDown, configs.QuotaEvent:
+	default:
+		return fmt.Errorf("invalid quota action %q", q.Action)
+	}
+	return nil
+}
+
+func staticRoutes(n *configs.Network) error {
+	if len(n.StaticRoutes) == 0 {
+		return nil
+	}
+	if n.Type == "loopback" {
+		return errors.New("static routes are not supported on loopback networks")
+	}
+	for _, r := range n.StaticRoutes {
+		_, dst, err := net.ParseCIDR(r.Destination)
+		if err != nil {
+			return fmt.Errorf("invalid static route destination: %w", err)
+		}
+		if r.Gateway != "" {
+			gw := net.ParseIP(r.Gateway)
+			if gw == nil {
+				return fmt.Errorf("invalid gateway %q of static route %s", r.Gateway, r.Destination)
+			}
+			if (gw.To4() == nil) != (dst.IP.To4() == nil) {
+				return fmt.Errorf("gateway %s of static route %s is of another family", r.Gateway, r.Destination)
+			}
+		}
+		if r.Metric < 0 {
+			return fmt.Errorf("invalid metric %d of static route %s", r.Metric, r.Destination)
+		}
+		if r.Table < 0 || r.Table == unix.RT_TABLE_LOCAL {
+			return fmt.Errorf("invalid table %d of static route %s", r.Table, r.Destination)
+		}
+		switch r.Scope {
+		case "", "universe", "link":
+		case "host":
+			if r.Gateway != "" {
+				return fmt.Errorf("static route %s with a gateway can not have host scope", r.Destination)
+			}
+		default:
+			return fmt.Errorf("invalid scope %q of static route %s", r.Scope, r.Destination)
+		}
+	}
+	return nil
+}
+
+// trafficFilters validates the traffic filters of a network. The targets of
+// the mirred filters are checked against the other networks by
+// trafficFilterTargets.
+func trafficFilters(n *configs.Network) error {
+	if len(n.TrafficFilters) == 0 {
+		return nil
+	}
+	if n.Type == "loopback" {
+		return errors.New("traffic filters are not supported on loopback networks")
+	}
+	// Each filter takes two tc priorities, which are 16 bits wide.
+	if len(n.TrafficFilters) > maxTrafficFilters {
+		return fmt.Errorf("too many traffic filters, the maximum is %d", maxTrafficFilters)
+	}
+	for i, f := range n.TrafficFilters {
+		if err := trafficFilter(f); err != nil {
+			return fmt.Errorf("invalid traffic filter %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func trafficFilter(f *configs.TrafficFilter) error {
+	switch f.Direction {
+	case "", configs.FilterIngress, configs.FilterEgress:
+	default:
+		return fmt.Errorf("invalid direction %q", f.Direction)
+	}
+	switch f.Classifier {
+	case "", configs.FilterU32, configs.FilterFlower:
+	default:
+		return fmt.Errorf("invalid classifier %q", f.Classifier)
+	}
+	var ip4, ip6 bool
+	for _, addr := range []string{f.Source, f.Destination} {
+		if addr == "" {
+			continue
+		}
+		ip, _, err := net.ParseCIDR(addr)
+		if err != nil {
+			return err
+		}
+		if ip.To4() != nil {
+			ip4 = true
+		} else {
+			ip6 = true
+		}
+	}
+	switch f.Protocol {
+	case "", "tcp", "udp", "sctp":
+	case "icmp":
+		ip4 = true
+	case "icmpv6":
+		ip6 = true
+	default:
+		return fmt.Errorf("invalid protocol %q", f.Protocol)
+	}
+	if ip4 && ip6 {
+		return errors.New("addresses and protocol are of different families")
+	}
+	if (f.SourcePort != 0 || f.DestinationPort != 0) && f.Protocol != "tcp" && f.Protocol != "udp" && f.Protocol != "sctp" {
+		return errors.New("ports require the tcp, udp or sctp protocol")
+	}
+	switch f.Action {
+	case configs.FilterDrop, configs.FilterMirred:
+		if f.Rate != 0 || f.Burst != 0 {
+			return fmt.Errorf("rate and burst are not supported by the %s action", f.Action)
+		}
+	case configs.FilterPolice:
+		if f.Rate == 0 || f.Burst == 0 {
+			return errors.New("police action requires a rate and a burst")
+		}
+	default:
+		return fmt.Errorf("invalid action %q", f.Action)
+	}
+	if f.Action == configs.FilterMirred {
+		if f.Target == "" {
+			return errors.New("mirred action requires a target")
+		}
+		return interfaceName(f.Target)
+	}
+	if f.Target != "" {
+		return fmt.Errorf("target is not supported by the %s action", f.Action)
+	}
+	return nil
+}
+
+func hostShaping(n *configs.Network) error {
+	s := n.HostShaping
+	if s == nil {
+		return nil
+	}
+	if n.Type == "loopback" {
+		return errors.New("host shaping is not supported on loopback networks")
+	}
+	if s.IngressRate > 0 && s.IngressBurst == 0 {
+		return errors.New("host shaping ingress rate requires a burst")
+	}
+	if s.EgressRate > 0 && s.EgressBurst == 0 {
+		return errors.New("host shaping egress rate requires a burst")
+	}
+	return nil
+}
+
+// canCtrlModes are the SocketCAN controller modes which can be configured.
+var canCtrlModes = map[string]bool{
+	"loopback": true, "listen-only": true, "triple-sampling": true,
+	"one-shot": true, "berr-reporting": true, "fd": true, "fd-non-iso": true,
+	"presume-ack": true, "cc-len8-dlc": true,
+}
+
+// canNetwork validates the SocketCAN networks: can moves the host interface
+// HostInterfaceName to the container, vcan creates a virtual interface in the
+// container, and vxcan creates a tunnel between the host and the container.
+func canNetwork(n *configs.Network) error {
+	switch n.Type {
+	case "can", "vcan", "vxcan":
+	default:
+		if n.CAN != nil {
+			return fmt.Errorf("can settings are not supported on %s networks", n.Type)
+		}
+		return nil
+	}
+	if n.Name == "" {
+		return fmt.Errorf("%s networks require a name", n.Type)
+	}
+	if n.Type == "vcan" {
+		if n.HostInterfaceName != "" {
+			return errors.New("vcan networks have no host interface")
+		}
+	} else if n.HostInterfaceName == "" {
+		return fmt.Errorf("%s networks require a host interface name", n.Type)
+	}
+	if n.CAN != nil {
+		if n.Type != "can" {
+			return fmt.Errorf("can settings are not supported on %s networks", n.Type)
+		}
+		for mode := range n.CAN.CtrlMode {
+			if !canCtrlModes[mode] {
+				return fmt.Errorf("unknown can controller mode %q", mode)
+			}
+		}
+	}
+	// CAN interfaces carry no IP traffic, and have no host side interface
+	// other than the vxcan peer.
+	if n.MacAddress != "" || n.Address != "" || n.Gateway != "" || n.IPv6Address != "" || n.IPv6Gateway != "" ||
+		n.AutoIPv4LinkLocal || n.Unnumbered || n.PinGateway || n.RPFilter != nil || n.AcceptLocal != nil || n.RouteLocalnet != nil {
+		return fmt.Errorf("addressing settings are not supported on %s networks", n.Type)
+	}
+	if n.Profile != "" || n.HostFirewallMark != 0 || n.HostShaping != nil {
+		return fmt.Errorf("profiles and host interface settings are not supported on %s networks", n.Type)
+	}
+	return nil
+}
+
+// ipoibNetwork validates the ipoib networks, which create an IP over
+// InfiniBand child interface of Parent in the container.
+func ipoibNetwork(n *configs.Network) error {
+	if n.Type != "ipoib" {
+		if n.IPoIB != nil {
+			return fmt.Errorf("ipoib settings are not supported on %s networks", n.Type)
+		}
+		if n.Parent != "" && n.Type != "sriov" && n.Type != "macvlan" && n.Type != "macvtap" && n.Type != "ipvlan" && n.Type != "vlan" && n.Type != "vxlan" {
+			return fmt.Errorf("parent interface is not supported on %s networks", n.Type)
+		}
+		return nil
+	}
+	if n.Name == "" {
+		return errors.New("ipoib networks require a name")
+	}
+	if err := interfaceName(n.Parent); err != nil {
+		return fmt.Errorf("invalid parent interface name: %w", err)
+	}
+	if n.IPoIB == nil || n.IPoIB.Pkey&0x7fff == 0 {
+		return errors.New("ipoib networks require a partition key")
+	}
+	switch n.IPoIB.Mode {
+	case "", "datagram", "connected":
+	default:
+		return fmt.Errorf("invalid ipoib mode %q", n.IPoIB.Mode)
+	}
+	// InfiniBand hardware addresses are assigned by the subnet manager, and
+	// there is no host side interface.
+	if n.MacAddress != "" || n.AutoIPv4LinkLocal {
+		return errors.New("mac address and IPv4 link-local settings are not supported on ipoib networks")
+	}
+	if n.HostInterfaceName != "" || n.HostFirewallMark != 0 || n.HostShaping != nil {
+		return errors.New("host interface settings are not supported on ipoib networks")
+	}
+	return nil
+}
+
+func rdmaLimits(n *configs.Network) error {
+	if n.RDMA == nil {
+		return nil
+	}
+	if n.Type != "sriov" && n.Type != "ipoib" {
+		return fmt.Errorf("rdma limits are not supported on %s networks", n.Type)
+	}
+	if n.RDMA.HcaHandles == nil && n.RDMA.HcaObjects == nil {
+		return errors.New("rdma limits require hca_handles or hca_objects")
+	}
+	return nil
+}
+
+// sriovNetwork validates the sriov networks, which move a virtual function of
+// the physical function Parent to the container.
+func sriovNetwork(n *configs.Network) error {
+	if n.Type != "sriov" {
+		if n.SRIOV != nil {
+			return fmt.Errorf("sriov settings are not supported on %s networks", n.Type)
+		}
+		return nil
+	}
+	if n.Name == "" {
+		return errors.New("sriov networks require a name")
+	}
+	s := n.SRIOV
+	if s == nil {
+		s = &configs.SRIOVSettings{}
+	}
+	switch {
+	case s.PCIAddress == "":
+		if err := interfaceName(n.Parent); err != nil {
+			return fmt.Errorf("invalid physical function name: %w", err)
+		}
+	case n.Parent != "":
+		return errors.New("the physical function can not be given both by name and by PCI address")
+	case !pciAddress(s.PCIAddress):
+		return fmt.Errorf("invalid physical function PCI address %q", s.PCIAddress)
+	}
+	if s.NumVFs < 0 {
+		return fmt.Errorf("invalid number of virtual functions %d", s.NumVFs)
+	}
+	if s.Vlan < 0 || s.Vlan > 4094 {
+		return fmt.Errorf("invalid virtual function vlan %d", s.Vlan)
+	}
+	if s.Qos < 0 || s.Qos > 7 {
+		return fmt.Errorf("invalid virtual function qos %d", s.Qos)
+	}
+	if s.Qos != 0 && s.Vlan == 0 {
+		return errors.New("virtual function qos requires a vlan")
+	}
+	if s.AdminMAC && n.MacAddress == "" {
+		return errors.New("an administrative address requires a mac address")
+	}
+	if s.ClaimTTL != 0 && s.ClaimTTL < time.Second {
+		return fmt.Errorf("invalid virtual function claim ttl %s, at least 1s is required", s.ClaimTTL)
+	}
+	// The virtual function is chosen when the container is created, and
+	// has no host side once moved.
+	if n.HostInterfaceName != "" || n.HostFirewallMark != 0 || n.HostShaping != nil {
+		return errors.New("host interface settings are not supported on sriov networks")
+	}
+	return nil
+}
+
+// pciAddress returns whether addr is a PCI address in the canonical
+// domain:bus:device.function form used by sysfs.
+func pciAddress(addr string) bool {
+	var domain, bus, device, function uint
+	if _, err := fmt.Sscanf(addr, "%x:%x:%x.%x", &domain, &bus, &device, &function); err != nil {
+		return false
+	}
+	return device < 32 && function < 8 &&
+		fmt.Sprintf("%04x:%02x:%02x.%x", domain, bus, device, function) == addr
+}
+
+// vdpaNetwork validates the vdpa networks, which create a vDPA device bound
+// to virtio_vdpa and move its interface to the container.
+func vdpaNetwork(n *configs.Network) error {
+	if n.Type != "vdpa" {
+		if n.VDPA != nil {
+			return fmt.Errorf("vdpa settings are not supported on %s networks", n.Type)
+		}
+		return nil
+	}
+	if n.Name == "" {
+		return errors.New("vdpa networks require a name")
+	}
+	if n.VDPA == nil || n.VDPA.MgmtDev == "" || n.VDPA.Device == "" {
+		return errors.New("vdpa networks require a management device and a device name")
+	}
+	if strings.Count(n.VDPA.MgmtDev, "/") > 1 || strings.HasPrefix(n.VDPA.MgmtDev, "/") || strings.HasSuffix(n.VDPA.MgmtDev, "/") {
+		return fmt.Errorf("invalid vdpa management device %q", n.VDPA.MgmtDev)
+	}
+	// The device name ends up in sysfs paths.
+	if err := interfaceName(n.VDPA.Device); err != nil {
+		return fmt.Errorf("invalid vdpa device name: %w", err)
+	}
+	if n.HostInterfaceName != "" || n.HostFirewallMark != 0 || n.HostShaping != nil {
+		return errors.New("host interface settings are not supported on vdpa networks")
+	}
+	return nil
+}
+
+// vethNetwork validates the bridge settings, which only apply to the host
+// end of veth pairs.
+func vethNetwork(n *configs.Network) error {
+	if n.Type != "veth" {
+		if n.Bridge != "" || n.HairpinMode || n.CreateBridge {
+			return fmt.Errorf("bridge settings are not supported on %s networks", n.Type)
+		}
+		return nil
+	}
+	if n.Bridge != "" {
+		if err := interfaceName(n.Bridge); err != nil {
+			return fmt.Errorf("invalid bridge name: %w", err)
+		}
+	}
+	if n.HairpinMode && n.Bridge == "" {
+		return errors.New("hairpin mode requires a bridge")
+	}
+	if n.CreateBridge && n.Bridge == "" {
+		return errors.New("creating a bridge requires a bridge name")
+	}
+	return nil
+}
+
+// tunnelNetwork validates the ipip, sit, gre, gretap and ip6tnl networks,
+// which create a tunnel interface in the container encapsulating packets in
+// IPv4, or in IPv6 for ip6tnl.
+func tunnelNetwork(n *configs.Network) error {
+	switch n.Type {
+	case "ipip", "sit", "gre", "gretap", "ip6tnl":
+	default:
+		if n.Tunnel != nil {
+			return fmt.Errorf("tunnel settings are not supported on %s networks", n.Type)
+		}
+		return nil
+	}
+	if n.Name == "" {
+		return fmt.Errorf("%s networks require a name", n.Type)
+	}
+	if n.Tunnel == nil {
+		return fmt.Errorf("%s networks require tunnel settings", n.Type)
+	}
+	ipv6 := n.Type == "ip6tnl"
+	if ip := net.ParseIP(n.Tunnel.Remote); ip == nil || (ip.To4() == nil) != ipv6 {
+		return fmt.Errorf("invalid tunnel remote address %q", n.Tunnel.Remote)
+	}
+	if n.Tunnel.Local != "" {
+		if ip := net.ParseIP(n.Tunnel.Local); ip == nil || (ip.To4() == nil) != ipv6 {
+			return fmt.Errorf("invalid tunnel local address %q", n.Tunnel.Local)
+		}
+	}
+	switch n.Tunnel.Mode {
+	case "":
+	case "ipip6", "ip6ip6", "any":
+		if !ipv6 {
+			return fmt.Errorf("tunnel modes are not supported on %s networks", n.Type)
+		}
+	default:
+		return fmt.Errorf("invalid tunnel mode %q", n.Tunnel.Mode)
+	}
+	if (n.Tunnel.InputKey != 0 || n.Tunnel.OutputKey != 0) && n.Type != "gre" && n.Type != "gretap" {
+		return fmt.Errorf("tunnel keys are not supported on %s networks", n.Type)
+	}
+	// Tunnel interfaces have no link layer address, except for gretap
+	// ones, and no host side interface.
+	if n.Type != "gretap" && (n.MacAddress != "" || n.AutoIPv4LinkLocal) {
+		return fmt.Errorf("mac address and IPv4 link-local settings are not supported on %s networks", n.Type)
+	}
+	if n.HostInterfaceName != "" || n.HostFirewallMark != 0 || n.HostShaping != nil {
+		return fmt.Errorf("host interface settings are not supported on %s networks", n.Type)
+	}
+	return nil
+}
+
+// l2tpNetwork validates the l2tpeth networks, which create an L2TPv3 tunnel
+// in the host and move the interface of its ethernet pseudowire session to
+// the container.
+func l2tpNetwork(n *configs.Network) error {
+	if n.Type != "l2tpeth" {
+		if n.L2TP != nil {
+			return fmt.Errorf("l2tp settings are not supported on %s networks", n.Type)
+		}
+		return nil
+	}
+	if n.Name == "" {
+		return errors.New("l2tpeth networks require a name")
+	}
+	s := n.L2TP
+	if s == nil {
+		return errors.New("l2tpeth networks require l2tp settings")
+	}
+	local, remote := net.ParseIP(s.Local), net.ParseIP(s.Remote)
+	if local == nil || remote == nil {
+		return fmt.Errorf("invalid l2tp tunnel addresses %q and %q", s.Local, s.Remote)
+	}
+	if (local.To4() == nil) != (remote.To4() == nil) {
+		return errors.New("l2tp tunnel addresses must be of the same family")
+	}
+	switch s.Encap {
+	case "", "udp":
+		if s.LocalPort == 0 || s.RemotePort == 0 {
+			return errors.New("l2tp udp encapsulation requires local and remote ports")
+		}
+	case "ip":
+		if s.LocalPort != 0 || s.RemotePort != 0 {
+			return errors.New("l2tp ip encapsulation has no ports")
+		}
+	default:
+		return fmt.Errorf("invalid l2tp encapsulation %q", s.Encap)
+	}
+	if s.TunnelID == 0 || s.PeerTunnelID == 0 || s.SessionID == 0 || s.PeerSessionID == 0 {
+		return errors.New("l2tp tunnel and session identifiers must not be zero")
+	}
+	if n.HostInterfaceName != "" || n.HostFirewallMark != 0 || n.HostShaping != nil {
+		return errors.New("host interface settings are not supported on l2tpeth networks")
+	}
+	return nil
+}
+
+// macvlanNetwork validates the macvlan and macvtap networks, which create a
+// macvlan or macvtap interface on a parent device of the host directly in the
+// container.
+func macvlanNetwork(n *configs.Network) error {
+	if n.Type != "macvlan" && n.Type != "macvtap" {
+		if n.Macvlan != nil {
+			return fmt.Errorf("macvlan settings are not supported on %s networks", n.Type)
+		}
+		return nil
+	}
+	if n.Name == "" {
+		return fmt.Errorf("%s networks require a name", n.Type)
+	}
+	if err := interfaceName(n.Parent); err != nil {
+		return fmt.Errorf("invalid parent interface name: %w", err)
+	}
+	if n.Macvlan != nil {
+		switch n.Macvlan.Mode {
+		case "", "bridge", "private", "vepa":
+		case "passthru":
+			if n.Type == "macvtap" {
+				return errors.New("passthru mode is not supported on macvtap networks")
+			}
+		default:
+			return fmt.Errorf("invalid macvlan mode %q", n.Macvlan.Mode)
+		}
+	}
+	if n.HostInterfaceName != "" || n.HostFirewallMark != 0 || n.HostShaping != nil {
+		return fmt.Errorf("host interface settings are not supported on %s networks", n.Type)
+	}
+	return nil
+}
+
+// ipvlanNetwork validates the ipvlan networks, which create an ipvlan
+// interface on a parent device of the host directly in the container.
+func ipvlanNetwork(n *configs.Network) error {
+	if n.Type != "ipvlan" {
+		if n.Ipvlan != nil {
+			return fmt.Errorf("ipvlan settings are not supported on %s networks", n.Type)
+		}
+		return nil
+	}
+	if n.Name == "" {
+		return errors.New("ipvlan networks require a name")
+	}
+	if err := interfaceName(n.Parent); err != nil {
+		return fmt.Errorf("invalid parent interface name: %w", err)
+	}
+	if n.MacAddress != "" {
+		return errors.New("ipvlan interfaces use the hardware address of their parent")
+	}
+	if n.Ipvlan != nil {
+		switch n.Ipvlan.Mode {
+		case "", "l2", "l3", "l3s":
+		default:
+			return fmt.Errorf("invalid ipvlan mode %q", n.Ipvlan.Mode)
+		}
+		switch n.Ipvlan.Flag {
+		case "", "bridge", "private", "vepa":
+		default:
+			return fmt.Errorf("invalid ipvlan flag %q", n.Ipvlan.Flag)
+		}
+	}
+	if n.HostInterfaceName != "" || n.HostFirewallMark != 0 || n.HostShaping != nil {
+		return errors.New("host interface settings are not supported on ipvlan networks")
+	}
+	return nil
+}
+
+// vlanNetwork validates the vlan networks, which create a VLAN subinterface
+// of a parent device of the host directly in the container.
+func vlanNetwork(n *configs.Network) error {
+	if n.Type != "vlan" {
+		if n.VLAN != nil {
+			return fmt.Errorf("vlan settings are not supported on %s networks", n.Type)
+		}
+		return nil
+	}
+	if n.Name == "" {
+		return errors.New("vlan networks require a name")
+	}
+	if err := interfaceName(n.Parent); err != nil {
+		return fmt.Errorf("invalid parent interface name: %w", err)
+	}
+	if n.VLAN == nil || n.VLAN.ID < 1 || n.VLAN.ID > 4094 {
+		return errors.New("vlan networks require a vlan id from 1 to 4094")
+	}
+	switch n.VLAN.Protocol {
+	case "", "802.1q", "802.1ad":
+	default:
+		return fmt.Errorf("invalid vlan protocol %q", n.VLAN.Protocol)
+	}
+	for from := range n.VLAN.IngressQosMap {
+		if from > 7 {
+			return fmt.Errorf("invalid vlan ingress priority %d", from)
+		}
+	}
+	for _, to := range n.VLAN.EgressQosMap {
+		if to > 7 {
+			return fmt.Errorf("invalid vlan egress priority %d", to)
+		}
+	}
+	if n.HostInterfaceName != "" || n.HostFirewallMark != 0 || n.HostShaping != nil {
+		return errors.New("host interface settings are not supported on vlan networks")
+	}
+	return nil
+}
+
+// tapNetwork validates the tap networks, which create a tap device directly in
+// the container.
+func tapNetwork(n *configs.Network) error {
+	if n.Type != "tap" {
+		if n.Tap != nil {
+			return fmt.Errorf("tap settings are not supported on %s networks", n.Type)
+		}
+		return nil
+	}
+	if n.Name == "" {
+		return errors.New("tap networks require a name")
+	}
+	if n.HostInterfaceName != "" || n.HostFirewallMark != 0 || n.HostShaping != nil {
+		return errors.New("host interface settings are not supported on tap networks")
+	}
+	return nil
+}
+
+// wireguardNetwork validates the wireguard networks, which create a WireGuard
+// interface in the host and move it to the container.
+func wireguardNetwork(n *configs.Network) error {
+	if n.Type != "wireguard" {
+		if n.WireGuard != nil {
+			return fmt.Errorf("wireguard settings are not supported on %s networks", n.Type)
+		}
+		return nil
+	}
+	if n.Name == "" {
+		return errors.New("wireguard networks require a name")
+	}
+	s := n.WireGuard
+	if s == nil || s.PrivateKeyFile == "" {
+		return errors.New("wireguard networks require a private key file")
+	}
+	if !filepath.IsAbs(s.PrivateKeyFile) {
+		return fmt.Errorf("private key file %q is not absolute", s.PrivateKeyFile)
+	}
+	seen := make(map[string]struct{}, len(s.Peers))
+	for _, p := range s.Peers {
+		if key, err := base64.StdEncoding.DecodeString(p.PublicKey); err != nil || len(key) != 32 {
+			return fmt.Errorf("invalid wireguard public key %q", p.PublicKey)
+		}
+		if _, ok := seen[p.PublicKey]; ok {
+			return fmt.Errorf("wireguard peer %s is configured more than once", p.PublicKey)
+		}
+		seen[p.PublicKey] = struct{}{}
+		if p.PresharedKeyFile != "" && !filepath.IsAbs(p.PresharedKeyFile) {
+			return fmt.Errorf("preshared key file %q is not absolute", p.PresharedKeyFile)
+		}
+		if p.Endpoint != "" {
+			host, port, err := net.SplitHostPort(p.Endpoint)
+			if err != nil || host == "" {
+				return fmt.Errorf("invalid wireguard endpoint %q", p.Endpoint)
+			}
+			if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+				return fmt.Errorf("invalid wireguard endpoint port %q", port)
+			}
+		}
+		for _, ip := range p.AllowedIPs {
+			if _, _, err := net.ParseCIDR(ip); err != nil {
+				return fmt.Errorf("invalid wireguard allowed IP: %w", err)
+			}
+		}
+	}
+	// WireGuard interfaces carry IP packets only.
+	if n.MacAddress != "" || n.AutoIPv4LinkLocal || n.PinGateway || len(n.Neighbors) > 0 {
+		return errors.New("link layer settings are not supported on wireguard networks")
+	}
+	if n.HostInterfaceName != "" || n.HostFirewallMark != 0 || n.HostShaping != nil {
+		return errors.New("host interface settings are not supported on wireguard networks")
+	}
+	return nil
+}
+
+// vxlanNetwork validates the vxlan networks, which create a VXLAN interface
+// in the host and move it to the container.
+func vxlanNetwork(n *configs.Network) error {
+	if n.Type != "vxlan" {
+		if n.VXLAN != nil {
+			return fmt.Errorf("vxlan settings are not supported on %s networks", n.Type)
+		}
+		return nil
+	}
+	if n.Name == "" {
+		return errors.New("vxlan networks require a name")
+	}
+	s := n.VXLAN
+	if s == nil || s.VNI >= 1<<24 {
+		return errors.New("vxlan networks require a vni lower than 16777216")
+	}
+	var local, remote net.IP
+	if s.Local != "" {
+		if local = net.ParseIP(s.Local); local == nil {
+			return fmt.Errorf("invalid vxlan local address %q", s.Local)
+		}
+	}
+	if s.Remote != "" {
+		if remote = net.ParseIP(s.Remote); remote == nil {
+			return fmt.Errorf("invalid vxlan remote address %q", s.Remote)
+		}
+	}
+	if local != nil && remote != nil && (local.To4() == nil) != (remote.To4() == nil) {
+		return errors.New("vxlan local and remote addresses must be of the same family")
+	}
+	if n.Parent != "" {
+		if err := interfaceName(n.Parent); err != nil {
+			return fmt.Errorf("invalid parent interface name: %w", err)
+		}
+	} else if remote.IsMulticast() {
+		return errors.New("vxlan multicast groups require a parent interface")
+	}
+	if (s.SrcPortLow == 0) != (s.SrcPortHigh == 0) || s.SrcPortLow > s.SrcPortHigh {
+		return fmt.Errorf("invalid vxlan source port range %d-%d", s.SrcPortLow, s.SrcPortHigh)
+	}
+	if err := tunnelTuning("vxlan", &s.TunnelTuning, local, remote); err != nil {
+		return err
+	}
+	if n.HostInterfaceName != "" || n.HostFirewallMark != 0 || n.HostShaping != nil {
+		return errors.New("host interface settings are not supported on vxlan networks")
+	}
+	return nil
+}
+
+// tunnelTuning validates the outer header settings of a UDP tunnel network of
+// the given kind, whose local and remote addresses may be nil.
+func tunnelTuning(kind string, t *configs.TunnelTuning, local, remote net.IP) error {
+	switch t.DF {
+	case "":
+		return nil
+	case configs.TunnelDFUnset, configs.TunnelDFSet, configs.TunnelDFInherit:
+	default:
+		return fmt.Errorf("invalid %s df %q", kind, t.DF)
+	}
+	for _, ip := range []net.IP{local, remote} {
+		if ip != nil && ip.To4() == nil {
+			return fmt.Errorf("%s df is only supported on IPv4 tunnels", kind)
+		}
+	}
+	return nil
+}
+
+// lldp validates the LLDP settings of a network.
+func lldp(n *configs.Network) error {
+	s := n.LLDP
+	if s == nil {
+		return nil
+	}
+	// The frames of the other types would not reach the physical link.
+	passthru := n.Type == "macvlan" && n.Macvlan != nil && n.Macvlan.Mode == "passthru"
+	if n.Type != "sriov" && !passthru {
+		return fmt.Errorf("lldp is not supported on %s networks other than passthru ones", n.Type)
+	}
+	if s.Interval < 0 || (s.Interval != 0 && s.Interval < time.Second) {
+		return fmt.Errorf("lldp interval must be at least one second, got %s", s.Interval)
+	}
+	for _, str := range []string{s.SystemName, s.SystemDescription, s.PortDescription} {
+		if len(str) > 255 {
+			return fmt.Errorf("lldp string %q is longer than 255 bytes", str)
+		}
+	}
+	for _, tlv := range s.TLVs {
+		if tlv.Type < 8 || tlv.Type > 127 {
+			return fmt.Errorf("lldp tlv type must be from 8 to 127, got %d", tlv.Type)
+		}
+		if len(tlv.Value) > 511 {
+			return fmt.Errorf("lldp tlv of type %d is longer than 511 bytes", tlv.Type)
+		}
+	}
+	return nil
+}
+
+func rpFilter(mode *int) error {
+	if mode != nil && (*mode < 0 || *mode > 2) {
+		return fmt.Errorf("rp_filter must be 0, 1 or 2, got %d", *mode)
+	}
+	return nil
+}
+
+func portForwards(n *configs.Network) error {
+	if len(n.PortForwards) == 0 {
+		return nil
+	}
+	if n.Type != "loopback" {
+		return errors.New("port forwards are only supported on loopback networks")
+	}
+	seen := make(map[uint16]struct{}, len(n.PortForwards))
+	for _, f := range n.PortForwards {
+		if f.HostPort == 0 || f.ContainerPort == 0 {
+			return errors.New("port forwards require both host and container ports")
+		}
+		if _, ok := seen[f.HostPort]; ok {
+			return fmt.Errorf("host port %d is forwarded more than once", f.HostPort)
+		}
+		seen[f.HostPort] = struct{}{}
+	}
+	return nil
+}
+
+func activationSocket(s *configs.ActivationSocket) error {
+	if strings.ContainsRune(s.Name, ':') {
+		return errors.New("name must not contain ':'")
+	}
+	switch s.Network {
+	case "tcp", "tcp4", "tcp6", "udp", "udp4", "udp6":
+		if _, _, err := net.SplitHostPort(s.Address); err != nil {
+			return err
+		}
+	case "unix", "unixgram":
+		// Abstract sockets start with '@'.
+		if !strings.HasPrefix(s.Address, "@") && !filepath.IsAbs(s.Address) {
+			return errors.New("unix socket address must be an absolute path")
+		}
+	default:
+		return fmt.Errorf("unsupported network %q", s.Network)
+	}
+	return nil
+}
+
+// geneveNetwork validates the geneve networks, which create a Geneve
+// interface in the host and move it to the container.
+func geneveNetwork(n *configs.Network) error {
+	if n.Type != "geneve" {
+		if n.Geneve != nil {
+			return fmt.Errorf("geneve settings are not supported on %s networks", n.Type)
+		}
+		return nil
+	}
+	if n.Name == "" {
+		return errors.New("geneve networks require a name")
+	}
+	s := n.Geneve
+	if s == nil {
+		return errors.New("geneve networks require geneve settings")
+	}
+	var remote net.IP
+	if s.External {
+		if s.VNI != 0 || s.Remote != "" {
+			return errors.New("geneve networks in external mode do not support a vni or a remote address")
+		}
+	} else {
+		if s.VNI >= 1<<24 {
+			return errors.New("geneve networks require a vni lower than 16777216")
+		}
+		remote = net.ParseIP(s.Remote)
+		if remote == nil || remote.IsMulticast() || remote.IsUnspecified() {
+			return fmt.Errorf("invalid geneve remote address %q", s.Remote)
+		}
+	}
+	if err := tunnelTuning("geneve", &s.TunnelTuning, nil, remote); err != nil {
+		return err
+	}
+	if n.HostInterfaceName != "" || n.HostFirewallMark != 0 || n.HostShaping != nil {
+		return errors.New("host interface settings are not supported on geneve networks")
+	}
+	return nil
+}
+
+// bondNetwork validates the bond networks, which create a bond interface in
+// the container. Their slaves are checked against the other networks by
+// bondSlaves.
+func bondNetwork(n *configs.Network) error {
+	if n.Type != "bond" {
+		if n.Bond != nil {
+			return fmt.Errorf("bond settings are not supported on %s networks", n.Type)
+		}
+		return nil
+	}
+	if n.Name == "" {
+		return errors.New("bond networks require a name")
+	}
+	s := n.Bond
+	if s == nil {
+		return errors.New("bond networks require bond settings")
+	}
+	switch s.Mode {
+	case configs.BondActiveBackup:
+		if s.LACPRate != "" || s.XmitHashPolicy != "" {
+			return errors.New("lacp rate and transmit hash policy are only supported in 802.3ad mode")
+		}
+	case configs.Bond8023AD:
+		if s.Primary != "" {
+			return errors.New("primary slave is only supported in active-backup mode")
+		}
+		switch s.LACPRate {
+		case "", "slow", "fast":
+		default:
+			return fmt.Errorf("invalid lacp rate %q", s.LACPRate)
+		}
+		switch s.XmitHashPolicy {
+		case "", "layer2", "layer2+3", "layer3+4", "encap2+3", "encap3+4":
+		default:
+			return fmt.Errorf("invalid transmit hash policy %q", s.XmitHashPolicy)
+		}
+	default:
+		return fmt.Errorf("invalid bonding mode %q", s.Mode)
+	}
+	if len(s.Slaves) < 2 {
+		return errors.New("bond networks require at least two slaves")
+	}
+	seen := make(map[string]bool, len(s.Slaves))
+	for _, slave := range s.Slaves {
+		if seen[slave] {
+			return fmt.Errorf("duplicate slave %q", slave)
+		}
+		seen[slave] = true
+	}
+	if s.Primary != "" && !seen[s.Primary] {
+		return fmt.Errorf("primary slave %q is not a slave of the bond", s.Primary)
+	}
+	if n.HostInterfaceName != "" || n.HostFirewallMark != 0 || n.HostShaping != nil {
+		return errors.New("host interface settings are not supported on bond networks")
+	}
+	return nil
+}
+
+// teamNetwork validates the team networks, which create a team interface in
+// the container. Their ports are checked against the other networks by
+// bondSlaves.
+func teamNetwork(n *configs.Network) error {
+	if n.Type != "team" {
+		if n.Team != nil {
+			return fmt.Errorf("team settings are not supported on %s networks", n.Type)
+		}
+		return nil
+	}
+	if n.Name == "" {
+		return errors.New("team networks require a name")
+	}
+	s := n.Team
+	if s == nil {
+		return errors.New("team networks require team settings")
+	}
+	switch s.Runner {
+	case configs.TeamRunnerActiveBackup:
+	case configs.TeamRunnerRoundRobin, configs.TeamRunnerBroadcast, configs.TeamRunnerRandom:
+		if s.ActivePort != "" {
+			return errors.New("active port is only supported by the activebackup runner")
+		}
+	default:
+		return fmt.Errorf("invalid team runner %q", s.Runner)
+	}
+	if len(s.Ports) < 2 {
+		return errors.New("team networks require at least two ports")
+	}
+	seen := make(map[string]bool, len(s.Ports))
+	for _, port := range s.Ports {
+		if seen[port] {
+			return fmt.Errorf("duplicate port %q", port)
+		}
+		seen[port] = true
+	}
+	if s.ActivePort != "" && !seen[s.ActivePort] {
+		return fmt.Errorf("active port %q is not a port of the team", s.ActivePort)
+	}
+	if n.HostInterfaceName != "" || n.HostFirewallMark != 0 || n.HostShaping != nil {
+		return errors.New("host interface settings are not supported on team networks")
+	}
+	return nil
+}
+
+// macsecNetwork validates the macsec networks, which create a MACsec
+// interface in the container. Their link is checked against the other
+// networks by macsecLinks.
+func macsecNetwork(n *configs.Network) error {
+	if n.Type != "macsec" {
+		if n.MACsec != nil {
+			return fmt.Errorf("macsec settings are not supported on %s networks", n.Type)
+		}
+		return nil
+	}
+	if n.Name == "" {
+		return errors.New("macsec networks require a name")
+	}
+	s := n.MACsec
+	if s == nil {
+		return errors.New("macsec networks require macsec settings")
+	}
+	if s.Link == "" {
+		return errors.New("macsec networks require a link")
+	}
+	switch s.CipherSuite {
+	case "", "gcm-aes-128", "gcm-aes-256":
+	default:
+		return fmt.Errorf("invalid macsec cipher suite %q", s.CipherSuite)
+	}
+	if s.TxSA == nil {
+		return errors.New("macsec networks require a transmit secure association")
+	}
+	if err := macsecSA(s.TxSA); err != nil {
+		return err
+	}
+	seen := make(map[string]bool, len(s.RxSCs))
+	for _, sc := range s.RxSCs {
+		mac, err := net.ParseMAC(sc.Address)
+		if err != nil || len(mac) != 6 {
+			return fmt.Errorf("invalid macsec peer address %q", sc.Address)
+		}
+		port := sc.Port
+		if port == 0 {
+			port = 1
+		}
+		sci := mac.String() + "/" + strconv.Itoa(int(port))
+		if seen[sci] {
+			return fmt.Errorf("duplicate macsec peer %s", sci)
+		}
+		seen[sci] = true
+		if len(sc.SAs) == 0 {
+			return fmt.Errorf("macsec peer %s requires a secure association", sci)
+		}
+		ans := make(map[uint8]bool, len(sc.SAs))
+		for _, sa := range sc.SAs {
+			if err := macsecSA(sa); err != nil {
+				return err
+			}
+			if ans[sa.AN] {
+				return fmt.Errorf("duplicate association number %d for macsec peer %s", sa.AN, sci)
+			}
+			ans[sa.AN] = true
+		}
+	}
+	if n.HostInterfaceName != "" || n.HostFirewallMark != 0 || n.HostShaping != nil {
+		return errors.New("host interface settings are not supported on macsec networks")
+	}
+	return nil
+}
+
+// pluginNetwork validates the plugin networks, whose interface is created by
+// an external program.
+func pluginNetwork(n *configs.Network) error {
+	if n.Type != "plugin" {
+		if n.Plugin != nil {
+			return fmt.Errorf("plugin settings are not supported on %s networks", n.Type)
+		}
+		return nil
+	}
+	if n.Name == "" {
+		return errors.New("plugin networks require a name")
+	}
+	if n.Plugin == nil || n.Plugin.Command == nil {
+		return errors.New("plugin networks require a plugin command")
+	}
+	if !filepath.IsAbs(n.Plugin.Command.Path) {
+		return fmt.Errorf("plugin path %q is not absolute", n.Plugin.Command.Path)
+	}
+	if len(n.Plugin.Config) > 0 && !json.Valid(n.Plugin.Config) {
+		return errors.New("plugin config is not valid JSON")
+	}
+	if n.HostInterfaceName != "" || n.HostFirewallMark != 0 || n.HostShaping != nil {
+		return errors.New("host interface settings are not supported on plugin networks")
+	}
+	return nil
+}
+
+func macsecSA(sa *configs.MACsecSA) error {
+	if sa.AN > 3 {
+		return fmt.Errorf("macsec association number must be from 0 to 3, got %d", sa.AN)
+	}
+	if id, err := hex.DecodeString(sa.KeyID); err != nil || len(id) != 16 {
+		return fmt.Errorf("invalid macsec key id %q, 16 hex encoded bytes are required", sa.KeyID)
+	}
+	if !filepath.IsAbs(sa.KeyFile) {
+		return fmt.Errorf("macsec key file path %q is not absolute", sa.KeyFile)
+	}
+	return nil
+}
+
+// macsecLinks checks that the links of the macsec networks are the
+// interfaces of networks set up before them, which can carry ethernet
+// frames.
+func macsecLinks(networks []*configs.Network) error {
+	before := make(map[string]*configs.Network, len(networks))
+	for _, n := range networks {
+		if n.MACsec != nil {
+			l, ok := before[n.MACsec.Link]
+			if !ok {
+				return fmt.Errorf("invalid network %q: link %q must be the interface of a network listed before the macsec one", n.Name, n.MACsec.Link)
+			}
+			switch l.Type {
+			case "loopback", "ipip", "sit", "gre", "ip6tnl", "wireguard", "can", "vcan", "vxcan", "ipoib":
+				return fmt.Errorf("invalid network %q: macsec is not supported over %s interfaces", n.Name, l.Type)
+			}
+			if l.Optional {
+				return fmt.Errorf("invalid network %q: macsec is not supported over optional network %q", n.Name, l.Name)
+			}
+		}
+		if n.Name != "" {
+			before[n.Name] = n
+		}
+	}
+	return nil
+}
+
+// trafficFilterTargets checks that the targets of the mirred traffic filters
+// are the interfaces of networks set up before the filtered ones, which are
+// not optional.
+func trafficFilterTargets(networks []*configs.Network) error {
+	before := make(map[string]*configs.Network, len(networks))
+	for _, n := range networks {
+		for _, f := range n.TrafficFilters {
+			if f.Action != configs.FilterMirred {
+				continue
+			}
+			t, ok := before[f.Target]
+			if !ok {
+				return fmt.Errorf("invalid network %q: traffic filter target %q must be the interface of a network listed before", n.Name, f.Target)
+			}
+			if t.Optional {
+				return fmt.Errorf("invalid network %q: traffic filter target can not be optional network %q", n.Name, f.Target)
+			}
+		}
+		if n.Name != "" {
+			before[n.Name] = n
+		}
+	}
+	return nil
+}
+
+// bondSlaves checks that the slaves of the bond networks and the ports of the
+// team networks are the interfaces of networks set up before them, enslaved
+// to a single bond or team, and without addresses or gateways of their own.
+func bondSlaves(networks []*configs.Network) error {
+	before := make(map[string]*configs.Network, len(networks))
+	master := make(map[string]string)
+	for _, n := range networks {
+		var slaves []string
+		switch {
+		case n.Bond != nil:
+			slaves = n.Bond.Slaves
+		case n.Team != nil:
+			slaves = n.Team.Ports
+		}
+		for _, slave := range slaves {
+			s, ok := before[slave]
+			if !ok {
+				return fmt.Errorf("invalid network %q: slave %q must be the interface of a network listed before the %s", n.Name, slave, n.Type)
+			}
+			if s.Type == "loopback" || s.Type == "bond" || s.Type == "team" {
+				return fmt.Errorf("invalid network %q: %s interfaces can not be enslaved", n.Name, s.Type)
+			}
+			if s.Optional {
+				return fmt.Errorf("invalid network %q: optional network %q can not be enslaved", n.Name, slave)
+			}
+			if other, ok := master[slave]; ok {
+				return fmt.Errorf("invalid network %q: slave %q is already enslaved to %q", n.Name, slave, other)
+			}
+			master[slave] = n.Name
+			if s.Address != "" || s.IPv6Address != "" || s.Gateway != "" || s.IPv6Gateway != "" {
+				return fmt.Errorf("invalid network %q: slave %q must not have addresses or gateways", n.Name, slave)
+			}
+		}
+		if n.Name != "" {
+			before[n.Name] = n
+		}
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+//go:build gofuzz
+// +build gofuzz
+
+package validate
+
+import (
+	"encoding/json"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+// FuzzNetworkConfig parses the network related parts of a container
+// configuration and validates them.
+func FuzzNetworkConfig(data []byte) int {
+	config := &configs.Config{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return 0
+	}
+	config.Namespaces = configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}})
+	if err := network(config); err != nil {
+		return 0
+	}
+	return 1
+}
@@ -71,10 +71,45 @@ func rootfs(config *configs.Config) error {
 
 func network(config *configs.Config) error {
 	if !config.Namespaces.Contains(configs.NEWNET) {
-		if len(config.Networks) > 0 || len(config.Routes) > 0 {
+		if len(config.Networks) > 0 || len(config.Routes) > 0 || config.NetworkOptions != nil {
 			return errors.New("unable to apply network settings without a private NET namespace")
 		}
 	}
+	if err := networkOptions(config.NetworkOptions); err != nil {
+		return err
+	}
+	for i, n := range config.Networks {
+		if err := networkDevice(n); err != nil {
+			return fmt.Errorf("invalid network %q: %w", n.Name, err)
+		}
+		// The interfaces of the networks listed before would be removed.
+		if n.Isolate != "" && i != 0 {
+			return fmt.Errorf("invalid network %q: the network isolating the namespace must be listed first", n.Name)
+		}
+		if err := rawLinkAttributes(n, config.NetworkOptions); err != nil {
+			return fmt.Errorf("invalid network %q: %w", n.Name, err)
+		}
+	}
+	if err := bondSlaves(config.Networks); err != nil {
+		return err
+	}
+	if err := macsecLinks(config.Networks); err != nil {
+		return err
+	}
+	if err := prefixDelegationInterfaces(config.Networks); err != nil {
+		return err
+	}
+	if err := trafficFilterTargets(config.Networks); err != nil {
+		return err
+	}
+	if err := maskedProcNet(config); err != nil {
+		return err
+	}
+	for _, s := range config.ActivationSockets {
+		if err := activationSocket(s); err != nil {
+			return fmt.Errorf("invalid activation socket %s %s: %w", s.Network, s.Address, err)
+		}
+	}
 	return nil
 }
 
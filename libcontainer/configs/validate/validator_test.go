@@ -3,7 +3,9 @@ package validate
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/opencontainers/runc/libcontainer/configs"
 	"github.com/opencontainers/runtime-spec/specs-go"
@@ -839,3 +841,1654 @@ func TestValidateScheduler(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateNetworkPortForwards(t *testing.T) {
+	testCases := []struct {
+		name    string
+		network *configs.Network
+		isErr   bool
+	}{
+		{
+			name: "valid",
+			network: &configs.Network{
+				Type:         "loopback",
+				PortForwards: []*configs.PortForward{{HostPort: 18080, ContainerPort: 8080}, {HostPort: 18081, ContainerPort: 8080}},
+			},
+		},
+		{
+			name: "not loopback",
+			network: &configs.Network{
+				Type:         "veth",
+				PortForwards: []*configs.PortForward{{HostPort: 18080, ContainerPort: 8080}},
+			},
+			isErr: true,
+		},
+		{
+			name: "missing port",
+			network: &configs.Network{
+				Type:         "loopback",
+				PortForwards: []*configs.PortForward{{HostPort: 18080}},
+			},
+			isErr: true,
+		},
+		{
+			name: "duplicated host port",
+			network: &configs.Network{
+				Type:         "loopback",
+				PortForwards: []*configs.PortForward{{HostPort: 18080, ContainerPort: 80}, {HostPort: 18080, ContainerPort: 81}},
+			},
+			isErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		config := &configs.Config{
+			Rootfs: "/var",
+			Namespaces: configs.Namespaces(
+				[]configs.Namespace{{Type: configs.NEWNET}},
+			),
+			Networks: []*configs.Network{tc.network},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("%s: expected error, got nil", tc.name)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+		}
+	}
+}
+
+func TestValidateActivationSockets(t *testing.T) {
+	testCases := []struct {
+		socket *configs.ActivationSocket
+		isErr  bool
+	}{
+		{socket: &configs.ActivationSocket{Network: "tcp", Address: ":8080"}},
+		{socket: &configs.ActivationSocket{Network: "udp6", Address: "[::1]:53", Name: "dns"}},
+		{socket: &configs.ActivationSocket{Network: "unix", Address: "/run/app.sock"}},
+		{socket: &configs.ActivationSocket{Network: "unixgram", Address: "@app"}},
+		{socket: &configs.ActivationSocket{Network: "tcp", Address: "8080"}, isErr: true},
+		{socket: &configs.ActivationSocket{Network: "unix", Address: "run/app.sock"}, isErr: true},
+		{socket: &configs.ActivationSocket{Network: "sctp", Address: ":8080"}, isErr: true},
+		{socket: &configs.ActivationSocket{Network: "tcp", Address: ":8080", Name: "a:b"}, isErr: true},
+	}
+	for _, tc := range testCases {
+		config := &configs.Config{
+			Rootfs:            "/var",
+			ActivationSockets: []*configs.ActivationSocket{tc.socket},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("%+v: expected error, got nil", tc.socket)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("%+v: unexpected error: %v", tc.socket, err)
+		}
+	}
+}
+
+func TestValidateNetworkRPFilter(t *testing.T) {
+	for _, mode := range []int{-1, 0, 1, 2, 3} {
+		mode := mode
+		isErr := mode < 0 || mode > 2
+		for _, config := range []*configs.Config{
+			{
+				Rootfs:     "/var",
+				Namespaces: configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+				Networks:   []*configs.Network{{Type: "loopback", RPFilter: &mode}},
+			},
+			{
+				Rootfs:         "/var",
+				Namespaces:     configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+				NetworkOptions: &configs.NetworkOptions{RPFilter: &mode},
+			},
+		} {
+			err := Validate(config)
+			if isErr && err == nil {
+				t.Errorf("rp_filter %d: expected error, got nil", mode)
+			}
+			if !isErr && err != nil {
+				t.Errorf("rp_filter %d: unexpected error: %v", mode, err)
+			}
+		}
+	}
+
+	config := &configs.Config{
+		Rootfs:         "/var",
+		NetworkOptions: &configs.NetworkOptions{},
+	}
+	if err := Validate(config); err == nil {
+		t.Error("expected error for network options without a NET namespace")
+	}
+}
+
+func TestValidateMaskedProcNet(t *testing.T) {
+	testCases := []struct {
+		policy    configs.MaskedProcNetPolicy
+		maskPaths []string
+		networks  []*configs.Network
+		isErr     bool
+	}{
+		{policy: configs.MaskedProcNetError, maskPaths: []string{"/proc/net"}, networks: []*configs.Network{{Type: "veth", Name: "eth0"}}, isErr: true},
+		{policy: configs.MaskedProcNetError, maskPaths: []string{"/proc/net/dev/"}, networks: []*configs.Network{{Type: "veth", Name: "eth0"}}, isErr: true},
+		{policy: configs.MaskedProcNetError, maskPaths: []string{"/proc/network"}, networks: []*configs.Network{{Type: "veth", Name: "eth0"}}},
+		{policy: configs.MaskedProcNetError, maskPaths: []string{"/proc/net"}, networks: []*configs.Network{{Type: "loopback"}}},
+		{policy: configs.MaskedProcNetWarn, maskPaths: []string{"/proc/net"}, networks: []*configs.Network{{Type: "veth", Name: "eth0"}}},
+		{policy: configs.MaskedProcNetIgnore, maskPaths: []string{"/proc/net"}, networks: []*configs.Network{{Type: "veth", Name: "eth0"}}},
+		{policy: "fail", isErr: true},
+	}
+	for _, tc := range testCases {
+		config := &configs.Config{
+			Rootfs: "/var",
+			Namespaces: configs.Namespaces(
+				[]configs.Namespace{{Type: configs.NEWNET}, {Type: configs.NEWNS}},
+			),
+			MaskPaths:      tc.maskPaths,
+			Networks:       tc.networks,
+			NetworkOptions: &configs.NetworkOptions{MaskedProcNet: tc.policy},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("%+v: expected error, got nil", tc)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("%+v: unexpected error: %v", tc, err)
+		}
+	}
+}
+
+func TestValidateNetworkInterfaceName(t *testing.T) {
+	testCases := []struct {
+		name, hostName string
+		isErr          bool
+	}{
+		{name: "eth0", hostName: "veth1234"},
+		{name: "eth0.100"},
+		{name: "", hostName: "veth1234"},
+		{name: "0123456789abcde"},
+		{name: "0123456789abcdef", isErr: true},
+		{name: "..", isErr: true},
+		{name: "eth/0", isErr: true},
+		{name: "eth:0", isErr: true},
+		{name: "eth 0", isErr: true},
+		{name: "eth0", hostName: "veth\"}", isErr: true},
+		{name: "eth0", hostName: "../all", isErr: true},
+	}
+	for _, tc := range testCases {
+		config := &configs.Config{
+			Rootfs:     "/var",
+			Namespaces: configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+			Networks:   []*configs.Network{{Type: "veth", Name: tc.name, HostInterfaceName: tc.hostName}},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("%q/%q: expected error, got nil", tc.name, tc.hostName)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("%q/%q: unexpected error: %v", tc.name, tc.hostName, err)
+		}
+	}
+}
+
+func TestValidateRawLinkAttributes(t *testing.T) {
+	testCases := []struct {
+		attr         configs.LinkAttribute
+		experimental bool
+		isErr        bool
+	}{
+		{attr: configs.LinkAttribute{Type: unix.IFLA_MTU}, experimental: true},
+		{attr: configs.LinkAttribute{Type: unix.IFLA_LINKINFO | unix.NLA_F_NESTED}, experimental: true},
+		{attr: configs.LinkAttribute{Type: unix.IFLA_MTU}, isErr: true},
+		{attr: configs.LinkAttribute{Type: unix.IFLA_IFNAME}, experimental: true, isErr: true},
+		{attr: configs.LinkAttribute{Type: unix.IFLA_NET_NS_FD | unix.NLA_F_NESTED}, experimental: true, isErr: true},
+		{attr: configs.LinkAttribute{Type: unix.IFLA_TARGET_NETNSID}, experimental: true, isErr: true},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		config := &configs.Config{
+			Rootfs:         "/var",
+			Namespaces:     configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+			NetworkOptions: &configs.NetworkOptions{Experimental: tc.experimental},
+			Networks:       []*configs.Network{{Type: "veth", Name: "eth0", RawLinkAttributes: []*configs.LinkAttribute{&tc.attr}}},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("attribute %d (experimental %v): expected error, got nil", tc.attr.Type, tc.experimental)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("attribute %d (experimental %v): unexpected error: %v", tc.attr.Type, tc.experimental, err)
+		}
+	}
+}
+
+func TestValidateNetworkStatsHistory(t *testing.T) {
+	testCases := []struct {
+		history configs.StatsHistory
+		isErr   bool
+	}{
+		{history: configs.StatsHistory{Interval: time.Second, Samples: 60}},
+		{history: configs.StatsHistory{Interval: time.Millisecond, Samples: 60}, isErr: true},
+		{history: configs.StatsHistory{Interval: time.Second}, isErr: true},
+		{history: configs.StatsHistory{Interval: time.Second, Samples: 1 << 20}, isErr: true},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		config := &configs.Config{
+			Rootfs:         "/var",
+			Namespaces:     configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+			NetworkOptions: &configs.NetworkOptions{StatsHistory: &tc.history},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("%+v: expected error, got nil", tc.history)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("%+v: unexpected error: %v", tc.history, err)
+		}
+	}
+}
+
+func TestValidateNetworkSetupTimeout(t *testing.T) {
+	for _, timeout := range []time.Duration{0, 30 * time.Second, -time.Second} {
+		config := &configs.Config{
+			Rootfs:         "/var",
+			Namespaces:     configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+			NetworkOptions: &configs.NetworkOptions{SetupTimeout: timeout},
+		}
+		err := Validate(config)
+		if timeout < 0 && err == nil {
+			t.Errorf("%s: expected error, got nil", timeout)
+		}
+		if timeout >= 0 && err != nil {
+			t.Errorf("%s: unexpected error: %v", timeout, err)
+		}
+	}
+}
+
+func TestValidateNetworkHostRoutes(t *testing.T) {
+	testCases := []struct {
+		network configs.Network
+		isErr   bool
+	}{
+		{network: configs.Network{Type: "veth", Name: "eth0", HostInterfaceName: "veth0", Address: "10.0.0.2/32", HostRoutes: true}},
+		{network: configs.Network{Type: "veth", Name: "eth0", HostInterfaceName: "veth0", IPv6Address: "fd00::2/128", HostRoutes: true}},
+		{network: configs.Network{Type: "veth", Name: "eth0", HostInterfaceName: "veth0", HostRoutes: true}, isErr: true},
+		{network: configs.Network{Type: "macvlan", Name: "eth0", Parent: "eno1", Address: "10.0.0.2/32", HostRoutes: true}, isErr: true},
+	}
+	for _, tc := range testCases {
+		config := &configs.Config{
+			Rootfs:     "/var",
+			Namespaces: configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+			Networks:   []*configs.Network{&tc.network},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("network %+v: expected error, got nil", tc.network)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("network %+v: unexpected error: %v", tc.network, err)
+		}
+	}
+}
+
+func TestValidateNetworkUnnumbered(t *testing.T) {
+	testCases := []struct {
+		network configs.Network
+		isErr   bool
+	}{
+		{network: configs.Network{Type: "veth", Name: "eth0", HostInterfaceName: "veth0", Unnumbered: true}},
+		{network: configs.Network{Type: "veth", Name: "eth0", HostInterfaceName: "veth0", IPv6Address: "fd00::2/64", Unnumbered: true}, isErr: true},
+		{network: configs.Network{Type: "veth", Name: "eth0", HostInterfaceName: "veth0", Gateway: "10.0.0.1", Unnumbered: true}, isErr: true},
+		{network: configs.Network{Type: "loopback", Unnumbered: true}, isErr: true},
+		{network: configs.Network{Type: "vcan", Name: "vcan0", Unnumbered: true}, isErr: true},
+	}
+	for _, tc := range testCases {
+		config := &configs.Config{
+			Rootfs:     "/var",
+			Namespaces: configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+			Networks:   []*configs.Network{&tc.network},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("network %+v: expected error, got nil", tc.network)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("network %+v: unexpected error: %v", tc.network, err)
+		}
+	}
+}
+
+func TestValidateNetworkDropRouterAdvertisements(t *testing.T) {
+	testCases := []struct {
+		network configs.Network
+		isErr   bool
+	}{
+		{network: configs.Network{Type: "veth", Name: "eth0", HostInterfaceName: "veth0", IPv6Address: "fd00::2/64", DropRouterAdvertisements: true}},
+		{network: configs.Network{Type: "macvlan", Name: "eth0", Parent: "eth0", DropRouterAdvertisements: true}},
+		{network: configs.Network{Type: "loopback", DropRouterAdvertisements: true}, isErr: true},
+		{network: configs.Network{Type: "veth", Name: "eth0", HostInterfaceName: "veth0", Unnumbered: true, DropRouterAdvertisements: true}, isErr: true},
+	}
+	for _, tc := range testCases {
+		config := &configs.Config{
+			Rootfs:     "/var",
+			Namespaces: configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+			Networks:   []*configs.Network{&tc.network},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("network %+v: expected error, got nil", tc.network)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("network %+v: unexpected error: %v", tc.network, err)
+		}
+	}
+}
+
+func TestValidateNetworkLLDP(t *testing.T) {
+	testCases := []struct {
+		network configs.Network
+		isErr   bool
+	}{
+		{network: configs.Network{Type: "sriov", Name: "eth0", Parent: "ens1f0", LLDP: &configs.LLDPSettings{}}},
+		{network: configs.Network{Type: "sriov", Name: "eth0", Parent: "ens1f0", LLDP: &configs.LLDPSettings{
+			Interval: 10 * time.Second, SystemName: "web-1", TLVs: []*configs.LLDPTLV{{Type: 127, Value: []byte{0x00, 0x80, 0xc2, 0x01, 0x00, 0x2a}}},
+		}}},
+		{network: configs.Network{Type: "macvlan", Name: "eth0", Parent: "ens1f0", Macvlan: &configs.MacvlanSettings{Mode: "passthru"}, LLDP: &configs.LLDPSettings{}}},
+		{network: configs.Network{Type: "macvlan", Name: "eth0", Parent: "ens1f0", LLDP: &configs.LLDPSettings{}}, isErr: true},
+		{network: configs.Network{Type: "veth", Name: "eth0", HostInterfaceName: "veth0", LLDP: &configs.LLDPSettings{}}, isErr: true},
+		{network: configs.Network{Type: "sriov", Name: "eth0", Parent: "ens1f0", LLDP: &configs.LLDPSettings{Interval: time.Millisecond}}, isErr: true},
+		{network: configs.Network{Type: "sriov", Name: "eth0", Parent: "ens1f0", LLDP: &configs.LLDPSettings{PortDescription: strings.Repeat("x", 256)}}, isErr: true},
+		{network: configs.Network{Type: "sriov", Name: "eth0", Parent: "ens1f0", LLDP: &configs.LLDPSettings{TLVs: []*configs.LLDPTLV{{Type: 5}}}}, isErr: true},
+		{network: configs.Network{Type: "sriov", Name: "eth0", Parent: "ens1f0", LLDP: &configs.LLDPSettings{TLVs: []*configs.LLDPTLV{{Type: 127, Value: make([]byte, 512)}}}}, isErr: true},
+	}
+	for _, tc := range testCases {
+		config := &configs.Config{
+			Rootfs:     "/var",
+			Namespaces: configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+			Networks:   []*configs.Network{&tc.network},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("network %+v: expected error, got nil", tc.network)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("network %+v: unexpected error: %v", tc.network, err)
+		}
+	}
+}
+
+func TestValidateNetworkStatsBackend(t *testing.T) {
+	for _, backend := range []configs.NetworkStatsBackend{"", "sysfs", "netlink", "ebpf", "procfs"} {
+		config := &configs.Config{
+			Rootfs:         "/var",
+			Namespaces:     configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+			NetworkOptions: &configs.NetworkOptions{StatsBackend: backend},
+		}
+		err := Validate(config)
+		if backend == "procfs" && err == nil {
+			t.Errorf("%q: expected error, got nil", backend)
+		}
+		if backend != "procfs" && err != nil {
+			t.Errorf("%q: unexpected error: %v", backend, err)
+		}
+	}
+}
+
+func TestValidateNetlinkRateLimit(t *testing.T) {
+	for _, tc := range []struct {
+		limit configs.RateLimit
+		isErr bool
+	}{
+		{limit: configs.RateLimit{Rate: 0.5, Burst: 1}},
+		{limit: configs.RateLimit{Rate: 0, Burst: 1}, isErr: true},
+		{limit: configs.RateLimit{Rate: 10}, isErr: true},
+	} {
+		tc := tc
+		config := &configs.Config{
+			Rootfs:         "/var",
+			Namespaces:     configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+			NetworkOptions: &configs.NetworkOptions{NetlinkRateLimit: &tc.limit},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("%+v: expected error, got nil", tc.limit)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("%+v: unexpected error: %v", tc.limit, err)
+		}
+	}
+}
+
+func TestValidateNetworkPinGateway(t *testing.T) {
+	testCases := []struct {
+		network configs.Network
+		isErr   bool
+	}{
+		{network: configs.Network{Type: "veth", Gateway: "10.0.0.1", PinGateway: true}},
+		{network: configs.Network{Type: "veth", IPv6Gateway: "fd00::1", PinGateway: true, GatewayMacAddress: "02:00:00:00:00:01"}},
+		{network: configs.Network{Type: "veth", PinGateway: true}, isErr: true},
+		{network: configs.Network{Type: "veth", Gateway: "invalid", PinGateway: true}, isErr: true},
+		{network: configs.Network{Type: "veth", Gateway: "10.0.0.1", PinGateway: true, GatewayMacAddress: "invalid"}, isErr: true},
+		{network: configs.Network{Type: "veth", Gateway: "10.0.0.1", GatewayMacAddress: "02:00:00:00:00:01"}, isErr: true},
+		{network: configs.Network{Type: "loopback", Gateway: "127.0.0.1", PinGateway: true}, isErr: true},
+		{network: configs.Network{Type: "veth", Gateway: "10.0.0.1", GatewayMetric: 100}},
+		{network: configs.Network{Type: "veth", GatewayMetric: 100}, isErr: true},
+		{network: configs.Network{Type: "veth", IPv6Gateway: "fd00::1", GatewayMetric: -1}, isErr: true},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		config := &configs.Config{
+			Rootfs:     "/var",
+			Namespaces: configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+			Networks:   []*configs.Network{&tc.network},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("%+v: expected error, got nil", tc.network)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("%+v: unexpected error: %v", tc.network, err)
+		}
+	}
+}
+
+func TestValidateNetworkAutoIPv4LinkLocal(t *testing.T) {
+	testCases := []struct {
+		network configs.Network
+		isErr   bool
+	}{
+		{network: configs.Network{Type: "veth", Name: "eth0", AutoIPv4LinkLocal: true}},
+		{network: configs.Network{Type: "veth", Name: "eth0", AutoIPv4LinkLocal: true, Address: "10.0.0.2/24"}, isErr: true},
+		{network: configs.Network{Type: "loopback", AutoIPv4LinkLocal: true}, isErr: true},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		config := &configs.Config{
+			Rootfs:     "/var",
+			Namespaces: configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+			Networks:   []*configs.Network{&tc.network},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("%+v: expected error, got nil", tc.network)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("%+v: unexpected error: %v", tc.network, err)
+		}
+	}
+}
+
+func TestValidateNetworkProfile(t *testing.T) {
+	testCases := []struct {
+		network configs.Network
+		isErr   bool
+	}{
+		{network: configs.Network{Type: "veth", Name: "eth0", Profile: "lowlatency"}},
+		{network: configs.Network{Type: "veth", Name: "eth0", Profile: "unknown"}, isErr: true},
+		{network: configs.Network{Type: "loopback", Profile: "default"}, isErr: true},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		config := &configs.Config{
+			Rootfs:     "/var",
+			Namespaces: configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+			Networks:   []*configs.Network{&tc.network},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("%+v: expected error, got nil", tc.network)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("%+v: unexpected error: %v", tc.network, err)
+		}
+	}
+}
+
+func TestValidateNetworkApplyPolicy(t *testing.T) {
+	testCases := []struct {
+		policy configs.ApplyPolicy
+		isErr  bool
+	}{
+		{policy: ""},
+		{policy: configs.ApplyPolicyStrict},
+		{policy: configs.ApplyPolicyBestEffort},
+		{policy: "lenient", isErr: true},
+	}
+	for _, tc := range testCases {
+		config := &configs.Config{
+			Rootfs:     "/var",
+			Namespaces: configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+			Networks:   []*configs.Network{{Type: "loopback", ApplyPolicy: tc.policy}},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("policy %q: expected error, got nil", tc.policy)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("policy %q: unexpected error: %v", tc.policy, err)
+		}
+	}
+}
+
+func TestValidateNetworkAddressConflict(t *testing.T) {
+	for _, policy := range []configs.AddressConflictPolicy{"", configs.AddressConflictFail, configs.AddressConflictIgnore, configs.AddressConflictReplace, "overwrite"} {
+		config := &configs.Config{
+			Rootfs:     "/var",
+			Namespaces: configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+			Networks:   []*configs.Network{{Type: "veth", Name: "eth0", AddressConflict: policy}},
+		}
+		err := Validate(config)
+		if policy == "overwrite" && err == nil {
+			t.Errorf("policy %q: expected error, got nil", policy)
+		}
+		if policy != "overwrite" && err != nil {
+			t.Errorf("policy %q: unexpected error: %v", policy, err)
+		}
+	}
+}
+
+func TestValidateNetworkMacvlan(t *testing.T) {
+	testCases := []struct {
+		network configs.Network
+		isErr   bool
+	}{
+		{network: configs.Network{Type: "macvlan", Name: "eth0", Parent: "eno1"}},
+		{network: configs.Network{Type: "macvlan", Name: "eth0", Parent: "eno1", Macvlan: &configs.MacvlanSettings{Mode: "passthru"}}},
+		{network: configs.Network{Type: "macvlan", Name: "eth0", Parent: "eno1", Macvlan: &configs.MacvlanSettings{Mode: "source"}}, isErr: true},
+		{network: configs.Network{Type: "macvlan", Name: "eth0"}, isErr: true},
+		{network: configs.Network{Type: "macvlan", Parent: "eno1"}, isErr: true},
+		{network: configs.Network{Type: "macvlan", Name: "eth0", Parent: "eno1", HostInterfaceName: "mv0"}, isErr: true},
+		{network: configs.Network{Type: "veth", Name: "eth0", Macvlan: &configs.MacvlanSettings{}}, isErr: true},
+		{network: configs.Network{Type: "macvtap", Name: "mvt0", Parent: "eno1", Macvlan: &configs.MacvlanSettings{Mode: "vepa"}}},
+		{network: configs.Network{Type: "macvtap", Name: "mvt0", Parent: "eno1", Macvlan: &configs.MacvlanSettings{Mode: "passthru"}}, isErr: true},
+		{network: configs.Network{Type: "macvtap", Name: "mvt0"}, isErr: true},
+	}
+	for _, tc := range testCases {
+		config := &configs.Config{
+			Rootfs:     "/var",
+			Namespaces: configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+			Networks:   []*configs.Network{&tc.network},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("network %+v: expected error, got nil", tc.network)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("network %+v: unexpected error: %v", tc.network, err)
+		}
+	}
+}
+
+func TestValidateNetworkIpvlan(t *testing.T) {
+	testCases := []struct {
+		network configs.Network
+		isErr   bool
+	}{
+		{network: configs.Network{Type: "ipvlan", Name: "eth0", Parent: "eno1"}},
+		{network: configs.Network{Type: "ipvlan", Name: "eth0", Parent: "eno1", Ipvlan: &configs.IpvlanSettings{Mode: "l3s", Flag: "vepa"}}},
+		{network: configs.Network{Type: "ipvlan", Name: "eth0", Parent: "eno1", Ipvlan: &configs.IpvlanSettings{Mode: "l4"}}, isErr: true},
+		{network: configs.Network{Type: "ipvlan", Name: "eth0", Parent: "eno1", Ipvlan: &configs.IpvlanSettings{Flag: "passthru"}}, isErr: true},
+		{network: configs.Network{Type: "ipvlan", Name: "eth0", Parent: "eno1", MacAddress: "02:00:00:00:00:01"}, isErr: true},
+		{network: configs.Network{Type: "ipvlan", Name: "eth0"}, isErr: true},
+		{network: configs.Network{Type: "ipvlan", Parent: "eno1"}, isErr: true},
+		{network: configs.Network{Type: "macvlan", Name: "eth0", Parent: "eno1", Ipvlan: &configs.IpvlanSettings{}}, isErr: true},
+	}
+	for _, tc := range testCases {
+		config := &configs.Config{
+			Rootfs:     "/var",
+			Namespaces: configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+			Networks:   []*configs.Network{&tc.network},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("network %+v: expected error, got nil", tc.network)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("network %+v: unexpected error: %v", tc.network, err)
+		}
+	}
+}
+
+func TestValidateNetworkVLAN(t *testing.T) {
+	testCases := []struct {
+		network configs.Network
+		isErr   bool
+	}{
+		{network: configs.Network{Type: "vlan", Name: "eth0", Parent: "eno1", VLAN: &configs.VLANSettings{ID: 100}}},
+		{network: configs.Network{Type: "vlan", Name: "eth0", Parent: "eno1", VLAN: &configs.VLANSettings{
+			ID: 4094, Protocol: "802.1ad", IngressQosMap: map[uint32]uint32{7: 100}, EgressQosMap: map[uint32]uint32{100: 7},
+		}}},
+		{network: configs.Network{Type: "vlan", Name: "eth0", Parent: "eno1"}, isErr: true},
+		{network: configs.Network{Type: "vlan", Name: "eth0", Parent: "eno1", VLAN: &configs.VLANSettings{ID: 4095}}, isErr: true},
+		{network: configs.Network{Type: "vlan", Name: "eth0", Parent: "eno1", VLAN: &configs.VLANSettings{ID: 100, Protocol: "802.1x"}}, isErr: true},
+		{network: configs.Network{Type: "vlan", Name: "eth0", Parent: "eno1", VLAN: &configs.VLANSettings{ID: 100, IngressQosMap: map[uint32]uint32{8: 0}}}, isErr: true},
+		{network: configs.Network{Type: "vlan", Name: "eth0", Parent: "eno1", VLAN: &configs.VLANSettings{ID: 100, EgressQosMap: map[uint32]uint32{0: 8}}}, isErr: true},
+		{network: configs.Network{Type: "vlan", Name: "eth0", VLAN: &configs.VLANSettings{ID: 100}}, isErr: true},
+		{network: configs.Network{Type: "vlan", Parent: "eno1", VLAN: &configs.VLANSettings{ID: 100}}, isErr: true},
+		{network: configs.Network{Type: "macvlan", Name: "eth0", Parent: "eno1", VLAN: &configs.VLANSettings{ID: 100}}, isErr: true},
+	}
+	for _, tc := range testCases {
+		config := &configs.Config{
+			Rootfs:     "/var",
+			Namespaces: configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+			Networks:   []*configs.Network{&tc.network},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("network %+v: expected error, got nil", tc.network)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("network %+v: unexpected error: %v", tc.network, err)
+		}
+	}
+}
+
+func TestValidateNetworkTap(t *testing.T) {
+	owner := uint32(1000)
+	testCases := []struct {
+		network configs.Network
+		isErr   bool
+	}{
+		{network: configs.Network{Type: "tap", Name: "tap0"}},
+		{network: configs.Network{Type: "tap", Name: "tap0", Tap: &configs.TapSettings{MultiQueue: true, Owner: &owner}}},
+		{network: configs.Network{Type: "tap", Tap: &configs.TapSettings{}}, isErr: true},
+		{network: configs.Network{Type: "tap", Name: "tap0", HostInterfaceName: "tap1"}, isErr: true},
+		{network: configs.Network{Type: "veth", Name: "eth0", HostInterfaceName: "veth0", Tap: &configs.TapSettings{}}, isErr: true},
+	}
+	for _, tc := range testCases {
+		config := &configs.Config{
+			Rootfs:     "/var",
+			Namespaces: configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+			Networks:   []*configs.Network{&tc.network},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("network %+v: expected error, got nil", tc.network)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("network %+v: unexpected error: %v", tc.network, err)
+		}
+	}
+}
+
+func TestValidateNetworkWireGuard(t *testing.T) {
+	key := "AgICAgICAgICAgICAgICAgICAgICAgICAgICAgICAgI="
+	wg := func(peers ...*configs.WireGuardPeer) *configs.WireGuardSettings {
+		return &configs.WireGuardSettings{PrivateKeyFile: "/etc/wireguard/wg0.key", Peers: peers}
+	}
+	testCases := []struct {
+		network configs.Network
+		isErr   bool
+	}{
+		{network: configs.Network{Type: "wireguard", Name: "wg0", WireGuard: wg()}},
+		{network: configs.Network{Type: "wireguard", Name: "wg0", Address: "10.0.0.2/24", WireGuard: wg(&configs.WireGuardPeer{
+			PublicKey: key, Endpoint: "vpn.example.com:51820", AllowedIPs: []string{"10.0.0.0/24", "fd00::/64"},
+		})}},
+		{network: configs.Network{Type: "wireguard", Name: "wg0"}, isErr: true},
+		{network: configs.Network{Type: "wireguard", WireGuard: wg()}, isErr: true},
+		{network: configs.Network{Type: "wireguard", Name: "wg0", WireGuard: &configs.WireGuardSettings{PrivateKeyFile: "wg0.key"}}, isErr: true},
+		{network: configs.Network{Type: "wireguard", Name: "wg0", WireGuard: wg(&configs.WireGuardPeer{PublicKey: "AgIC"})}, isErr: true},
+		{network: configs.Network{Type: "wireguard", Name: "wg0", WireGuard: wg(&configs.WireGuardPeer{PublicKey: key}, &configs.WireGuardPeer{PublicKey: key})}, isErr: true},
+		{network: configs.Network{Type: "wireguard", Name: "wg0", WireGuard: wg(&configs.WireGuardPeer{PublicKey: key, Endpoint: "192.0.2.1"})}, isErr: true},
+		{network: configs.Network{Type: "wireguard", Name: "wg0", WireGuard: wg(&configs.WireGuardPeer{PublicKey: key, AllowedIPs: []string{"10.0.0.1"}})}, isErr: true},
+		{network: configs.Network{Type: "wireguard", Name: "wg0", MacAddress: "02:00:00:00:00:01", WireGuard: wg()}, isErr: true},
+		{network: configs.Network{Type: "veth", Name: "eth0", HostInterfaceName: "veth0", WireGuard: wg()}, isErr: true},
+	}
+	for _, tc := range testCases {
+		config := &configs.Config{
+			Rootfs:     "/var",
+			Namespaces: configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+			Networks:   []*configs.Network{&tc.network},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("network %+v: expected error, got nil", tc.network)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("network %+v: unexpected error: %v", tc.network, err)
+		}
+	}
+}
+
+func TestValidateNetworkVeth(t *testing.T) {
+	testCases := []struct {
+		network configs.Network
+		isErr   bool
+	}{
+		{network: configs.Network{Type: "veth", Name: "eth0", HostInterfaceName: "veth0"}},
+		{network: configs.Network{Type: "veth", Name: "eth0", HostInterfaceName: "veth0", Bridge: "br0", HairpinMode: true}},
+		{network: configs.Network{Type: "veth", Name: "eth0", HostInterfaceName: "veth0", HairpinMode: true}, isErr: true},
+		{network: configs.Network{Type: "veth", Name: "eth0", HostInterfaceName: "veth0", Bridge: "br/0"}, isErr: true},
+		{network: configs.Network{Type: "veth", Name: "eth0", HostInterfaceName: "veth0", Bridge: "br0", CreateBridge: true}},
+		{network: configs.Network{Type: "veth", Name: "eth0", HostInterfaceName: "veth0", CreateBridge: true}, isErr: true},
+		{network: configs.Network{Type: "loopback", Bridge: "br0"}, isErr: true},
+		{network: configs.Network{Type: "macvlan", Name: "eth0", Parent: "eno1", CreateBridge: true}, isErr: true},
+	}
+	for _, tc := range testCases {
+		config := &configs.Config{
+			Rootfs:     "/var",
+			Namespaces: configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+			Networks:   []*configs.Network{&tc.network},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("network %+v: expected error, got nil", tc.network)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("network %+v: unexpected error: %v", tc.network, err)
+		}
+	}
+}
+
+func TestValidateNetworkPreUpHook(t *testing.T) {
+	testCases := []struct {
+		network configs.Network
+		isErr   bool
+	}{
+		{network: configs.Network{Type: "veth", Name: "eth0", PreUpHook: &configs.Command{Path: "/usr/bin/setup"}}},
+		{network: configs.Network{Type: "veth", Name: "eth0", PreUpHook: &configs.Command{Path: "setup"}}, isErr: true},
+		{network: configs.Network{Type: "loopback", PreUpHook: &configs.Command{Path: "/usr/bin/setup"}}, isErr: true},
+	}
+	for _, tc := range testCases {
+		config := &configs.Config{
+			Rootfs:     "/var",
+			Namespaces: configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+			Networks:   []*configs.Network{&tc.network},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("network %+v: expected error, got nil", tc.network)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("network %+v: unexpected error: %v", tc.network, err)
+		}
+	}
+}
+
+func TestValidateNetworkStandby(t *testing.T) {
+	testCases := []struct {
+		network configs.Network
+		isErr   bool
+	}{
+		{network: configs.Network{Type: "veth", Name: "eth0", HostInterfaceName: "veth0", Standby: true}},
+		{network: configs.Network{Type: "loopback", Standby: true}, isErr: true},
+	}
+	for _, tc := range testCases {
+		config := &configs.Config{
+			Rootfs:     "/var",
+			Namespaces: configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+			Networks:   []*configs.Network{&tc.network},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("network %+v: expected error, got nil", tc.network)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("network %+v: unexpected error: %v", tc.network, err)
+		}
+	}
+}
+
+func TestValidateNetworkOptional(t *testing.T) {
+	testCases := []struct {
+		network configs.Network
+		isErr   bool
+	}{
+		{network: configs.Network{Type: "sriov", Name: "eth1", Parent: "ens1f0", Optional: true}},
+		{network: configs.Network{Type: "loopback", Optional: true}, isErr: true},
+	}
+	for _, tc := range testCases {
+		config := &configs.Config{
+			Rootfs:     "/var",
+			Namespaces: configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+			Networks:   []*configs.Network{&tc.network},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("network %+v: expected error, got nil", tc.network)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("network %+v: unexpected error: %v", tc.network, err)
+		}
+	}
+}
+
+func TestValidateNetworkCAN(t *testing.T) {
+	testCases := []struct {
+		network configs.Network
+		isErr   bool
+	}{
+		{network: configs.Network{Type: "can", Name: "can0", HostInterfaceName: "can1", CAN: &configs.CANSettings{
+			Bitrate:  500000,
+			CtrlMode: map[string]bool{"fd": true},
+		}}},
+		{network: configs.Network{Type: "vcan", Name: "vcan0"}},
+		{network: configs.Network{Type: "vxcan", Name: "vxcan0", HostInterfaceName: "vxcan1"}},
+		{network: configs.Network{Type: "can", Name: "can0"}, isErr: true},
+		{network: configs.Network{Type: "vcan", Name: "vcan0", HostInterfaceName: "vcan1"}, isErr: true},
+		{network: configs.Network{Type: "vcan", HostInterfaceName: "vcan1"}, isErr: true},
+		{network: configs.Network{Type: "vcan", Name: "vcan0", CAN: &configs.CANSettings{Bitrate: 500000}}, isErr: true},
+		{network: configs.Network{Type: "loopback", CAN: &configs.CANSettings{Bitrate: 500000}}, isErr: true},
+		{network: configs.Network{Type: "can", Name: "can0", HostInterfaceName: "can1", CAN: &configs.CANSettings{
+			CtrlMode: map[string]bool{"turbo": true},
+		}}, isErr: true},
+		{network: configs.Network{Type: "vcan", Name: "vcan0", Address: "10.0.0.1/24"}, isErr: true},
+		{network: configs.Network{Type: "vxcan", Name: "vxcan0", HostInterfaceName: "vxcan1", HostFirewallMark: 1}, isErr: true},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		config := &configs.Config{
+			Rootfs:     "/var",
+			Namespaces: configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+			Networks:   []*configs.Network{&tc.network},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("%+v: expected error, got nil", tc.network)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("%+v: unexpected error: %v", tc.network, err)
+		}
+	}
+}
+
+func TestValidateNetworkIPoIB(t *testing.T) {
+	testCases := []struct {
+		network configs.Network
+		isErr   bool
+	}{
+		{network: configs.Network{Type: "ipoib", Name: "ib0", Parent: "ib1", Address: "10.0.0.2/24", IPoIB: &configs.IPoIBSettings{Pkey: 0x8001}}},
+		{network: configs.Network{Type: "ipoib", Name: "ib0", Parent: "ib1", IPoIB: &configs.IPoIBSettings{Pkey: 1, Mode: "connected"}}},
+		{network: configs.Network{Type: "ipoib", Name: "ib0", Parent: "ib1"}, isErr: true},
+		{network: configs.Network{Type: "ipoib", Name: "ib0", Parent: "ib1", IPoIB: &configs.IPoIBSettings{Pkey: 0x8000}}, isErr: true},
+		{network: configs.Network{Type: "ipoib", Name: "ib0", IPoIB: &configs.IPoIBSettings{Pkey: 1}}, isErr: true},
+		{network: configs.Network{Type: "ipoib", Parent: "ib1", IPoIB: &configs.IPoIBSettings{Pkey: 1}}, isErr: true},
+		{network: configs.Network{Type: "ipoib", Name: "ib0", Parent: "ib1", IPoIB: &configs.IPoIBSettings{Pkey: 1, Mode: "unreliable"}}, isErr: true},
+		{network: configs.Network{Type: "ipoib", Name: "ib0", Parent: "ib1", MacAddress: "00:11:22:33:44:55", IPoIB: &configs.IPoIBSettings{Pkey: 1}}, isErr: true},
+		{network: configs.Network{Type: "ipoib", Name: "ib0", Parent: "ib1", HostInterfaceName: "ib2", IPoIB: &configs.IPoIBSettings{Pkey: 1}}, isErr: true},
+		{network: configs.Network{Type: "loopback", Parent: "ib1"}, isErr: true},
+		{network: configs.Network{Type: "loopback", IPoIB: &configs.IPoIBSettings{Pkey: 1}}, isErr: true},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		config := &configs.Config{
+			Rootfs:     "/var",
+			Namespaces: configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+			Networks:   []*configs.Network{&tc.network},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("%+v: expected error, got nil", tc.network)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("%+v: unexpected error: %v", tc.network, err)
+		}
+	}
+}
+
+func TestValidateNetworkAltNames(t *testing.T) {
+	testCases := []struct {
+		altNames []string
+		netType  string
+		isErr    bool
+	}{
+		{altNames: []string{"container-uplink-interface", "can-bus-0"}},
+		{altNames: []string{strings.Repeat("a", 127)}},
+		{altNames: []string{strings.Repeat("a", 128)}, isErr: true},
+		{altNames: []string{""}, isErr: true},
+		{altNames: []string{"bus/0"}, isErr: true},
+		{altNames: []string{"bus 0"}, isErr: true},
+		{altNames: []string{"bus0", "bus0"}, isErr: true},
+		{altNames: []string{"can0"}, isErr: true},
+		{altNames: []string{"bus0"}, netType: "vcan", isErr: true},
+	}
+	for _, tc := range testCases {
+		n := &configs.Network{Type: "can", Name: "can0", HostInterfaceName: "can1", AltNames: tc.altNames}
+		if tc.netType != "" {
+			n = &configs.Network{Type: tc.netType, Name: "can0", AltNames: tc.altNames}
+		}
+		config := &configs.Config{
+			Rootfs:     "/var",
+			Namespaces: configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+			Networks:   []*configs.Network{n},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("%q: expected error, got nil", tc.altNames)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("%q: unexpected error: %v", tc.altNames, err)
+		}
+	}
+}
+
+func TestValidateNetworkSRIOV(t *testing.T) {
+	testCases := []struct {
+		network configs.Network
+		isErr   bool
+	}{
+		{network: configs.Network{Type: "sriov", Name: "eth1", Parent: "ens1f0"}},
+		{network: configs.Network{Type: "sriov", Name: "eth1", Parent: "ens1f0", SRIOV: &configs.SRIOVSettings{NumVFs: 8}}},
+		{network: configs.Network{Type: "sriov", Name: "eth1", Parent: "ens1f0", AltNames: []string{"data-plane-uplink"}}},
+		{network: configs.Network{Type: "sriov", Name: "eth1"}, isErr: true},
+		{network: configs.Network{Type: "sriov", Parent: "ens1f0"}, isErr: true},
+		{network: configs.Network{Type: "sriov", Name: "eth1", Parent: "ens1f0", SRIOV: &configs.SRIOVSettings{NumVFs: -1}}, isErr: true},
+		{network: configs.Network{Type: "sriov", Name: "eth1", Parent: "ens1f0", HostInterfaceName: "ens1f0v0"}, isErr: true},
+		{network: configs.Network{Type: "sriov", Name: "eth1", SRIOV: &configs.SRIOVSettings{PCIAddress: "0000:65:00.0"}}},
+		{network: configs.Network{Type: "sriov", Name: "eth1", Parent: "ens1f0", SRIOV: &configs.SRIOVSettings{PCIAddress: "0000:65:00.0"}}, isErr: true},
+		{network: configs.Network{Type: "sriov", Name: "eth1", SRIOV: &configs.SRIOVSettings{PCIAddress: "65:00.0"}}, isErr: true},
+		{network: configs.Network{Type: "sriov", Name: "eth1", SRIOV: &configs.SRIOVSettings{PCIAddress: "0000:65:00.8"}}, isErr: true},
+		{network: configs.Network{Type: "sriov", Name: "eth1", Parent: "ens1f0", MacAddress: "02:00:00:00:00:01", SRIOV: &configs.SRIOVSettings{Vlan: 100, Qos: 3, AdminMAC: true}}},
+		{network: configs.Network{Type: "sriov", Name: "eth1", Parent: "ens1f0", SRIOV: &configs.SRIOVSettings{Vlan: 4095}}, isErr: true},
+		{network: configs.Network{Type: "sriov", Name: "eth1", Parent: "ens1f0", SRIOV: &configs.SRIOVSettings{Qos: 3}}, isErr: true},
+		{network: configs.Network{Type: "sriov", Name: "eth1", Parent: "ens1f0", SRIOV: &configs.SRIOVSettings{AdminMAC: true}}, isErr: true},
+		{network: configs.Network{Type: "sriov", Name: "eth1", Parent: "ens1f0", SRIOV: &configs.SRIOVSettings{ClaimTTL: time.Minute}}},
+		{network: configs.Network{Type: "sriov", Name: "eth1", Parent: "ens1f0", SRIOV: &configs.SRIOVSettings{ClaimTTL: -time.Minute}}, isErr: true},
+		{network: configs.Network{Type: "sriov", Name: "eth1", Parent: "ens1f0", SRIOV: &configs.SRIOVSettings{ClaimTTL: time.Millisecond}}, isErr: true},
+		{network: configs.Network{Type: "loopback", SRIOV: &configs.SRIOVSettings{}}, isErr: true},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		config := &configs.Config{
+			Rootfs:     "/var",
+			Namespaces: configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+			Networks:   []*configs.Network{&tc.network},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("%+v: expected error, got nil", tc.network)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("%+v: unexpected error: %v", tc.network, err)
+		}
+	}
+}
+
+func TestValidateNetworkVDPA(t *testing.T) {
+	vdpa := &configs.VDPASettings{MgmtDev: "pci/0000:65:00.2", Device: "vdpa0"}
+	testCases := []struct {
+		network configs.Network
+		isErr   bool
+	}{
+		{network: configs.Network{Type: "vdpa", Name: "eth1", VDPA: vdpa}},
+		{network: configs.Network{Type: "vdpa", Name: "eth1", VDPA: &configs.VDPASettings{MgmtDev: "vdpasim_net", Device: "vdpa0"}}},
+		{network: configs.Network{Type: "vdpa", Name: "eth1"}, isErr: true},
+		{network: configs.Network{Type: "vdpa", VDPA: vdpa}, isErr: true},
+		{network: configs.Network{Type: "vdpa", Name: "eth1", VDPA: &configs.VDPASettings{MgmtDev: "pci/0000:65:00.2"}}, isErr: true},
+		{network: configs.Network{Type: "vdpa", Name: "eth1", VDPA: &configs.VDPASettings{MgmtDev: "pci/0000:65:00.2", Device: "../vdpa0"}}, isErr: true},
+		{network: configs.Network{Type: "vdpa", Name: "eth1", VDPA: &configs.VDPASettings{MgmtDev: "pci/", Device: "vdpa0"}}, isErr: true},
+		{network: configs.Network{Type: "vdpa", Name: "eth1", VDPA: vdpa, HostInterfaceName: "veth0"}, isErr: true},
+		{network: configs.Network{Type: "loopback", VDPA: vdpa}, isErr: true},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		config := &configs.Config{
+			Rootfs:     "/var",
+			Namespaces: configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+			Networks:   []*configs.Network{&tc.network},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("%+v: expected error, got nil", tc.network)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("%+v: unexpected error: %v", tc.network, err)
+		}
+	}
+}
+
+func TestValidateNetworkTunnel(t *testing.T) {
+	tunnel := &configs.TunnelSettings{Local: "198.51.100.1", Remote: "198.51.100.2", TTL: 64}
+	testCases := []struct {
+		network configs.Network
+		isErr   bool
+	}{
+		{network: configs.Network{Type: "ipip", Name: "tun0", Tunnel: tunnel}},
+		{network: configs.Network{Type: "sit", Name: "tun0", Tunnel: &configs.TunnelSettings{Remote: "198.51.100.2"}, IPv6Address: "2001:db8::2/64"}},
+		{network: configs.Network{Type: "ipip", Name: "tun0"}, isErr: true},
+		{network: configs.Network{Type: "ipip", Tunnel: tunnel}, isErr: true},
+		{network: configs.Network{Type: "ipip", Name: "tun0", Tunnel: &configs.TunnelSettings{}}, isErr: true},
+		{network: configs.Network{Type: "ipip", Name: "tun0", Tunnel: &configs.TunnelSettings{Remote: "2001:db8::1"}}, isErr: true},
+		{network: configs.Network{Type: "sit", Name: "tun0", Tunnel: &configs.TunnelSettings{Local: "local", Remote: "198.51.100.2"}}, isErr: true},
+		{network: configs.Network{Type: "ipip", Name: "tun0", Tunnel: tunnel, MacAddress: "02:00:00:00:00:01"}, isErr: true},
+		{network: configs.Network{Type: "ipip", Name: "tun0", Tunnel: tunnel, HostInterfaceName: "tun0"}, isErr: true},
+		{network: configs.Network{Type: "gre", Name: "gre0", Tunnel: &configs.TunnelSettings{Remote: "198.51.100.2", InputKey: 42, OutputKey: 42}}},
+		{network: configs.Network{Type: "gretap", Name: "gretap0", Tunnel: tunnel, MacAddress: "02:00:00:00:00:01"}},
+		{network: configs.Network{Type: "gre", Name: "gre0", Tunnel: tunnel, MacAddress: "02:00:00:00:00:01"}, isErr: true},
+		{network: configs.Network{Type: "ipip", Name: "tun0", Tunnel: &configs.TunnelSettings{Remote: "198.51.100.2", InputKey: 42}}, isErr: true},
+		{network: configs.Network{Type: "ip6tnl", Name: "tun0", Tunnel: &configs.TunnelSettings{Local: "2001:db8::1", Remote: "2001:db8::2", Mode: "ipip6"}, Address: "10.0.0.1/30"}},
+		{network: configs.Network{Type: "ip6tnl", Name: "tun0", Tunnel: &configs.TunnelSettings{Remote: "2001:db8::2"}, IPv6Address: "fd00::1/64"}},
+		{network: configs.Network{Type: "ip6tnl", Name: "tun0", Tunnel: tunnel}, isErr: true},
+		{network: configs.Network{Type: "ip6tnl", Name: "tun0", Tunnel: &configs.TunnelSettings{Local: "198.51.100.1", Remote: "2001:db8::2"}}, isErr: true},
+		{network: configs.Network{Type: "ip6tnl", Name: "tun0", Tunnel: &configs.TunnelSettings{Remote: "2001:db8::2", Mode: "gre"}}, isErr: true},
+		{network: configs.Network{Type: "ipip", Name: "tun0", Tunnel: &configs.TunnelSettings{Remote: "198.51.100.2", Mode: "any"}}, isErr: true},
+		{network: configs.Network{Type: "loopback", Tunnel: tunnel}, isErr: true},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		config := &configs.Config{
+			Rootfs:     "/var",
+			Namespaces: configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+			Networks:   []*configs.Network{&tc.network},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("%+v: expected error, got nil", tc.network)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("%+v: unexpected error: %v", tc.network, err)
+		}
+	}
+}
+
+func TestValidateNetworkL2TP(t *testing.T) {
+	l2tp := func(f func(*configs.L2TPSettings)) *configs.L2TPSettings {
+		s := &configs.L2TPSettings{
+			Local: "198.51.100.1", Remote: "198.51.100.2",
+			LocalPort: 1701, RemotePort: 1701,
+			TunnelID: 1, PeerTunnelID: 2, SessionID: 3, PeerSessionID: 4,
+		}
+		if f != nil {
+			f(s)
+		}
+		return s
+	}
+	testCases := []struct {
+		network configs.Network
+		isErr   bool
+	}{
+		{network: configs.Network{Type: "l2tpeth", Name: "pw0", L2TP: l2tp(nil)}},
+		{network: configs.Network{Type: "l2tpeth", Name: "pw0", L2TP: l2tp(func(s *configs.L2TPSettings) {
+			s.Encap, s.LocalPort, s.RemotePort = "ip", 0, 0
+		})}},
+		{network: configs.Network{Type: "l2tpeth", Name: "pw0"}, isErr: true},
+		{network: configs.Network{Type: "l2tpeth", L2TP: l2tp(nil)}, isErr: true},
+		{network: configs.Network{Type: "l2tpeth", Name: "pw0", L2TP: l2tp(func(s *configs.L2TPSettings) { s.Remote = "2001:db8::2" })}, isErr: true},
+		{network: configs.Network{Type: "l2tpeth", Name: "pw0", L2TP: l2tp(func(s *configs.L2TPSettings) { s.Encap = "ppp" })}, isErr: true},
+		{network: configs.Network{Type: "l2tpeth", Name: "pw0", L2TP: l2tp(func(s *configs.L2TPSettings) { s.RemotePort = 0 })}, isErr: true},
+		{network: configs.Network{Type: "l2tpeth", Name: "pw0", L2TP: l2tp(func(s *configs.L2TPSettings) { s.Encap = "ip" })}, isErr: true},
+		{network: configs.Network{Type: "l2tpeth", Name: "pw0", L2TP: l2tp(func(s *configs.L2TPSettings) { s.SessionID = 0 })}, isErr: true},
+		{network: configs.Network{Type: "l2tpeth", Name: "pw0", L2TP: l2tp(nil), HostInterfaceName: "pw0"}, isErr: true},
+		{network: configs.Network{Type: "loopback", L2TP: l2tp(nil)}, isErr: true},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		config := &configs.Config{
+			Rootfs:     "/var",
+			Namespaces: configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+			Networks:   []*configs.Network{&tc.network},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("%+v: expected error, got nil", tc.network)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("%+v: unexpected error: %v", tc.network, err)
+		}
+	}
+}
+
+func TestValidateNetworkNeighbors(t *testing.T) {
+	testCases := []struct {
+		neighbors []*configs.Neighbor
+		isErr     bool
+	}{
+		{neighbors: []*configs.Neighbor{{IP: "10.0.0.3", MacAddress: "02:00:00:00:00:03"}, {IP: "fd00::3", MacAddress: "02:00:00:00:00:04"}}},
+		{neighbors: []*configs.Neighbor{{IP: "10.0.0.3"}}, isErr: true},
+		{neighbors: []*configs.Neighbor{{IP: "10.0.0.3/24", MacAddress: "02:00:00:00:00:03"}}, isErr: true},
+	}
+	for _, tc := range testCases {
+		config := &configs.Config{
+			Rootfs:     "/var",
+			Namespaces: configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+			Networks:   []*configs.Network{{Type: "veth", Name: "eth0", Neighbors: tc.neighbors}},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("%+v: expected error, got nil", tc.neighbors)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("%+v: unexpected error: %v", tc.neighbors, err)
+		}
+	}
+	config := &configs.Config{
+		Rootfs:     "/var",
+		Namespaces: configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+		Networks:   []*configs.Network{{Type: "loopback", Neighbors: []*configs.Neighbor{{IP: "10.0.0.3", MacAddress: "02:00:00:00:00:03"}}}},
+	}
+	if err := Validate(config); err == nil {
+		t.Error("expected error for neighbors on a loopback network")
+	}
+}
+
+func TestValidateNetworkVXLAN(t *testing.T) {
+	testCases := []struct {
+		network configs.Network
+		isErr   bool
+	}{
+		{network: configs.Network{Type: "vxlan", Name: "vxlan0", VXLAN: &configs.VXLANSettings{VNI: 42, Remote: "192.0.2.1"}}},
+		{network: configs.Network{Type: "vxlan", Name: "vxlan0", Parent: "eth0", Address: "10.0.0.2/24", VXLAN: &configs.VXLANSettings{
+			VNI: 1<<24 - 1, Local: "fd00::1", Remote: "ff05::100", Port: 8472, TTL: 64, Learning: true,
+		}}},
+		{network: configs.Network{Type: "vxlan", Name: "vxlan0"}, isErr: true},
+		{network: configs.Network{Type: "vxlan", VXLAN: &configs.VXLANSettings{VNI: 42}}, isErr: true},
+		{network: configs.Network{Type: "vxlan", Name: "vxlan0", VXLAN: &configs.VXLANSettings{VNI: 1 << 24}}, isErr: true},
+		{network: configs.Network{Type: "vxlan", Name: "vxlan0", VXLAN: &configs.VXLANSettings{VNI: 42, Remote: "example.com"}}, isErr: true},
+		{network: configs.Network{Type: "vxlan", Name: "vxlan0", VXLAN: &configs.VXLANSettings{VNI: 42, Local: "fd00::1", Remote: "192.0.2.1"}}, isErr: true},
+		{network: configs.Network{Type: "vxlan", Name: "vxlan0", VXLAN: &configs.VXLANSettings{VNI: 42, Remote: "239.1.1.1"}}, isErr: true},
+		{network: configs.Network{Type: "vxlan", Name: "vxlan0", HostInterfaceName: "veth0", VXLAN: &configs.VXLANSettings{VNI: 42}}, isErr: true},
+		{network: configs.Network{Type: "vxlan", Name: "vxlan0", VXLAN: &configs.VXLANSettings{
+			VNI: 42, Remote: "192.0.2.1", SrcPortLow: 49152, SrcPortHigh: 50175,
+			TunnelTuning: configs.TunnelTuning{TOS: 1, DF: configs.TunnelDFInherit, UDPChecksum: true},
+		}}},
+		{network: configs.Network{Type: "vxlan", Name: "vxlan0", VXLAN: &configs.VXLANSettings{VNI: 42, SrcPortLow: 49152}}, isErr: true},
+		{network: configs.Network{Type: "vxlan", Name: "vxlan0", VXLAN: &configs.VXLANSettings{VNI: 42, SrcPortLow: 50175, SrcPortHigh: 49152}}, isErr: true},
+		{network: configs.Network{Type: "vxlan", Name: "vxlan0", VXLAN: &configs.VXLANSettings{VNI: 42, TunnelTuning: configs.TunnelTuning{DF: "always"}}}, isErr: true},
+		{network: configs.Network{Type: "vxlan", Name: "vxlan0", VXLAN: &configs.VXLANSettings{VNI: 42, Local: "fd00::1", TunnelTuning: configs.TunnelTuning{DF: configs.TunnelDFSet}}}, isErr: true},
+		{network: configs.Network{Type: "macvlan", Name: "eth0", Parent: "vxlan0", VXLAN: &configs.VXLANSettings{VNI: 42}}, isErr: true},
+	}
+	for _, tc := range testCases {
+		config := &configs.Config{
+			Rootfs:     "/var",
+			Namespaces: configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+			Networks:   []*configs.Network{&tc.network},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("network %+v: expected error, got nil", tc.network)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("network %+v: unexpected error: %v", tc.network, err)
+		}
+	}
+}
+
+func TestValidateNetworkGeneve(t *testing.T) {
+	testCases := []struct {
+		network configs.Network
+		isErr   bool
+	}{
+		{network: configs.Network{Type: "geneve", Name: "gnv0", Geneve: &configs.GeneveSettings{VNI: 42, Remote: "192.0.2.1"}}},
+		{network: configs.Network{Type: "geneve", Name: "gnv0", Address: "10.0.0.2/24", Geneve: &configs.GeneveSettings{VNI: 1<<24 - 1, Remote: "2001:db8::1", Port: 6082, TTL: 64}}},
+		{network: configs.Network{Type: "geneve", Name: "gnv0", Geneve: &configs.GeneveSettings{External: true}}},
+		{network: configs.Network{Type: "geneve", Name: "gnv0"}, isErr: true},
+		{network: configs.Network{Type: "geneve", Geneve: &configs.GeneveSettings{VNI: 42, Remote: "192.0.2.1"}}, isErr: true},
+		{network: configs.Network{Type: "geneve", Name: "gnv0", Geneve: &configs.GeneveSettings{VNI: 1 << 24, Remote: "192.0.2.1"}}, isErr: true},
+		{network: configs.Network{Type: "geneve", Name: "gnv0", Geneve: &configs.GeneveSettings{VNI: 42}}, isErr: true},
+		{network: configs.Network{Type: "geneve", Name: "gnv0", Geneve: &configs.GeneveSettings{VNI: 42, Remote: "239.1.1.1"}}, isErr: true},
+		{network: configs.Network{Type: "geneve", Name: "gnv0", Geneve: &configs.GeneveSettings{External: true, VNI: 42}}, isErr: true},
+		{network: configs.Network{Type: "geneve", Name: "gnv0", Parent: "eth0", Geneve: &configs.GeneveSettings{VNI: 42, Remote: "192.0.2.1"}}, isErr: true},
+		{network: configs.Network{Type: "geneve", Name: "gnv0", HostInterfaceName: "veth0", Geneve: &configs.GeneveSettings{External: true}}, isErr: true},
+		{network: configs.Network{Type: "geneve", Name: "gnv0", Geneve: &configs.GeneveSettings{VNI: 42, Remote: "192.0.2.1", TunnelTuning: configs.TunnelTuning{TOS: 1, DF: configs.TunnelDFInherit, UDPChecksum: true}}}},
+		{network: configs.Network{Type: "geneve", Name: "gnv0", Geneve: &configs.GeneveSettings{External: true, TunnelTuning: configs.TunnelTuning{DF: configs.TunnelDFSet}}}},
+		{network: configs.Network{Type: "geneve", Name: "gnv0", Geneve: &configs.GeneveSettings{VNI: 42, Remote: "192.0.2.1", TunnelTuning: configs.TunnelTuning{DF: "always"}}}, isErr: true},
+		{network: configs.Network{Type: "geneve", Name: "gnv0", Geneve: &configs.GeneveSettings{VNI: 42, Remote: "2001:db8::1", TunnelTuning: configs.TunnelTuning{DF: configs.TunnelDFSet}}}, isErr: true},
+		{network: configs.Network{Type: "vxlan", Name: "vxlan0", VXLAN: &configs.VXLANSettings{VNI: 42}, Geneve: &configs.GeneveSettings{External: true}}, isErr: true},
+	}
+	for _, tc := range testCases {
+		config := &configs.Config{
+			Rootfs:     "/var",
+			Namespaces: configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+			Networks:   []*configs.Network{&tc.network},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("network %+v: expected error, got nil", tc.network)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("network %+v: unexpected error: %v", tc.network, err)
+		}
+	}
+}
+
+func TestValidateNetworkDummy(t *testing.T) {
+	testCases := []struct {
+		network configs.Network
+		isErr   bool
+	}{
+		{network: configs.Network{Type: "dummy", Name: "vip0", Address: "192.0.2.10/32"}},
+		{network: configs.Network{Type: "dummy", Name: "vip0", MacAddress: "02:42:c0:00:02:0a", IPv6Address: "2001:db8::10/128"}},
+		{network: configs.Network{Type: "dummy", Address: "192.0.2.10/32"}, isErr: true},
+		{network: configs.Network{Type: "dummy", Name: "vip0", HostInterfaceName: "veth0"}, isErr: true},
+		{network: configs.Network{Type: "dummy", Name: "vip0", Parent: "eth0"}, isErr: true},
+	}
+	for _, tc := range testCases {
+		config := &configs.Config{
+			Rootfs:     "/var",
+			Namespaces: configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+			Networks:   []*configs.Network{&tc.network},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("network %+v: expected error, got nil", tc.network)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("network %+v: unexpected error: %v", tc.network, err)
+		}
+	}
+}
+
+func TestValidateNetworkMACsec(t *testing.T) {
+	port := &configs.Network{Type: "sriov", Name: "eth0", Parent: "ens1f0"}
+	sa := func(an uint8) *configs.MACsecSA {
+		return &configs.MACsecSA{AN: an, KeyID: "00112233445566778899aabbccddeeff", KeyFile: "/etc/macsec/key"}
+	}
+	macsec := func(s *configs.MACsecSettings) *configs.Network {
+		return &configs.Network{Type: "macsec", Name: "macsec0", Address: "10.0.0.2/24", MACsec: s}
+	}
+	peer := &configs.MACsecRxSC{Address: "02:00:00:00:00:02", SAs: []*configs.MACsecSA{sa(0), sa(1)}}
+	testCases := []struct {
+		networks []*configs.Network
+		isErr    bool
+	}{
+		{networks: []*configs.Network{port, macsec(&configs.MACsecSettings{Link: "eth0", TxSA: sa(0), RxSCs: []*configs.MACsecRxSC{peer}})}},
+		{networks: []*configs.Network{port, macsec(&configs.MACsecSettings{Link: "eth0", CipherSuite: "gcm-aes-256", IntegrityOnly: true, ReplayWindow: 32, TxSA: sa(3)})}},
+		{networks: []*configs.Network{port, {Type: "macsec", Name: "macsec0"}}, isErr: true},
+		{networks: []*configs.Network{port, macsec(&configs.MACsecSettings{TxSA: sa(0)})}, isErr: true},
+		{networks: []*configs.Network{port, macsec(&configs.MACsecSettings{Link: "eth0"})}, isErr: true},
+		{networks: []*configs.Network{port, macsec(&configs.MACsecSettings{Link: "eth0", CipherSuite: "gcm-aes-xpn-128", TxSA: sa(0)})}, isErr: true},
+		{networks: []*configs.Network{port, macsec(&configs.MACsecSettings{Link: "eth0", TxSA: sa(4)})}, isErr: true},
+		{networks: []*configs.Network{port, macsec(&configs.MACsecSettings{Link: "eth0", TxSA: &configs.MACsecSA{KeyID: "01", KeyFile: "/etc/macsec/key"}})}, isErr: true},
+		{networks: []*configs.Network{port, macsec(&configs.MACsecSettings{Link: "eth0", TxSA: &configs.MACsecSA{KeyID: "00112233445566778899aabbccddeeff", KeyFile: "key"}})}, isErr: true},
+		{networks: []*configs.Network{port, macsec(&configs.MACsecSettings{Link: "eth0", TxSA: sa(0), RxSCs: []*configs.MACsecRxSC{{Address: "02:00:00:00:00:02"}}})}, isErr: true},
+		{networks: []*configs.Network{port, macsec(&configs.MACsecSettings{Link: "eth0", TxSA: sa(0), RxSCs: []*configs.MACsecRxSC{{Address: "02:00:00:00:00:02", SAs: []*configs.MACsecSA{sa(0), sa(0)}}}})}, isErr: true},
+		{networks: []*configs.Network{port, macsec(&configs.MACsecSettings{Link: "eth0", TxSA: sa(0), RxSCs: []*configs.MACsecRxSC{peer, {Address: "02:00:00:00:00:02", Port: 1, SAs: []*configs.MACsecSA{sa(0)}}}})}, isErr: true},
+		// The link must be set up before the macsec interface.
+		{networks: []*configs.Network{macsec(&configs.MACsecSettings{Link: "eth0", TxSA: sa(0)}), port}, isErr: true},
+		{networks: []*configs.Network{{Type: "wireguard", Name: "eth0", WireGuard: &configs.WireGuardSettings{PrivateKeyFile: "/etc/wg/key"}}, macsec(&configs.MACsecSettings{Link: "eth0", TxSA: sa(0)})}, isErr: true},
+		{networks: []*configs.Network{{Type: "sriov", Name: "eth0", Parent: "ens1f0", Optional: true}, macsec(&configs.MACsecSettings{Link: "eth0", TxSA: sa(0)})}, isErr: true},
+		{networks: []*configs.Network{{Type: "sriov", Name: "eth0", Parent: "ens1f0", MACsec: &configs.MACsecSettings{}}}, isErr: true},
+	}
+	for i, tc := range testCases {
+		config := &configs.Config{
+			Rootfs:     "/var",
+			Namespaces: configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+			Networks:   tc.networks,
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("case %d: expected error, got nil", i)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+		}
+	}
+}
+
+func TestValidateNetworkBond(t *testing.T) {
+	slave := func(name string) *configs.Network {
+		return &configs.Network{Type: "sriov", Name: name, Parent: "ens1f0"}
+	}
+	bond := func(s *configs.BondSettings) *configs.Network {
+		return &configs.Network{Type: "bond", Name: "bond0", Address: "10.0.0.2/24", Bond: s}
+	}
+	activeBackup := &configs.BondSettings{Mode: configs.BondActiveBackup, Slaves: []string{"eth0", "eth1"}, Primary: "eth0"}
+	testCases := []struct {
+		networks []*configs.Network
+		isErr    bool
+	}{
+		{networks: []*configs.Network{slave("eth0"), slave("eth1"), bond(activeBackup)}},
+		{networks: []*configs.Network{slave("eth0"), slave("eth1"), bond(&configs.BondSettings{
+			Mode: configs.Bond8023AD, Slaves: []string{"eth0", "eth1"}, Miimon: 50, LACPRate: "fast", XmitHashPolicy: "layer3+4",
+		})}},
+		{networks: []*configs.Network{slave("eth0"), slave("eth1"), {Type: "bond", Name: "bond0"}}, isErr: true},
+		{networks: []*configs.Network{slave("eth0"), slave("eth1"), {Type: "bond", Bond: activeBackup}}, isErr: true},
+		{networks: []*configs.Network{slave("eth0"), slave("eth1"), bond(&configs.BondSettings{Mode: "balance-rr", Slaves: []string{"eth0", "eth1"}})}, isErr: true},
+		{networks: []*configs.Network{slave("eth0"), bond(&configs.BondSettings{Mode: configs.BondActiveBackup, Slaves: []string{"eth0"}})}, isErr: true},
+		{networks: []*configs.Network{slave("eth0"), bond(&configs.BondSettings{Mode: configs.BondActiveBackup, Slaves: []string{"eth0", "eth0"}})}, isErr: true},
+		{networks: []*configs.Network{slave("eth0"), slave("eth1"), bond(&configs.BondSettings{Mode: configs.BondActiveBackup, Slaves: []string{"eth0", "eth1"}, Primary: "eth2"})}, isErr: true},
+		{networks: []*configs.Network{slave("eth0"), slave("eth1"), bond(&configs.BondSettings{Mode: configs.BondActiveBackup, Slaves: []string{"eth0", "eth1"}, LACPRate: "fast"})}, isErr: true},
+		{networks: []*configs.Network{slave("eth0"), slave("eth1"), bond(&configs.BondSettings{Mode: configs.Bond8023AD, Slaves: []string{"eth0", "eth1"}, Primary: "eth0"})}, isErr: true},
+		{networks: []*configs.Network{slave("eth0"), slave("eth1"), bond(&configs.BondSettings{Mode: configs.Bond8023AD, Slaves: []string{"eth0", "eth1"}, XmitHashPolicy: "layer4"})}, isErr: true},
+		// The slaves must be set up before the bond.
+		{networks: []*configs.Network{slave("eth0"), bond(activeBackup), slave("eth1")}, isErr: true},
+		{networks: []*configs.Network{slave("eth0"), {Type: "sriov", Name: "eth1", Parent: "ens1f0", Address: "10.0.1.2/24"}, bond(activeBackup)}, isErr: true},
+		{networks: []*configs.Network{slave("eth0"), {Type: "sriov", Name: "eth1", Parent: "ens1f0", Optional: true}, bond(activeBackup)}, isErr: true},
+		{networks: []*configs.Network{slave("eth0"), slave("eth1"), bond(activeBackup), {Type: "bond", Name: "bond1", Bond: activeBackup}}, isErr: true},
+		{networks: []*configs.Network{{Type: "veth", Name: "eth0", HostInterfaceName: "veth0", Bond: activeBackup}}, isErr: true},
+	}
+	for i, tc := range testCases {
+		config := &configs.Config{
+			Rootfs:     "/var",
+			Namespaces: configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+			Networks:   tc.networks,
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("case %d: expected error, got nil", i)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+		}
+	}
+}
+
+func TestValidateNetworkTeam(t *testing.T) {
+	port := func(name string) *configs.Network {
+		return &configs.Network{Type: "sriov", Name: name, Parent: "ens1f0"}
+	}
+	team := func(s *configs.TeamSettings) *configs.Network {
+		return &configs.Network{Type: "team", Name: "team0", Address: "10.0.0.2/24", Team: s}
+	}
+	activeBackup := &configs.TeamSettings{Runner: configs.TeamRunnerActiveBackup, Ports: []string{"eth0", "eth1"}, ActivePort: "eth1"}
+	testCases := []struct {
+		networks []*configs.Network
+		isErr    bool
+	}{
+		{networks: []*configs.Network{port("eth0"), port("eth1"), team(activeBackup)}},
+		{networks: []*configs.Network{port("eth0"), port("eth1"), team(&configs.TeamSettings{Runner: configs.TeamRunnerRoundRobin, Ports: []string{"eth0", "eth1"}})}},
+		{networks: []*configs.Network{port("eth0"), port("eth1"), {Type: "team", Name: "team0"}}, isErr: true},
+		{networks: []*configs.Network{port("eth0"), port("eth1"), {Type: "team", Team: activeBackup}}, isErr: true},
+		// The lacp and loadbalance runners are implemented by teamd.
+		{networks: []*configs.Network{port("eth0"), port("eth1"), team(&configs.TeamSettings{Runner: "lacp", Ports: []string{"eth0", "eth1"}})}, isErr: true},
+		{networks: []*configs.Network{port("eth0"), port("eth1"), team(&configs.TeamSettings{Runner: "loadbalance", Ports: []string{"eth0", "eth1"}})}, isErr: true},
+		{networks: []*configs.Network{port("eth0"), team(&configs.TeamSettings{Runner: configs.TeamRunnerBroadcast, Ports: []string{"eth0"}})}, isErr: true},
+		{networks: []*configs.Network{port("eth0"), team(&configs.TeamSettings{Runner: configs.TeamRunnerBroadcast, Ports: []string{"eth0", "eth0"}})}, isErr: true},
+		{networks: []*configs.Network{port("eth0"), port("eth1"), team(&configs.TeamSettings{Runner: configs.TeamRunnerActiveBackup, Ports: []string{"eth0", "eth1"}, ActivePort: "eth2"})}, isErr: true},
+		{networks: []*configs.Network{port("eth0"), port("eth1"), team(&configs.TeamSettings{Runner: configs.TeamRunnerRandom, Ports: []string{"eth0", "eth1"}, ActivePort: "eth0"})}, isErr: true},
+		// The ports must be set up before the team, and not be enslaved
+		// to a bond as well.
+		{networks: []*configs.Network{port("eth0"), team(activeBackup), port("eth1")}, isErr: true},
+		{networks: []*configs.Network{port("eth0"), port("eth1"), team(activeBackup), {Type: "bond", Name: "bond0", Bond: &configs.BondSettings{Mode: configs.BondActiveBackup, Slaves: []string{"eth0", "eth1"}}}}, isErr: true},
+		{networks: []*configs.Network{port("eth0"), port("eth1"), team(activeBackup), port("eth2"), {Type: "team", Name: "team1", Team: &configs.TeamSettings{Runner: configs.TeamRunnerBroadcast, Ports: []string{"team0", "eth2"}}}}, isErr: true},
+		{networks: []*configs.Network{{Type: "veth", Name: "eth0", HostInterfaceName: "veth0", Team: activeBackup}}, isErr: true},
+	}
+	for i, tc := range testCases {
+		config := &configs.Config{
+			Rootfs:     "/var",
+			Namespaces: configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+			Networks:   tc.networks,
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("case %d: expected error, got nil", i)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+		}
+	}
+}
+
+func TestValidateNetworkPrefixDelegation(t *testing.T) {
+	lan := &configs.Network{Type: "dummy", Name: "lan0"}
+	uplink := func(pd *configs.PrefixDelegationSettings) *configs.Network {
+		return &configs.Network{Type: "sriov", Name: "eth0", Parent: "ens1f0", PrefixDelegation: pd}
+	}
+	testCases := []struct {
+		networks []*configs.Network
+		isErr    bool
+	}{
+		{networks: []*configs.Network{lan, uplink(&configs.PrefixDelegationSettings{Interface: "lan0", PrefixLength: 56, SubnetID: 3})}},
+		{networks: []*configs.Network{uplink(&configs.PrefixDelegationSettings{Interface: "lo", SubnetLength: 128})}},
+		{networks: []*configs.Network{uplink(&configs.PrefixDelegationSettings{})}, isErr: true},
+		// The interface must be set up before the uplink.
+		{networks: []*configs.Network{uplink(&configs.PrefixDelegationSettings{Interface: "lan0"}), lan}, isErr: true},
+		{networks: []*configs.Network{uplink(&configs.PrefixDelegationSettings{Interface: "eth0"})}, isErr: true},
+		{networks: []*configs.Network{{Type: "dummy", Name: "lan0", Optional: true}, uplink(&configs.PrefixDelegationSettings{Interface: "lan0"})}, isErr: true},
+		{networks: []*configs.Network{uplink(&configs.PrefixDelegationSettings{Interface: "lo", PrefixLength: 129})}, isErr: true},
+		{networks: []*configs.Network{uplink(&configs.PrefixDelegationSettings{Interface: "lo", PrefixLength: 64, SubnetLength: 60})}, isErr: true},
+		{networks: []*configs.Network{uplink(&configs.PrefixDelegationSettings{Interface: "lo", Timeout: -1})}, isErr: true},
+		{networks: []*configs.Network{{Type: "loopback", PrefixDelegation: &configs.PrefixDelegationSettings{Interface: "lo"}}}, isErr: true},
+		{networks: []*configs.Network{{Type: "sriov", Name: "eth0", Parent: "ens1f0", Standby: true, PrefixDelegation: &configs.PrefixDelegationSettings{Interface: "lo"}}}, isErr: true},
+	}
+	for i, tc := range testCases {
+		config := &configs.Config{
+			Rootfs:     "/var",
+			Namespaces: configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+			Networks:   tc.networks,
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("case %d: expected error, got nil", i)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+		}
+	}
+}
+
+func TestValidateNetworkPlugin(t *testing.T) {
+	plugin := func(p *configs.PluginSettings) *configs.Network {
+		return &configs.Network{Type: "plugin", Name: "eth0", Plugin: p}
+	}
+	testCases := []struct {
+		network *configs.Network
+		isErr   bool
+	}{
+		{network: plugin(&configs.PluginSettings{Command: &configs.Command{Path: "/opt/net/plugin"}, Config: []byte(`{"ipam":"pool0"}`)})},
+		{network: plugin(&configs.PluginSettings{Command: &configs.Command{Path: "/opt/net/plugin"}})},
+		{network: plugin(nil), isErr: true},
+		{network: plugin(&configs.PluginSettings{}), isErr: true},
+		{network: plugin(&configs.PluginSettings{Command: &configs.Command{Path: "plugin"}}), isErr: true},
+		{network: plugin(&configs.PluginSettings{Command: &configs.Command{Path: "/opt/net/plugin"}, Config: []byte(`{`)}), isErr: true},
+		{network: &configs.Network{Type: "plugin", Plugin: &configs.PluginSettings{Command: &configs.Command{Path: "/opt/net/plugin"}}}, isErr: true},
+		{network: &configs.Network{Type: "veth", Name: "eth0", HostInterfaceName: "veth0", Plugin: &configs.PluginSettings{Command: &configs.Command{Path: "/opt/net/plugin"}}}, isErr: true},
+	}
+	for i, tc := range testCases {
+		config := &configs.Config{
+			Rootfs:     "/var",
+			Namespaces: configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+			Networks:   []*configs.Network{tc.network},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("case %d: expected error, got nil", i)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+		}
+	}
+}
+
+func TestValidateNetworkIsolate(t *testing.T) {
+	veth := &configs.Network{Type: "veth", Name: "eth0", HostInterfaceName: "veth0"}
+	testCases := []struct {
+		networks []*configs.Network
+		isErr    bool
+	}{
+		{networks: []*configs.Network{{Type: "loopback", Isolate: configs.IsolateDown}, veth}},
+		{networks: []*configs.Network{{Type: "loopback", Isolate: configs.IsolateDelete}}},
+		// The namespace must be isolated before the other networks are set up.
+		{networks: []*configs.Network{veth, {Type: "loopback", Isolate: configs.IsolateDown}}, isErr: true},
+		{networks: []*configs.Network{{Type: "loopback", Isolate: "flush"}}, isErr: true},
+		{networks: []*configs.Network{{Type: "veth", Name: "eth0", HostInterfaceName: "veth0", Isolate: configs.IsolateDown}}, isErr: true},
+	}
+	for i, tc := range testCases {
+		config := &configs.Config{
+			Rootfs:     "/var",
+			Namespaces: configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+			Networks:   tc.networks,
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("case %d: expected error, got nil", i)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+		}
+	}
+}
+
+func TestValidateNetworkStaticRoutes(t *testing.T) {
+	testCases := []struct {
+		routes []*configs.NetworkRoute
+		isErr  bool
+	}{
+		{routes: []*configs.NetworkRoute{{Destination: "10.1.0.0/16", Gateway: "192.0.2.1", Metric: 100}, {Destination: "fd01::/64", Gateway: "fd00::1"}}},
+		{routes: []*configs.NetworkRoute{{Destination: "198.51.100.0/24", Scope: "link"}, {Destination: "198.51.100.7/32", Scope: "host"}}},
+		{routes: []*configs.NetworkRoute{{Destination: "10.1.0.1"}}, isErr: true},
+		{routes: []*configs.NetworkRoute{{Destination: "10.1.0.0/16", Gateway: "gateway"}}, isErr: true},
+		{routes: []*configs.NetworkRoute{{Destination: "10.1.0.0/16", Gateway: "fd00::1"}}, isErr: true},
+		{routes: []*configs.NetworkRoute{{Destination: "10.1.0.0/16", Metric: -1}}, isErr: true},
+		{routes: []*configs.NetworkRoute{{Destination: "10.1.0.0/16", Gateway: "192.0.2.1", Scope: "host"}}, isErr: true},
+		{routes: []*configs.NetworkRoute{{Destination: "10.1.0.0/16", Scope: "site"}}, isErr: true},
+		{routes: []*configs.NetworkRoute{{Destination: "0.0.0.0/0", Gateway: "192.0.2.1", Table: 100}}},
+		{routes: []*configs.NetworkRoute{{Destination: "10.1.0.0/16", Table: -1}}, isErr: true},
+		{routes: []*configs.NetworkRoute{{Destination: "10.1.0.0/16", Table: 255}}, isErr: true},
+	}
+	for _, tc := range testCases {
+		config := &configs.Config{
+			Rootfs:     "/var",
+			Namespaces: configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+			Networks:   []*configs.Network{{Type: "veth", Name: "eth0", HostInterfaceName: "veth0", StaticRoutes: tc.routes}},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("%+v: expected error, got nil", tc.routes)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("%+v: unexpected error: %v", tc.routes, err)
+		}
+	}
+	config := &configs.Config{
+		Rootfs:     "/var",
+		Namespaces: configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+		Networks:   []*configs.Network{{Type: "loopback", StaticRoutes: []*configs.NetworkRoute{{Destination: "10.1.0.0/16"}}}},
+	}
+	if err := Validate(config); err == nil {
+		t.Error("expected error for static routes on a loopback network")
+	}
+}
+
+func TestValidateNetworkRdma(t *testing.T) {
+	limit := uint32(100)
+	testCases := []struct {
+		network *configs.Network
+		isErr   bool
+	}{
+		{network: &configs.Network{Type: "sriov", Name: "eth0", Parent: "ens1f0", RDMA: &configs.LinuxRdma{HcaHandles: &limit}}},
+		{network: &configs.Network{Type: "ipoib", Name: "ib0", Parent: "ib0", IPoIB: &configs.IPoIBSettings{Pkey: 0x8001}, RDMA: &configs.LinuxRdma{HcaObjects: &limit}}},
+		{network: &configs.Network{Type: "sriov", Name: "eth0", Parent: "ens1f0", RDMA: &configs.LinuxRdma{}}, isErr: true},
+		{network: &configs.Network{Type: "veth", Name: "eth0", HostInterfaceName: "veth0", RDMA: &configs.LinuxRdma{HcaHandles: &limit}}, isErr: true},
+	}
+	for _, tc := range testCases {
+		config := &configs.Config{
+			Rootfs:     "/var",
+			Namespaces: configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+			Networks:   []*configs.Network{tc.network},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("%+v: expected error, got nil", tc.network)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("%+v: unexpected error: %v", tc.network, err)
+		}
+	}
+}
+
+func TestValidateNetworkTrafficFilters(t *testing.T) {
+	testCases := []struct {
+		filters []*configs.TrafficFilter
+		isErr   bool
+	}{
+		{filters: []*configs.TrafficFilter{{Action: configs.FilterDrop}}},
+		{filters: []*configs.TrafficFilter{{Direction: configs.FilterEgress, Destination: "10.0.0.0/8", Protocol: "tcp", DestinationPort: 80, Action: configs.FilterDrop}}},
+		{filters: []*configs.TrafficFilter{{Source: "2001:db8::/32", Protocol: "icmpv6", Action: configs.FilterPolice, Rate: 125000, Burst: 10000}}},
+		{filters: []*configs.TrafficFilter{{Classifier: configs.FilterFlower, Protocol: "sctp", SourcePort: 5000, Action: configs.FilterDrop}}},
+		{filters: []*configs.TrafficFilter{{Classifier: "bpf", Action: configs.FilterDrop}}, isErr: true},
+		{filters: []*configs.TrafficFilter{{Protocol: "udp", SourcePort: 53, Action: configs.FilterMirred, Target: "eth0"}}},
+		{filters: []*configs.TrafficFilter{{Action: "accept"}}, isErr: true},
+		{filters: []*configs.TrafficFilter{{Direction: "both", Action: configs.FilterDrop}}, isErr: true},
+		{filters: []*configs.TrafficFilter{{Source: "10.0.0.1", Action: configs.FilterDrop}}, isErr: true},
+		{filters: []*configs.TrafficFilter{{Source: "10.0.0.0/8", Destination: "2001:db8::/32", Action: configs.FilterDrop}}, isErr: true},
+		{filters: []*configs.TrafficFilter{{Source: "10.0.0.0/8", Protocol: "icmpv6", Action: configs.FilterDrop}}, isErr: true},
+		{filters: []*configs.TrafficFilter{{Protocol: "gre", Action: configs.FilterDrop}}, isErr: true},
+		{filters: []*configs.TrafficFilter{{Protocol: "icmp", DestinationPort: 80, Action: configs.FilterDrop}}, isErr: true},
+		{filters: []*configs.TrafficFilter{{Action: configs.FilterPolice, Rate: 125000}}, isErr: true},
+		{filters: []*configs.TrafficFilter{{Action: configs.FilterDrop, Rate: 125000, Burst: 10000}}, isErr: true},
+		{filters: []*configs.TrafficFilter{{Action: configs.FilterDrop, Target: "eth0"}}, isErr: true},
+		{filters: []*configs.TrafficFilter{{Action: configs.FilterMirred}}, isErr: true},
+		// The target must be the interface of a network listed before.
+		{filters: []*configs.TrafficFilter{{Action: configs.FilterMirred, Target: "eth2"}}, isErr: true},
+		{filters: []*configs.TrafficFilter{{Action: configs.FilterMirred, Target: "eth1"}}, isErr: true},
+	}
+	for _, tc := range testCases {
+		config := &configs.Config{
+			Rootfs:     "/var",
+			Namespaces: configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+			Networks: []*configs.Network{
+				{Type: "veth", Name: "eth0", HostInterfaceName: "veth0"},
+				{Type: "veth", Name: "eth1", HostInterfaceName: "veth1", TrafficFilters: tc.filters},
+			},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("%+v: expected error, got nil", tc.filters)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("%+v: unexpected error: %v", tc.filters, err)
+		}
+	}
+	config := &configs.Config{
+		Rootfs:     "/var",
+		Namespaces: configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+		Networks: []*configs.Network{
+			{Type: "veth", Name: "eth0", HostInterfaceName: "veth0", Optional: true},
+			{Type: "veth", Name: "eth1", HostInterfaceName: "veth1", TrafficFilters: []*configs.TrafficFilter{{Action: configs.FilterMirred, Target: "eth0"}}},
+		},
+	}
+	if err := Validate(config); err == nil {
+		t.Error("expected error for a traffic filter targeting an optional network")
+	}
+}
+
+func TestValidateNetworkQuota(t *testing.T) {
+	testCases := []struct {
+		network *configs.Network
+		isErr   bool
+	}{
+		{network: &configs.Network{Type: "veth", Name: "eth0", HostInterfaceName: "veth0", Quota: &configs.InterfaceQuota{Bytes: 1 << 30}}},
+		{network: &configs.Network{Type: "veth", Name: "eth0", HostInterfaceName: "veth0", Quota: &configs.InterfaceQuota{Bytes: 1 << 30, Action: configs.QuotaLinkDown}}},
+		{network: &configs.Network{Type: "veth", Name: "eth0", HostInterfaceName: "veth0", Quota: &configs.InterfaceQuota{Packets: 1 << 20}}},
+		{network: &configs.Network{Type: "veth", Name: "eth0", HostInterfaceName: "veth0", Quota: &configs.InterfaceQuota{Bytes: 1 << 30, Packets: 1 << 20, Action: configs.QuotaDrop}}},
+		{network: &configs.Network{Type: "veth", Name: "eth0", HostInterfaceName: "veth0", Quota: &configs.InterfaceQuota{}}, isErr: true},
+		{network: &configs.Network{Type: "veth", Name: "eth0", HostInterfaceName: "veth0", Quota: &configs.InterfaceQuota{Bytes: 1, Action: "reject"}}, isErr: true},
+		{network: &configs.Network{Type: "loopback", Quota: &configs.InterfaceQuota{Bytes: 1}}, isErr: true},
+	}
+	for i, tc := range testCases {
+		config := &configs.Config{
+			Rootfs:     "/var",
+			Namespaces: configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+			Networks:   []*configs.Network{tc.network},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("case %d: expected error, got nil", i)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+		}
+	}
+}
+
+func TestValidateRoutingRules(t *testing.T) {
+	testCases := []struct {
+		rule  *configs.RoutingRule
+		isErr bool
+	}{
+		{rule: &configs.RoutingRule{From: "192.0.2.0/24", Table: 100}},
+		{rule: &configs.RoutingRule{Priority: 1000, From: "2001:db8::/64", To: "2001:db8:1::/64", Table: 254}},
+		{rule: &configs.RoutingRule{Mark: 0x1, Mask: 0xff, Iif: "eth0", Oif: "eth1", Table: 100}},
+		{rule: &configs.RoutingRule{From: "192.0.2.0/24"}, isErr: true},
+		{rule: &configs.RoutingRule{From: "192.0.2.0/24", Table: 255}, isErr: true},
+		{rule: &configs.RoutingRule{Priority: -1, Table: 100}, isErr: true},
+		{rule: &configs.RoutingRule{From: "192.0.2.1", Table: 100}, isErr: true},
+		{rule: &configs.RoutingRule{From: "192.0.2.0/24", To: "2001:db8::/64", Table: 100}, isErr: true},
+		{rule: &configs.RoutingRule{Iif: "eth/0", Table: 100}, isErr: true},
+	}
+	for i, tc := range testCases {
+		config := &configs.Config{
+			Rootfs:         "/var",
+			Namespaces:     configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+			NetworkOptions: &configs.NetworkOptions{RoutingRules: []*configs.RoutingRule{tc.rule}},
+		}
+		err := Validate(config)
+		if tc.isErr && err == nil {
+			t.Errorf("case %d: expected error, got nil", i)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+		}
+	}
+}
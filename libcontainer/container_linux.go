@@ -46,6 +46,10 @@ type Container struct {
 	state                containerState
 	created              time.Time
 	fifo                 *os.File
+	skippedNetwork       []SkippedNetworkSetting
+	pendingNetworks      []*configs.Network
+	pendingRoutes        []*configs.Route
+	networkRdma          map[string]string
 }
 
 // State represents a running container's state
@@ -75,6 +79,23 @@ type State struct {
 
 	// Intel RDT "resource control" filesystem path
 	IntelRdtPath string `json:"intel_rdt_path"`
+
+	// SkippedNetworkSettings lists the optional network settings which
+	// could not be applied, as they are not supported by the kernel.
+	SkippedNetworkSettings []SkippedNetworkSetting `json:"skipped_network_settings,omitempty"`
+
+	// PendingNetworks lists the optional networks which were skipped as
+	// their device was unavailable, until ReconcileNetworks attaches them.
+	PendingNetworks []*configs.Network `json:"pending_networks,omitempty"`
+
+	// PendingRoutes lists the routes through the interfaces of the pending
+	// networks, which are added once the networks are attached.
+	PendingRoutes []*configs.Route `json:"pending_routes,omitempty"`
+
+	// NetworkRdmaDevices maps the interfaces of the networks to the RDMA
+	// devices whose limits were added for them, which are lifted when the
+	// network is detached.
+	NetworkRdmaDevices map[string]string `json:"network_rdma_devices,omitempty"`
 }
 
 // ID returns the container's unique ID
@@ -148,16 +169,22 @@ func (c *Container) Stats() (*Stats, error) {
 			return stats, fmt.Errorf("unable to get container Intel RDT stats: %w", err)
 		}
 	}
-	for _, iface := range c.config.Networks {
-		switch iface.Type {
-		case "veth":
-			istats, err := getNetworkInterfaceStats(iface.HostInterfaceName)
-			if err != nil {
-				return stats, fmt.Errorf("unable to get network stats for interface %q: %w", iface.HostInterfaceName, err)
-			}
-			stats.Interfaces = append(stats.Interfaces, istats)
+	if len(c.config.Networks) == 0 {
+		return stats, nil
+	}
+	backend, err := getStatsBackend(c.config.NetworkOptions)
+	if err != nil {
+		return stats, err
+	}
+	var nsPath string
+	if _, ok := backend.(*sysfsStats); !ok {
+		if nsPath, err = c.netNSPath(); err != nil {
+			return stats, err
 		}
 	}
+	if stats.Interfaces, err = backend.interfaceStats(nsPath, c.config.Networks); err != nil {
+		return stats, fmt.Errorf("unable to get network stats: %w", err)
+	}
 	return stats, nil
 }
 
@@ -343,6 +370,14 @@ func (c *Container) start(process *Process) (retErr error) {
 			if err != nil {
 				return err
 			}
+			if len(c.config.Hooks[configs.Poststart]) != 0 {
+				if err := annotateNetworkStatus(s, fmt.Sprintf("/proc/%d/ns/net", s.Pid), c.config.Networks); err != nil {
+					if err := ignoreTerminateErrors(parent.terminate()); err != nil {
+						logrus.Warn(fmt.Errorf("error inspecting network interfaces for poststart hooks: %w", err))
+					}
+					return fmt.Errorf("unable to inspect network interfaces for hooks: %w", err)
+				}
+			}
 
 			if err := c.config.Hooks.Run(configs.Poststart, s); err != nil {
 				if err := ignoreTerminateErrors(parent.terminate()); err != nil {
@@ -563,6 +598,19 @@ func (c *Container) newParentProcess(p *Process) (parentProcess, error) {
 		cmd.SysProcAttr = &unix.SysProcAttr{}
 	}
 	cmd.Env = append(cmd.Env, "GOMAXPROCS="+os.Getenv("GOMAXPROCS"))
+	if p.Init && len(c.config.ActivationSockets) > 0 {
+		// Reserve the file descriptors right after stdio for the activation
+		// sockets. They can only be created by runc init once it is inside
+		// the container namespaces, it then places them over the
+		// placeholders.
+		comm.activationPlaceholder, err = os.Open(os.DevNull)
+		if err != nil {
+			return nil, err
+		}
+		for range c.config.ActivationSockets {
+			cmd.ExtraFiles = append(cmd.ExtraFiles, comm.activationPlaceholder)
+		}
+	}
 	cmd.ExtraFiles = append(cmd.ExtraFiles, p.ExtraFiles...)
 	if p.ConsoleSocket != nil {
 		cmd.ExtraFiles = append(cmd.ExtraFiles, p.ConsoleSocket)
@@ -738,6 +786,9 @@ func (c *Container) newInitConfig(process *Process) *initConfig {
 		ConsoleWidth:     process.ConsoleWidth,
 		ConsoleHeight:    process.ConsoleHeight,
 	}
+	if process.Init {
+		cfg.PassedFilesCount += len(c.config.ActivationSockets)
+	}
 	if process.NoNewPrivileges != nil {
 		cfg.NoNewPrivileges = *process.NoNewPrivileges
 	}
@@ -855,8 +906,14 @@ func (c *Container) updateState(process parentProcess) (*State, error) {
 	return state, nil
 }
 
-func (c *Container) saveState(s *State) (retErr error) {
-	tmpFile, err := os.CreateTemp(c.stateDir, "state-")
+func (c *Container) saveState(s *State) error {
+	return c.writeStateFile(stateFilename, s)
+}
+
+// writeStateFile atomically writes v, encoded as JSON, to the file name in
+// the container state directory.
+func (c *Container) writeStateFile(name string, v interface{}) (retErr error) {
+	tmpFile, err := os.CreateTemp(c.stateDir, name+"-")
 	if err != nil {
 		return err
 	}
@@ -868,7 +925,7 @@ func (c *Container) saveState(s *State) (retErr error) {
 		}
 	}()
 
-	err = utils.WriteJSON(tmpFile, s)
+	err = utils.WriteJSON(tmpFile, v)
 	if err != nil {
 		return err
 	}
@@ -877,8 +934,7 @@ func (c *Container) saveState(s *State) (retErr error) {
 		return err
 	}
 
-	stateFilePath := filepath.Join(c.stateDir, stateFilename)
-	return os.Rename(tmpFile.Name(), stateFilePath)
+	return os.Rename(tmpFile.Name(), filepath.Join(c.stateDir, name))
 }
 
 func (c *Container) currentStatus() (Status, error) {
@@ -964,6 +1020,11 @@ func (c *Container) currentState() (*State, error) {
 		IntelRdtPath:        intelRdtPath,
 		NamespacePaths:      make(map[configs.NamespaceType]string),
 		ExternalDescriptors: externalDescriptors,
+
+		SkippedNetworkSettings: c.skippedNetwork,
+		PendingNetworks:        c.pendingNetworks,
+		PendingRoutes:          c.pendingRoutes,
+		NetworkRdmaDevices:     c.networkRdma,
 	}
 	if pid > 0 {
 		for _, ns := range c.config.Namespaces {
@@ -14,6 +14,7 @@ type mockCgroupManager struct {
 	pids    []int
 	allPids []int
 	paths   map[string]string
+	freezer configs.FreezerState
 }
 
 func (m *mockCgroupManager) GetPids() ([]int, error) {
@@ -58,6 +59,7 @@ func (m *mockCgroupManager) Path(subsys string) string {
 }
 
 func (m *mockCgroupManager) Freeze(state configs.FreezerState) error {
+	m.freezer = state
 	return nil
 }
 
@@ -66,6 +68,9 @@ func (m *mockCgroupManager) GetCgroups() (*configs.Cgroup, error) {
 }
 
 func (m *mockCgroupManager) GetFreezerState() (configs.FreezerState, error) {
+	if m.freezer == configs.Frozen {
+		return configs.Frozen, nil
+	}
 	return configs.Thawed, nil
 }
 
@@ -0,0 +1,58 @@
+package libcontainer
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+)
+
+// dummy is a network strategy that creates a dummy interface in the
+// container. The packets routed to it are dropped, which makes it a holder
+// for service or anycast addresses announced by a routing daemon, and a
+// device for tests which does not touch the host.
+type dummy struct{}
+
+func (d *dummy) create(ctx context.Context, n *network, nspid int) error {
+	link, err := dummyLink(&n.Network)
+	if err != nil {
+		return err
+	}
+	link.Namespace = netlink.NsPid(nspid)
+	if err := netlink.LinkAdd(link); err != nil {
+		return fmt.Errorf("unable to create dummy interface %s: %w", n.Name, err)
+	}
+	return nil
+}
+
+func (d *dummy) initialize(ctx context.Context, n *network) error {
+	link, err := netlink.LinkByName(n.Name)
+	if err != nil {
+		return err
+	}
+	return configureLink(link, &n.Network)
+}
+
+func (d *dummy) attach(n *configs.Network) error {
+	return nil
+}
+
+func (d *dummy) detach(n *configs.Network) error {
+	return nil
+}
+
+// dummyLink returns the dummy link of the dummy network n.
+func dummyLink(n *configs.Network) (*netlink.Dummy, error) {
+	attrs := netlink.NewLinkAttrs()
+	attrs.Name = n.Name
+	if n.MacAddress != "" {
+		mac, err := net.ParseMAC(n.MacAddress)
+		if err != nil {
+			return nil, err
+		}
+		attrs.HardwareAddr = mac
+	}
+	return &netlink.Dummy{LinkAttrs: attrs}, nil
+}
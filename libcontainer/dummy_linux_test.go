@@ -0,0 +1,53 @@
+package libcontainer
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/runc/internal/testutil/nettest"
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"golang.org/x/sys/unix"
+)
+
+func TestDummy(t *testing.T) {
+	ctr := nettest.NewNS(t)
+	// A process in the namespace stands for the container init process.
+	cmd := exec.Command("sleep", "60")
+	ctr.Do(t, cmd.Start)
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+	n := &network{Network: configs.Network{
+		Type:        "dummy",
+		Name:        "vip0",
+		MacAddress:  "02:00:00:00:00:01",
+		Address:     "192.0.2.10/32",
+		IPv6Address: "2001:db8::10/128",
+	}}
+	d := &dummy{}
+	if err := d.create(context.Background(), n, cmd.Process.Pid); err != nil {
+		if errors.Is(err, unix.EOPNOTSUPP) {
+			t.Skip("dummy interfaces are not supported by the kernel")
+		}
+		t.Fatal(err)
+	}
+	ctr.Do(t, func() error { return d.initialize(context.Background(), n) })
+
+	link := ctr.Link(t, "vip0")
+	if link == nil || link.Type() != "dummy" {
+		t.Fatalf("expected a dummy interface in the container, got %v", link)
+	}
+	if link.Attrs().HardwareAddr.String() != n.MacAddress || link.Attrs().Flags&net.FlagUp == 0 {
+		t.Errorf("expected the interface to be up with mac %s, got %+v", n.MacAddress, link.Attrs())
+	}
+	// The link-local address of the interface is listed too.
+	addrs := strings.Join(ctr.Addrs(t, "vip0"), " ")
+	if !strings.Contains(addrs, "192.0.2.10/32") || !strings.Contains(addrs, "2001:db8::10/128") {
+		t.Errorf("expected the addresses of the network, got %v", addrs)
+	}
+}
@@ -10,4 +10,8 @@ var (
 	ErrRunning    = errors.New("container still running")
 	ErrNotRunning = errors.New("container not running")
 	ErrNotPaused  = errors.New("container not paused")
+
+	// ErrNamespaceGone is returned when the network namespace of a container
+	// disappears during an operation, because the container init exited.
+	ErrNamespaceGone = errors.New("container network namespace is gone")
 )
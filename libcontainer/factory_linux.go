@@ -19,8 +19,11 @@ import (
 )
 
 const (
-	stateFilename    = "state.json"
-	execFifoFilename = "exec.fifo"
+	stateFilename        = "state.json"
+	execFifoFilename     = "exec.fifo"
+	networkReadyFilename = "network-ready.json"
+	networkStatsFilename = "network-stats.json"
+	networkLockFilename  = "network.lock"
 )
 
 // Create creates a new container with the given id inside a given state
@@ -40,6 +43,9 @@ func Create(root, id string, config *configs.Config) (*Container, error) {
 	if err := validateID(id); err != nil {
 		return nil, err
 	}
+	named := *config
+	named.Networks = assignInterfaceNames(id, config.Networks)
+	config = &named
 	if err := validate.Validate(config); err != nil {
 		return nil, err
 	}
@@ -140,6 +146,10 @@ func Load(root, id string) (*Container, error) {
 		intelRdtManager:      intelrdt.NewManager(&state.Config, id, state.IntelRdtPath),
 		stateDir:             stateDir,
 		created:              state.Created,
+		skippedNetwork:       state.SkippedNetworkSettings,
+		pendingNetworks:      state.PendingNetworks,
+		pendingRoutes:        state.PendingRoutes,
+		networkRdma:          state.NetworkRdmaDevices,
 	}
 	c.state = &loadedState{c: c}
 	if err := c.refreshState(); err != nil {
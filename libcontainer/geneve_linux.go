@@ -0,0 +1,123 @@
+package libcontainer
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+// genevePort is the IANA assigned Geneve port, used unless a port is given.
+const genevePort = 6081
+
+// geneve is a network strategy that creates a Geneve interface in the host
+// and moves it to the container. Like vxlan, the UDP socket of the interface
+// stays in the host network namespace.
+type geneve struct{}
+
+func (g *geneve) create(ctx context.Context, n *network, nspid int) error {
+	ns, err := os.Open("/proc/" + strconv.Itoa(nspid) + "/ns/net")
+	if err != nil {
+		return err
+	}
+	defer ns.Close()
+	return createGeneve(&n.Network, int(ns.Fd()))
+}
+
+func (g *geneve) initialize(ctx context.Context, n *network) error {
+	link, err := netlink.LinkByName(n.Name)
+	if err != nil {
+		return err
+	}
+	return configureLink(link, &n.Network)
+}
+
+func (g *geneve) attach(n *configs.Network) error {
+	return nil
+}
+
+func (g *geneve) detach(n *configs.Network) error {
+	return nil
+}
+
+// createGeneve creates the Geneve interface of n in the host with a temporary
+// name, and moves it to the network namespace nsFd. The interface is brought
+// up by initialize.
+func createGeneve(n *configs.Network, nsFd int) (retErr error) {
+	name, err := tempInterfaceName("gnv")
+	if err != nil {
+		return err
+	}
+	req := nl.NewNetlinkRequest(unix.RTM_NEWLINK, unix.NLM_F_CREATE|unix.NLM_F_EXCL|unix.NLM_F_ACK)
+	req.AddData(nl.NewIfInfomsg(unix.AF_UNSPEC))
+	req.AddData(nl.NewRtAttr(unix.IFLA_IFNAME, nl.ZeroTerminated(name)))
+	if n.Mtu != 0 {
+		req.AddData(nl.NewRtAttr(unix.IFLA_MTU, nl.Uint32Attr(uint32(n.Mtu))))
+	}
+	if n.TxQueueLen != 0 {
+		req.AddData(nl.NewRtAttr(unix.IFLA_TXQLEN, nl.Uint32Attr(uint32(n.TxQueueLen))))
+	}
+	if n.MacAddress != "" {
+		mac, err := net.ParseMAC(n.MacAddress)
+		if err != nil {
+			return err
+		}
+		req.AddData(nl.NewRtAttr(unix.IFLA_ADDRESS, []byte(mac)))
+	}
+	linkInfo := nl.NewRtAttr(unix.IFLA_LINKINFO, nil)
+	linkInfo.AddRtAttr(nl.IFLA_INFO_KIND, nl.NonZeroTerminated("geneve"))
+	addGeneveAttrs(linkInfo.AddRtAttr(nl.IFLA_INFO_DATA, nil), n.Geneve)
+	req.AddData(linkInfo)
+	if _, err := req.Execute(unix.NETLINK_ROUTE, 0); err != nil {
+		return fmt.Errorf("unable to create geneve interface %s: %w", n.Name, err)
+	}
+	host, err := netlink.LinkByName(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if retErr != nil {
+			_ = netlink.LinkDel(host)
+		}
+	}()
+	return moveLink(host, nsFd, &linkMove{name: n.Name, altNames: n.AltNames, raw: n.RawLinkAttributes})
+}
+
+// addGeneveAttrs adds the IFLA_GENEVE attributes of s to data.
+func addGeneveAttrs(data *nl.RtAttr, s *configs.GeneveSettings) {
+	port := make([]byte, 2)
+	binary.BigEndian.PutUint16(port, genevePort)
+	if s.Port != 0 {
+		binary.BigEndian.PutUint16(port, s.Port)
+	}
+	data.AddRtAttr(unix.IFLA_GENEVE_PORT, port)
+	if s.TTL != 0 {
+		data.AddRtAttr(unix.IFLA_GENEVE_TTL, nl.Uint8Attr(s.TTL))
+	}
+	if s.TOS != 0 {
+		data.AddRtAttr(unix.IFLA_GENEVE_TOS, nl.Uint8Attr(s.TOS))
+	}
+	if s.DF != "" {
+		data.AddRtAttr(unix.IFLA_GENEVE_DF, nl.Uint8Attr(tunnelDF(s.DF)))
+	}
+	if s.UDPChecksum {
+		data.AddRtAttr(unix.IFLA_GENEVE_UDP_CSUM, nl.Uint8Attr(1))
+	}
+	if s.External {
+		data.AddRtAttr(unix.IFLA_GENEVE_COLLECT_METADATA, nil)
+		return
+	}
+	data.AddRtAttr(unix.IFLA_GENEVE_ID, nl.Uint32Attr(s.VNI))
+	if remote := net.ParseIP(s.Remote); remote.To4() != nil {
+		data.AddRtAttr(unix.IFLA_GENEVE_REMOTE, []byte(remote.To4()))
+	} else {
+		data.AddRtAttr(unix.IFLA_GENEVE_REMOTE6, []byte(remote.To16()))
+	}
+}
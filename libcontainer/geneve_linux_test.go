@@ -0,0 +1,111 @@
+package libcontainer
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/opencontainers/runc/internal/testutil/nettest"
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+func TestCreateGeneve(t *testing.T) {
+	host := nettest.NewNS(t)
+	ctr := nettest.NewNS(t)
+	nsFd := ctr.Fd(t)
+	networks := []*configs.Network{
+		{
+			Type:   "geneve",
+			Name:   "gnv0",
+			Mtu:    1400,
+			Geneve: &configs.GeneveSettings{VNI: 42, Remote: "2001:db8::1", TTL: 64},
+		},
+		{
+			Type:   "geneve",
+			Name:   "gnv1",
+			Geneve: &configs.GeneveSettings{External: true, Port: 6082},
+		},
+	}
+	for _, n := range networks {
+		if err := host.Run(func() error { return createGeneve(n, nsFd) }); err != nil {
+			if errors.Is(err, unix.EOPNOTSUPP) {
+				t.Skip("geneve is not supported by the kernel")
+			}
+			t.Fatal(err)
+		}
+		link := ctr.Link(t, n.Name)
+		if link == nil || link.Type() != "geneve" {
+			t.Fatalf("expected a geneve interface in the container, got %v", link)
+		}
+		if n.Mtu != 0 && link.Attrs().MTU != n.Mtu {
+			t.Errorf("expected mtu %d, got %d", n.Mtu, link.Attrs().MTU)
+		}
+	}
+
+	// A second interface in external mode on the same port is rejected by
+	// the kernel, and leaves nothing behind in the host.
+	n := &configs.Network{Type: "geneve", Name: "gnv2", Geneve: networks[1].Geneve}
+	if err := host.Run(func() error { return createGeneve(n, nsFd) }); err == nil {
+		t.Fatal("expected error for a duplicate external interface")
+	}
+}
+
+func TestGeneveAttrs(t *testing.T) {
+	parse := func(s *configs.GeneveSettings) map[int][]byte {
+		data := nl.NewRtAttr(nl.IFLA_INFO_DATA, nil)
+		addGeneveAttrs(data, s)
+		parsed, err := nl.ParseRouteAttr(data.Serialize()[unix.SizeofRtAttr:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		attrs := map[int][]byte{}
+		for _, a := range parsed {
+			attrs[int(a.Attr.Type)] = a.Value
+		}
+		return attrs
+	}
+
+	attrs := parse(&configs.GeneveSettings{VNI: 42, Remote: "192.0.2.1"})
+	if vni := nl.NativeEndian().Uint32(attrs[unix.IFLA_GENEVE_ID]); vni != 42 {
+		t.Errorf("expected vni 42, got %d", vni)
+	}
+	if !bytes.Equal(attrs[unix.IFLA_GENEVE_REMOTE], net.IPv4(192, 0, 2, 1).To4()) {
+		t.Errorf("unexpected remote %x", attrs[unix.IFLA_GENEVE_REMOTE])
+	}
+	if !bytes.Equal(attrs[unix.IFLA_GENEVE_PORT], []byte{0x17, 0xc1}) {
+		t.Errorf("expected the default port in network byte order, got %x", attrs[unix.IFLA_GENEVE_PORT])
+	}
+	if _, ok := attrs[unix.IFLA_GENEVE_COLLECT_METADATA]; ok {
+		t.Error("unexpected collect metadata flag")
+	}
+
+	attrs = parse(&configs.GeneveSettings{External: true, Port: 6082, TTL: 64})
+	if _, ok := attrs[unix.IFLA_GENEVE_COLLECT_METADATA]; !ok {
+		t.Error("expected the collect metadata flag")
+	}
+	if _, ok := attrs[unix.IFLA_GENEVE_ID]; ok {
+		t.Error("unexpected vni in external mode")
+	}
+	if !bytes.Equal(attrs[unix.IFLA_GENEVE_PORT], []byte{0x17, 0xc2}) || !bytes.Equal(attrs[unix.IFLA_GENEVE_TTL], []byte{64}) {
+		t.Errorf("unexpected port %x or ttl %x", attrs[unix.IFLA_GENEVE_PORT], attrs[unix.IFLA_GENEVE_TTL])
+	}
+	for _, a := range []int{unix.IFLA_GENEVE_TOS, unix.IFLA_GENEVE_DF, unix.IFLA_GENEVE_UDP_CSUM} {
+		if _, ok := attrs[a]; ok {
+			t.Errorf("unexpected attribute %d without tuning", a)
+		}
+	}
+
+	attrs = parse(&configs.GeneveSettings{
+		VNI: 42, Remote: "192.0.2.1",
+		TunnelTuning: configs.TunnelTuning{TOS: 0x10, DF: configs.TunnelDFSet, UDPChecksum: true},
+	})
+	if !bytes.Equal(attrs[unix.IFLA_GENEVE_TOS], []byte{0x10}) || !bytes.Equal(attrs[unix.IFLA_GENEVE_DF], []byte{1}) {
+		t.Errorf("unexpected tos %x or df %x", attrs[unix.IFLA_GENEVE_TOS], attrs[unix.IFLA_GENEVE_DF])
+	}
+	if !bytes.Equal(attrs[unix.IFLA_GENEVE_UDP_CSUM], []byte{1}) {
+		t.Errorf("expected udp checksums, got %x", attrs[unix.IFLA_GENEVE_UDP_CSUM])
+	}
+}
@@ -0,0 +1,117 @@
+package libcontainer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// HostNetworkDevice describes a network interface of the host, as a candidate
+// to be moved to a container.
+type HostNetworkDevice struct {
+	// Name is the interface name.
+	Name string `json:"name"`
+	// Index is the interface index.
+	Index int `json:"index"`
+	// Driver is the name of the kernel driver of the device, if any.
+	Driver string `json:"driver,omitempty"`
+	// Speed is the link speed in Mb/s, or 0 if unknown, for example because
+	// the link is down.
+	Speed int `json:"speed,omitempty"`
+	// NUMANode is the NUMA node of the device, or -1 if unknown.
+	NUMANode int `json:"numa_node"`
+	// SRIOVTotalVFs is the number of virtual functions the device supports.
+	SRIOVTotalVFs int `json:"sriov_total_vfs,omitempty"`
+	// SRIOVNumVFs is the number of virtual functions currently enabled.
+	SRIOVNumVFs int `json:"sriov_num_vfs,omitempty"`
+	// VirtualFunction is true if the device is an SR-IOV virtual function.
+	VirtualFunction bool `json:"virtual_function,omitempty"`
+	// Eligible is true if the device can be attached to a container.
+	Eligible bool `json:"eligible"`
+	// Reason explains why the device is not eligible.
+	Reason string `json:"reason,omitempty"`
+}
+
+// ListHostNetworkDevices returns the network interfaces of the host, sorted
+// by name. Only physical devices (backed by a bus device) which are not
+// enslaved to another interface are eligible to be attached to a container.
+// If allow is not empty, eligible devices must also match one of its
+// patterns, in the filepath.Match syntax.
+func ListHostNetworkDevices(allow []string) ([]HostNetworkDevice, error) {
+	return listHostNetworkDevices("/sys/class/net", allow)
+}
+
+func listHostNetworkDevices(sysfs string, allow []string) ([]HostNetworkDevice, error) {
+	for _, pattern := range allow {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, &os.PathError{Op: "match", Path: pattern, Err: err}
+		}
+	}
+	entries, err := os.ReadDir(sysfs)
+	if err != nil {
+		return nil, err
+	}
+	devices := make([]HostNetworkDevice, 0, len(entries))
+	for _, e := range entries {
+		d := HostNetworkDevice{Name: e.Name(), NUMANode: -1}
+		dir := filepath.Join(sysfs, d.Name)
+		if d.Index, err = readSysfsInt(dir, "ifindex"); err != nil {
+			// The interface went away while listing.
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		if speed, err := readSysfsInt(dir, "speed"); err == nil && speed > 0 {
+			d.Speed = speed
+		}
+		if driver, err := os.Readlink(filepath.Join(dir, "device", "driver")); err == nil {
+			d.Driver = filepath.Base(driver)
+		}
+		if node, err := readSysfsInt(dir, "device", "numa_node"); err == nil {
+			d.NUMANode = node
+		}
+		d.SRIOVTotalVFs, _ = readSysfsInt(dir, "device", "sriov_totalvfs")
+		d.SRIOVNumVFs, _ = readSysfsInt(dir, "device", "sriov_numvfs")
+		if _, err := os.Stat(filepath.Join(dir, "device", "physfn")); err == nil {
+			d.VirtualFunction = true
+		}
+		d.Reason = hostDeviceIneligibility(dir, d.Name, allow)
+		d.Eligible = d.Reason == ""
+		devices = append(devices, d)
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].Name < devices[j].Name })
+	return devices, nil
+}
+
+// hostDeviceIneligibility returns why the interface whose sysfs directory is
+// dir can not be attached to a container, or an empty string if it can.
+func hostDeviceIneligibility(dir, name string, allow []string) string {
+	if _, err := os.Stat(filepath.Join(dir, "device")); err != nil {
+		return "virtual device"
+	}
+	if master, err := os.Readlink(filepath.Join(dir, "master")); err == nil {
+		return "enslaved to " + filepath.Base(master)
+	}
+	if len(allow) == 0 {
+		return ""
+	}
+	for _, pattern := range allow {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return ""
+		}
+	}
+	return "not allowed"
+}
+
+// readSysfsInt reads an integer sysfs attribute.
+func readSysfsInt(elem ...string) (int, error) {
+	data, err := os.ReadFile(filepath.Join(elem...))
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(data)))
+}
@@ -0,0 +1,84 @@
+package libcontainer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestListHostNetworkDevices(t *testing.T) {
+	sysfs := t.TempDir()
+	pci := t.TempDir()
+	write := func(path, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	link := func(target, path string) {
+		t.Helper()
+		if err := os.Symlink(target, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A physical function with SR-IOV support.
+	write(filepath.Join(sysfs, "ens1f0", "ifindex"), "2\n")
+	write(filepath.Join(sysfs, "ens1f0", "speed"), "25000\n")
+	write(filepath.Join(pci, "pf", "numa_node"), "1\n")
+	write(filepath.Join(pci, "pf", "sriov_totalvfs"), "64\n")
+	write(filepath.Join(pci, "pf", "sriov_numvfs"), "4\n")
+	link(filepath.Join(pci, "pf"), filepath.Join(sysfs, "ens1f0", "device"))
+	link("../../../bus/pci/drivers/mlx5_core", filepath.Join(pci, "pf", "driver"))
+	// A virtual function.
+	write(filepath.Join(sysfs, "ens1f0v0", "ifindex"), "3\n")
+	write(filepath.Join(sysfs, "ens1f0v0", "speed"), "-1\n")
+	write(filepath.Join(pci, "vf", "numa_node"), "1\n")
+	link(filepath.Join(pci, "pf"), filepath.Join(pci, "vf", "physfn"))
+	link(filepath.Join(pci, "vf"), filepath.Join(sysfs, "ens1f0v0", "device"))
+	// A physical device enslaved to a bond.
+	write(filepath.Join(sysfs, "eno1", "ifindex"), "4\n")
+	write(filepath.Join(pci, "eno1", "numa_node"), "-1\n")
+	link(filepath.Join(pci, "eno1"), filepath.Join(sysfs, "eno1", "device"))
+	link("../bond0", filepath.Join(sysfs, "eno1", "master"))
+	// A virtual device.
+	write(filepath.Join(sysfs, "bond0", "ifindex"), "5\n")
+
+	devices, err := listHostNetworkDevices(sysfs, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devices) != 4 {
+		t.Fatalf("expected 4 devices, got %+v", devices)
+	}
+	bond, eno1, pf, vf := devices[0], devices[1], devices[2], devices[3]
+	if bond.Name != "bond0" || bond.Eligible || bond.Reason != "virtual device" {
+		t.Errorf("unexpected bond0: %+v", bond)
+	}
+	if eno1.Eligible || eno1.Reason != "enslaved to bond0" || eno1.NUMANode != -1 {
+		t.Errorf("unexpected eno1: %+v", eno1)
+	}
+	expected := HostNetworkDevice{
+		Name: "ens1f0", Index: 2, Driver: "mlx5_core", Speed: 25000, NUMANode: 1,
+		SRIOVTotalVFs: 64, SRIOVNumVFs: 4, Eligible: true,
+	}
+	if pf != expected {
+		t.Errorf("expected %+v, got %+v", expected, pf)
+	}
+	if !vf.VirtualFunction || vf.Speed != 0 || !vf.Eligible {
+		t.Errorf("unexpected ens1f0v0: %+v", vf)
+	}
+
+	devices, err = listHostNetworkDevices(sysfs, []string{"ens1f0v*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if devices[2].Eligible || devices[2].Reason != "not allowed" || !devices[3].Eligible {
+		t.Errorf("unexpected eligibility with an allowlist: %+v", devices)
+	}
+	if _, err := listHostNetworkDevices(sysfs, []string{"["}); err == nil {
+		t.Error("expected error for an invalid pattern")
+	}
+}
@@ -0,0 +1,91 @@
+package libcontainer
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// hostRoutes returns the routes of the host to the addresses of n, through
+// link.
+func hostRoutes(n *configs.Network, link netlink.Link) ([]*netlink.Route, error) {
+	var routes []*netlink.Route
+	for _, addr := range []string{n.Address, n.IPv6Address} {
+		if addr == "" {
+			continue
+		}
+		ip, _, err := net.ParseCIDR(addr)
+		if err != nil {
+			return nil, err
+		}
+		bits := 32
+		if ip.To4() == nil {
+			bits = 128
+		}
+		routes = append(routes, &netlink.Route{
+			LinkIndex: link.Attrs().Index,
+			Scope:     netlink.SCOPE_LINK,
+			Dst:       &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)},
+		})
+	}
+	return routes, nil
+}
+
+// setupHostRoutes routes the addresses of the container through the host side
+// interface of n, and enables proxy ARP on it so the host answers for the
+// gateway of the container.
+func setupHostRoutes(n *configs.Network) error {
+	if n.HostInterfaceName == "" || !n.HostRoutes {
+		return nil
+	}
+	link, err := netlink.LinkByName(n.HostInterfaceName)
+	if err != nil {
+		return err
+	}
+	routes, err := hostRoutes(n, link)
+	if err != nil {
+		return err
+	}
+	for _, route := range routes {
+		if err := netlink.RouteReplace(route); err != nil {
+			return fmt.Errorf("unable to route %s through %s: %w", route.Dst, n.HostInterfaceName, err)
+		}
+	}
+	if n.Address != "" {
+		if err := setNetworkSysctl("1", "ipv4", "conf", n.HostInterfaceName, "proxy_arp"); err != nil {
+			return fmt.Errorf("unable to enable proxy ARP on %s: %w", n.HostInterfaceName, err)
+		}
+	}
+	return nil
+}
+
+// teardownHostRoutes removes the routes added by setupHostRoutes. They are
+// usually gone already, together with the host side interface.
+func teardownHostRoutes(n *configs.Network) error {
+	if n.HostInterfaceName == "" || !n.HostRoutes {
+		return nil
+	}
+	link, err := netlink.LinkByName(n.HostInterfaceName)
+	if err != nil {
+		var notFound netlink.LinkNotFoundError
+		if errors.As(err, &notFound) {
+			return nil
+		}
+		return err
+	}
+	routes, err := hostRoutes(n, link)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, route := range routes {
+		if err := netlink.RouteDel(route); err != nil && !errors.Is(err, unix.ESRCH) {
+			errs = append(errs, fmt.Errorf("unable to remove the route to %s: %w", route.Dst, err))
+		}
+	}
+	return errors.Join(errs...)
+}
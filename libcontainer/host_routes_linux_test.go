@@ -0,0 +1,73 @@
+package libcontainer
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/runc/internal/testutil/nettest"
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+)
+
+func TestHostRoutes(t *testing.T) {
+	host := nettest.NewNS(t)
+	ctr := nettest.NewNS(t)
+	host.AddVeth(t, "rveth0", "eth0", ctr)
+	n := &configs.Network{
+		Type:              "veth",
+		HostInterfaceName: "rveth0",
+		Address:           "10.0.0.2/24",
+		IPv6Address:       "fd00::2/64",
+		HostRoutes:        true,
+	}
+	hostRoutes := func() []string {
+		var dsts []string
+		host.Do(t, func() error {
+			link, err := netlink.LinkByName("rveth0")
+			if err != nil {
+				return err
+			}
+			routes, err := netlink.RouteList(link, netlink.FAMILY_ALL)
+			if err != nil {
+				return err
+			}
+			for _, r := range routes {
+				if r.Dst != nil && !r.Dst.IP.IsLinkLocalUnicast() {
+					dsts = append(dsts, r.Dst.String())
+				}
+			}
+			return nil
+		})
+		sort.Strings(dsts)
+		return dsts
+	}
+
+	host.Do(t, func() error {
+		if err := netlink.LinkSetUp(&netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: "rveth0"}}); err != nil {
+			return err
+		}
+		return setupHostRoutes(n)
+	})
+	if routes := hostRoutes(); strings.Join(routes, " ") != "10.0.0.2/32 fd00::2/128" {
+		t.Errorf("expected routes to the container addresses, got %v", routes)
+	}
+	host.Do(t, func() error {
+		data, err := os.ReadFile("/proc/sys/net/ipv4/conf/rveth0/proxy_arp")
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(string(data)) != "1" {
+			t.Errorf("expected proxy ARP to be enabled, got %q", data)
+		}
+		return nil
+	})
+
+	host.Do(t, func() error { return teardownHostRoutes(n) })
+	if routes := hostRoutes(); len(routes) != 0 {
+		t.Errorf("expected the routes to be removed, got %v", routes)
+	}
+	// The routes are gone already.
+	host.Do(t, func() error { return teardownHostRoutes(n) })
+}
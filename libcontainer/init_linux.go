@@ -2,6 +2,7 @@ package libcontainer
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,8 +10,10 @@ import (
 	"os"
 	"runtime"
 	"runtime/debug"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/containerd/console"
 	"github.com/moby/sys/user"
@@ -22,6 +25,7 @@ import (
 	"github.com/opencontainers/runc/libcontainer/capabilities"
 	"github.com/opencontainers/runc/libcontainer/cgroups"
 	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/opencontainers/runc/libcontainer/devices"
 	"github.com/opencontainers/runc/libcontainer/system"
 	"github.com/opencontainers/runc/libcontainer/utils"
 )
@@ -45,6 +49,29 @@ type network struct {
 	// TempVethPeerName is a unique temporary veth peer name that was placed into
 	// the container's namespace.
 	TempVethPeerName string `json:"temp_veth_peer_name"`
+
+	// Routes are the routes supplied by Process.ResolveNetwork, which are
+	// added in addition to the routes of the container configuration.
+	Routes []*configs.Route `json:"routes,omitempty"`
+
+	// detached is set when the network is released because it is detached
+	// from the running container, instead of the container being destroyed.
+	detached bool
+
+	// stateDir is the state directory of the container the network is
+	// created for, used by strategies keeping host wide state. It is only
+	// set in the runc process creating the network.
+	stateDir string
+
+	// devices are the device nodes giving access to the interface, which
+	// are created in the container along with the other devices. They are
+	// only set in the runc process creating the network.
+	devices []*devices.Device
+
+	// rdmaDevice is the RDMA device backing the interface, whose resources
+	// are limited according to the RDMA settings. It is only set in the
+	// runc process creating the network.
+	rdmaDevice string
 }
 
 // initConfig is used for transferring parameters from Exec() to Init()
@@ -70,6 +97,7 @@ type initConfig struct {
 	RootlessCgroups  bool                  `json:"rootless_cgroups,omitempty"`
 	SpecState        *specs.State          `json:"spec_state,omitempty"`
 	Cgroup2Path      string                `json:"cgroup2_path,omitempty"`
+	NetworkDeadline  time.Time             `json:"network_deadline,omitempty"`
 }
 
 // Init is part of "runc init" implementation.
@@ -567,22 +595,106 @@ func fixStdioPermissions(u *user.ExecUser) error {
 	return nil
 }
 
-// setupNetwork sets up and initializes any network interface inside the container.
-func setupNetwork(config *initConfig) error {
-	for _, config := range config.Networks {
-		strategy, err := getStrategy(config.Type)
-		if err != nil {
+// setupNetwork sets up and initializes any network interface inside the
+// container, before the network deadline if there is one. It returns the
+// optional settings which were skipped.
+func setupNetwork(ctx context.Context, config *initConfig) ([]SkippedNetworkSetting, error) {
+	var skipped []SkippedNetworkSetting
+	err := runNetworkSetup(ctx, config.NetworkDeadline, func(ctx context.Context) error {
+		if err := setupNetworkOptions(config.Config.NetworkOptions); err != nil {
 			return err
 		}
-		if err := strategy.initialize(config); err != nil {
+		for _, config := range config.Networks {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			strategy, err := getStrategy(config.Type)
+			if err != nil {
+				return err
+			}
+			s, err := initializeNetwork(ctx, strategy, config)
+			if err != nil {
+				return err
+			}
+			skipped = append(skipped, s...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return skipped, nil
+}
+
+// networkEnv returns the environment variables holding the addresses of the
+// given networks: RUNC_NETDEV_<NAME>_IPV4 and RUNC_NETDEV_<NAME>_IPV6 are
+// set to the addresses, and the _CIDR suffixed variables to the addresses
+// with their prefix length. NAME is the interface name in upper case, with
+// the characters which are not letters or digits replaced by underscores.
+func networkEnv(networks []*network) []string {
+	var env []string
+	for _, n := range networks {
+		prefix := "RUNC_NETDEV_" + strings.Map(func(r rune) rune {
+			switch {
+			case r >= 'a' && r <= 'z':
+				return r - 'a' + 'A'
+			case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+				return r
+			}
+			return '_'
+		}, containerInterfaceName(&n.Network)) + "_"
+		for family, address := range map[string]string{"IPV4": n.Address, "IPV6": n.IPv6Address} {
+			ip, _, err := net.ParseCIDR(address)
+			if err != nil {
+				continue
+			}
+			env = append(env, prefix+family+"="+ip.String(), prefix+family+"_CIDR="+address)
+		}
+	}
+	sort.Strings(env)
+	return env
+}
+
+// exportNetworkEnv adds the variables returned by networkEnv to the
+// environment of the current process, unless they are already set.
+func exportNetworkEnv(networks []*network) error {
+	for _, v := range networkEnv(networks) {
+		name, value, _ := strings.Cut(v, "=")
+		if _, ok := os.LookupEnv(name); ok {
+			continue
+		}
+		if err := os.Setenv(name, value); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func setupRoute(config *configs.Config) error {
-	for _, config := range config.Routes {
+func setupRoute(config *initConfig) error {
+	// The routes through the interfaces of standby networks can only be
+	// added once they are up, see activateLink.
+	standby := make(map[string]bool)
+	for _, n := range config.Networks {
+		if n.Standby {
+			standby[containerInterfaceName(&n.Network)] = true
+		}
+	}
+	routes := make([]*configs.Route, 0, len(config.Config.Routes))
+	for _, r := range config.Config.Routes {
+		if !standby[r.InterfaceName] {
+			routes = append(routes, r)
+		}
+	}
+	for _, n := range config.Networks {
+		routes = append(routes, n.Routes...)
+	}
+	return addRoutes(routes, netlink.RouteAdd)
+}
+
+// addRoutes adds routes in the current network namespace with add, which is
+// either netlink.RouteAdd or netlink.RouteReplace.
+func addRoutes(routes []*configs.Route, add func(*netlink.Route) error) error {
+	for _, config := range routes {
 		_, dst, err := net.ParseCIDR(config.Destination)
 		if err != nil {
 			return err
@@ -606,7 +718,7 @@ func setupRoute(config *configs.Config) error {
 			Gw:        gw,
 			LinkIndex: l.Attrs().Index,
 		}
-		if err := netlink.RouteAdd(route); err != nil {
+		if err := add(route); err != nil {
 			return err
 		}
 	}
@@ -0,0 +1,59 @@
+package libcontainer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+)
+
+// ipoib is a network strategy that creates an IP over InfiniBand child
+// interface of the host interface Parent, for the partition key of the
+// network, directly in the container network namespace.
+type ipoib struct{}
+
+func (i *ipoib) create(ctx context.Context, n *network, nspid int) error {
+	parent, err := netlink.LinkByName(n.Parent)
+	if err != nil {
+		return fmt.Errorf("unable to find ipoib parent interface %s: %w", n.Parent, err)
+	}
+	if n.RDMA != nil {
+		// The child interface shares the RDMA device of its parent.
+		if n.rdmaDevice, err = rdmaDevice(sysfsNet, n.Parent); err != nil {
+			return err
+		}
+	}
+	attrs := netlink.NewLinkAttrs()
+	attrs.Name = n.Name
+	attrs.ParentIndex = parent.Attrs().Index
+	attrs.Namespace = netlink.NsPid(nspid)
+	link := &netlink.IPoIB{
+		LinkAttrs: attrs,
+		Pkey:      n.IPoIB.Pkey,
+		Mode:      netlink.StringToIPoIBMode[n.IPoIB.Mode],
+	}
+	if n.IPoIB.Umcast {
+		link.Umcast = 1
+	}
+	if err := netlink.LinkAdd(link); err != nil {
+		return fmt.Errorf("unable to create ipoib interface %s on %s: %w", n.Name, n.Parent, err)
+	}
+	return nil
+}
+
+func (i *ipoib) initialize(ctx context.Context, n *network) error {
+	link, err := netlink.LinkByName(n.Name)
+	if err != nil {
+		return err
+	}
+	return configureLink(link, &n.Network)
+}
+
+func (i *ipoib) attach(n *configs.Network) error {
+	return nil
+}
+
+func (i *ipoib) detach(n *configs.Network) error {
+	return nil
+}
@@ -0,0 +1,84 @@
+package libcontainer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+)
+
+// ipvlanModes maps the ipvlan mode names to their netlink values.
+var ipvlanModes = map[string]netlink.IPVlanMode{
+	"":    netlink.IPVLAN_MODE_L2,
+	"l2":  netlink.IPVLAN_MODE_L2,
+	"l3":  netlink.IPVLAN_MODE_L3,
+	"l3s": netlink.IPVLAN_MODE_L3S,
+}
+
+// ipvlanFlags maps the ipvlan flag names to their netlink values.
+var ipvlanFlags = map[string]netlink.IPVlanFlag{
+	"":        netlink.IPVLAN_FLAG_BRIDGE,
+	"bridge":  netlink.IPVLAN_FLAG_BRIDGE,
+	"private": netlink.IPVLAN_FLAG_PRIVATE,
+	"vepa":    netlink.IPVLAN_FLAG_VEPA,
+}
+
+// ipvlan is a network strategy that creates an ipvlan interface on the
+// Parent device of the host directly in the container network namespace.
+// The interface shares the hardware address of the parent, for networks
+// which drop the frames of unknown addresses.
+type ipvlan struct{}
+
+func (v *ipvlan) create(ctx context.Context, n *network, nspid int) error {
+	ns, err := os.Open("/proc/" + strconv.Itoa(nspid) + "/ns/net")
+	if err != nil {
+		return err
+	}
+	defer ns.Close()
+	return createIpvlan(&n.Network, int(ns.Fd()))
+}
+
+func (v *ipvlan) initialize(ctx context.Context, n *network) error {
+	link, err := netlink.LinkByName(n.Name)
+	if err != nil {
+		return err
+	}
+	return configureLink(link, &n.Network)
+}
+
+func (v *ipvlan) attach(n *configs.Network) error {
+	return nil
+}
+
+func (v *ipvlan) detach(n *configs.Network) error {
+	return nil
+}
+
+// createIpvlan creates the ipvlan interface of n in the network namespace
+// nsFd. The interface is brought up by initialize.
+func createIpvlan(n *configs.Network, nsFd int) error {
+	parent, err := netlink.LinkByName(n.Parent)
+	if err != nil {
+		return fmt.Errorf("unable to find parent interface %s: %w", n.Parent, err)
+	}
+	attrs := netlink.NewLinkAttrs()
+	attrs.Name = n.Name
+	attrs.ParentIndex = parent.Attrs().Index
+	attrs.MTU = n.Mtu
+	attrs.Namespace = netlink.NsFd(nsFd)
+	if n.TxQueueLen != 0 {
+		attrs.TxQLen = n.TxQueueLen
+	}
+	link := &netlink.IPVlan{LinkAttrs: attrs}
+	if n.Ipvlan != nil {
+		link.Mode = ipvlanModes[n.Ipvlan.Mode]
+		link.Flag = ipvlanFlags[n.Ipvlan.Flag]
+	}
+	if err := netlink.LinkAdd(link); err != nil {
+		return fmt.Errorf("unable to create ipvlan interface %s on %s: %w", n.Name, n.Parent, err)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package libcontainer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/opencontainers/runc/internal/testutil/nettest"
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+func TestCreateIpvlan(t *testing.T) {
+	host := nettest.NewNS(t)
+	ctr := nettest.NewNS(t)
+	host.AddVeth(t, "eth0", "peer0", nil)
+	nsFd := ctr.Fd(t)
+	n := &configs.Network{
+		Type:   "ipvlan",
+		Name:   "ipvl0",
+		Parent: "eth0",
+		Mtu:    1400,
+		Ipvlan: &configs.IpvlanSettings{Mode: "l3", Flag: "private"},
+	}
+	if err := host.Run(func() error { return createIpvlan(n, nsFd) }); err != nil {
+		if errors.Is(err, unix.EOPNOTSUPP) {
+			t.Skip("ipvlan is not supported by the kernel")
+		}
+		t.Fatal(err)
+	}
+	if host.Link(t, "ipvl0") != nil {
+		t.Error("expected the ipvlan interface to be created in the container only")
+	}
+	link, ok := ctr.Link(t, "ipvl0").(*netlink.IPVlan)
+	if !ok {
+		t.Fatal("expected an ipvlan interface in the container")
+	}
+	if link.Mode != netlink.IPVLAN_MODE_L3 || link.Flag != netlink.IPVLAN_FLAG_PRIVATE || link.Attrs().MTU != 1400 {
+		t.Errorf("unexpected ipvlan interface: mode %d, flag %d, mtu %d", link.Mode, link.Flag, link.Attrs().MTU)
+	}
+	parent := host.Link(t, "eth0")
+	if link.Attrs().HardwareAddr.String() != parent.Attrs().HardwareAddr.String() {
+		t.Errorf("expected the hardware address of the parent, got %s", link.Attrs().HardwareAddr)
+	}
+}
@@ -0,0 +1,59 @@
+package libcontainer
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// isolateNetNS removes the interfaces of the network namespace at nsPath,
+// other than loopback, according to mode. It returns the names of the
+// interfaces removed.
+func isolateNetNS(nsPath string, mode configs.IsolateMode) ([]string, error) {
+	var removed []string
+	err := doInNetNS(nsPath, func() error {
+		links, err := netlink.LinkList()
+		if err != nil {
+			return err
+		}
+		for _, link := range links {
+			attrs := link.Attrs()
+			if attrs.Flags&net.FlagLoopback != 0 {
+				continue
+			}
+			if err := isolateLink(link, mode); err != nil {
+				return fmt.Errorf("unable to remove interface %s: %w", attrs.Name, err)
+			}
+			removed = append(removed, attrs.Name)
+		}
+		return nil
+	})
+	return removed, err
+}
+
+// isolateLink deletes link if mode is configs.IsolateDelete and it can be
+// deleted, or brings it down and flushes its addresses and routes.
+func isolateLink(link netlink.Link, mode configs.IsolateMode) error {
+	if mode == configs.IsolateDelete {
+		err := netlink.LinkDel(link)
+		// The peer of a veth pair already deleted is gone too.
+		if err == nil || errors.Is(err, unix.ENODEV) {
+			return nil
+		}
+		// Physical devices can not be deleted.
+		if !errors.Is(err, unix.EOPNOTSUPP) {
+			return err
+		}
+	}
+	if err := netlink.LinkSetDown(link); err != nil {
+		if errors.Is(err, unix.ENODEV) {
+			return nil
+		}
+		return err
+	}
+	return flushInterface(link.Attrs().Name)
+}
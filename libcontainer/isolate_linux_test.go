@@ -0,0 +1,70 @@
+package libcontainer
+
+import (
+	"net"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/opencontainers/runc/internal/testutil/nettest"
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+)
+
+func TestIsolateNetNS(t *testing.T) {
+	for _, mode := range []configs.IsolateMode{configs.IsolateDown, configs.IsolateDelete} {
+		t.Run(string(mode), func(t *testing.T) {
+			host := nettest.NewNS(t)
+			ctr := nettest.NewNS(t)
+			host.AddVeth(t, "veth0", "eth0", ctr)
+			host.AddVeth(t, "veth1", "eth1", ctr)
+			ctr.Do(t, func() error {
+				link, err := netlink.LinkByName("eth1")
+				if err != nil {
+					return err
+				}
+				addr, _ := netlink.ParseAddr("10.0.0.2/24")
+				if err := netlink.AddrAdd(link, addr); err != nil {
+					return err
+				}
+				return netlink.LinkSetUp(link)
+			})
+
+			removed, err := isolateNetNS(ctr.Path, mode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sort.Strings(removed)
+			if !reflect.DeepEqual(removed, []string{"eth0", "eth1"}) {
+				t.Errorf("expected eth0 and eth1 to be removed, got %v", removed)
+			}
+			ctr.Do(t, func() error {
+				links, err := netlink.LinkList()
+				if err != nil {
+					return err
+				}
+				for _, link := range links {
+					attrs := link.Attrs()
+					if attrs.Name == "lo" {
+						continue
+					}
+					if mode == configs.IsolateDelete {
+						t.Errorf("expected %s to be deleted", attrs.Name)
+					}
+					if attrs.Flags&net.FlagUp != 0 {
+						t.Errorf("expected %s to be down", attrs.Name)
+					}
+				}
+				_, err = netlink.LinkByName("lo")
+				return err
+			})
+			if mode == configs.IsolateDown {
+				for _, addr := range ctr.Addrs(t, "eth1") {
+					if addr == "10.0.0.2/24" {
+						t.Errorf("expected the addresses of eth1 to be flushed")
+					}
+				}
+			}
+		})
+	}
+}
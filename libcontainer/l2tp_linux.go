@@ -0,0 +1,165 @@
+package libcontainer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+// l2tp generic netlink commands, attributes and values, from linux/l2tp.h.
+const (
+	l2tpCmdTunnelCreate  = 1
+	l2tpCmdTunnelDelete  = 2
+	l2tpCmdSessionCreate = 5
+	l2tpCmdSessionGet    = 8
+
+	l2tpAttrPwType        = 1
+	l2tpAttrEncapType     = 2
+	l2tpAttrProtoVersion  = 7
+	l2tpAttrIfname        = 8
+	l2tpAttrConnID        = 9
+	l2tpAttrPeerConnID    = 10
+	l2tpAttrSessionID     = 11
+	l2tpAttrPeerSessionID = 12
+	l2tpAttrIPSaddr       = 24
+	l2tpAttrIPDaddr       = 25
+	l2tpAttrUDPSport      = 26
+	l2tpAttrUDPDport      = 27
+	l2tpAttrIP6Saddr      = 31
+	l2tpAttrIP6Daddr      = 32
+
+	l2tpEncapTypeUDP = 0
+	l2tpEncapTypeIP  = 1
+	l2tpPwTypeEth    = 5
+)
+
+// l2tpEth is a network strategy that creates an L2TPv3 tunnel in the host,
+// with an ethernet pseudowire session whose interface is moved to the
+// container.
+type l2tpEth struct{}
+
+func (l *l2tpEth) create(ctx context.Context, n *network, nspid int) error {
+	s := n.L2TP
+	if _, err := genlExecute("l2tp", l2tpCmdTunnelCreate, 0, l2tpTunnelAttrs(s)...); err != nil {
+		return fmt.Errorf("unable to create l2tp tunnel %d: %w", s.TunnelID, err)
+	}
+	err := func() error {
+		// The tunnel is deleted if the setup was abandoned meanwhile.
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if _, err := genlExecute("l2tp", l2tpCmdSessionCreate, 0, l2tpSessionAttrs(s)...); err != nil {
+			return fmt.Errorf("unable to create l2tp session %d: %w", s.SessionID, err)
+		}
+		// The session interface is named by the kernel, to avoid clashes
+		// with the host interfaces until it is renamed by the move.
+		replies, err := genlExecute("l2tp", l2tpCmdSessionGet, 0,
+			nl.NewRtAttr(l2tpAttrConnID, nl.Uint32Attr(s.TunnelID)),
+			nl.NewRtAttr(l2tpAttrSessionID, nl.Uint32Attr(s.SessionID)))
+		if err != nil {
+			return err
+		}
+		if len(replies) == 0 || len(replies[0][l2tpAttrIfname]) == 0 {
+			return fmt.Errorf("l2tp session %d has no interface", s.SessionID)
+		}
+		link, err := netlink.LinkByName(unix.ByteSliceToString(replies[0][l2tpAttrIfname]))
+		if err != nil {
+			return err
+		}
+		m := &linkMove{name: n.Name, mtu: n.Mtu, raw: n.RawLinkAttributes}
+		if n.MacAddress != "" {
+			if m.mac, err = net.ParseMAC(n.MacAddress); err != nil {
+				return err
+			}
+		}
+		ns, err := os.Open("/proc/" + strconv.Itoa(nspid) + "/ns/net")
+		if err != nil {
+			return err
+		}
+		defer ns.Close()
+		return moveLink(link, int(ns.Fd()), m)
+	}()
+	if err != nil {
+		_ = deleteL2TPTunnel(s.TunnelID)
+		return err
+	}
+	return nil
+}
+
+func (l *l2tpEth) initialize(ctx context.Context, n *network) error {
+	link, err := netlink.LinkByName(n.Name)
+	if err != nil {
+		return err
+	}
+	return configureLink(link, &n.Network)
+}
+
+// release deletes the tunnel of the network. The session interface goes away
+// with the container network namespace.
+func (l *l2tpEth) release(n *network) error {
+	return deleteL2TPTunnel(n.L2TP.TunnelID)
+}
+
+func (l *l2tpEth) attach(n *configs.Network) error {
+	return nil
+}
+
+func (l *l2tpEth) detach(n *configs.Network) error {
+	return nil
+}
+
+// l2tpTunnelAttrs returns the attributes of the request creating the static
+// tunnel of s. The kernel creates the tunnel socket itself, in the current
+// network namespace.
+func l2tpTunnelAttrs(s *configs.L2TPSettings) []*nl.RtAttr {
+	attrs := []*nl.RtAttr{
+		nl.NewRtAttr(l2tpAttrConnID, nl.Uint32Attr(s.TunnelID)),
+		nl.NewRtAttr(l2tpAttrPeerConnID, nl.Uint32Attr(s.PeerTunnelID)),
+		nl.NewRtAttr(l2tpAttrProtoVersion, nl.Uint8Attr(3)),
+	}
+	if s.Encap == "ip" {
+		attrs = append(attrs, nl.NewRtAttr(l2tpAttrEncapType, nl.Uint16Attr(l2tpEncapTypeIP)))
+	} else {
+		attrs = append(attrs,
+			nl.NewRtAttr(l2tpAttrEncapType, nl.Uint16Attr(l2tpEncapTypeUDP)),
+			nl.NewRtAttr(l2tpAttrUDPSport, nl.Uint16Attr(s.LocalPort)),
+			nl.NewRtAttr(l2tpAttrUDPDport, nl.Uint16Attr(s.RemotePort)))
+	}
+	local, remote := net.ParseIP(s.Local), net.ParseIP(s.Remote)
+	if local4, remote4 := local.To4(), remote.To4(); local4 != nil && remote4 != nil {
+		attrs = append(attrs,
+			nl.NewRtAttr(l2tpAttrIPSaddr, local4),
+			nl.NewRtAttr(l2tpAttrIPDaddr, remote4))
+	} else {
+		attrs = append(attrs,
+			nl.NewRtAttr(l2tpAttrIP6Saddr, local.To16()),
+			nl.NewRtAttr(l2tpAttrIP6Daddr, remote.To16()))
+	}
+	return attrs
+}
+
+// l2tpSessionAttrs returns the attributes of the request creating the
+// ethernet pseudowire session of s.
+func l2tpSessionAttrs(s *configs.L2TPSettings) []*nl.RtAttr {
+	return []*nl.RtAttr{
+		nl.NewRtAttr(l2tpAttrConnID, nl.Uint32Attr(s.TunnelID)),
+		nl.NewRtAttr(l2tpAttrSessionID, nl.Uint32Attr(s.SessionID)),
+		nl.NewRtAttr(l2tpAttrPeerSessionID, nl.Uint32Attr(s.PeerSessionID)),
+		nl.NewRtAttr(l2tpAttrPwType, nl.Uint16Attr(l2tpPwTypeEth)),
+	}
+}
+
+// deleteL2TPTunnel deletes the tunnel id, and its sessions.
+func deleteL2TPTunnel(id uint32) error {
+	if _, err := genlExecute("l2tp", l2tpCmdTunnelDelete, 0, nl.NewRtAttr(l2tpAttrConnID, nl.Uint32Attr(id))); err != nil {
+		return fmt.Errorf("unable to delete l2tp tunnel %d: %w", id, err)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package libcontainer
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink/nl"
+)
+
+func TestL2TPTunnelAttrs(t *testing.T) {
+	values := func(attrs []*nl.RtAttr) map[int][]byte {
+		m := make(map[int][]byte, len(attrs))
+		for _, a := range attrs {
+			m[int(a.Type)] = a.Data
+		}
+		return m
+	}
+	s := &configs.L2TPSettings{
+		Local: "198.51.100.1", Remote: "198.51.100.2",
+		LocalPort: 1701, RemotePort: 1702,
+		TunnelID: 10, PeerTunnelID: 20,
+	}
+	attrs := values(l2tpTunnelAttrs(s))
+	if !bytes.Equal(attrs[l2tpAttrIPSaddr], net.ParseIP("198.51.100.1").To4()) || !bytes.Equal(attrs[l2tpAttrIPDaddr], net.ParseIP("198.51.100.2").To4()) {
+		t.Errorf("unexpected tunnel addresses %v and %v", attrs[l2tpAttrIPSaddr], attrs[l2tpAttrIPDaddr])
+	}
+	if nl.NativeEndian().Uint16(attrs[l2tpAttrEncapType]) != l2tpEncapTypeUDP || nl.NativeEndian().Uint16(attrs[l2tpAttrUDPDport]) != 1702 {
+		t.Error("expected an udp tunnel to port 1702")
+	}
+	if nl.NativeEndian().Uint32(attrs[l2tpAttrPeerConnID]) != 20 {
+		t.Error("expected the peer tunnel id to be set")
+	}
+
+	s = &configs.L2TPSettings{Encap: "ip", Local: "2001:db8::1", Remote: "2001:db8::2", TunnelID: 10, PeerTunnelID: 20}
+	attrs = values(l2tpTunnelAttrs(s))
+	if nl.NativeEndian().Uint16(attrs[l2tpAttrEncapType]) != l2tpEncapTypeIP {
+		t.Error("expected an ip tunnel")
+	}
+	if _, ok := attrs[l2tpAttrUDPSport]; ok {
+		t.Error("expected no ports for an ip tunnel")
+	}
+	if !bytes.Equal(attrs[l2tpAttrIP6Daddr], net.ParseIP("2001:db8::2")) {
+		t.Errorf("unexpected tunnel remote address %v", attrs[l2tpAttrIP6Daddr])
+	}
+}
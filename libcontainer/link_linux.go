@@ -0,0 +1,138 @@
+package libcontainer
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+// linkMove holds the settings applied to a link by the request moving it to
+// the container. Empty fields are not changed.
+type linkMove struct {
+	// name is the name of the link in the container.
+	name string
+	// mac is the hardware address of the link.
+	mac net.HardwareAddr
+	// mtu is the MTU of the link.
+	mtu int
+	// down sets the link down before the move, if it is up.
+	down bool
+	// altNames are alternative names added to the link.
+	altNames []string
+	// raw are added to the request as is, so drivers can be given
+	// attributes which are not modeled by the netlink library. They must
+	// have been validated.
+	raw []*configs.LinkAttribute
+}
+
+// moveLink moves link to the network namespace nsFd, applying the settings
+// of m with a single RTM_NEWLINK request instead of one request per setting.
+// The alternative names are added before the move, as they can only be set
+// with RTM_NEWLINKPROP, and removed again if the move fails. The link is only
+// set down beforehand if m.down is set, for the drivers which need it: the
+// kernel closes it as part of the move, so an explicit down would otherwise
+// only add a round trip and, for links up in the host, a carrier change. It
+// is brought up once configured in the container, not by the move.
+//
+// Wireless interfaces are moved together with their phy using nl80211, once
+// set down if m.down is set, and then given the settings from inside the
+// container.
+func moveLink(link netlink.Link, nsFd int, m *linkMove) (retErr error) {
+	if m.down && link.Attrs().Flags&net.FlagUp != 0 {
+		if err := netlink.LinkSetDown(link); err != nil {
+			return fmt.Errorf("unable to set %s down: %w", link.Attrs().Name, err)
+		}
+		defer func() {
+			if retErr != nil {
+				_ = netlink.LinkSetUp(link)
+			}
+		}()
+	}
+	if len(m.altNames) > 0 {
+		if err := addAltNames(link, m.altNames); err != nil {
+			return err
+		}
+		defer func() {
+			if retErr != nil {
+				_ = linkAltNamesRequest(unix.RTM_DELLINKPROP, link, m.altNames)
+			}
+		}()
+	}
+	wireless, err := getWirelessDevice(link.Attrs().Index)
+	if err != nil {
+		return fmt.Errorf("unable to check if %s is a wireless interface: %w", link.Attrs().Name, err)
+	}
+	if wireless != nil {
+		index, err := moveWirelessLink(link, wireless, nsFd)
+		if err != nil {
+			return err
+		}
+		return doInNetNS("/proc/self/fd/"+strconv.Itoa(nsFd), func() error {
+			return setLinkAttributes(link.Attrs().Name, index, -1, m)
+		})
+	}
+	return setLinkAttributes(link.Attrs().Name, link.Attrs().Index, nsFd, m)
+}
+
+// setLinkAttributes sends a single RTM_NEWLINK request for the interface with
+// the given index, moving it to nsFd unless it is negative. The kernel moves
+// the link before applying the other settings of the request.
+func setLinkAttributes(linkName string, index, nsFd int, m *linkMove) error {
+	req := nl.NewNetlinkRequest(unix.RTM_NEWLINK, unix.NLM_F_ACK)
+	msg := nl.NewIfInfomsg(unix.AF_UNSPEC)
+	msg.Index = int32(index)
+	req.AddData(msg)
+	if nsFd >= 0 {
+		req.AddData(nl.NewRtAttr(unix.IFLA_NET_NS_FD, nl.Uint32Attr(uint32(nsFd))))
+	}
+	if m.name != "" {
+		req.AddData(nl.NewRtAttr(unix.IFLA_IFNAME, nl.ZeroTerminated(m.name)))
+	}
+	if len(m.mac) > 0 {
+		req.AddData(nl.NewRtAttr(unix.IFLA_ADDRESS, m.mac))
+	}
+	if m.mtu != 0 {
+		req.AddData(nl.NewRtAttr(unix.IFLA_MTU, nl.Uint32Attr(uint32(m.mtu))))
+	}
+	for _, attr := range m.raw {
+		req.AddData(nl.NewRtAttr(int(attr.Type), attr.Value))
+	}
+	if _, err := req.Execute(unix.NETLINK_ROUTE, 0); err != nil {
+		return fmt.Errorf("unable to move %s: %w", linkName, err)
+	}
+	return nil
+}
+
+// addAltNames adds alternative names to link with a single request.
+func addAltNames(link netlink.Link, names []string) error {
+	if err := linkAltNamesRequest(unix.RTM_NEWLINKPROP, link, names); err != nil {
+		return fmt.Errorf("unable to add alternative names to %s: %w", link.Attrs().Name, err)
+	}
+	return nil
+}
+
+// linkAltNamesRequest sends the request adding or deleting, depending on
+// typ, the alternative names of link.
+func linkAltNamesRequest(typ int, link netlink.Link, names []string) error {
+	flags := unix.NLM_F_ACK
+	if typ == unix.RTM_NEWLINKPROP {
+		// For deletions, the flag means a bulk deletion instead.
+		flags |= unix.NLM_F_EXCL
+	}
+	req := nl.NewNetlinkRequest(typ, flags)
+	msg := nl.NewIfInfomsg(unix.AF_UNSPEC)
+	msg.Index = int32(link.Attrs().Index)
+	req.AddData(msg)
+	props := nl.NewRtAttr(unix.IFLA_PROP_LIST|unix.NLA_F_NESTED, nil)
+	for _, name := range names {
+		props.AddRtAttr(unix.IFLA_ALT_IFNAME, nl.ZeroTerminated(name))
+	}
+	req.AddData(props)
+	_, err := req.Execute(unix.NETLINK_ROUTE, 0)
+	return err
+}
@@ -0,0 +1,252 @@
+package libcontainer
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"net"
+	"time"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+// linkLocalTiming holds the timing of the IPv4 link-local address
+// configuration, see RFC 3927 section 9.
+type linkLocalTiming struct {
+	// probeWait is the maximum initial random delay before probing.
+	probeWait time.Duration
+	// probeNum is the number of probes sent for each candidate address.
+	probeNum int
+	// probeMin and probeMax bound the random delay between probes.
+	probeMin, probeMax time.Duration
+	// announceWait is the delay before announcing the selected address.
+	announceWait time.Duration
+	// announceNum is the number of announcements sent.
+	announceNum int
+	// announceInterval is the time between announcements.
+	announceInterval time.Duration
+}
+
+// rfc3927Timing is the timing defined by RFC 3927. It can be overridden
+// by tests.
+var rfc3927Timing = linkLocalTiming{
+	probeWait:        time.Second,
+	probeNum:         3,
+	probeMin:         time.Second,
+	probeMax:         2 * time.Second,
+	announceWait:     2 * time.Second,
+	announceNum:      2,
+	announceInterval: 2 * time.Second,
+}
+
+// maxLinkLocalConflicts is the number of conflicting candidate addresses
+// after which the configuration gives up.
+const maxLinkLocalConflicts = 10
+
+// setupLinkLocal selects an IPv4 link-local address for the interface name,
+// as described in RFC 3927, checking it is not used by another host on the
+// link, and assigns it to the interface. The interface must be up.
+func setupLinkLocal(ctx context.Context, name string) error {
+	link, err := netlink.LinkByName(name)
+	if err != nil {
+		return err
+	}
+	mac := link.Attrs().HardwareAddr
+	if len(mac) != 6 {
+		return fmt.Errorf("IPv4 link-local configuration requires an ethernet interface, %s is not", name)
+	}
+	conn, err := newARPConn(link.Attrs().Index, mac)
+	if err != nil {
+		return err
+	}
+	defer conn.close()
+
+	timing := rfc3927Timing
+	// The generator is seeded with the MAC address, so the interface gets
+	// the same address every time if there is no conflict.
+	h := fnv.New64a()
+	_, _ = h.Write(mac)
+	rnd := rand.New(rand.NewSource(int64(h.Sum64())))
+	if err := sleepCtx(ctx, randDuration(rnd, 0, timing.probeWait)); err != nil {
+		return err
+	}
+	for conflicts := 0; conflicts < maxLinkLocalConflicts; conflicts++ {
+		ip := linkLocalCandidate(rnd)
+		conflict, err := conn.probe(ctx, rnd, ip, timing)
+		if err != nil {
+			return err
+		}
+		if conflict {
+			continue
+		}
+		addr := &netlink.Addr{
+			IPNet: &net.IPNet{IP: ip, Mask: net.CIDRMask(16, 32)},
+			Scope: unix.RT_SCOPE_LINK,
+		}
+		if err := netlink.AddrAdd(link, addr); err != nil {
+			return fmt.Errorf("unable to add link-local address %s to %s: %w", ip, name, err)
+		}
+		return conn.announce(ctx, ip, timing)
+	}
+	return fmt.Errorf("unable to find a free link-local address for %s after %d conflicts", name, maxLinkLocalConflicts)
+}
+
+// linkLocalCandidate returns a random address in 169.254.1.0 - 169.254.254.255,
+// the range allowed by RFC 3927.
+func linkLocalCandidate(rnd *rand.Rand) net.IP {
+	ip := make(net.IP, net.IPv4len)
+	binary.BigEndian.PutUint32(ip, 0xa9fe0100+uint32(rnd.Intn(254*256)))
+	return ip
+}
+
+func randDuration(rnd *rand.Rand, min, max time.Duration) time.Duration {
+	if max <= min {
+		return min
+	}
+	return min + time.Duration(rnd.Int63n(int64(max-min)))
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// arpConn is a datagram packet socket sending and receiving ARP packets on an
+// ethernet interface.
+type arpConn struct {
+	fd      int
+	ifIndex int
+	mac     net.HardwareAddr
+}
+
+func newARPConn(ifIndex int, mac net.HardwareAddr) (*arpConn, error) {
+	proto := htons(unix.ETH_P_ARP)
+	fd, err := unix.Socket(unix.AF_PACKET, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, int(proto))
+	if err != nil {
+		return nil, fmt.Errorf("unable to open ARP socket: %w", err)
+	}
+	if err := unix.Bind(fd, &unix.SockaddrLinklayer{Protocol: proto, Ifindex: ifIndex}); err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("unable to bind ARP socket: %w", err)
+	}
+	return &arpConn{fd: fd, ifIndex: ifIndex, mac: mac}, nil
+}
+
+func (c *arpConn) close() {
+	unix.Close(c.fd)
+}
+
+// send broadcasts an ARP request with the given sender and target addresses.
+func (c *arpConn) send(sender, target net.IP) error {
+	pkt := make([]byte, 28)
+	binary.BigEndian.PutUint16(pkt[0:], 1) // Ethernet
+	binary.BigEndian.PutUint16(pkt[2:], unix.ETH_P_IP)
+	pkt[4], pkt[5] = 6, 4
+	binary.BigEndian.PutUint16(pkt[6:], 1) // Request
+	copy(pkt[8:], c.mac)
+	copy(pkt[14:], sender.To4())
+	copy(pkt[24:], target.To4())
+	addr := &unix.SockaddrLinklayer{
+		Protocol: htons(unix.ETH_P_ARP),
+		Ifindex:  c.ifIndex,
+		Halen:    6,
+		Addr:     [8]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+	}
+	return unix.Sendto(c.fd, pkt, 0, addr)
+}
+
+// conflicts reads the ARP packets received until the deadline, and reports
+// whether another host uses ip, or is probing for it.
+func (c *arpConn) conflicts(ctx context.Context, ip net.IP, deadline time.Time) (bool, error) {
+	buf := make([]byte, 1500)
+	for {
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+		wait := time.Until(deadline)
+		if wait <= 0 {
+			return false, nil
+		}
+		// Wake up regularly to check ctx.
+		if wait > 100*time.Millisecond {
+			wait = 100 * time.Millisecond
+		}
+		fds := []unix.PollFd{{Fd: int32(c.fd), Events: unix.POLLIN}}
+		n, err := unix.Poll(fds, int(wait.Milliseconds())+1)
+		if err != nil {
+			if errors.Is(err, unix.EINTR) {
+				continue
+			}
+			return false, err
+		}
+		if n == 0 {
+			continue
+		}
+		n, _, err = unix.Recvfrom(c.fd, buf, 0)
+		if err != nil {
+			return false, err
+		}
+		if n < 28 {
+			continue
+		}
+		sha, spa, tpa := net.HardwareAddr(buf[8:14]), net.IP(buf[14:18]), net.IP(buf[24:28])
+		if sha.String() == c.mac.String() {
+			continue
+		}
+		if spa.Equal(ip) || (spa.Equal(net.IPv4zero) && tpa.Equal(ip)) {
+			return true, nil
+		}
+	}
+}
+
+// probe checks whether ip is used by another host on the link.
+func (c *arpConn) probe(ctx context.Context, rnd *rand.Rand, ip net.IP, timing linkLocalTiming) (bool, error) {
+	for i := 0; i < timing.probeNum; i++ {
+		if err := c.send(net.IPv4zero, ip); err != nil {
+			return false, fmt.Errorf("unable to send ARP probe: %w", err)
+		}
+		wait := randDuration(rnd, timing.probeMin, timing.probeMax)
+		if i == timing.probeNum-1 {
+			wait = timing.announceWait
+		}
+		conflict, err := c.conflicts(ctx, ip, time.Now().Add(wait))
+		if conflict || err != nil {
+			return conflict, err
+		}
+	}
+	return false, nil
+}
+
+// announce tells the other hosts on the link that ip is now in use.
+func (c *arpConn) announce(ctx context.Context, ip net.IP, timing linkLocalTiming) error {
+	for i := 0; i < timing.announceNum; i++ {
+		if i > 0 {
+			if err := sleepCtx(ctx, timing.announceInterval); err != nil {
+				return err
+			}
+		}
+		if err := c.send(ip, ip); err != nil {
+			return fmt.Errorf("unable to send ARP announcement: %w", err)
+		}
+	}
+	return nil
+}
+
+// htons converts v from host to network byte order, as expected for the
+// protocols of packet sockets.
+func htons(v uint16) uint16 {
+	b := make([]byte, 2)
+	binary.BigEndian.PutUint16(b, v)
+	return nl.NativeEndian().Uint16(b)
+}
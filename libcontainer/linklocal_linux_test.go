@@ -0,0 +1,76 @@
+package libcontainer
+
+import (
+	"context"
+	"hash/fnv"
+	"math/rand"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/runc/internal/testutil/nettest"
+	"github.com/vishvananda/netlink"
+)
+
+func TestSetupLinkLocal(t *testing.T) {
+	timing := rfc3927Timing
+	t.Cleanup(func() { rfc3927Timing = timing })
+	rfc3927Timing = linkLocalTiming{
+		probeWait:        10 * time.Millisecond,
+		probeNum:         2,
+		probeMin:         20 * time.Millisecond,
+		probeMax:         40 * time.Millisecond,
+		announceWait:     50 * time.Millisecond,
+		announceNum:      1,
+		announceInterval: 10 * time.Millisecond,
+	}
+
+	other := nettest.NewNS(t)
+	ctr := nettest.NewNS(t)
+	other.AddVeth(t, "peer0", "eth0", ctr)
+	mac := ctr.Link(t, "eth0").Attrs().HardwareAddr
+
+	// Compute the first candidate of the container interface, and make the
+	// other host use it so the container has to pick the next one.
+	h := fnv.New64a()
+	_, _ = h.Write(mac)
+	rnd := rand.New(rand.NewSource(int64(h.Sum64())))
+	_ = randDuration(rnd, 0, rfc3927Timing.probeWait)
+	taken := linkLocalCandidate(rnd)
+	other.Do(t, func() error {
+		link, err := netlink.LinkByName("peer0")
+		if err != nil {
+			return err
+		}
+		addr := &netlink.Addr{IPNet: &net.IPNet{IP: taken, Mask: net.CIDRMask(16, 32)}}
+		if err := netlink.AddrAdd(link, addr); err != nil {
+			return err
+		}
+		return netlink.LinkSetUp(link)
+	})
+	ctr.Do(t, func() error {
+		return netlink.LinkSetUp(&netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: "eth0"}})
+	})
+
+	ctr.Do(t, func() error { return setupLinkLocal(context.Background(), "eth0") })
+	var found string
+	for _, addr := range ctr.Addrs(t, "eth0") {
+		ip, ipNet, _ := net.ParseCIDR(addr)
+		if ip.To4() == nil {
+			continue
+		}
+		if !ipNet.Contains(taken) || ip.Equal(taken) {
+			t.Errorf("expected a free link-local address, got %s (taken %s)", addr, taken)
+		}
+		found = addr
+	}
+	if found == "" {
+		t.Fatal("no link-local address assigned")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := ctr.Run(func() error { return setupLinkLocal(ctx, "eth0") }); err == nil {
+		t.Error("expected error with a cancelled context")
+	}
+}
@@ -0,0 +1,190 @@
+package libcontainer
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// lldpInterval is the time between two LLDP frames, unless an interval
+	// is given.
+	lldpInterval = 30 * time.Second
+
+	// lldpHoldMultiplier is the number of intervals an LLDP frame is valid
+	// for.
+	lldpHoldMultiplier = 4
+)
+
+// LLDP TLV types and subtypes set by runc.
+const (
+	lldpTLVEnd               = 0
+	lldpTLVChassisID         = 1
+	lldpTLVPortID            = 2
+	lldpTLVTTL               = 3
+	lldpTLVPortDescription   = 4
+	lldpTLVSystemName        = 5
+	lldpTLVSystemDescription = 6
+
+	lldpChassisIDLocal      = 7
+	lldpPortIDInterfaceName = 5
+)
+
+// lldpMulticast is the nearest bridge group address, which bridges do not
+// forward, so the frames only reach the switch port of the interface.
+var lldpMulticast = [8]byte{0x01, 0x80, 0xc2, 0x00, 0x00, 0x0e}
+
+// AnnounceLLDP periodically sends LLDP frames on the interfaces of the
+// container networks with LLDP settings. It returns once ctx is cancelled,
+// after sending frames telling the neighbors to forget the container, or
+// once the container has stopped. Failures to send a frame are logged, so a
+// link which is down does not stop the announcements on the others. No
+// frame is sent while the container is paused.
+func (c *Container) AnnounceLLDP(ctx context.Context) error {
+	var networks []*configs.Network
+	for _, n := range c.config.Networks {
+		if n.LLDP != nil {
+			networks = append(networks, n)
+		}
+	}
+	if len(networks) == 0 {
+		return errors.New("lldp is not enabled on any network")
+	}
+	monitor := newNetworkMonitor(c, "lldp")
+	defer monitor.stop()
+	next := make([]time.Time, len(networks))
+	for {
+		nsPath, suspended, err := monitor.check()
+		if err != nil {
+			if errors.Is(err, ErrNotRunning) {
+				return nil
+			}
+			return err
+		}
+		now := time.Now()
+		wake := now.Add(lldpInterval)
+		for i, n := range networks {
+			interval := lldpSettingsInterval(n.LLDP)
+			// The frames due while the container is paused are sent
+			// once it is resumed.
+			if !suspended && !now.Before(next[i]) {
+				if err := sendLLDP(nsPath, c.id, n, lldpTTL(interval)); err != nil {
+					logrus.Warnf("unable to send lldp frame on %s: %v", containerInterfaceName(n), err)
+				}
+				next[i] = now.Add(interval)
+			}
+			if next[i].Before(wake) {
+				wake = next[i]
+			}
+		}
+		timer := time.NewTimer(time.Until(wake))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			for _, n := range networks {
+				_ = sendLLDP(nsPath, c.id, n, 0)
+			}
+			return nil
+		case <-timer.C:
+		}
+	}
+}
+
+func lldpSettingsInterval(s *configs.LLDPSettings) time.Duration {
+	if s.Interval == 0 {
+		return lldpInterval
+	}
+	return s.Interval
+}
+
+// lldpTTL returns the time to live of the frames sent every interval.
+func lldpTTL(interval time.Duration) uint16 {
+	ttl := lldpHoldMultiplier * interval / time.Second
+	if ttl > 0xffff {
+		return 0xffff
+	}
+	return uint16(ttl)
+}
+
+// sendLLDP sends an LLDP frame with the given time to live on the interface
+// of n, in the network namespace at nsPath. Interfaces which can not be found
+// or are down are skipped.
+func sendLLDP(nsPath, id string, n *configs.Network, ttl uint16) error {
+	name := containerInterfaceName(n)
+	return doInNetNS(nsPath, func() error {
+		link, err := netlink.LinkByName(name)
+		if err != nil {
+			var notFound netlink.LinkNotFoundError
+			if errors.As(err, &notFound) {
+				return nil
+			}
+			return err
+		}
+		fd, err := unix.Socket(unix.AF_PACKET, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, 0)
+		if err != nil {
+			return fmt.Errorf("unable to open LLDP socket: %w", err)
+		}
+		defer unix.Close(fd)
+		addr := &unix.SockaddrLinklayer{
+			Protocol: htons(unix.ETH_P_LLDP),
+			Ifindex:  link.Attrs().Index,
+			Halen:    6,
+			Addr:     lldpMulticast,
+		}
+		err = unix.Sendto(fd, lldpFrame(id, name, n.LLDP, ttl), 0, addr)
+		if errors.Is(err, unix.ENETDOWN) {
+			return nil
+		}
+		return err
+	})
+}
+
+// lldpFrame returns the LLDP data unit announcing the interface name of the
+// container id. A frame with a zero ttl is a shutdown frame, which only
+// holds the mandatory TLVs.
+func lldpFrame(id, name string, s *configs.LLDPSettings, ttl uint16) []byte {
+	var b []byte
+	b = appendLLDPTLV(b, lldpTLVChassisID, append([]byte{lldpChassisIDLocal}, lldpString(id)...))
+	b = appendLLDPTLV(b, lldpTLVPortID, append([]byte{lldpPortIDInterfaceName}, name...))
+	b = appendLLDPTLV(b, lldpTLVTTL, binary.BigEndian.AppendUint16(nil, ttl))
+	if ttl != 0 {
+		if s.PortDescription != "" {
+			b = appendLLDPTLV(b, lldpTLVPortDescription, []byte(s.PortDescription))
+		}
+		systemName := s.SystemName
+		if systemName == "" {
+			systemName = lldpString(id)
+		}
+		b = appendLLDPTLV(b, lldpTLVSystemName, []byte(systemName))
+		if s.SystemDescription != "" {
+			b = appendLLDPTLV(b, lldpTLVSystemDescription, []byte(s.SystemDescription))
+		}
+		for _, tlv := range s.TLVs {
+			b = appendLLDPTLV(b, tlv.Type, tlv.Value)
+		}
+	}
+	return appendLLDPTLV(b, lldpTLVEnd, nil)
+}
+
+// lldpString truncates s to the 255 bytes an LLDP string can hold, leaving
+// room for the subtype of the chassis ID.
+func lldpString(s string) string {
+	if len(s) > 254 {
+		return s[:254]
+	}
+	return s
+}
+
+// appendLLDPTLV appends to b the TLV of the given type, its 7 bits type and
+// 9 bits length followed by value.
+func appendLLDPTLV(b []byte, typ uint8, value []byte) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(typ)<<9|uint16(len(value)))
+	return append(b, value...)
+}
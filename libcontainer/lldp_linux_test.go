@@ -0,0 +1,113 @@
+package libcontainer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/runc/internal/testutil/nettest"
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// parseLLDPFrame returns the TLVs of frame, in order.
+func parseLLDPFrame(t *testing.T, frame []byte) (types []uint8, values [][]byte) {
+	t.Helper()
+	for len(frame) > 0 {
+		if len(frame) < 2 {
+			t.Fatalf("truncated tlv header %x", frame)
+		}
+		header := binary.BigEndian.Uint16(frame)
+		length := int(header & 0x1ff)
+		if len(frame) < 2+length {
+			t.Fatalf("truncated tlv value %x", frame)
+		}
+		types = append(types, uint8(header>>9))
+		values = append(values, frame[2:2+length])
+		frame = frame[2+length:]
+	}
+	return types, values
+}
+
+func TestLLDPFrame(t *testing.T) {
+	s := &configs.LLDPSettings{
+		PortDescription: "uplink",
+		TLVs:            []*configs.LLDPTLV{{Type: 127, Value: []byte{0x00, 0x80, 0xc2, 0x01, 0x00, 0x2a}}},
+	}
+	types, values := parseLLDPFrame(t, lldpFrame("ctr1", "eth0", s, 120))
+	expectedTypes := []uint8{lldpTLVChassisID, lldpTLVPortID, lldpTLVTTL, lldpTLVPortDescription, lldpTLVSystemName, 127, lldpTLVEnd}
+	if !bytes.Equal(types, expectedTypes) {
+		t.Fatalf("expected tlv types %v, got %v", expectedTypes, types)
+	}
+	expectedValues := [][]byte{
+		append([]byte{lldpChassisIDLocal}, "ctr1"...),
+		append([]byte{lldpPortIDInterfaceName}, "eth0"...),
+		{0, 120},
+		[]byte("uplink"),
+		[]byte("ctr1"),
+		s.TLVs[0].Value,
+		{},
+	}
+	for i, v := range expectedValues {
+		if !bytes.Equal(values[i], v) {
+			t.Errorf("tlv %d: expected %x, got %x", types[i], v, values[i])
+		}
+	}
+
+	// Shutdown frames only hold the mandatory TLVs.
+	types, values = parseLLDPFrame(t, lldpFrame("ctr1", "eth0", s, 0))
+	if !bytes.Equal(types, []uint8{lldpTLVChassisID, lldpTLVPortID, lldpTLVTTL, lldpTLVEnd}) || !bytes.Equal(values[2], []byte{0, 0}) {
+		t.Errorf("unexpected shutdown frame tlvs %v %x", types, values)
+	}
+
+	if ttl := lldpTTL(30 * time.Second); ttl != 120 {
+		t.Errorf("expected a ttl of 120, got %d", ttl)
+	}
+	if ttl := lldpTTL(24 * time.Hour); ttl != 0xffff {
+		t.Errorf("expected the ttl to be capped, got %d", ttl)
+	}
+}
+
+func TestSendLLDP(t *testing.T) {
+	ctr := nettest.NewNS(t)
+	peer := nettest.NewNS(t)
+	ctr.AddVeth(t, "eth0", "swp1", peer)
+	eth0, swp1 := ctr.Link(t, "eth0"), peer.Link(t, "swp1")
+	ctr.Do(t, func() error { return netlink.LinkSetUp(eth0) })
+	peer.Do(t, func() error { return netlink.LinkSetUp(swp1) })
+	var fd int
+	peer.Do(t, func() error {
+		var err error
+		fd, err = unix.Socket(unix.AF_PACKET, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, int(htons(unix.ETH_P_LLDP)))
+		return err
+	})
+	defer unix.Close(fd)
+	tv := unix.NsecToTimeval(int64(5 * time.Second))
+	if err := unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &tv); err != nil {
+		t.Fatal(err)
+	}
+
+	n := &configs.Network{Type: "sriov", Name: "eth0", LLDP: &configs.LLDPSettings{}}
+	if err := sendLLDP(ctr.Path, "ctr1", n, 120); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 1500)
+	size, from, err := unix.Recvfrom(fd, buf, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf[:size], lldpFrame("ctr1", "eth0", n.LLDP, 120)) {
+		t.Errorf("unexpected frame %x", buf[:size])
+	}
+	if ll, ok := from.(*unix.SockaddrLinklayer); !ok || ll.Pkttype != unix.PACKET_MULTICAST {
+		t.Errorf("expected a multicast frame, got %+v", from)
+	}
+
+	// Missing interfaces are skipped.
+	n.Name = "eth1"
+	if err := sendLLDP(ctr.Path, "ctr1", n, 120); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,228 @@
+package libcontainer
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+// Generic netlink commands and attributes of the macsec family, from
+// linux/if_macsec.h.
+const (
+	macsecCmdAddRxSC = 1
+	macsecCmdAddTxSA = 4
+	macsecCmdAddRxSA = 7
+
+	macsecAttrIfindex    = 1
+	macsecAttrRxSCConfig = 2
+	macsecAttrSAConfig   = 3
+
+	macsecRxSCAttrSCI    = 1
+	macsecRxSCAttrActive = 2
+
+	macsecSAAttrAN     = 1
+	macsecSAAttrActive = 2
+	macsecSAAttrPN     = 3
+	macsecSAAttrKey    = 4
+	macsecSAAttrKeyID  = 5
+)
+
+// MACsec cipher suite identifiers.
+const (
+	macsecCipherGCMAES128 = 0x0080C20001000001
+	macsecCipherGCMAES256 = 0x0080C20001000002
+)
+
+// macsec is a network strategy that creates a MACsec interface inside the
+// container over the interface of another network of the container, and
+// installs its secure associations, so the container gets an encrypted link
+// without running an agent.
+type macsec struct{}
+
+func (m *macsec) create(ctx context.Context, n *network, nspid int) error {
+	// The interface is created by initialize, once its link is in the
+	// container.
+	return nil
+}
+
+func (m *macsec) initialize(ctx context.Context, n *network) (retErr error) {
+	s := n.MACsec
+	lower, err := netlink.LinkByName(s.Link)
+	if err != nil {
+		return fmt.Errorf("unable to find macsec link %s: %w", s.Link, err)
+	}
+	req := nl.NewNetlinkRequest(unix.RTM_NEWLINK, unix.NLM_F_CREATE|unix.NLM_F_EXCL|unix.NLM_F_ACK)
+	req.AddData(nl.NewIfInfomsg(unix.AF_UNSPEC))
+	req.AddData(nl.NewRtAttr(unix.IFLA_IFNAME, nl.ZeroTerminated(n.Name)))
+	req.AddData(nl.NewRtAttr(unix.IFLA_LINK, nl.Uint32Attr(uint32(lower.Attrs().Index))))
+	if n.TxQueueLen != 0 {
+		req.AddData(nl.NewRtAttr(unix.IFLA_TXQLEN, nl.Uint32Attr(uint32(n.TxQueueLen))))
+	}
+	if n.MacAddress != "" {
+		mac, err := net.ParseMAC(n.MacAddress)
+		if err != nil {
+			return err
+		}
+		req.AddData(nl.NewRtAttr(unix.IFLA_ADDRESS, []byte(mac)))
+	}
+	linkInfo := nl.NewRtAttr(unix.IFLA_LINKINFO, nil)
+	linkInfo.AddRtAttr(nl.IFLA_INFO_KIND, nl.NonZeroTerminated("macsec"))
+	addMACsecAttrs(linkInfo.AddRtAttr(nl.IFLA_INFO_DATA, nil), s)
+	req.AddData(linkInfo)
+	if _, err := req.Execute(unix.NETLINK_ROUTE, 0); err != nil {
+		return fmt.Errorf("unable to create macsec interface %s: %w", n.Name, err)
+	}
+	link, err := netlink.LinkByName(n.Name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if retErr != nil {
+			_ = netlink.LinkDel(link)
+		}
+	}()
+	if err := addMACsecSAs(link.Attrs().Index, s); err != nil {
+		return fmt.Errorf("unable to configure macsec interface %s: %w", n.Name, err)
+	}
+	return configureLink(link, &n.Network)
+}
+
+func (m *macsec) attach(n *configs.Network) error {
+	return nil
+}
+
+func (m *macsec) detach(n *configs.Network) error {
+	return nil
+}
+
+// addMACsecAttrs adds the IFLA_MACSEC attributes of s to data.
+func addMACsecAttrs(data *nl.RtAttr, s *configs.MACsecSettings) {
+	port := make([]byte, 2)
+	binary.BigEndian.PutUint16(port, macsecPort(s.Port))
+	data.AddRtAttr(unix.IFLA_MACSEC_PORT, port)
+	cipher := uint64(macsecCipherGCMAES128)
+	if s.CipherSuite == "gcm-aes-256" {
+		cipher = macsecCipherGCMAES256
+	}
+	data.AddRtAttr(unix.IFLA_MACSEC_CIPHER_SUITE, nl.Uint64Attr(cipher))
+	data.AddRtAttr(unix.IFLA_MACSEC_ENCODING_SA, nl.Uint8Attr(s.TxSA.AN))
+	encrypt := uint8(1)
+	if s.IntegrityOnly {
+		encrypt = 0
+	}
+	data.AddRtAttr(unix.IFLA_MACSEC_ENCRYPT, nl.Uint8Attr(encrypt))
+	if s.ReplayWindow != 0 {
+		data.AddRtAttr(unix.IFLA_MACSEC_REPLAY_PROTECT, nl.Uint8Attr(1))
+		data.AddRtAttr(unix.IFLA_MACSEC_WINDOW, nl.Uint32Attr(s.ReplayWindow))
+	}
+}
+
+// addMACsecSAs installs the transmit secure association and the receive
+// secure channels of s on the MACsec interface with the given index.
+func addMACsecSAs(index int, s *configs.MACsecSettings) error {
+	ifindex := nl.NewRtAttr(macsecAttrIfindex, nl.Uint32Attr(uint32(index)))
+	keyLen := macsecKeyLen(s)
+	sa, err := macsecSAAttr(s.TxSA, keyLen)
+	if err != nil {
+		return err
+	}
+	if _, err := genlExecute("macsec", macsecCmdAddTxSA, 0, ifindex, sa); err != nil {
+		return fmt.Errorf("unable to add the transmit secure association: %w", err)
+	}
+	for _, sc := range s.RxSCs {
+		sci, err := macsecSCI(sc.Address, sc.Port)
+		if err != nil {
+			return err
+		}
+		rxsc := nl.NewRtAttr(unix.NLA_F_NESTED|macsecAttrRxSCConfig, nil)
+		rxsc.AddRtAttr(macsecRxSCAttrSCI, sci)
+		rxsc.AddRtAttr(macsecRxSCAttrActive, nl.Uint8Attr(1))
+		if _, err := genlExecute("macsec", macsecCmdAddRxSC, 0, ifindex, rxsc); err != nil {
+			return fmt.Errorf("unable to add the secure channel of %s: %w", sc.Address, err)
+		}
+		rxsc = nl.NewRtAttr(unix.NLA_F_NESTED|macsecAttrRxSCConfig, nil)
+		rxsc.AddRtAttr(macsecRxSCAttrSCI, sci)
+		for _, a := range sc.SAs {
+			sa, err := macsecSAAttr(a, keyLen)
+			if err != nil {
+				return err
+			}
+			if _, err := genlExecute("macsec", macsecCmdAddRxSA, 0, ifindex, rxsc, sa); err != nil {
+				return fmt.Errorf("unable to add secure association %d of %s: %w", a.AN, sc.Address, err)
+			}
+		}
+	}
+	return nil
+}
+
+// macsecSAAttr returns the MACSEC_ATTR_SA_CONFIG attribute of the active
+// secure association sa, with its key of keyLen bytes read from its key file.
+func macsecSAAttr(sa *configs.MACsecSA, keyLen int) (*nl.RtAttr, error) {
+	keyID, err := hex.DecodeString(sa.KeyID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid macsec key id %q: %w", sa.KeyID, err)
+	}
+	key, err := readMACsecKey(sa.KeyFile, keyLen)
+	if err != nil {
+		return nil, err
+	}
+	pn := sa.PN
+	if pn == 0 {
+		pn = 1
+	}
+	attr := nl.NewRtAttr(unix.NLA_F_NESTED|macsecAttrSAConfig, nil)
+	attr.AddRtAttr(macsecSAAttrAN, nl.Uint8Attr(sa.AN))
+	attr.AddRtAttr(macsecSAAttrActive, nl.Uint8Attr(1))
+	attr.AddRtAttr(macsecSAAttrPN, nl.Uint32Attr(pn))
+	attr.AddRtAttr(macsecSAAttrKey, key)
+	attr.AddRtAttr(macsecSAAttrKeyID, keyID)
+	return attr, nil
+}
+
+// readMACsecKey reads the hex encoded key of keyLen bytes in the file at
+// path.
+func readMACsecKey(path string, keyLen int) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := hex.DecodeString(string(bytes.TrimSpace(data)))
+	if err != nil || len(key) != keyLen {
+		return nil, fmt.Errorf("invalid macsec key in %s, %d hex encoded bytes are required", path, keyLen)
+	}
+	return key, nil
+}
+
+// macsecSCI returns the secure channel identifier made of the MAC address
+// addr and the port, in network byte order.
+func macsecSCI(addr string, port uint16) ([]byte, error) {
+	mac, err := net.ParseMAC(addr)
+	if err != nil || len(mac) != 6 {
+		return nil, fmt.Errorf("invalid macsec peer address %q", addr)
+	}
+	return binary.BigEndian.AppendUint16(append([]byte{}, mac...), macsecPort(port)), nil
+}
+
+// macsecKeyLen returns the length of the keys of the cipher suite of s.
+func macsecKeyLen(s *configs.MACsecSettings) int {
+	if s.CipherSuite == "gcm-aes-256" {
+		return 32
+	}
+	return 16
+}
+
+func macsecPort(port uint16) uint16 {
+	if port == 0 {
+		return 1
+	}
+	return port
+}
@@ -0,0 +1,106 @@
+package libcontainer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/runc/internal/testutil/nettest"
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+func writeMACsecKey(t *testing.T, key string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(path, []byte(key+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestMACsecAttrs(t *testing.T) {
+	s := &configs.MACsecSettings{
+		Link:         "eth0",
+		CipherSuite:  "gcm-aes-256",
+		ReplayWindow: 32,
+		TxSA:         &configs.MACsecSA{AN: 2},
+	}
+	data := nl.NewRtAttr(nl.IFLA_INFO_DATA, nil)
+	addMACsecAttrs(data, s)
+	parsed, err := nl.ParseRouteAttr(data.Serialize()[unix.SizeofRtAttr:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	attrs := map[int][]byte{}
+	for _, a := range parsed {
+		attrs[int(a.Attr.Type)] = a.Value
+	}
+	if !bytes.Equal(attrs[unix.IFLA_MACSEC_PORT], []byte{0, 1}) {
+		t.Errorf("expected the default port in network byte order, got %x", attrs[unix.IFLA_MACSEC_PORT])
+	}
+	if cipher := nl.NativeEndian().Uint64(attrs[unix.IFLA_MACSEC_CIPHER_SUITE]); cipher != macsecCipherGCMAES256 {
+		t.Errorf("expected the gcm-aes-256 cipher suite, got %#x", cipher)
+	}
+	if !bytes.Equal(attrs[unix.IFLA_MACSEC_ENCODING_SA], []byte{2}) || !bytes.Equal(attrs[unix.IFLA_MACSEC_ENCRYPT], []byte{1}) {
+		t.Errorf("unexpected encoding sa %x or encryption %x", attrs[unix.IFLA_MACSEC_ENCODING_SA], attrs[unix.IFLA_MACSEC_ENCRYPT])
+	}
+	if window := nl.NativeEndian().Uint32(attrs[unix.IFLA_MACSEC_WINDOW]); window != 32 || !bytes.Equal(attrs[unix.IFLA_MACSEC_REPLAY_PROTECT], []byte{1}) {
+		t.Errorf("expected replay protection with a window of 32, got %d", window)
+	}
+
+	sci, err := macsecSCI("02:00:00:00:00:01", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(sci, []byte{0x02, 0, 0, 0, 0, 0x01, 0, 1}) {
+		t.Errorf("unexpected sci %x", sci)
+	}
+
+	if _, err := macsecSAAttr(&configs.MACsecSA{KeyID: "00112233445566778899aabbccddeeff", KeyFile: writeMACsecKey(t, "00112233445566778899aabbccddeeff")}, 32); err == nil {
+		t.Error("expected error for a key shorter than the cipher suite one")
+	}
+}
+
+func TestMACsec(t *testing.T) {
+	ctr := nettest.NewNS(t)
+	peer := nettest.NewNS(t)
+	ctr.AddVeth(t, "eth0", "peer0", peer)
+	key := writeMACsecKey(t, "00112233445566778899aabbccddeeff")
+	n := &network{Network: configs.Network{
+		Type:    "macsec",
+		Name:    "macsec0",
+		Address: "192.0.2.10/24",
+		MACsec: &configs.MACsecSettings{
+			Link: "eth0",
+			TxSA: &configs.MACsecSA{AN: 0, KeyID: "0100000000000000000000000000000a", KeyFile: key},
+			RxSCs: []*configs.MACsecRxSC{{
+				Address: "02:00:00:00:00:02",
+				SAs:     []*configs.MACsecSA{{AN: 0, KeyID: "0200000000000000000000000000000b", KeyFile: key}},
+			}},
+		},
+	}}
+	eth0 := ctr.Link(t, "eth0")
+	err := ctr.Run(func() error {
+		if err := netlink.LinkSetUp(eth0); err != nil {
+			return err
+		}
+		return (&macsec{}).initialize(context.Background(), n)
+	})
+	if err != nil {
+		if errors.Is(err, unix.EOPNOTSUPP) {
+			t.Skip("macsec is not supported by the kernel")
+		}
+		t.Fatal(err)
+	}
+	link := ctr.Link(t, "macsec0")
+	if link.Type() != "macsec" || link.Attrs().Flags&net.FlagUp == 0 {
+		t.Errorf("expected a macsec interface which is up, got %s %v", link.Type(), link.Attrs().Flags)
+	}
+}
@@ -0,0 +1,112 @@
+package libcontainer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+)
+
+// macvlanModes maps the macvlan mode names to their netlink values.
+var macvlanModes = map[string]netlink.MacvlanMode{
+	"":         netlink.MACVLAN_MODE_BRIDGE,
+	"bridge":   netlink.MACVLAN_MODE_BRIDGE,
+	"private":  netlink.MACVLAN_MODE_PRIVATE,
+	"vepa":     netlink.MACVLAN_MODE_VEPA,
+	"passthru": netlink.MACVLAN_MODE_PASSTHRU,
+}
+
+// macvlan is a network strategy that creates a macvlan interface on the
+// Parent device of the host directly in the container network namespace, so
+// the container is present on the network of the parent.
+type macvlan struct{}
+
+func (m *macvlan) create(ctx context.Context, n *network, nspid int) error {
+	ns, err := os.Open("/proc/" + strconv.Itoa(nspid) + "/ns/net")
+	if err != nil {
+		return err
+	}
+	defer ns.Close()
+	if macvlanMode(&n.Network) != netlink.MACVLAN_MODE_PASSTHRU {
+		return createMacvlan(&n.Network, int(ns.Fd()))
+	}
+	if n.stateDir == "" {
+		return errors.New("passthru macvlan networks require the container state directory")
+	}
+	registry := filepath.Join(filepath.Dir(n.stateDir), passthruRegistryFilename)
+	owner := filepath.Base(n.stateDir)
+	if err := claimPassthruParent(registry, n.Parent, owner); err != nil {
+		return err
+	}
+	if err := createMacvlan(&n.Network, int(ns.Fd())); err != nil {
+		_ = releasePassthruParent(registry, owner, n.Parent)
+		return err
+	}
+	return nil
+}
+
+func (m *macvlan) initialize(ctx context.Context, n *network) error {
+	link, err := netlink.LinkByName(n.Name)
+	if err != nil {
+		return err
+	}
+	return configureLink(link, &n.Network)
+}
+
+// release restores the state of the parent device of a passthru macvlan.
+func (m *macvlan) release(n *network) error {
+	if macvlanMode(&n.Network) != netlink.MACVLAN_MODE_PASSTHRU {
+		return nil
+	}
+	registry := filepath.Join(filepath.Dir(n.stateDir), passthruRegistryFilename)
+	return releasePassthruParent(registry, filepath.Base(n.stateDir), n.Parent)
+}
+
+func (m *macvlan) attach(n *configs.Network) error {
+	return nil
+}
+
+func (m *macvlan) detach(n *configs.Network) error {
+	return nil
+}
+
+// macvlanMode returns the mode of the macvlan network n.
+func macvlanMode(n *configs.Network) netlink.MacvlanMode {
+	if n.Macvlan == nil {
+		return netlink.MACVLAN_MODE_BRIDGE
+	}
+	return macvlanModes[n.Macvlan.Mode]
+}
+
+// createMacvlan creates the macvlan interface of n in the network namespace
+// nsFd. The interface is brought up by initialize.
+func createMacvlan(n *configs.Network, nsFd int) error {
+	parent, err := netlink.LinkByName(n.Parent)
+	if err != nil {
+		return fmt.Errorf("unable to find parent interface %s: %w", n.Parent, err)
+	}
+	attrs := netlink.NewLinkAttrs()
+	attrs.Name = n.Name
+	attrs.ParentIndex = parent.Attrs().Index
+	attrs.MTU = n.Mtu
+	attrs.Namespace = netlink.NsFd(nsFd)
+	if n.MacAddress != "" {
+		if attrs.HardwareAddr, err = net.ParseMAC(n.MacAddress); err != nil {
+			return err
+		}
+	}
+	if n.TxQueueLen != 0 {
+		attrs.TxQLen = n.TxQueueLen
+	}
+	link := &netlink.Macvlan{LinkAttrs: attrs, Mode: macvlanMode(n)}
+	if err := netlink.LinkAdd(link); err != nil {
+		return fmt.Errorf("unable to create macvlan interface %s on %s: %w", n.Name, n.Parent, err)
+	}
+	return nil
+}
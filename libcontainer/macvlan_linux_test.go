@@ -0,0 +1,40 @@
+package libcontainer
+
+import (
+	"testing"
+
+	"github.com/opencontainers/runc/internal/testutil/nettest"
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+)
+
+func TestCreateMacvlan(t *testing.T) {
+	host := nettest.NewNS(t)
+	ctr := nettest.NewNS(t)
+	host.AddVeth(t, "eth0", "peer0", nil)
+	nsFd := ctr.Fd(t)
+	n := &configs.Network{
+		Type:       "macvlan",
+		Name:       "mv0",
+		Parent:     "eth0",
+		MacAddress: "02:00:00:00:00:02",
+		Mtu:        1400,
+		Macvlan:    &configs.MacvlanSettings{Mode: "private"},
+	}
+	host.Do(t, func() error { return createMacvlan(n, nsFd) })
+	if host.Link(t, "mv0") != nil {
+		t.Error("expected the macvlan interface to be created in the container only")
+	}
+	link, ok := ctr.Link(t, "mv0").(*netlink.Macvlan)
+	if !ok {
+		t.Fatal("expected a macvlan interface in the container")
+	}
+	if link.Mode != netlink.MACVLAN_MODE_PRIVATE || link.Attrs().HardwareAddr.String() != n.MacAddress || link.Attrs().MTU != 1400 {
+		t.Errorf("unexpected macvlan interface: mode %d, mac %s, mtu %d", link.Mode, link.Attrs().HardwareAddr, link.Attrs().MTU)
+	}
+
+	n = &configs.Network{Type: "macvlan", Name: "mv1", Parent: "missing0"}
+	if err := host.Run(func() error { return createMacvlan(n, nsFd) }); err == nil {
+		t.Error("expected error with a missing parent")
+	}
+}
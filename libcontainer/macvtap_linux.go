@@ -0,0 +1,140 @@
+package libcontainer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/opencontainers/runc/libcontainer/devices"
+	"github.com/vishvananda/netlink"
+)
+
+// macvtap is a network strategy that creates a macvtap interface on the
+// Parent device of the host, moves it to the container network namespace,
+// and has its character device created in the container as /dev/tapN, N
+// being the index of the interface in the container, so that programs like
+// QEMU can use it.
+//
+// The interface is created in the host, as the number of its character
+// device can only be read from the sysfs of the network namespace holding
+// it. The device is also allowed in the container cgroup. As device nodes
+// can not be added to a running container, macvtap networks can not be
+// attached at runtime, or moved between containers.
+type macvtap struct{}
+
+func (m *macvtap) create(ctx context.Context, n *network, nspid int) error {
+	ns, err := os.Open("/proc/" + strconv.Itoa(nspid) + "/ns/net")
+	if err != nil {
+		return err
+	}
+	defer ns.Close()
+	return createMacvtap(n, int(ns.Fd()))
+}
+
+func (m *macvtap) initialize(ctx context.Context, n *network) error {
+	link, err := netlink.LinkByName(n.Name)
+	if err != nil {
+		return err
+	}
+	return configureLink(link, &n.Network)
+}
+
+func (m *macvtap) attach(n *configs.Network) error {
+	return nil
+}
+
+func (m *macvtap) detach(n *configs.Network) error {
+	return nil
+}
+
+// createMacvtap creates the macvtap interface of n in the host with a
+// temporary name, and moves it to the network namespace nsFd. The character
+// device of the interface is added to the devices of n. The interface is
+// brought up by initialize.
+func createMacvtap(n *network, nsFd int) (retErr error) {
+	parent, err := netlink.LinkByName(n.Parent)
+	if err != nil {
+		return fmt.Errorf("unable to find parent interface %s: %w", n.Parent, err)
+	}
+	name, err := tempInterfaceName("mvtap")
+	if err != nil {
+		return err
+	}
+	attrs := netlink.NewLinkAttrs()
+	attrs.Name = name
+	attrs.ParentIndex = parent.Attrs().Index
+	attrs.MTU = n.Mtu
+	if n.MacAddress != "" {
+		if attrs.HardwareAddr, err = net.ParseMAC(n.MacAddress); err != nil {
+			return err
+		}
+	}
+	if n.TxQueueLen != 0 {
+		attrs.TxQLen = n.TxQueueLen
+	}
+	link := &netlink.Macvtap{Macvlan: netlink.Macvlan{LinkAttrs: attrs, Mode: macvlanMode(&n.Network)}}
+	if err := netlink.LinkAdd(link); err != nil {
+		return fmt.Errorf("unable to create macvtap interface %s on %s: %w", n.Name, n.Parent, err)
+	}
+	defer func() {
+		if retErr != nil {
+			_ = netlink.LinkDel(link)
+		}
+	}()
+	host, err := netlink.LinkByName(name)
+	if err != nil {
+		return err
+	}
+	major, minor, err := macvtapDeviceNumber(sysfsNet, name, host.Attrs().Index)
+	if err != nil {
+		return err
+	}
+	if err := moveLink(host, nsFd, &linkMove{name: n.Name, altNames: n.AltNames, raw: n.RawLinkAttributes}); err != nil {
+		return err
+	}
+	// The interface keeps its index in the container, unless it is taken.
+	var index int
+	err = doInNetNS("/proc/self/fd/"+strconv.Itoa(nsFd), func() error {
+		link, err := netlink.LinkByName(n.Name)
+		if err != nil {
+			return err
+		}
+		index = link.Attrs().Index
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	n.devices = append(n.devices, &devices.Device{
+		Rule: devices.Rule{
+			Type:        devices.CharDevice,
+			Major:       major,
+			Minor:       minor,
+			Permissions: "rwm",
+			Allow:       true,
+		},
+		Path:     "/dev/tap" + strconv.Itoa(index),
+		FileMode: 0o600,
+	})
+	return nil
+}
+
+// macvtapDeviceNumber returns the major and minor numbers of the character
+// device of the macvtap interface name, whose index is index, from the sysfs
+// directory of the network interfaces.
+func macvtapDeviceNumber(sysfs, name string, index int) (int64, int64, error) {
+	data, err := os.ReadFile(filepath.Join(sysfs, name, "macvtap", "tap"+strconv.Itoa(index), "dev"))
+	if err != nil {
+		return 0, 0, err
+	}
+	var major, minor int64
+	if _, err := fmt.Sscanf(strings.TrimSpace(string(data)), "%d:%d", &major, &minor); err != nil {
+		return 0, 0, fmt.Errorf("invalid device number of %s: %w", name, err)
+	}
+	return major, minor, nil
+}
@@ -0,0 +1,82 @@
+package libcontainer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/opencontainers/runc/internal/testutil/nettest"
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+func TestCreateMacvtap(t *testing.T) {
+	host := nettest.NewNS(t)
+	ctr := nettest.NewNS(t)
+	host.AddVeth(t, "eth0", "peer0", nil)
+	n := &network{Network: configs.Network{
+		Type:       "macvtap",
+		Name:       "mvt0",
+		Parent:     "eth0",
+		MacAddress: "02:00:00:00:00:03",
+		Mtu:        1400,
+		Macvlan:    &configs.MacvlanSettings{Mode: "private"},
+	}}
+	nsFd := ctr.Fd(t)
+	host.Do(t, func() error {
+		// The sysfs of the host namespace is needed to read the device
+		// number of the interface.
+		if err := unix.Unshare(unix.CLONE_FS | unix.CLONE_NEWNS); err != nil {
+			return err
+		}
+		if err := unix.Mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, ""); err != nil {
+			return err
+		}
+		if err := unix.Mount("sysfs", "/sys", "sysfs", 0, ""); err != nil {
+			return err
+		}
+		return createMacvtap(n, nsFd)
+	})
+	ctr.Do(t, func() error { return (&macvtap{}).initialize(context.Background(), n) })
+
+	link, ok := ctr.Link(t, "mvt0").(*netlink.Macvtap)
+	if !ok {
+		t.Fatal("expected a macvtap interface to be created")
+	}
+	if link.Mode != netlink.MACVLAN_MODE_PRIVATE || link.Attrs().HardwareAddr.String() != n.MacAddress || link.Attrs().MTU != 1400 {
+		t.Errorf("unexpected macvtap interface: mode %d, mac %s, mtu %d", link.Mode, link.Attrs().HardwareAddr, link.Attrs().MTU)
+	}
+	if len(n.devices) != 1 {
+		t.Fatalf("expected the character device to be added, got %+v", n.devices)
+	}
+	if d := n.devices[0]; d.Path != "/dev/tap"+strconv.Itoa(link.Attrs().Index) || d.Major == 0 || !d.Allow {
+		t.Errorf("unexpected character device: %+v", d)
+	}
+	if l := host.Link(t, "eth0"); l == nil {
+		t.Error("expected the parent interface to be kept")
+	}
+}
+
+func TestMacvtapDeviceNumber(t *testing.T) {
+	sysfs := t.TempDir()
+	dir := filepath.Join(sysfs, "mvtap0", "macvtap", "tap7")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "dev"), []byte("241:3\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	major, minor, err := macvtapDeviceNumber(sysfs, "mvtap0", 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if major != 241 || minor != 3 {
+		t.Errorf("expected 241:3, got %d:%d", major, minor)
+	}
+	if _, _, err := macvtapDeviceNumber(sysfs, "mvtap0", 8); err == nil {
+		t.Error("expected error for a missing interface")
+	}
+}
@@ -0,0 +1,165 @@
+package libcontainer
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// MetadataAddress is the well-known link-local address the metadata
+// endpoint listens on inside the container.
+const MetadataAddress = "169.254.169.254"
+
+// metadataTimeout bounds the time a client can take to send its request.
+const metadataTimeout = 10 * time.Second
+
+// ServeMetadata serves the JSON document doc over HTTP on MetadataAddress
+// port 80 inside the container network namespace, similar to the metadata
+// services provided by cloud environments. The address is added to the
+// container loopback interface, and removed once ctx is cancelled unless it
+// was already there.
+//
+// A request for "/" returns the whole document, and a request for "/a/b"
+// returns the value of the "b" key of the "a" object. String values are
+// returned as plain text, other values as JSON.
+func (c *Container) ServeMetadata(ctx context.Context, doc []byte) error {
+	var root interface{}
+	if err := json.Unmarshal(doc, &root); err != nil {
+		return fmt.Errorf("invalid metadata document: %w", err)
+	}
+	c.m.Lock()
+	nsPath, err := c.netNSPath()
+	c.m.Unlock()
+	if err != nil {
+		return err
+	}
+
+	addr := &netlink.Addr{IPNet: &net.IPNet{IP: net.ParseIP(MetadataAddress), Mask: net.CIDRMask(32, 32)}}
+	var (
+		l     net.Listener
+		added bool
+	)
+	// An address already on the interface is left in place once done.
+	removeAddr := func() error {
+		lo, err := netlink.LinkByName("lo")
+		if err != nil {
+			return err
+		}
+		return netlink.AddrDel(lo, addr)
+	}
+	err = doInNetNS(nsPath, func() error {
+		lo, err := netlink.LinkByName("lo")
+		if err != nil {
+			return err
+		}
+		if err := netlink.AddrAdd(lo, addr); err == nil {
+			added = true
+		} else if !errors.Is(err, unix.EEXIST) {
+			return fmt.Errorf("unable to add %s to the loopback interface: %w", MetadataAddress, err)
+		}
+		l, err = net.Listen("tcp", net.JoinHostPort(MetadataAddress, "80"))
+		if err != nil && added {
+			_ = removeAddr()
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if added {
+		defer func() {
+			_ = doInNetNS(nsPath, removeAddr)
+		}()
+	}
+
+	go func() {
+		<-ctx.Done()
+		l.Close()
+	}()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		go func() {
+			if err := serveMetadataConn(conn, root); err != nil {
+				logrus.WithError(err).Debug("metadata: error serving request")
+			}
+		}()
+	}
+}
+
+// serveMetadataConn answers a single HTTP request and closes the connection.
+func serveMetadataConn(conn net.Conn, root interface{}) error {
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(metadataTimeout))
+
+	r := textproto.NewReader(bufio.NewReader(conn))
+	line, err := r.ReadLine()
+	if err != nil {
+		return err
+	}
+	// Discard the request headers.
+	if _, err := r.ReadMIMEHeader(); err != nil {
+		return err
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) != 3 || !strings.HasPrefix(fields[2], "HTTP/") {
+		return writeMetadataResponse(conn, "400 Bad Request", "text/plain", []byte("bad request\n"))
+	}
+	if fields[0] != "GET" {
+		return writeMetadataResponse(conn, "405 Method Not Allowed", "text/plain", []byte("method not allowed\n"))
+	}
+	v, ok := lookupMetadata(root, fields[1])
+	if !ok {
+		return writeMetadataResponse(conn, "404 Not Found", "text/plain", []byte("not found\n"))
+	}
+	if s, ok := v.(string); ok {
+		return writeMetadataResponse(conn, "200 OK", "text/plain", []byte(s))
+	}
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return writeMetadataResponse(conn, "200 OK", "application/json", body)
+}
+
+func writeMetadataResponse(conn net.Conn, status, contentType string, body []byte) error {
+	_, err := fmt.Fprintf(conn, "HTTP/1.0 %s\r\nContent-Type: %s\r\nContent-Length: %d\r\nConnection: close\r\n\r\n%s",
+		status, contentType, len(body), body)
+	return err
+}
+
+// lookupMetadata walks the document following the elements of path, ignoring
+// the query string and empty elements.
+func lookupMetadata(root interface{}, path string) (interface{}, bool) {
+	path, _, _ = strings.Cut(path, "?")
+	v := root
+	for _, elem := range strings.Split(path, "/") {
+		if elem == "" {
+			continue
+		}
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if v, ok = m[elem]; !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
@@ -0,0 +1,47 @@
+package libcontainer
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestServeMetadataConn(t *testing.T) {
+	var root interface{}
+	if err := json.Unmarshal([]byte(`{"instance-id": "i-1234", "network": {"mtu": 1500}}`), &root); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		request, status, body string
+	}{
+		{request: "GET /instance-id HTTP/1.1\r\nHost: x\r\n\r\n", status: "200 OK", body: "i-1234"},
+		{request: "GET /network?x=y HTTP/1.0\r\n\r\n", status: "200 OK", body: `{"mtu":1500}`},
+		{request: "GET /network/mtu/ HTTP/1.0\r\n\r\n", status: "200 OK", body: "1500"},
+		{request: "GET /missing HTTP/1.0\r\n\r\n", status: "404 Not Found", body: "not found\n"},
+		{request: "POST / HTTP/1.0\r\n\r\n", status: "405 Method Not Allowed", body: "method not allowed\n"},
+		{request: "GARBAGE\r\n\r\n", status: "400 Bad Request", body: "bad request\n"},
+	} {
+		client, server := net.Pipe()
+		errCh := make(chan error, 1)
+		go func() { errCh <- serveMetadataConn(server, root) }()
+		if _, err := io.WriteString(client, tc.request); err != nil {
+			t.Fatal(err)
+		}
+		resp, err := io.ReadAll(client)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := <-errCh; err != nil {
+			t.Fatal(err)
+		}
+		head, body, _ := strings.Cut(string(resp), "\r\n\r\n")
+		if !strings.HasPrefix(head, "HTTP/1.0 "+tc.status+"\r\n") {
+			t.Errorf("%q: expected status %q, got %q", tc.request, tc.status, head)
+		}
+		if body != tc.body {
+			t.Errorf("%q: expected body %q, got %q", tc.request, tc.body, body)
+		}
+	}
+}
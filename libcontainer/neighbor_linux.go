@@ -0,0 +1,130 @@
+package libcontainer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+)
+
+// gatewayResolveTimeout bounds the time spent learning the MAC address of a
+// gateway to pin.
+const gatewayResolveTimeout = 3 * time.Second
+
+// setupGatewayNeighbors installs permanent neighbor entries for the gateways
+// of n, if PinGateway is set. It must be called in the container network
+// namespace, once the interface is up and has its addresses.
+func setupGatewayNeighbors(ctx context.Context, n *configs.Network) error {
+	if !n.PinGateway {
+		return nil
+	}
+	link, err := netlink.LinkByName(containerInterfaceName(n))
+	if err != nil {
+		return err
+	}
+	var mac net.HardwareAddr
+	if n.GatewayMacAddress != "" {
+		if mac, err = net.ParseMAC(n.GatewayMacAddress); err != nil {
+			return err
+		}
+	}
+	for _, gw := range []string{n.Gateway, n.IPv6Gateway} {
+		if gw == "" {
+			continue
+		}
+		ip := net.ParseIP(gw)
+		family := netlink.FAMILY_V6
+		if ip.To4() != nil {
+			family = netlink.FAMILY_V4
+		}
+		hw := mac
+		if hw == nil {
+			if hw, err = resolveNeighbor(ctx, link, family, ip); err != nil {
+				return err
+			}
+		}
+		neigh := &netlink.Neigh{
+			LinkIndex:    link.Attrs().Index,
+			Family:       family,
+			State:        netlink.NUD_PERMANENT,
+			IP:           ip,
+			HardwareAddr: hw,
+		}
+		if err := netlink.NeighSet(neigh); err != nil {
+			return fmt.Errorf("unable to pin gateway %s: %w", gw, err)
+		}
+	}
+	return nil
+}
+
+// setupNeighbors adds the known neighbors of n to the neighbor table of its
+// interface. It must be called in the container network namespace, once the
+// interface is up and has its addresses.
+func setupNeighbors(n *configs.Network) error {
+	if len(n.Neighbors) == 0 {
+		return nil
+	}
+	link, err := netlink.LinkByName(containerInterfaceName(n))
+	if err != nil {
+		return err
+	}
+	for _, neighbor := range n.Neighbors {
+		ip := net.ParseIP(neighbor.IP)
+		mac, err := net.ParseMAC(neighbor.MacAddress)
+		if ip == nil || err != nil {
+			return fmt.Errorf("invalid neighbor %s (%s)", neighbor.IP, neighbor.MacAddress)
+		}
+		family := netlink.FAMILY_V6
+		if ip.To4() != nil {
+			family = netlink.FAMILY_V4
+		}
+		// A stale entry is used right away, and confirmed by the kernel
+		// with unicast probes instead of a broadcast resolution.
+		neigh := &netlink.Neigh{
+			LinkIndex:    link.Attrs().Index,
+			Family:       family,
+			State:        netlink.NUD_STALE,
+			IP:           ip,
+			HardwareAddr: mac,
+		}
+		if err := netlink.NeighSet(neigh); err != nil {
+			return fmt.Errorf("unable to add neighbor %s: %w", neighbor.IP, err)
+		}
+	}
+	return nil
+}
+
+// resolveNeighbor returns the MAC address of ip, a neighbor reachable
+// through link, triggering its resolution by the kernel if needed.
+func resolveNeighbor(ctx context.Context, link netlink.Link, family int, ip net.IP) (net.HardwareAddr, error) {
+	ctx, cancel := context.WithTimeout(ctx, gatewayResolveTimeout)
+	defer cancel()
+	// Sending a datagram to the discard port makes the kernel resolve the
+	// neighbor, the datagram itself does not matter.
+	if conn, err := net.Dial("udp", net.JoinHostPort(ip.String(), "9")); err == nil {
+		_, _ = conn.Write([]byte{0})
+		conn.Close()
+	}
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		neighs, err := netlink.NeighList(link.Attrs().Index, family)
+		if err != nil {
+			return nil, err
+		}
+		for _, neigh := range neighs {
+			if neigh.IP.Equal(ip) && len(neigh.HardwareAddr) > 0 &&
+				neigh.State&(netlink.NUD_INCOMPLETE|netlink.NUD_FAILED) == 0 {
+				return neigh.HardwareAddr, nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("unable to resolve the MAC address of %s: %w", ip, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package libcontainer
+
+import (
+	"context"
+	"math"
+	"path/filepath"
+	"time"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+// netlinkBucketFilename is the file, in the root directory of the
+// containers, holding the token bucket of the NetlinkRateLimit option.
+const netlinkBucketFilename = "netlink-bucket.json"
+
+// netlinkBucket is the state of a token bucket shared by several runc
+// processes.
+type netlinkBucket struct {
+	// Tokens is the number of tokens in the bucket at Time. It is negative
+	// when tokens have been reserved by waiting processes.
+	Tokens float64   `json:"tokens"`
+	Time   time.Time `json:"time"`
+}
+
+// netlinkRateLimit returns the NetlinkRateLimit option of config, if any.
+func netlinkRateLimit(config *configs.Config) *configs.RateLimit {
+	if config.NetworkOptions == nil {
+		return nil
+	}
+	return config.NetworkOptions.NetlinkRateLimit
+}
+
+// waitNetlinkToken takes a token from the bucket of the containers of root,
+// waiting for it if needed. It returns right away if limit is nil.
+func waitNetlinkToken(ctx context.Context, root string, limit *configs.RateLimit) error {
+	if limit == nil {
+		return nil
+	}
+	wait, err := reserveNetlinkToken(filepath.Join(root, netlinkBucketFilename), limit, time.Now())
+	if err != nil || wait <= 0 {
+		return err
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// reserveNetlinkToken takes a token from the bucket saved in path at the
+// given time, and returns how long to wait before using it. The lock on the
+// bucket is not held while waiting: the token is reserved by letting the
+// bucket go negative, so the next processes wait longer.
+func reserveNetlinkToken(path string, limit *configs.RateLimit, now time.Time) (time.Duration, error) {
+	var wait time.Duration
+	var b netlinkBucket
+	err := updateLockedJSON(path, &b, func() error {
+		if b.Time.IsZero() {
+			b.Tokens = float64(limit.Burst)
+		} else if elapsed := now.Sub(b.Time); elapsed > 0 {
+			b.Tokens = math.Min(float64(limit.Burst), b.Tokens+elapsed.Seconds()*limit.Rate)
+		}
+		b.Time = now
+		b.Tokens--
+		if b.Tokens < 0 {
+			wait = time.Duration(-b.Tokens / limit.Rate * float64(time.Second))
+		}
+		return nil
+	})
+	return wait, err
+}
@@ -0,0 +1,59 @@
+package libcontainer
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+func TestReserveNetlinkToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), netlinkBucketFilename)
+	limit := &configs.RateLimit{Rate: 10, Burst: 2}
+	now := time.Now()
+	for i, expected := range []time.Duration{
+		// The bucket starts full.
+		0, 0,
+		// Then each token is reserved 100ms after the previous one.
+		100 * time.Millisecond, 200 * time.Millisecond,
+	} {
+		wait, err := reserveNetlinkToken(path, limit, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if wait != expected {
+			t.Errorf("token %d: expected to wait %s, got %s", i, expected, wait)
+		}
+	}
+	// After a second, the reservations are paid back and the bucket is full
+	// again, but not more.
+	now = now.Add(time.Second)
+	for i, expected := range []time.Duration{0, 0, 100 * time.Millisecond} {
+		wait, err := reserveNetlinkToken(path, limit, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if wait.Round(time.Millisecond) != expected {
+			t.Errorf("token %d after a second: expected to wait %s, got %s", i, expected, wait)
+		}
+	}
+}
+
+func TestWaitNetlinkToken(t *testing.T) {
+	root := t.TempDir()
+	if err := waitNetlinkToken(context.Background(), root, nil); err != nil {
+		t.Fatal(err)
+	}
+	limit := &configs.RateLimit{Rate: 0.001, Burst: 1}
+	if err := waitNetlinkToken(context.Background(), root, limit); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := waitNetlinkToken(ctx, root, limit); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the wait to be cancelled, got %v", err)
+	}
+}
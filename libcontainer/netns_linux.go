@@ -0,0 +1,181 @@
+package libcontainer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"github.com/opencontainers/runc/libcontainer/capabilities"
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"golang.org/x/sys/unix"
+)
+
+// doInNetNS runs fn on a dedicated OS thread that has joined the network
+// namespace at nsPath. Sockets created and processes started by fn belong to
+// that namespace. Other namespaces (mount, pid, user, ...) are not affected.
+// If nsPath no longer exists, ErrNamespaceGone is returned.
+func doInNetNS(nsPath string, fn func() error) error {
+	ns, err := os.Open(nsPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) || errors.Is(err, unix.ESRCH) {
+			return fmt.Errorf("%w: %w", ErrNamespaceGone, err)
+		}
+		return err
+	}
+	defer ns.Close()
+
+	errCh := make(chan error, 1)
+	go func() {
+		// The thread is never unlocked, so once this goroutine returns the
+		// runtime terminates the thread instead of reusing it with a
+		// modified network namespace.
+		runtime.LockOSThread()
+		if err := unix.Setns(int(ns.Fd()), unix.CLONE_NEWNET); err != nil {
+			errCh <- &os.PathError{Op: "setns", Path: nsPath, Err: err}
+			return
+		}
+		errCh <- fn()
+	}()
+	return <-errCh
+}
+
+// doInNetNSWithCaps is like doInNetNS, but fn runs with only the capabilities
+// caps, so that a bug in code handling untrusted network settings can not use
+// the other privileges of runc, such as CAP_SYS_ADMIN. Capabilities are per
+// thread on Linux: they are dropped on the thread which joined the namespace,
+// after joining it as this requires CAP_SYS_ADMIN, and the thread is
+// terminated once fn returns. Processes started by fn inherit them, but
+// goroutines started by fn run on other threads with all the capabilities of
+// runc, so fn must not start any.
+func doInNetNSWithCaps(nsPath string, caps []string, fn func() error) error {
+	return doInNetNS(nsPath, func() error {
+		if err := dropCapabilities(caps); err != nil {
+			return err
+		}
+		return fn()
+	})
+}
+
+// doWithCaps runs fn on a dedicated OS thread with only the capabilities
+// caps, in the network namespace of the calling thread, as doInNetNSWithCaps
+// does in another one. The same restriction applies to the goroutines started
+// by fn, except for those joining another namespace, such as doInNetNS, as
+// this requires CAP_SYS_ADMIN anyway.
+func doWithCaps(caps []string, fn func() error) error {
+	return doInNetNSWithCaps("/proc/thread-self/ns/net", caps, fn)
+}
+
+// dropCapabilities drops all the capabilities of the current thread but caps.
+func dropCapabilities(caps []string) error {
+	c, err := capabilities.New(&configs.Capabilities{
+		Bounding:  caps,
+		Effective: caps,
+		Permitted: caps,
+	})
+	if err != nil {
+		return err
+	}
+	if err := c.ApplyCaps(); err != nil {
+		return fmt.Errorf("unable to drop capabilities: %w", err)
+	}
+	return nil
+}
+
+// doInMountNS runs fn on a dedicated OS thread with a private copy of the
+// mount namespace, so that mounts made by fn, or by the processes it starts,
+// are not seen by the host, and mounts made by the host while fn runs, such
+// as those preparing the container rootfs, are not seen by fn. Goroutines
+// started by fn run on other threads, in the host mount namespace.
+func doInMountNS(fn func() error) error {
+	errCh := make(chan error, 1)
+	go func() {
+		// As in doInNetNS, the thread is terminated once this goroutine
+		// returns.
+		runtime.LockOSThread()
+		// The filesystem information is shared by all the threads of the
+		// process, and must be unshared too.
+		if err := unix.Unshare(unix.CLONE_FS | unix.CLONE_NEWNS); err != nil {
+			errCh <- os.NewSyscallError("unshare", err)
+			return
+		}
+		if err := unix.Mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, ""); err != nil {
+			errCh <- &os.PathError{Op: "mount", Path: "/", Err: err}
+			return
+		}
+		errCh <- fn()
+	}()
+	return <-errCh
+}
+
+// networkCapabilities returns the capabilities needed to set up the interface
+// of n inside the container.
+func networkCapabilities(n *configs.Network) []string {
+	caps := []string{"CAP_NET_ADMIN"}
+	if n.AutoIPv4LinkLocal {
+		// Address conflicts are detected with a packet socket.
+		caps = append(caps, "CAP_NET_RAW")
+	}
+	return caps
+}
+
+// hostNetworkCapabilities returns the capabilities needed to create the
+// interface of n and set up its host side. The container network namespace
+// is opened through the /proc directory of the container init process, which
+// requires CAP_SYS_PTRACE unless it runs as the same dumpable user as runc.
+func hostNetworkCapabilities(n *configs.Network) []string {
+	caps := []string{"CAP_NET_ADMIN", "CAP_SYS_PTRACE"}
+	if n.Type == "plugin" {
+		// The program joins the container network namespace itself.
+		caps = append(caps, "CAP_SYS_ADMIN")
+	}
+	return caps
+}
+
+// netNSPath returns the path to the network namespace of a running container.
+func (c *Container) netNSPath() (string, error) {
+	status, err := c.currentStatus()
+	if err != nil {
+		return "", err
+	}
+	if status == Stopped {
+		return "", ErrNotRunning
+	}
+	state, err := c.currentState()
+	if err != nil {
+		return "", err
+	}
+	path, ok := state.NamespacePaths[configs.NEWNET]
+	if !ok || path == "" {
+		return "", errors.New("unable to find the container network namespace")
+	}
+	return path, nil
+}
+
+// namespaceError wraps err with ErrNamespaceGone if the container init
+// process, which keeps the network namespace alive, has exited, so callers can
+// tell a failure caused by the container going away from other failures.
+func (c *Container) namespaceError(err error) error {
+	if err != nil && !errors.Is(err, ErrNamespaceGone) && !c.hasInit() {
+		return fmt.Errorf("%w: %w", ErrNamespaceGone, err)
+	}
+	return err
+}
+
+// StartInNetNS starts cmd as a host process that joins only the network
+// namespace of the container. The process keeps the host mount, pid, user and
+// other namespaces, so host binaries can be used to inspect the container
+// networking. The caller is responsible for waiting on cmd.
+func (c *Container) StartInNetNS(cmd *exec.Cmd) error {
+	c.m.Lock()
+	defer c.m.Unlock()
+	path, err := c.netNSPath()
+	if err != nil {
+		return err
+	}
+	if err := doInNetNS(path, cmd.Start); err != nil {
+		return fmt.Errorf("unable to start process in network namespace %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package libcontainer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/opencontainers/runc/internal/testutil/nettest"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+func TestDoInNetNS(t *testing.T) {
+	path := nettest.NewNS(t).Path
+	var links []netlink.Link
+	err := doInNetNS(path, func() (err error) {
+		links, err = netlink.LinkList()
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(links) != 1 || links[0].Attrs().Name != "lo" {
+		t.Fatalf("expected only the loopback device in a new namespace, got %d links", len(links))
+	}
+
+	if err := doInNetNS("/proc/self/ns/nonexistent", func() error { return nil }); err == nil {
+		t.Fatal("expected an error for a nonexistent namespace path")
+	}
+}
+
+func TestDoInNetNSWithCaps(t *testing.T) {
+	path := nettest.NewNS(t).Path
+	var data unix.CapUserData
+	err := doInNetNSWithCaps(path, []string{"CAP_NET_ADMIN"}, func() error {
+		if err := unix.Capget(&unix.CapUserHeader{Version: unix.LINUX_CAPABILITY_VERSION_3}, &data); err != nil {
+			return err
+		}
+		// The interfaces of the namespace can still be managed.
+		return netlink.LinkSetDown(&netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: "lo"}})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Effective != 1<<unix.CAP_NET_ADMIN || data.Permitted != 1<<unix.CAP_NET_ADMIN {
+		t.Errorf("expected only CAP_NET_ADMIN, got effective %#x permitted %#x", data.Effective, data.Permitted)
+	}
+
+	// The capabilities of the other threads are kept.
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	if err := unix.Capget(&unix.CapUserHeader{Version: unix.LINUX_CAPABILITY_VERSION_3}, &data); err != nil {
+		t.Fatal(err)
+	}
+	if data.Effective&(1<<unix.CAP_SYS_ADMIN) == 0 {
+		t.Error("expected CAP_SYS_ADMIN to be kept outside of the namespace thread")
+	}
+}
+
+func TestDoWithCaps(t *testing.T) {
+	ns := nettest.NewNS(t)
+	ns.AddVeth(t, "veth0", "peer0", nil)
+	var data unix.CapUserData
+	ns.Do(t, func() error {
+		return doWithCaps([]string{"CAP_NET_ADMIN"}, func() error {
+			if err := unix.Capget(&unix.CapUserHeader{Version: unix.LINUX_CAPABILITY_VERSION_3}, &data); err != nil {
+				return err
+			}
+			// fn runs in the network namespace of the caller.
+			_, err := netlink.LinkByName("veth0")
+			return err
+		})
+	})
+	if data.Effective != 1<<unix.CAP_NET_ADMIN || data.Permitted != 1<<unix.CAP_NET_ADMIN {
+		t.Errorf("expected only CAP_NET_ADMIN, got effective %#x permitted %#x", data.Effective, data.Permitted)
+	}
+}
+
+func TestDoInMountNS(t *testing.T) {
+	dir := t.TempDir()
+	err := doInMountNS(func() error {
+		if err := unix.Mount("tmpfs", dir, "tmpfs", 0, ""); err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dir, "file"), nil, 0o600)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Neither the mount nor the file written to it are seen by the host.
+	if _, err := os.Stat(filepath.Join(dir, "file")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected the mount to stay private, got %v", err)
+	}
+}
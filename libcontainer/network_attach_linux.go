@@ -0,0 +1,414 @@
+package libcontainer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	securejoin "github.com/cyphar/filepath-securejoin"
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/opencontainers/runc/libcontainer/configs/validate"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// AttachNetwork adds the network n to the running container id, whose state
+// is found in root. Unlike the Container methods, it can be used by any
+// process, not only the one which created the container: the changes to the
+// networks of a container are serialized with a lock file in its state
+// directory, and the state is reloaded once the lock is held. If the container
+// exits while the network is attached, the error wraps ErrNamespaceGone.
+func AttachNetwork(ctx context.Context, root, id string, n *configs.Network) error {
+	return withNetworkLock(root, id, func(c *Container) error {
+		return c.attachNetwork(ctx, n, nil)
+	})
+}
+
+// DetachNetwork removes the network whose interface is named name from the
+// running container id, whose state is found in root, along with the routes
+// through its interface, its skipped settings and the RDMA limits added for
+// it. See AttachNetwork.
+func DetachNetwork(root, id, name string) error {
+	return withNetworkLock(root, id, func(c *Container) error {
+		return c.detachNetwork(name)
+	})
+}
+
+// ReconcileNetworks attaches the optional networks of the running container
+// id, whose state is found in root, which were skipped at creation as their
+// device was unavailable, and have become available since then, for example
+// by being hot-plugged. It returns the names of the interfaces attached. The
+// networks whose device is still unavailable are kept for a later call. See
+// AttachNetwork.
+func ReconcileNetworks(ctx context.Context, root, id string) ([]string, error) {
+	var attached []string
+	err := withNetworkLock(root, id, func(c *Container) error {
+		var err error
+		attached, err = c.reconcileNetworks(ctx)
+		return err
+	})
+	return attached, err
+}
+
+// withNetworkLock loads the container id and runs fn with the network lock of
+// the container held.
+func withNetworkLock(root, id string, fn func(*Container) error) error {
+	if err := validateID(id); err != nil {
+		return err
+	}
+	stateDir, err := securejoin.SecureJoin(root, id)
+	if err != nil {
+		return err
+	}
+	lockPath := filepath.Join(stateDir, networkLockFilename)
+	lock, err := os.OpenFile(lockPath, os.O_RDWR|os.O_CREATE|unix.O_CLOEXEC, 0o600)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return ErrNotExist
+		}
+		return err
+	}
+	defer lock.Close()
+	if err := unix.Flock(int(lock.Fd()), unix.LOCK_EX); err != nil {
+		return &os.PathError{Op: "flock", Path: lockPath, Err: err}
+	}
+	c, err := Load(root, id)
+	if err != nil {
+		return err
+	}
+	c.m.Lock()
+	defer c.m.Unlock()
+	return fn(c)
+}
+
+// attachNetwork creates the network n and sets it up in the container, along
+// with routes, which go through its interface, undoing the changes on
+// failure. The container state is updated to include the new network and
+// routes.
+func (c *Container) attachNetwork(ctx context.Context, n *configs.Network, routes []*configs.Route) (retErr error) {
+	nsPath, err := c.netNSPath()
+	if err != nil {
+		return err
+	}
+	// Keep the namespace open, so it can still be entered if the path goes
+	// stale while the host side of the network is set up.
+	ns, err := os.Open(nsPath)
+	if err != nil {
+		return c.namespaceError(err)
+	}
+	defer ns.Close()
+	nsPath = "/proc/self/fd/" + strconv.Itoa(int(ns.Fd()))
+	n = assignInterfaceNames(c.id, []*configs.Network{n})[0]
+	name := containerInterfaceName(n)
+	if c.hasNetwork(name) {
+		return fmt.Errorf("network %q is already attached", name)
+	}
+	config := *c.config
+	config.Networks = append(config.Networks[:len(config.Networks):len(config.Networks)], n)
+	config.Routes = append(config.Routes[:len(config.Routes):len(config.Routes)], routes...)
+	if err := validate.Validate(&config); err != nil {
+		return fmt.Errorf("invalid network %q: %w", name, err)
+	}
+	strategy, err := getStrategy(n.Type)
+	if err != nil {
+		return err
+	}
+	if _, ok := strategy.(*macvtap); ok {
+		// Its device node could not be created in the container.
+		return errors.New("macvtap networks can not be attached to a running container")
+	}
+
+	nw := &network{Network: *n, stateDir: c.stateDir}
+	applyNetworkProfile(&nw.Network)
+	if err := waitNetlinkToken(ctx, filepath.Dir(c.stateDir), netlinkRateLimit(c.config)); err != nil {
+		return err
+	}
+	// The interface is created and set up with the capabilities it needs
+	// only, in the host and then inside the container.
+	created := false
+	defer func() {
+		if retErr != nil && created {
+			_ = removeNetwork(nsPath, strategy, nw)
+		}
+	}()
+	var skipped []SkippedNetworkSetting
+	err = doWithCaps(hostNetworkCapabilities(n), func() (err error) {
+		if err := strategy.create(ctx, nw, c.initProcess.pid()); err != nil {
+			return c.namespaceError(err)
+		}
+		created = true
+		skipped, err = setupHostInterface(ctx, &nw.Network)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	err = doInNetNSWithCaps(nsPath, networkCapabilities(n), func() error {
+		s, err := initializeNetwork(ctx, strategy, nw)
+		skipped = append(skipped, s...)
+		if err != nil || n.Standby {
+			// The routes through a standby network are added once it
+			// is activated.
+			return err
+		}
+		return addRoutes(routes, netlink.RouteAdd)
+	})
+	if err != nil {
+		return c.namespaceError(err)
+	}
+	if err := prepareNetworkStats(c.config.NetworkOptions, nsPath, []*configs.Network{&nw.Network}); err != nil {
+		return c.namespaceError(err)
+	}
+	warnNetworkMTU(nsPath, []*configs.Network{&nw.Network})
+	if cg := c.config.Cgroups; cg != nil && cg.Resources != nil && addNetworkRdmaLimits(cg.Resources, []*network{nw}) != nil {
+		if err := c.cgroupManager.Set(cg.Resources); err != nil {
+			delete(cg.Resources.Rdma, nw.rdmaDevice)
+			return fmt.Errorf("unable to set rdma limits: %w", err)
+		}
+		if c.networkRdma == nil {
+			c.networkRdma = make(map[string]string)
+		}
+		c.networkRdma[name] = nw.rdmaDevice
+	}
+
+	c.config.Networks = config.Networks
+	c.config.Routes = config.Routes
+	c.skippedNetwork = append(c.skippedNetwork, skipped...)
+	state, err := c.currentState()
+	if err != nil {
+		return err
+	}
+	return c.saveState(state)
+}
+
+// reconcileNetworks attaches the pending networks of the container whose
+// device is available, along with the pending routes through their
+// interface, and returns the names of their interfaces. The other errors are
+// returned once all the networks have been tried.
+func (c *Container) reconcileNetworks(ctx context.Context) ([]string, error) {
+	var (
+		attached []string
+		pending  []*configs.Network
+		errs     []error
+		changed  bool
+	)
+	for _, n := range c.pendingNetworks {
+		name := containerInterfaceName(n)
+		if c.hasNetwork(name) {
+			// It was attached by a reconciliation which failed to
+			// save the pending networks afterwards.
+			changed = true
+			continue
+		}
+		var routes []*configs.Route
+		for _, r := range c.pendingRoutes {
+			if r.InterfaceName == name {
+				routes = append(routes, r)
+			}
+		}
+		if err := c.attachNetwork(ctx, n, routes); err != nil {
+			if ctx.Err() != nil || errors.Is(err, ErrNamespaceGone) {
+				return attached, err
+			}
+			pending = append(pending, n)
+			if !unavailableDevice(err) {
+				errs = append(errs, fmt.Errorf("unable to attach network %q: %w", name, err))
+			}
+			continue
+		}
+		attached = append(attached, name)
+		changed = true
+		// The device is no longer reported as skipped.
+		skipped := c.skippedNetwork[:0]
+		for _, s := range c.skippedNetwork {
+			if s.Interface != name || s.Setting != "device" {
+				skipped = append(skipped, s)
+			}
+		}
+		c.skippedNetwork = skipped
+	}
+	if changed {
+		c.pendingNetworks = pending
+		var routes []*configs.Route
+		for _, r := range c.pendingRoutes {
+			if !c.hasNetwork(r.InterfaceName) {
+				routes = append(routes, r)
+			}
+		}
+		c.pendingRoutes = routes
+		state, err := c.currentState()
+		if err != nil {
+			return attached, err
+		}
+		if err := c.saveState(state); err != nil {
+			return attached, err
+		}
+	}
+	return attached, errors.Join(errs...)
+}
+
+// hasNetwork reports whether the container has a network whose interface is
+// named name.
+func (c *Container) hasNetwork(name string) bool {
+	for _, n := range c.config.Networks {
+		if containerInterfaceName(n) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// masterNetwork returns the name of the interface of the bond or team network
+// of the container the interface name is enslaved to, or an empty string.
+func (c *Container) masterNetwork(name string) string {
+	for _, n := range c.config.Networks {
+		var slaves []string
+		switch {
+		case n.Type == "bond" && n.Bond != nil:
+			slaves = n.Bond.Slaves
+		case n.Type == "team" && n.Team != nil:
+			slaves = n.Team.Ports
+		}
+		for _, slave := range slaves {
+			if slave == name {
+				return containerInterfaceName(n)
+			}
+		}
+	}
+	return ""
+}
+
+// detachNetwork removes the network whose interface is named name from the
+// container, and updates the container state.
+func (c *Container) detachNetwork(name string) error {
+	nsPath, err := c.netNSPath()
+	if err != nil {
+		return err
+	}
+	i := -1
+	for j, n := range c.config.Networks {
+		if containerInterfaceName(n) == name {
+			i = j
+			break
+		}
+	}
+	if i == -1 {
+		return fmt.Errorf("network %q is not attached", name)
+	}
+	n := c.config.Networks[i]
+	// The master would be left without the interface, and the interface
+	// would be deleted or moved back while enslaved.
+	if master := c.masterNetwork(name); master != "" {
+		return fmt.Errorf("network %q is enslaved to network %q, which must be detached first", name, master)
+	}
+	strategy, err := getStrategy(n.Type)
+	if err != nil {
+		return err
+	}
+	ns, err := os.Open(nsPath)
+	if err != nil {
+		return c.namespaceError(err)
+	}
+	defer ns.Close()
+	nsPath = "/proc/self/fd/" + strconv.Itoa(int(ns.Fd()))
+	if err := removeNetwork(nsPath, strategy, &network{Network: *n, stateDir: c.stateDir}); err != nil {
+		return c.namespaceError(err)
+	}
+
+	// What was set up along with the network goes away with it, so that it
+	// can be attached again.
+	networks := make([]*configs.Network, 0, len(c.config.Networks)-1)
+	networks = append(networks, c.config.Networks[:i]...)
+	c.config.Networks = append(networks, c.config.Networks[i+1:]...)
+	var routes []*configs.Route
+	for _, r := range c.config.Routes {
+		if r.InterfaceName != name {
+			routes = append(routes, r)
+		}
+	}
+	c.config.Routes = routes
+	var skipped []SkippedNetworkSetting
+	for _, s := range c.skippedNetwork {
+		if s.Interface != name {
+			skipped = append(skipped, s)
+		}
+	}
+	c.skippedNetwork = skipped
+	var rdmaErr error
+	if device, ok := c.networkRdma[name]; ok {
+		delete(c.networkRdma, name)
+		if cg := c.config.Cgroups; cg != nil && cg.Resources != nil {
+			if err := removeNetworkRdmaLimits(c.cgroupManager, cg.Resources, device); err != nil {
+				rdmaErr = fmt.Errorf("unable to lift the rdma limits of %s: %w", device, err)
+			}
+		}
+	}
+	state, err := c.currentState()
+	if err != nil {
+		return err
+	}
+	if err := c.saveState(state); err != nil {
+		return err
+	}
+	return rdmaErr
+}
+
+// removeNetwork undoes the creation of the network n of a running container:
+// its host side settings are removed, its interface is removed from the
+// container network namespace at nsPath, and its host resources are
+// released. All the steps are run even if one fails, so a failure does not
+// strand the resources of the others.
+func removeNetwork(nsPath string, strategy networkStrategy, n *network) error {
+	errs := []error{strategy.detach(&n.Network), teardownHostInterface(&n.Network)}
+	// The loopback interface can not leave its namespace.
+	if n.Type != "loopback" {
+		errs = append(errs, removeInterface(nsPath, &n.Network))
+	}
+	if r, ok := strategy.(networkReleaser); ok {
+		n.detached = true
+		errs = append(errs, r.release(n))
+	}
+	return errors.Join(errs...)
+}
+
+// removeInterface removes the interface of n from the network namespace at
+// nsPath the way the kernel does when the namespace is destroyed: virtual
+// interfaces are deleted, and the other ones, such as SR-IOV virtual
+// functions, are moved back to the network namespace of runc, once flushed
+// if n.FlushOnDetach is set. They keep their name unless it is taken there,
+// in which case the kernel names them after the "dev%d" pattern, as it does
+// for the namespaces destroyed.
+func removeInterface(nsPath string, n *configs.Network) error {
+	host, err := os.Open("/proc/self/ns/net")
+	if err != nil {
+		return err
+	}
+	defer host.Close()
+	name := containerInterfaceName(n)
+	return doInNetNS(nsPath, func() error {
+		link, err := netlink.LinkByName(name)
+		if err != nil {
+			return fmt.Errorf("unable to find %s: %w", name, err)
+		}
+		// Only the virtual interfaces have a kind.
+		if link.Type() != "device" {
+			if err := netlink.LinkDel(link); err != nil {
+				return fmt.Errorf("unable to delete %s: %w", name, err)
+			}
+			return nil
+		}
+		if n.FlushOnDetach {
+			if err := flushInterface(name); err != nil {
+				return fmt.Errorf("unable to flush %s: %w", name, err)
+			}
+		}
+		err = moveLink(link, int(host.Fd()), &linkMove{})
+		if errors.Is(err, unix.EEXIST) {
+			err = moveLink(link, int(host.Fd()), &linkMove{name: "dev%d"})
+		}
+		return err
+	})
+}
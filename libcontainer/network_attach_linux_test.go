@@ -0,0 +1,320 @@
+package libcontainer
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/runc/internal/testutil/nettest"
+	"github.com/opencontainers/runc/libcontainer/cgroups"
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/opencontainers/runc/libcontainer/system"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+func TestAttachDetachNetwork(t *testing.T) {
+	ctr := nettest.NewNS(t)
+	ctr.Do(t, func() error {
+		return netlink.LinkSetDown(&netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: "lo"}})
+	})
+	// A process in the namespace stands for the container init process.
+	cmd := exec.Command("sleep", "60")
+	ctr.Do(t, cmd.Start)
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+	pid := cmd.Process.Pid
+	stat, err := system.Stat(pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Container{
+		id:       "myid",
+		stateDir: t.TempDir(),
+		config: &configs.Config{
+			Rootfs:     "/var",
+			Namespaces: []configs.Namespace{{Type: configs.NEWNET}},
+		},
+		initProcess:          &mockProcess{_pid: pid, started: stat.StartTime},
+		initProcessStartTime: stat.StartTime,
+		cgroupManager:        &mockCgroupManager{},
+	}
+	c.state = &runningState{c: c}
+
+	lo := &configs.Network{Type: "loopback"}
+	if err := c.attachNetwork(context.Background(), lo, nil); err != nil {
+		t.Fatal(err)
+	}
+	if ctr.Link(t, "lo").Attrs().Flags&net.FlagUp == 0 {
+		t.Error("expected lo to be up")
+	}
+	if err := c.attachNetwork(context.Background(), lo, nil); err == nil {
+		t.Error("expected error attaching the same network twice")
+	}
+	state, err := loadState(c.stateDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Config.Networks) != 1 {
+		t.Errorf("expected the network in the saved state, got %+v", state.Config.Networks)
+	}
+
+	c.skippedNetwork = []SkippedNetworkSetting{{Interface: "lo", Setting: "net.ipv4.conf.lo.unsupported"}}
+	networks, err := c.InspectNetworks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(networks) != 1 || networks[0].Status == nil || !networks[0].Status.Up || len(networks[0].Skipped) != 1 {
+		t.Errorf("unexpected inspection: %+v", networks)
+	}
+
+	if err := c.detachNetwork("eth0"); err == nil {
+		t.Error("expected error detaching a network which is not attached")
+	}
+	c.config.Networks = append(c.config.Networks, &configs.Network{Type: "dummy", Name: "eth0"}, &configs.Network{
+		Type: "bond",
+		Name: "bond0",
+		Bond: &configs.BondSettings{Mode: "active-backup", Slaves: []string{"eth0"}},
+	})
+	if err := c.detachNetwork("eth0"); err == nil || !strings.Contains(err.Error(), "bond0") {
+		t.Errorf("expected error detaching an enslaved network, got %v", err)
+	}
+	c.config.Networks = c.config.Networks[:1]
+	if err := c.detachNetwork("lo"); err != nil {
+		t.Fatal(err)
+	}
+	if state, err = loadState(c.stateDir); err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Config.Networks) != 0 {
+		t.Errorf("expected no network in the saved state, got %+v", state.Config.Networks)
+	}
+	if len(state.SkippedNetworkSettings) != 0 {
+		t.Errorf("expected the skipped settings to be removed from the saved state, got %+v", state.SkippedNetworkSettings)
+	}
+}
+
+func TestDetachAttachNetwork(t *testing.T) {
+	host := nettest.NewNS(t)
+	ctr := nettest.NewNS(t)
+	host.AddVeth(t, "underlay0", "eth0", nettest.NewNS(t))
+	c := runningNetNSContainer(t, "myid", ctr)
+	// We're using a fake cgroupfs.
+	cgroups.TestMode = true
+	rdma := t.TempDir()
+	if err := os.WriteFile(filepath.Join(rdma, "rdma.max"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c.cgroupManager = &mockCgroupManager{paths: map[string]string{"rdma": rdma}}
+	objects := uint32(1000)
+	c.config.Cgroups = &configs.Cgroup{Resources: &configs.Resources{
+		Rdma: map[string]configs.LinuxRdma{"mlx5_0": {HcaObjects: &objects}},
+	}}
+	n := &configs.Network{
+		Type:    "vxlan",
+		Name:    "vxlan0",
+		Parent:  "underlay0",
+		Address: "10.0.0.2/24",
+		VXLAN:   &configs.VXLANSettings{VNI: 42, Remote: "192.0.2.1"},
+	}
+	routes := []*configs.Route{
+		{Destination: "10.1.0.0/16", Source: "10.0.0.2", Gateway: "10.0.0.1", InterfaceName: "vxlan0"},
+	}
+	host.Do(t, func() error { return c.attachNetwork(context.Background(), n, routes) })
+	// Stands for the settings recorded when the network was set up.
+	c.skippedNetwork = []SkippedNetworkSetting{{Interface: "vxlan0", Setting: "quota", Reason: "not supported by the kernel"}}
+	c.networkRdma = map[string]string{"vxlan0": "mlx5_0"}
+
+	host.Do(t, func() error { return c.detachNetwork("vxlan0") })
+	state, err := loadState(c.stateDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Config.Networks) != 0 || len(state.Config.Routes) != 0 || len(state.SkippedNetworkSettings) != 0 {
+		t.Errorf("expected the network to be removed from the saved state with its routes and skipped settings, got %+v, %+v and %+v",
+			state.Config.Networks, state.Config.Routes, state.SkippedNetworkSettings)
+	}
+	if len(state.NetworkRdmaDevices) != 0 || len(state.Config.Cgroups.Resources.Rdma) != 0 {
+		t.Errorf("expected the rdma limits to be removed from the saved state, got %v and %+v",
+			state.NetworkRdmaDevices, state.Config.Cgroups.Resources.Rdma)
+	}
+	if data, err := os.ReadFile(filepath.Join(rdma, "rdma.max")); err != nil || string(data) != "mlx5_0 hca_handle=max hca_object=max" {
+		t.Errorf("expected the rdma limits to be lifted, got %q (%v)", data, err)
+	}
+
+	// The network can be attached again, with its routes.
+	host.Do(t, func() error { return c.attachNetwork(context.Background(), n, routes) })
+	if state, err = loadState(c.stateDir); err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Config.Networks) != 1 || len(state.Config.Routes) != 1 {
+		t.Errorf("expected the network and its route in the saved state, got %+v and %+v", state.Config.Networks, state.Config.Routes)
+	}
+}
+
+func TestAttachNetworkNotExist(t *testing.T) {
+	err := AttachNetwork(context.Background(), t.TempDir(), "missing", &configs.Network{Type: "loopback"})
+	if !errors.Is(err, ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got %v", err)
+	}
+}
+
+// exitingStrategy stands for a strategy failing because the container init
+// exits while the network is being created.
+type exitingStrategy struct {
+	loopback
+	cmd *exec.Cmd
+}
+
+func (s *exitingStrategy) create(ctx context.Context, n *network, nspid int) error {
+	_ = s.cmd.Process.Kill()
+	_ = s.cmd.Wait()
+	return unix.ESRCH
+}
+
+func TestAttachNetworkNamespaceGone(t *testing.T) {
+	ctr := nettest.NewNS(t)
+	cmd := exec.Command("sleep", "60")
+	ctr.Do(t, cmd.Start)
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+	stat, err := system.Stat(cmd.Process.Pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Container{
+		id:       "myid",
+		stateDir: t.TempDir(),
+		config: &configs.Config{
+			Rootfs:     "/var",
+			Namespaces: []configs.Namespace{{Type: configs.NEWNET}},
+		},
+		initProcess:          &mockProcess{_pid: cmd.Process.Pid, started: stat.StartTime},
+		initProcessStartTime: stat.StartTime,
+		cgroupManager:        &mockCgroupManager{},
+	}
+	c.state = &runningState{c: c}
+	strategies["exiting"] = &exitingStrategy{cmd: cmd}
+	t.Cleanup(func() { delete(strategies, "exiting") })
+
+	err = c.attachNetwork(context.Background(), &configs.Network{Type: "exiting", Name: "eth0"}, nil)
+	if !errors.Is(err, ErrNamespaceGone) || !errors.Is(err, unix.ESRCH) {
+		t.Fatalf("expected ErrNamespaceGone wrapping the create error, got %v", err)
+	}
+	if err := doInNetNS("/proc/self/fd/-1", func() error { return nil }); !errors.Is(err, ErrNamespaceGone) {
+		t.Errorf("expected ErrNamespaceGone for a missing namespace path, got %v", err)
+	}
+}
+
+func TestReconcileNetworks(t *testing.T) {
+	host := nettest.NewNS(t)
+	ctr := nettest.NewNS(t)
+	cmd := exec.Command("sleep", "60")
+	ctr.Do(t, cmd.Start)
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+	pid := cmd.Process.Pid
+	stat, err := system.Stat(pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := &configs.Network{
+		Type:     "vxlan",
+		Name:     "vxlan0",
+		Parent:   "underlay0",
+		Optional: true,
+		Address:  "10.0.0.2/24",
+		VXLAN:    &configs.VXLANSettings{VNI: 42, Remote: "192.0.2.1"},
+	}
+	route := &configs.Route{Destination: "10.1.0.0/16", Source: "10.0.0.2", Gateway: "10.0.0.1", InterfaceName: "vxlan0"}
+	c := &Container{
+		id:       "myid",
+		stateDir: t.TempDir(),
+		config: &configs.Config{
+			Rootfs:     "/var",
+			Namespaces: []configs.Namespace{{Type: configs.NEWNET}},
+		},
+		initProcess:          &mockProcess{_pid: pid, started: stat.StartTime},
+		initProcessStartTime: stat.StartTime,
+		cgroupManager:        &mockCgroupManager{},
+		skippedNetwork:       []SkippedNetworkSetting{{Interface: "vxlan0", Setting: "device", Reason: "missing"}},
+		pendingNetworks:      []*configs.Network{n},
+		pendingRoutes:        []*configs.Route{route},
+	}
+	c.state = &runningState{c: c}
+	reconcile := func() []string {
+		var attached []string
+		host.Do(t, func() error {
+			var err error
+			attached, err = c.reconcileNetworks(context.Background())
+			return err
+		})
+		return attached
+	}
+
+	// The parent device is still missing.
+	if attached := reconcile(); len(attached) != 0 || len(c.pendingNetworks) != 1 {
+		t.Fatalf("expected the network to be pending, got %v attached", attached)
+	}
+
+	peer := nettest.NewNS(t)
+	host.AddVeth(t, "underlay0", "eth0", peer)
+	if attached := reconcile(); len(attached) != 1 || attached[0] != "vxlan0" {
+		t.Fatalf("expected vxlan0 to be attached, got %v", attached)
+	}
+	if _, ok := ctr.Link(t, "vxlan0").(*netlink.Vxlan); !ok {
+		t.Error("expected a vxlan interface in the container")
+	}
+	state, err := loadState(c.stateDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.PendingNetworks) != 0 || len(state.SkippedNetworkSettings) != 0 || len(state.Config.Networks) != 1 {
+		t.Errorf("expected the network to be attached in the state, got %d pending, %d skipped and %d networks",
+			len(state.PendingNetworks), len(state.SkippedNetworkSettings), len(state.Config.Networks))
+	}
+	if len(state.PendingRoutes) != 0 || len(state.Config.Routes) != 1 {
+		t.Errorf("expected the route to be added in the state, got %d pending and %d routes", len(state.PendingRoutes), len(state.Config.Routes))
+	}
+	var routes []netlink.Route
+	ctr.Do(t, func() (err error) {
+		_, dst, _ := net.ParseCIDR(route.Destination)
+		routes, err = netlink.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{Dst: dst}, netlink.RT_FILTER_DST)
+		return err
+	})
+	if len(routes) != 1 {
+		t.Errorf("expected the route through vxlan0 in the container, got %+v", routes)
+	}
+	if attached := reconcile(); len(attached) != 0 {
+		t.Errorf("expected nothing left to attach, got %v", attached)
+	}
+}
+
+func TestRemoveInterface(t *testing.T) {
+	ctr := nettest.NewNS(t)
+	ctr.AddVeth(t, "eth1", "peer1", nil)
+	nsPath := "/proc/self/fd/" + strconv.Itoa(ctr.Fd(t))
+
+	// Virtual interfaces are deleted along with their peer.
+	if err := removeInterface(nsPath, &configs.Network{Type: "veth", Name: "eth1", FlushOnDetach: true}); err != nil {
+		t.Fatal(err)
+	}
+	if ctr.Link(t, "eth1") != nil || ctr.Link(t, "peer1") != nil {
+		t.Error("expected the veth pair to be deleted")
+	}
+	if err := removeInterface(nsPath, &configs.Network{Type: "veth", Name: "eth1"}); err == nil {
+		t.Error("expected error removing a missing interface")
+	}
+}
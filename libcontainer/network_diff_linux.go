@@ -0,0 +1,134 @@
+package libcontainer
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// NetworkChange is a difference between two inspections of the networks of a
+// container, taken before and after an update of its network settings.
+type NetworkChange struct {
+	// Interface is the name of the interface inside the container.
+	Interface string `json:"interface"`
+	// Field is the name of the setting which changed, or "network" if the
+	// network was added or removed, in which case Before or After is
+	// empty.
+	Field string `json:"field"`
+	// Before and After are the values of the setting.
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+func (c NetworkChange) String() string {
+	return fmt.Sprintf("%s: %s: %s -> %s", c.Interface, c.Field, orNone(c.Before), orNone(c.After))
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
+
+// State returns the state of the interface of the network, "up", "down",
+// "standby" or the inspection error, or an empty string if the container is
+// not running.
+func (n *NetworkInspection) State() string {
+	s := n.Status
+	switch {
+	case s == nil:
+		return ""
+	case s.Error != "":
+		return "error: " + s.Error
+	case s.Up:
+		return "up"
+	case n.Network.Standby:
+		return "standby"
+	}
+	return "down"
+}
+
+// DiffNetworks returns the changes of the networks of a container between
+// the inspections before and after, as returned by InspectNetworks. The
+// networks are matched by the name of their interface, and the changes are
+// sorted by interface.
+func DiffNetworks(before, after []NetworkInspection) []NetworkChange {
+	old := make(map[string]*NetworkInspection, len(before))
+	for i := range before {
+		old[containerInterfaceName(before[i].Network)] = &before[i]
+	}
+	var changes []NetworkChange
+	for i := range after {
+		n := &after[i]
+		name := containerInterfaceName(n.Network)
+		o, ok := old[name]
+		if !ok {
+			changes = append(changes, NetworkChange{Interface: name, Field: "network", After: n.Network.Type})
+			continue
+		}
+		delete(old, name)
+		for _, f := range networkDiffFields {
+			if b, a := f.value(o), f.value(n); a != b {
+				changes = append(changes, NetworkChange{Interface: name, Field: f.name, Before: b, After: a})
+			}
+		}
+	}
+	for name, o := range old {
+		changes = append(changes, NetworkChange{Interface: name, Field: "network", Before: o.Network.Type})
+	}
+	// The fields of an interface keep their order.
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].Interface < changes[j].Interface })
+	return changes
+}
+
+// networkDiffFields are the settings of a network compared by DiffNetworks,
+// both from its configuration and from the status of its interface.
+var networkDiffFields = []struct {
+	name  string
+	value func(*NetworkInspection) string
+}{
+	{"type", func(n *NetworkInspection) string { return n.Network.Type }},
+	{"host_interface_name", func(n *NetworkInspection) string { return n.Network.HostInterfaceName }},
+	{"state", func(n *NetworkInspection) string { return n.State() }},
+	{"mac_address", func(n *NetworkInspection) string {
+		if n.Status != nil {
+			return n.Status.MacAddress
+		}
+		return n.Network.MacAddress
+	}},
+	{"mtu", func(n *NetworkInspection) string {
+		if n.Status != nil && n.Status.Mtu != 0 {
+			return strconv.Itoa(n.Status.Mtu)
+		}
+		if n.Network.Mtu != 0 {
+			return strconv.Itoa(n.Network.Mtu)
+		}
+		return ""
+	}},
+	{"addresses", func(n *NetworkInspection) string {
+		var addrs []string
+		if n.Status != nil {
+			addrs = append(addrs, n.Status.Addresses...)
+		} else {
+			for _, a := range []string{n.Network.Address, n.Network.IPv6Address} {
+				if a != "" {
+					addrs = append(addrs, a)
+				}
+			}
+		}
+		sort.Strings(addrs)
+		return strings.Join(addrs, ",")
+	}},
+	{"gateway", func(n *NetworkInspection) string { return n.Network.Gateway }},
+	{"ipv6_gateway", func(n *NetworkInspection) string { return n.Network.IPv6Gateway }},
+	{"skipped", func(n *NetworkInspection) string {
+		skipped := make([]string, 0, len(n.Skipped))
+		for _, s := range n.Skipped {
+			skipped = append(skipped, s.Setting)
+		}
+		sort.Strings(skipped)
+		return strings.Join(skipped, ",")
+	}},
+}
@@ -0,0 +1,47 @@
+package libcontainer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+func TestDiffNetworks(t *testing.T) {
+	eth0 := &configs.Network{Type: "veth", Name: "eth0", HostInterfaceName: "veth0", Standby: true}
+	eth1 := &configs.Network{Type: "sriov", Name: "eth1"}
+	eth2 := &configs.Network{Type: "macvlan", Name: "eth2"}
+	before := []NetworkInspection{
+		{Network: eth0, Status: &NetworkInterfaceStatus{Name: "eth0", Mtu: 1500, Addresses: []string{"10.0.0.2/24"}}},
+		{Network: eth1, Status: &NetworkInterfaceStatus{Name: "eth1", Mtu: 1500, Up: true}},
+	}
+	after := []NetworkInspection{
+		{Network: eth2, Status: &NetworkInterfaceStatus{Name: "eth2", Mtu: 1500, Up: true}},
+		{
+			Network: eth0,
+			Status:  &NetworkInterfaceStatus{Name: "eth0", Mtu: 9000, Addresses: []string{"fd00::2/64", "10.0.0.2/24"}, Up: true},
+			Skipped: []SkippedNetworkSetting{{Interface: "eth0", Setting: "pin_gateway"}},
+		},
+	}
+	expected := []NetworkChange{
+		{Interface: "eth0", Field: "state", Before: "standby", After: "up"},
+		{Interface: "eth0", Field: "mtu", Before: "1500", After: "9000"},
+		{Interface: "eth0", Field: "addresses", Before: "10.0.0.2/24", After: "10.0.0.2/24,fd00::2/64"},
+		{Interface: "eth0", Field: "skipped", After: "pin_gateway"},
+		{Interface: "eth1", Field: "network", Before: "sriov"},
+		{Interface: "eth2", Field: "network", After: "macvlan"},
+	}
+	changes := DiffNetworks(before, after)
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("expected %v, got %v", expected, changes)
+	}
+	if s := changes[0].String(); s != "eth0: state: standby -> up" {
+		t.Errorf("unexpected change %q", s)
+	}
+	if s := changes[4].String(); s != "eth1: network: sriov -> (none)" {
+		t.Errorf("unexpected change %q", s)
+	}
+	if changes := DiffNetworks(after, after); len(changes) != 0 {
+		t.Errorf("expected no change, got %v", changes)
+	}
+}
@@ -0,0 +1,106 @@
+package libcontainer
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// networkTypeModules are the kernel modules providing the interfaces of the
+// network types. The types which are not listed need no module.
+var networkTypeModules = map[string]string{
+	"can":       "can_dev",
+	"vcan":      "vcan",
+	"vxcan":     "vxcan",
+	"ipoib":     "ib_ipoib",
+	"vdpa":      "virtio_vdpa",
+	"ipip":      "ipip",
+	"sit":       "sit",
+	"gre":       "ip_gre",
+	"gretap":    "ip_gre",
+	"ip6tnl":    "ip6_tunnel",
+	"l2tpeth":   "l2tp_eth",
+	"veth":      "veth",
+	"macvlan":   "macvlan",
+	"macvtap":   "macvtap",
+	"ipvlan":    "ipvlan",
+	"vlan":      "8021q",
+	"tap":       "tun",
+	"wireguard": "wireguard",
+	"vxlan":     "vxlan",
+	"geneve":    "geneve",
+	"bond":      "bonding",
+	"dummy":     "dummy",
+	"macsec":    "macsec",
+	"team":      "team",
+}
+
+// KnownNetworkTypes returns the types of networks runc can set up.
+func KnownNetworkTypes() []string {
+	types := make([]string, 0, len(strategies))
+	for t := range strategies {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// SupportedNetworkTypes returns the types of networks whose kernel module is
+// loaded, built in, or available to be loaded on the host. The modules are
+// looked up without being loaded, so a type can still fail for other reasons,
+// such as a missing device. An error is returned if the modules of the running
+// kernel are not installed, as the built in modules are then unknown.
+func SupportedNetworkTypes() ([]string, error) {
+	var uts unix.Utsname
+	if err := unix.Uname(&uts); err != nil {
+		return nil, &os.SyscallError{Syscall: "uname", Err: err}
+	}
+	modulesDir := filepath.Join("/lib/modules", unix.ByteSliceToString(uts.Release[:]))
+	return supportedNetworkTypes("/sys/module", modulesDir)
+}
+
+func supportedNetworkTypes(sysModule, modulesDir string) ([]string, error) {
+	available := map[string]bool{}
+	if err := readModuleIndex(filepath.Join(modulesDir, "modules.builtin"), available); err != nil {
+		return nil, err
+	}
+	// Kernels built without module support have no modules.dep.
+	if err := readModuleIndex(filepath.Join(modulesDir, "modules.dep"), available); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	var types []string
+	for _, t := range KnownNetworkTypes() {
+		module, ok := networkTypeModules[t]
+		if ok && !available[module] {
+			if _, err := os.Stat(filepath.Join(sysModule, module)); err != nil {
+				continue
+			}
+		}
+		types = append(types, t)
+	}
+	return types, nil
+}
+
+// readModuleIndex adds the modules listed in a modules.builtin or modules.dep
+// file to available, by name. The names are normalized to use underscores, as
+// the kernel does.
+func readModuleIndex(path string, available map[string]bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		// modules.dep lines start with "kernel/.../name.ko[.xz]: deps".
+		file, _, _ := strings.Cut(s.Text(), ":")
+		name, _, _ := strings.Cut(filepath.Base(file), ".ko")
+		available[strings.ReplaceAll(name, "-", "_")] = true
+	}
+	return s.Err()
+}
@@ -0,0 +1,36 @@
+package libcontainer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSupportedNetworkTypes(t *testing.T) {
+	sysModule := t.TempDir()
+	if err := os.Mkdir(filepath.Join(sysModule, "vcan"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	modulesDir := t.TempDir()
+	if _, err := supportedNetworkTypes(sysModule, modulesDir); err == nil {
+		t.Fatal("expected error without the module index")
+	}
+	files := map[string]string{
+		"modules.builtin": "kernel/drivers/net/veth.ko\nkernel/net/8021q/8021q.ko\n",
+		"modules.dep":     "kernel/drivers/net/macvlan.ko.xz:\nkernel/drivers/net/can/dev/can-dev.ko.zst: kernel/net/can/can.ko.zst\n",
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(modulesDir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	types, err := supportedNetworkTypes(sysModule, modulesDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"can", "loopback", "macvlan", "plugin", "sriov", "vcan", "veth", "vlan"}
+	if !reflect.DeepEqual(types, expected) {
+		t.Errorf("expected %v, got %v", expected, types)
+	}
+}
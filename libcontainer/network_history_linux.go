@@ -0,0 +1,106 @@
+package libcontainer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	statsv1 "github.com/opencontainers/runc/types/stats/v1"
+)
+
+// NetworkStatsSample holds the counters of the container interfaces, as
+// seen from inside the container, at a given time.
+type NetworkStatsSample = statsv1.NetworkStatsSample
+
+// SampleNetworkStats periodically samples the counters of the container
+// interfaces, and keeps the most recent samples in the container state
+// directory as configured in the StatsHistory network option. No sample is
+// taken while the container is paused. It returns once ctx is cancelled or
+// the container has stopped, leaving the history in place so it can be
+// inspected with NetworkStatsHistory until the container is destroyed.
+func (c *Container) SampleNetworkStats(ctx context.Context) error {
+	opts := c.config.NetworkOptions
+	if opts == nil || opts.StatsHistory == nil {
+		return errors.New("network stats history is not enabled")
+	}
+	history, err := c.NetworkStatsHistory()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	monitor := newNetworkMonitor(c, "stats_history")
+	defer monitor.stop()
+	ticker := time.NewTicker(opts.StatsHistory.Interval)
+	defer ticker.Stop()
+	for {
+		nsPath, suspended, err := monitor.check()
+		if err != nil {
+			if errors.Is(err, ErrNotRunning) {
+				return nil
+			}
+			return err
+		}
+		if !suspended {
+			sample, err := sampleNetworkStats(nsPath, c.config.Networks)
+			if err != nil {
+				return err
+			}
+			history = append(history, sample)
+			if n := len(history) - opts.StatsHistory.Samples; n > 0 {
+				history = history[n:]
+			}
+			if err := c.writeStateFile(networkStatsFilename, history); err != nil {
+				return err
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// sampleNetworkStats reads the counters of the interfaces of the given
+// networks in the network namespace at nsPath. Interfaces which can not be
+// found are skipped.
+func sampleNetworkStats(nsPath string, networks []*configs.Network) (*NetworkStatsSample, error) {
+	sample := &NetworkStatsSample{Time: time.Now()}
+	names := make(map[string]bool, len(networks))
+	for _, n := range networks {
+		names[containerInterfaceName(n)] = true
+	}
+	var stats map[string]*statsv1.NetworkInterface
+	err := doInNetNS(nsPath, func() (err error) {
+		stats, err = dumpLinkStats(names)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range networks {
+		if iface, ok := stats[containerInterfaceName(n)]; ok {
+			sample.Interfaces = append(sample.Interfaces, iface)
+		}
+	}
+	return sample, nil
+}
+
+// NetworkStatsHistory returns the samples recorded by SampleNetworkStats,
+// oldest first. An error wrapping os.ErrNotExist is returned if no sample
+// has been recorded.
+func (c *Container) NetworkStatsHistory() ([]*NetworkStatsSample, error) {
+	f, err := os.Open(filepath.Join(c.stateDir, networkStatsFilename))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var history []*NetworkStatsSample
+	if err := json.NewDecoder(f).Decode(&history); err != nil {
+		return nil, errors.New("invalid network stats history: " + err.Error())
+	}
+	return history, nil
+}
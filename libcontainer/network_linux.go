@@ -2,29 +2,279 @@ package libcontainer
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/opencontainers/runc/libcontainer/configs"
-	"github.com/opencontainers/runc/types"
+	statsv1 "github.com/opencontainers/runc/types/stats/v1"
+	"github.com/sirupsen/logrus"
 	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
 )
 
 var strategies = map[string]networkStrategy{
-	"loopback": &loopback{},
+	"loopback":  &loopback{},
+	"can":       &can{},
+	"vcan":      &vcan{},
+	"vxcan":     &vxcan{},
+	"ipoib":     &ipoib{},
+	"sriov":     &sriov{},
+	"vdpa":      &vdpa{},
+	"ipip":      &ipTunnel{},
+	"sit":       &ipTunnel{},
+	"gre":       &ipTunnel{},
+	"gretap":    &ipTunnel{},
+	"ip6tnl":    &ipTunnel{},
+	"l2tpeth":   &l2tpEth{},
+	"veth":      &veth{},
+	"macvlan":   &macvlan{},
+	"ipvlan":    &ipvlan{},
+	"vlan":      &vlan{},
+	"tap":       &tap{},
+	"macvtap":   &macvtap{},
+	"wireguard": &wireguard{},
+	"vxlan":     &vxlan{},
+	"geneve":    &geneve{},
+	"bond":      &bond{},
+	"dummy":     &dummy{},
+	"macsec":    &macsec{},
+	"team":      &team{},
+	"plugin":    &plugin{},
 }
 
 // networkStrategy represents a specific network configuration for
 // a container's networking stack
+//
+// The context passed to create and initialize is cancelled if the container
+// creation is aborted, and should be honoured by operations that can wait.
 type networkStrategy interface {
-	create(*network, int) error
-	initialize(*network) error
+	create(context.Context, *network, int) error
+	initialize(context.Context, *network) error
 	detach(*configs.Network) error
 	attach(*configs.Network) error
 }
 
+// networkReleaser is implemented by the strategies holding host resources,
+// such as devices or registry entries, beyond the lifetime of the container
+// network namespace. release is called when the container is destroyed, or
+// when the network is detached from the running container once its interface
+// is removed from the container network namespace.
+type networkReleaser interface {
+	release(*network) error
+}
+
+// ResolvedNetwork holds the settings of a network supplied when the container
+// is created, see Process.ResolveNetwork. Empty fields keep the value of the
+// container configuration.
+type ResolvedNetwork struct {
+	// MacAddress is the MAC address of the container interface.
+	MacAddress string
+	// Address is the IPv4 address and mask of the container interface.
+	Address string
+	// Gateway is the default IPv4 gateway.
+	Gateway string
+	// IPv6Address is the IPv6 address and mask of the container interface.
+	IPv6Address string
+	// IPv6Gateway is the default IPv6 gateway.
+	IPv6Gateway string
+	// Routes are added in addition to the routes of the container
+	// configuration. An empty InterfaceName stands for the interface of the
+	// network, which must not be on standby.
+	Routes []*configs.Route
+	// Neighbors are added in addition to the neighbors of the network
+	// configuration, for peers known to the orchestrator.
+	Neighbors []*configs.Neighbor
+}
+
+// apply checks the resolved settings, and sets them on n.
+func (r *ResolvedNetwork) apply(n *network) error {
+	if r == nil {
+		return nil
+	}
+	if r.MacAddress != "" {
+		if _, err := net.ParseMAC(r.MacAddress); err != nil {
+			return err
+		}
+		n.MacAddress = r.MacAddress
+	}
+	if err := resolveIP(&n.Address, r.Address, true, true); err != nil {
+		return err
+	}
+	if err := resolveIP(&n.IPv6Address, r.IPv6Address, true, false); err != nil {
+		return err
+	}
+	if err := resolveIP(&n.Gateway, r.Gateway, false, true); err != nil {
+		return err
+	}
+	if err := resolveIP(&n.IPv6Gateway, r.IPv6Gateway, false, false); err != nil {
+		return err
+	}
+	for _, route := range r.Routes {
+		route := *route
+		if route.InterfaceName == "" {
+			route.InterfaceName = containerInterfaceName(&n.Network)
+		}
+		if n.Standby && route.InterfaceName == containerInterfaceName(&n.Network) {
+			// Only the configured routes are added on activation.
+			return fmt.Errorf("route to %s is through a standby network", route.Destination)
+		}
+		if _, _, err := net.ParseCIDR(route.Destination); err != nil {
+			return fmt.Errorf("invalid route destination: %w", err)
+		}
+		n.Routes = append(n.Routes, &route)
+	}
+	for _, neigh := range r.Neighbors {
+		if net.ParseIP(neigh.IP) == nil {
+			return fmt.Errorf("invalid neighbor address %q", neigh.IP)
+		}
+		if _, err := net.ParseMAC(neigh.MacAddress); err != nil {
+			return fmt.Errorf("invalid MAC address of neighbor %s: %w", neigh.IP, err)
+		}
+	}
+	// The slice is copied, as n.Network shares it with the configuration.
+	n.Neighbors = append(n.Neighbors[:len(n.Neighbors):len(n.Neighbors)], r.Neighbors...)
+	return nil
+}
+
+// resolveIP sets dst to value if it is not empty, after checking it is an
+// address of the right family, in CIDR form if cidr is set.
+func resolveIP(dst *string, value string, cidr, v4 bool) error {
+	if value == "" {
+		return nil
+	}
+	var ip net.IP
+	if cidr {
+		var err error
+		if ip, _, err = net.ParseCIDR(value); err != nil {
+			return err
+		}
+	} else if ip = net.ParseIP(value); ip == nil {
+		return fmt.Errorf("invalid IP address %q", value)
+	}
+	if (ip.To4() != nil) != v4 {
+		return fmt.Errorf("%s is not an address of the expected family", value)
+	}
+	*dst = value
+	return nil
+}
+
+// runNetworkSetup runs the network setup fn, with a context expiring at the
+// given deadline, if it is not zero. Once the deadline expires, an error
+// wrapping context.DeadlineExceeded is returned right away, and fn is left
+// running, as operations which do not honour the context, such as a netlink
+// request to a wedged driver, can not be interrupted. The caller must then
+// abort the container creation, which rolls back what fn set up. As what fn
+// sets up afterwards is not rolled back, it must check ctx before each step
+// holding resources, such as the creation of a network.
+func runNetworkSetup(ctx context.Context, deadline time.Time, fn func(context.Context) error) error {
+	if deadline.IsZero() {
+		return fn(ctx)
+	}
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- fn(ctx) }()
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	var err error
+	select {
+	case err = <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			return err
+		}
+	case <-timer.C:
+		err = context.DeadlineExceeded
+	}
+	return fmt.Errorf("network setup did not complete before the deadline: %w", err)
+}
+
+// NetworkStrategy sets up the networks of a type registered by an embedder of
+// libcontainer with RegisterNetworkStrategy. The settings common to all the
+// network types, such as the host side settings or the sysctls of the
+// interface, are applied by libcontainer once the strategy is done.
+//
+// The contexts passed to Create and Initialize are cancelled if the container
+// creation is aborted, and should be honoured by operations that can wait.
+type NetworkStrategy interface {
+	// Create is called in the process creating the container, with the
+	// pid of the container init process, to create the interface of n and
+	// move it to the container network namespace. Changes made to n are
+	// seen by Initialize.
+	Create(ctx context.Context, n *configs.Network, nspid int) error
+	// Initialize is called in the container init process, in the container
+	// network namespace, to configure the interface of n, which usually
+	// ends with bringing it up.
+	Initialize(ctx context.Context, n *configs.Network) error
+	// Detach is called in the process running runc, in the host network
+	// namespace, when the network is detached from a running container,
+	// before its interface is removed from the container and Release is
+	// called, and when the network is locked for a checkpoint. Attach is
+	// only called, in the same namespace, when the network is unlocked
+	// after a checkpoint or a restore: a detached network is attached
+	// again with Create and Initialize.
+	Detach(n *configs.Network) error
+	Attach(n *configs.Network) error
+}
+
+// NetworkReleaser is implemented by the registered network strategies
+// holding host resources beyond the lifetime of the container network
+// namespace. Release is called when the container is destroyed, or when the
+// network is detached from the running container.
+type NetworkReleaser interface {
+	Release(n *configs.Network) error
+}
+
+// RegisterNetworkStrategy makes the networks of the type name set up by s.
+// It must be called from an init function, so the strategy is also registered
+// in the container init process, which runs the same binary. It panics if
+// name is empty or already registered, including by runc itself.
+func RegisterNetworkStrategy(name string, s NetworkStrategy) {
+	if name == "" || s == nil {
+		panic("libcontainer: network strategy requires a name and an implementation")
+	}
+	if _, ok := strategies[name]; ok {
+		panic("libcontainer: network strategy " + name + " is already registered")
+	}
+	strategies[name] = &registeredStrategy{s: s}
+}
+
+// registeredStrategy adapts a NetworkStrategy to the internal strategies.
+type registeredStrategy struct {
+	s NetworkStrategy
+}
+
+func (r *registeredStrategy) create(ctx context.Context, n *network, nspid int) error {
+	return r.s.Create(ctx, &n.Network, nspid)
+}
+
+func (r *registeredStrategy) initialize(ctx context.Context, n *network) error {
+	return r.s.Initialize(ctx, &n.Network)
+}
+
+func (r *registeredStrategy) detach(n *configs.Network) error {
+	return r.s.Detach(n)
+}
+
+func (r *registeredStrategy) attach(n *configs.Network) error {
+	return r.s.Attach(n)
+}
+
+func (r *registeredStrategy) release(n *network) error {
+	if releaser, ok := r.s.(NetworkReleaser); ok {
+		return releaser.Release(&n.Network)
+	}
+	return nil
+}
+
 // getStrategy returns the specific network strategy for the
 // provided type.
 func getStrategy(tpe string) (networkStrategy, error) {
@@ -36,8 +286,11 @@ func getStrategy(tpe string) (networkStrategy, error) {
 }
 
 // Returns the network statistics for the network interfaces represented by the NetworkRuntimeInfo.
-func getNetworkInterfaceStats(interfaceName string) (*types.NetworkInterface, error) {
-	out := &types.NetworkInterface{Name: interfaceName}
+// The counters are read on a best effort basis, as some virtual drivers do not
+// provide all of them: those which can not be read are left out of the
+// Supported set of the result, and an error is only returned if none can be.
+func getNetworkInterfaceStats(interfaceName string) (*statsv1.NetworkInterface, error) {
+	out := &statsv1.NetworkInterface{Name: interfaceName}
 	// This can happen if the network runtime information is missing - possible if the
 	// container was created by an old version of libcontainer.
 	if interfaceName == "" {
@@ -46,48 +299,524 @@ func getNetworkInterfaceStats(interfaceName string) (*types.NetworkInterface, er
 	type netStatsPair struct {
 		// Where to write the output.
 		Out *uint64
+		// The counter written.
+		Counter statsv1.Counter
 		// The network stats file to read.
 		File string
 	}
 	// Ingress for host veth is from the container. Hence tx_bytes stat on the host veth is actually number of bytes received by the container.
 	netStats := []netStatsPair{
-		{Out: &out.RxBytes, File: "tx_bytes"},
-		{Out: &out.RxPackets, File: "tx_packets"},
-		{Out: &out.RxErrors, File: "tx_errors"},
-		{Out: &out.RxDropped, File: "tx_dropped"},
+		{Out: &out.RxBytes, Counter: statsv1.CounterRxBytes, File: "tx_bytes"},
+		{Out: &out.RxPackets, Counter: statsv1.CounterRxPackets, File: "tx_packets"},
+		{Out: &out.RxErrors, Counter: statsv1.CounterRxErrors, File: "tx_errors"},
+		{Out: &out.RxDropped, Counter: statsv1.CounterRxDropped, File: "tx_dropped"},
 
-		{Out: &out.TxBytes, File: "rx_bytes"},
-		{Out: &out.TxPackets, File: "rx_packets"},
-		{Out: &out.TxErrors, File: "rx_errors"},
-		{Out: &out.TxDropped, File: "rx_dropped"},
+		{Out: &out.TxBytes, Counter: statsv1.CounterTxBytes, File: "rx_bytes"},
+		{Out: &out.TxPackets, Counter: statsv1.CounterTxPackets, File: "rx_packets"},
+		{Out: &out.TxErrors, Counter: statsv1.CounterTxErrors, File: "rx_errors"},
+		{Out: &out.TxDropped, Counter: statsv1.CounterTxDropped, File: "rx_dropped"},
 	}
+	var firstErr error
 	for _, netStat := range netStats {
 		data, err := readSysfsNetworkStats(interfaceName, netStat.File)
 		if err != nil {
-			return nil, err
+			if firstErr == nil {
+				firstErr = err
+			}
+			logrus.Debugf("unable to read %s of %s: %v", netStat.File, interfaceName, err)
+			continue
 		}
 		*(netStat.Out) = data
+		out.Supported |= netStat.Counter
+	}
+	if out.Supported == 0 {
+		return nil, firstErr
 	}
 	return out, nil
 }
 
 // Reads the specified statistics available under /sys/class/net/<EthInterface>/statistics
 func readSysfsNetworkStats(ethInterface, statsFile string) (uint64, error) {
-	data, err := os.ReadFile(filepath.Join("/sys/class/net", ethInterface, "statistics", statsFile))
+	data, err := os.ReadFile(filepath.Join(sysfsNet, ethInterface, "statistics", statsFile))
 	if err != nil {
 		return 0, err
 	}
-	return strconv.ParseUint(string(bytes.TrimSpace(data)), 10, 64)
+	value, err := strconv.ParseUint(string(bytes.TrimSpace(data)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s counter: %w", statsFile, err)
+	}
+	return value, nil
+}
+
+// setupHostInterface applies the host side settings of a network, once its
+// strategy created the host side interface. It returns the settings which
+// were skipped according to the apply policy of the network.
+func setupHostInterface(ctx context.Context, n *configs.Network) ([]SkippedNetworkSetting, error) {
+	var skipped []SkippedNetworkSetting
+	if err := setupHostFirewallMark(ctx, n); err != nil {
+		if ctx.Err() != nil || !skipNetworkSetting(n.ApplyPolicy, false, err) {
+			return nil, err
+		}
+		skipped = append(skipped, skippedSetting(n, "host_firewall_mark", err))
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := setupHostShaping(n); err != nil {
+		if !skipNetworkSetting(n.ApplyPolicy, false, err) {
+			return nil, err
+		}
+		// Do not leave a partial configuration behind.
+		_ = teardownHostShaping(n)
+		skipped = append(skipped, skippedSetting(n, "host_shaping", err))
+	}
+	if err := setupHostRoutes(n); err != nil {
+		return nil, err
+	}
+	return skipped, nil
+}
+
+// networkTeardownError reports the networks whose host resources could not
+// be released, and those which were.
+type networkTeardownError struct {
+	Released []string
+	Failed   map[string]error
+}
+
+func (e *networkTeardownError) Error() string {
+	names := make([]string, 0, len(e.Failed))
+	for name := range e.Failed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	fmt.Fprintf(&b, "unable to release %d of %d networks:", len(e.Failed), len(e.Failed)+len(e.Released))
+	for _, name := range names {
+		fmt.Fprintf(&b, " %s (%v)", name, e.Failed[name])
+	}
+	return b.String()
+}
+
+// teardownNetworks releases the host resources of all the networks of c.
+// Every network is torn down even if another one fails, so a single failure
+// does not strand the resources of the others. A *networkTeardownError is
+// returned if any network failed.
+func teardownNetworks(c *Container) error {
+	report := &networkTeardownError{Failed: map[string]error{}}
+	for _, n := range c.config.Networks {
+		name := containerInterfaceName(n)
+		errs := []error{teardownHostInterface(n)}
+		if strategy, err := getStrategy(n.Type); err != nil {
+			errs = append(errs, err)
+		} else if r, ok := strategy.(networkReleaser); ok {
+			errs = append(errs, r.release(&network{Network: *n, stateDir: c.stateDir}))
+		}
+		if err := errors.Join(errs...); err != nil {
+			report.Failed[name] = err
+		} else {
+			report.Released = append(report.Released, name)
+		}
+	}
+	if len(report.Failed) > 0 {
+		return report
+	}
+	return nil
+}
+
+// teardownHostInterface removes the host side settings of a network. It is
+// not cancellable, so that no half configured interface is left behind.
+func teardownHostInterface(n *configs.Network) error {
+	return errors.Join(teardownHostFirewallMark(n), teardownHostShaping(n), teardownHostRoutes(n))
+}
+
+func setupHostFirewallMark(ctx context.Context, n *configs.Network) error {
+	if n.HostInterfaceName == "" || n.HostFirewallMark == 0 {
+		return nil
+	}
+	table := hostInterfaceTable(n.HostInterfaceName)
+	body := fmt.Sprintf("\tchain mark {\n\t\ttype filter hook ingress device %q priority -150;\n\t\tmeta mark set %#x\n\t}\n",
+		n.HostInterfaceName, n.HostFirewallMark)
+	if err := nftApply(ctx, "", nftReplaceTable("netdev", table, body)); err != nil {
+		return fmt.Errorf("unable to set firewall mark on %s: %w", n.HostInterfaceName, err)
+	}
+	return nil
+}
+
+func teardownHostFirewallMark(n *configs.Network) error {
+	if n.HostInterfaceName == "" || n.HostFirewallMark == 0 {
+		return nil
+	}
+	return nftApply(context.Background(), "", nftDeleteTable("netdev", hostInterfaceTable(n.HostInterfaceName)))
+}
+
+// setupDropRouterAdvertisements installs the nftables rule dropping the IPv6
+// router advertisements received on the interface of n. It must be called in
+// the container network namespace.
+func setupDropRouterAdvertisements(ctx context.Context, n *configs.Network) error {
+	if !n.DropRouterAdvertisements {
+		return nil
+	}
+	name := containerInterfaceName(n)
+	body := fmt.Sprintf("\tchain ra {\n\t\ttype filter hook ingress device %q priority -150;\n\t\ticmpv6 type nd-router-advert drop\n\t}\n", name)
+	if err := nftApply(ctx, "", nftReplaceTable("netdev", containerInterfaceTable(name), body)); err != nil {
+		return fmt.Errorf("unable to drop router advertisements on %s: %w", name, err)
+	}
+	return nil
+}
+
+// setNetworkSysctl writes value to the /proc/sys/net file made of the given
+// path elements. Unlike writeSystemProperty, the path is not given in dotted
+// form, since interface names may contain dots.
+func setNetworkSysctl(value string, elem ...string) error {
+	return os.WriteFile(filepath.Join(append([]string{"/proc/sys/net"}, elem...)...), []byte(value), 0o644)
+}
+
+// setupNetworkOptions applies the settings for the network namespace as a
+// whole. It must be called from within the container network namespace.
+func setupNetworkOptions(opts *configs.NetworkOptions) error {
+	if opts == nil {
+		return nil
+	}
+	if opts.RPFilter != nil {
+		for _, dev := range []string{"all", "default"} {
+			if err := setNetworkSysctl(strconv.Itoa(*opts.RPFilter), "ipv4", "conf", dev, "rp_filter"); err != nil {
+				return fmt.Errorf("unable to set rp_filter: %w", err)
+			}
+		}
+	}
+	return setupRoutingRules(opts.RoutingRules)
+}
+
+// SkippedNetworkSetting is an optional network setting which was not applied,
+// because it is not supported by the kernel or the driver.
+type SkippedNetworkSetting struct {
+	// Interface is the name of the interface inside the container.
+	Interface string `json:"interface"`
+	// Setting is the name of the setting.
+	Setting string `json:"setting"`
+	// Reason is why the setting was skipped.
+	Reason string `json:"reason"`
+}
+
+// skipNetworkSetting reports whether a setting which failed with err can be
+// skipped according to policy. By default, only optional settings which are
+// not supported are skipped.
+func skipNetworkSetting(policy configs.ApplyPolicy, optional bool, err error) bool {
+	switch policy {
+	case configs.ApplyPolicyStrict:
+		return false
+	case configs.ApplyPolicyBestEffort:
+		return true
+	}
+	return optional && errors.Is(err, os.ErrNotExist)
+}
+
+// unavailableDevice reports whether err, returned when creating the interface
+// of a network, means that its device or parent device is missing or busy.
+func unavailableDevice(err error) bool {
+	var notFound netlink.LinkNotFoundError
+	return errors.As(err, &notFound) || errors.Is(err, os.ErrNotExist) || errors.Is(err, unix.ENODEV) || errors.Is(err, unix.EBUSY)
+}
+
+// removeNetworks removes the given networks from config, together with the
+// routes through their interface, and returns them.
+func removeNetworks(config *configs.Config, networks map[*configs.Network]bool) ([]*configs.Network, []*configs.Route) {
+	var (
+		kept, removedNetworks []*configs.Network
+		removed               = make(map[string]bool)
+	)
+	for _, n := range config.Networks {
+		if networks[n] {
+			removed[containerInterfaceName(n)] = true
+			removedNetworks = append(removedNetworks, n)
+		} else {
+			kept = append(kept, n)
+		}
+	}
+	config.Networks = kept
+	var routes, removedRoutes []*configs.Route
+	for _, r := range config.Routes {
+		if removed[r.InterfaceName] {
+			removedRoutes = append(removedRoutes, r)
+		} else {
+			routes = append(routes, r)
+		}
+	}
+	config.Routes = routes
+	return removedNetworks, removedRoutes
+}
+
+func skippedSetting(n *configs.Network, setting string, err error) SkippedNetworkSetting {
+	reason := err.Error()
+	if errors.Is(err, os.ErrNotExist) {
+		reason = "not supported by the kernel"
+	}
+	return SkippedNetworkSetting{Interface: containerInterfaceName(n), Setting: setting, Reason: reason}
+}
+
+// initializeNetwork sets up the interface of n inside the container network
+// namespace, using its strategy and then applying the settings common to all
+// strategies. It returns the optional settings which were skipped.
+func initializeNetwork(ctx context.Context, strategy networkStrategy, n *network) ([]SkippedNetworkSetting, error) {
+	if err := strategy.initialize(ctx, n); err != nil {
+		return nil, err
+	}
+	if n.AutoIPv4LinkLocal {
+		if err := setupLinkLocal(ctx, containerInterfaceName(&n.Network)); err != nil {
+			return nil, err
+		}
+	}
+	if err := setupPrefixDelegation(ctx, &n.Network); err != nil {
+		return nil, err
+	}
+	var skipped []SkippedNetworkSetting
+	if err := setupDropRouterAdvertisements(ctx, &n.Network); err != nil {
+		if ctx.Err() != nil || !skipNetworkSetting(n.ApplyPolicy, false, err) {
+			return nil, err
+		}
+		skipped = append(skipped, skippedSetting(&n.Network, "drop_router_advertisements", err))
+	}
+	if err := setupQuota(ctx, &n.Network); err != nil {
+		if ctx.Err() != nil || !skipNetworkSetting(n.ApplyPolicy, false, err) {
+			return nil, err
+		}
+		skipped = append(skipped, skippedSetting(&n.Network, "quota", err))
+	}
+	s, err := setupInterfaceSysctls(&n.Network)
+	if err != nil {
+		return nil, err
+	}
+	skipped = append(skipped, s...)
+	if err := setupGatewayNeighbors(ctx, &n.Network); err != nil {
+		if ctx.Err() != nil || !skipNetworkSetting(n.ApplyPolicy, false, err) {
+			return nil, err
+		}
+		skipped = append(skipped, skippedSetting(&n.Network, "pin_gateway", err))
+	}
+	if err := setupNeighbors(&n.Network); err != nil {
+		if !skipNetworkSetting(n.ApplyPolicy, false, err) {
+			return nil, err
+		}
+		skipped = append(skipped, skippedSetting(&n.Network, "neighbors", err))
+	}
+	if err := setupStaticRoutes(&n.Network); err != nil {
+		if !skipNetworkSetting(n.ApplyPolicy, false, err) {
+			return nil, err
+		}
+		skipped = append(skipped, skippedSetting(&n.Network, "static_routes", err))
+	}
+	if err := setupTrafficFilters(&n.Network); err != nil {
+		if !skipNetworkSetting(n.ApplyPolicy, false, err) {
+			return nil, err
+		}
+		// Do not leave a partial configuration behind.
+		_ = teardownTrafficFilters(&n.Network)
+		skipped = append(skipped, skippedSetting(&n.Network, "traffic_filters", err))
+	}
+	return skipped, nil
+}
+
+// setupInterfaceSysctls applies the per interface sysctls of a network, once
+// the interface has been initialized inside the container network namespace.
+// The sysctls of the network profile are optional, and are skipped by
+// default if the kernel does not support them.
+func setupInterfaceSysctls(n *configs.Network) ([]SkippedNetworkSetting, error) {
+	sysctls := map[string]string{}
+	optional := map[string]bool{}
+	if profile, ok := configs.GetNetworkProfile(n.Profile); ok {
+		for key, value := range profile.Sysctls {
+			sysctls[key] = value
+			optional[key] = true
+		}
+	}
+	if n.RPFilter != nil {
+		sysctls["ipv4.rp_filter"] = strconv.Itoa(*n.RPFilter)
+	}
+	if n.AcceptLocal != nil {
+		sysctls["ipv4.accept_local"] = boolSysctl(*n.AcceptLocal)
+	}
+	if n.RouteLocalnet != nil {
+		sysctls["ipv4.route_localnet"] = boolSysctl(*n.RouteLocalnet)
+	}
+	if n.Unnumbered {
+		// Routers have forwarding enabled, and ignore advertisements
+		// unless accept_ra is 2.
+		sysctls["ipv6.disable_ipv6"] = "0"
+		sysctls["ipv6.accept_ra"] = "2"
+		sysctls["ipv6.autoconf"] = "0"
+	}
+	return applyInterfaceSysctls(n, sysctls, optional)
+}
+
+// applyInterfaceSysctls sets the given sysctls of the interface of n, the
+// keys being relative to net.<family>.conf.<interface> and prefixed with the
+// family. Failures are skipped according to the apply policy of n.
+func applyInterfaceSysctls(n *configs.Network, sysctls map[string]string, optional map[string]bool) ([]SkippedNetworkSetting, error) {
+	name := containerInterfaceName(n)
+	var skipped []SkippedNetworkSetting
+	for setting, value := range sysctls {
+		family, key, _ := strings.Cut(setting, ".")
+		err := setNetworkSysctl(value, family, "conf", name, key)
+		if err == nil {
+			continue
+		}
+		if !skipNetworkSetting(n.ApplyPolicy, optional[setting], err) {
+			return nil, fmt.Errorf("unable to set %s.%s on %s: %w", family, key, name, err)
+		}
+		skipped = append(skipped, skippedSetting(n, "net."+family+".conf."+name+"."+key, err))
+	}
+	return skipped, nil
+}
+
+// applyNetworkProfile sets the settings of the profile of n which are not
+// set in n itself, except for sysctls which are applied by
+// setupInterfaceSysctls.
+func applyNetworkProfile(n *configs.Network) {
+	profile, ok := configs.GetNetworkProfile(n.Profile)
+	if !ok {
+		return
+	}
+	if n.TxQueueLen == 0 {
+		n.TxQueueLen = profile.TxQueueLen
+	}
+}
+
+// configureLink applies the MTU, queue length and addresses of n to link,
+// brings it up and adds the default routes through its gateways. It must be
+// called in the container network namespace.
+func configureLink(link netlink.Link, n *configs.Network) error {
+	if n.Mtu != 0 {
+		if err := netlink.LinkSetMTU(link, n.Mtu); err != nil {
+			return fmt.Errorf("unable to set the mtu of %s: %w", n.Name, err)
+		}
+	}
+	if n.TxQueueLen != 0 {
+		if err := netlink.LinkSetTxQLen(link, n.TxQueueLen); err != nil {
+			return fmt.Errorf("unable to set the queue length of %s: %w", n.Name, err)
+		}
+	}
+	for _, address := range []string{n.Address, n.IPv6Address} {
+		if address == "" {
+			continue
+		}
+		addr, err := netlink.ParseAddr(address)
+		if err != nil {
+			return err
+		}
+		if err := addAddress(link, addr, n.AddressConflict); err != nil {
+			return fmt.Errorf("unable to add %s to %s: %w", address, n.Name, err)
+		}
+	}
+	if n.PreUpHook != nil {
+		input, err := json.Marshal(n)
+		if err != nil {
+			return err
+		}
+		if err := n.PreUpHook.RunWithInput(input); err != nil {
+			return fmt.Errorf("error running the pre-up hook of %s: %w", n.Name, err)
+		}
+	}
+	if n.Standby {
+		// The interface is brought up by activateLink.
+		return nil
+	}
+	if err := netlink.LinkSetUp(link); err != nil {
+		return err
+	}
+	for _, gateway := range []string{n.Gateway, n.IPv6Gateway} {
+		if gateway == "" {
+			continue
+		}
+		route := &netlink.Route{
+			Scope:     netlink.SCOPE_UNIVERSE,
+			LinkIndex: link.Attrs().Index,
+			Gw:        net.ParseIP(gateway),
+			Priority:  n.GatewayMetric,
+		}
+		if err := netlink.RouteAdd(route); err != nil {
+			return fmt.Errorf("unable to add the default route through %s: %w", gateway, err)
+		}
+	}
+	return nil
 }
 
-// loopback is a network strategy that provides a basic loopback device
+// flushInterface removes the routes and addresses of the interface name,
+// except the IPv6 link-local addresses assigned by the kernel.
+func flushInterface(name string) error {
+	link, err := netlink.LinkByName(name)
+	if err != nil {
+		return err
+	}
+	filter := &netlink.Route{LinkIndex: link.Attrs().Index, Table: unix.RT_TABLE_UNSPEC}
+	routes, err := netlink.RouteListFiltered(netlink.FAMILY_ALL, filter, netlink.RT_FILTER_OIF|netlink.RT_FILTER_TABLE)
+	if err != nil {
+		return err
+	}
+	for i := range routes {
+		// The routes added by the kernel go away with their addresses.
+		if routes[i].Protocol == unix.RTPROT_KERNEL {
+			continue
+		}
+		if err := netlink.RouteDel(&routes[i]); err != nil && !errors.Is(err, unix.ESRCH) {
+			return fmt.Errorf("unable to delete route %s: %w", routes[i], err)
+		}
+	}
+	addrs, err := netlink.AddrList(link, netlink.FAMILY_ALL)
+	if err != nil {
+		return err
+	}
+	for i := range addrs {
+		if addrs[i].IP.To4() == nil && addrs[i].IP.IsLinkLocalUnicast() {
+			continue
+		}
+		if err := netlink.AddrDel(link, &addrs[i]); err != nil && !errors.Is(err, unix.EADDRNOTAVAIL) {
+			return fmt.Errorf("unable to delete address %s: %w", addrs[i].IPNet, err)
+		}
+	}
+	return nil
+}
+
+// addAddress adds addr to link, applying policy if it is already present.
+func addAddress(link netlink.Link, addr *netlink.Addr, policy configs.AddressConflictPolicy) error {
+	err := netlink.AddrAdd(link, addr)
+	if !errors.Is(err, unix.EEXIST) {
+		return err
+	}
+	switch policy {
+	case configs.AddressConflictIgnore:
+		return nil
+	case configs.AddressConflictReplace:
+		return netlink.AddrReplace(link, addr)
+	}
+	return err
+}
+
+func boolSysctl(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+// loopback is a network strategy that provides a basic loopback device, and
+// isolates the container network namespace if requested.
 type loopback struct{}
 
-func (l *loopback) create(n *network, nspid int) error {
+func (l *loopback) create(ctx context.Context, n *network, nspid int) error {
+	if n.Isolate == "" {
+		return nil
+	}
+	removed, err := isolateNetNS("/proc/"+strconv.Itoa(nspid)+"/ns/net", n.Isolate)
+	if err != nil {
+		return err
+	}
+	if len(removed) > 0 {
+		logrus.Debugf("isolated the container network namespace, removing %s", strings.Join(removed, ", "))
+	}
 	return nil
 }
 
-func (l *loopback) initialize(config *network) error {
+func (l *loopback) initialize(ctx context.Context, config *network) error {
 	return netlink.LinkSetUp(&netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: "lo"}})
 }
 
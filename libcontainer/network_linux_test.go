@@ -0,0 +1,952 @@
+package libcontainer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/runc/internal/testutil/nettest"
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+func readNetworkSysctl(t *testing.T, nsPath, path string) string {
+	t.Helper()
+	var data []byte
+	err := doInNetNS(nsPath, func() (err error) {
+		data, err = os.ReadFile(path)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func TestSetupNetworkSysctls(t *testing.T) {
+	nsPath := nettest.NewNS(t).Path
+	strict, loose := 1, 2
+	enabled := true
+	err := doInNetNS(nsPath, func() error {
+		if err := setupNetworkOptions(&configs.NetworkOptions{RPFilter: &loose}); err != nil {
+			return err
+		}
+		_, err := setupInterfaceSysctls(&configs.Network{
+			Type:          "loopback",
+			RPFilter:      &strict,
+			AcceptLocal:   &enabled,
+			RouteLocalnet: &enabled,
+		})
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, expected := range map[string]string{
+		"/proc/sys/net/ipv4/conf/all/rp_filter":     "2",
+		"/proc/sys/net/ipv4/conf/default/rp_filter": "2",
+		"/proc/sys/net/ipv4/conf/lo/rp_filter":      "1",
+		"/proc/sys/net/ipv4/conf/lo/accept_local":   "1",
+		"/proc/sys/net/ipv4/conf/lo/route_localnet": "1",
+	} {
+		if got := readNetworkSysctl(t, nsPath, path); got != expected {
+			t.Errorf("%s: expected %s, got %s", path, expected, got)
+		}
+	}
+}
+
+func TestUnnumberedNetwork(t *testing.T) {
+	ns := nettest.NewNS(t)
+	ns.AddVeth(t, "eth0", "peer0", nil)
+	n := &configs.Network{Type: "veth", Name: "eth0", Profile: "router", Unnumbered: true}
+	ns.Do(t, func() error {
+		if err := setNetworkSysctl("1", "ipv6", "conf", "eth0", "disable_ipv6"); err != nil {
+			return err
+		}
+		if _, err := setupInterfaceSysctls(n); err != nil {
+			return err
+		}
+		for _, name := range []string{"eth0", "peer0"} {
+			if err := netlink.LinkSetUp(&netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: name}}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	for path, expected := range map[string]string{
+		"/proc/sys/net/ipv6/conf/eth0/disable_ipv6": "0",
+		"/proc/sys/net/ipv6/conf/eth0/accept_ra":    "2",
+		"/proc/sys/net/ipv6/conf/eth0/autoconf":     "0",
+		"/proc/sys/net/ipv6/conf/eth0/forwarding":   "1",
+	} {
+		if got := readNetworkSysctl(t, ns.Path, path); got != expected {
+			t.Errorf("%s: expected %s, got %s", path, expected, got)
+		}
+	}
+	if addrs := ns.Addrs(t, "eth0"); len(addrs) != 1 || !strings.HasPrefix(addrs[0], "fe80::") {
+		t.Errorf("expected a link-local address only, got %v", addrs)
+	}
+}
+
+func TestNetworkProfile(t *testing.T) {
+	ns := nettest.NewNS(t)
+	ns.AddVeth(t, "eth0", "peer0", nil)
+	strict := 1
+	n := &configs.Network{Type: "veth", Name: "eth0", Profile: "router", RPFilter: &strict}
+	applyNetworkProfile(n)
+	ns.Do(t, func() error {
+		skipped, err := setupInterfaceSysctls(n)
+		if len(skipped) != 0 {
+			t.Errorf("unexpected skipped settings: %+v", skipped)
+		}
+		return err
+	})
+	for path, expected := range map[string]string{
+		"/proc/sys/net/ipv4/conf/eth0/forwarding": "1",
+		"/proc/sys/net/ipv6/conf/eth0/forwarding": "1",
+		// The network settings take precedence over the profile.
+		"/proc/sys/net/ipv4/conf/eth0/rp_filter": "1",
+	} {
+		if got := readNetworkSysctl(t, ns.Path, path); got != expected {
+			t.Errorf("%s: expected %s, got %s", path, expected, got)
+		}
+	}
+
+	n = &configs.Network{Type: "veth", Name: "eth0", Profile: "lowlatency"}
+	applyNetworkProfile(n)
+	if n.TxQueueLen != 128 {
+		t.Errorf("expected the profile txqueuelen, got %d", n.TxQueueLen)
+	}
+	n = &configs.Network{Type: "veth", Name: "eth0", Profile: "lowlatency", TxQueueLen: 1000}
+	applyNetworkProfile(n)
+	if n.TxQueueLen != 1000 {
+		t.Errorf("expected the network txqueuelen, got %d", n.TxQueueLen)
+	}
+}
+
+func TestNetworkApplyPolicy(t *testing.T) {
+	ns := nettest.NewNS(t)
+	ns.AddVeth(t, "eth0", "peer0", nil)
+	sysctls := map[string]string{"ipv4.forwarding": "1", "ipv4.unsupported": "1"}
+	for _, tc := range []struct {
+		policy   configs.ApplyPolicy
+		optional map[string]bool
+		skipped  int
+		isErr    bool
+	}{
+		{optional: map[string]bool{"ipv4.unsupported": true}, skipped: 1},
+		{isErr: true},
+		{policy: configs.ApplyPolicyStrict, optional: map[string]bool{"ipv4.unsupported": true}, isErr: true},
+		{policy: configs.ApplyPolicyBestEffort, skipped: 1},
+	} {
+		n := &configs.Network{Type: "veth", Name: "eth0", ApplyPolicy: tc.policy}
+		err := ns.Run(func() error {
+			skipped, err := applyInterfaceSysctls(n, sysctls, tc.optional)
+			if len(skipped) != tc.skipped {
+				t.Errorf("policy %q: expected %d skipped settings, got %+v", tc.policy, tc.skipped, skipped)
+			}
+			if len(skipped) > 0 && skipped[0].Setting != "net.ipv4.conf.eth0.unsupported" {
+				t.Errorf("policy %q: unexpected skipped setting %+v", tc.policy, skipped[0])
+			}
+			return err
+		})
+		if tc.isErr != (err != nil) {
+			t.Errorf("policy %q: unexpected error: %v", tc.policy, err)
+		}
+	}
+
+	// With the best-effort policy, failing host settings are skipped.
+	n := &configs.Network{
+		Type:              "veth",
+		HostInterfaceName: "missing0",
+		HostShaping:       &configs.Shaping{EgressRate: 1 << 20, EgressBurst: 1 << 16},
+		ApplyPolicy:       configs.ApplyPolicyBestEffort,
+	}
+	ns.Do(t, func() error {
+		skipped, err := setupHostInterface(context.Background(), n)
+		if len(skipped) != 1 || skipped[0].Setting != "host_shaping" {
+			t.Errorf("expected host shaping to be skipped, got %+v", skipped)
+		}
+		return err
+	})
+}
+
+func TestNftReplaceTable(t *testing.T) {
+	expected := `table netdev runc-veth0 {}
+delete table netdev runc-veth0
+table netdev runc-veth0 {
+	chain c {}
+}
+`
+	if got := nftReplaceTable("netdev", hostInterfaceTable("veth0"), "\tchain c {}\n"); got != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestNetworkStatus(t *testing.T) {
+	nsPath := nettest.NewNS(t).Path
+	err := doInNetNS(nsPath, func() error {
+		return (&loopback{}).initialize(context.Background(), nil)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, err := networkStatus(nsPath, []*configs.Network{{Type: "loopback"}, {Type: "veth", Name: "missing0"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status) != 2 {
+		t.Fatalf("expected 2 interfaces, got %+v", status)
+	}
+	lo := status[0]
+	if lo.Name != "lo" || !lo.Up || lo.Index == 0 || lo.Error != "" {
+		t.Errorf("unexpected loopback status: %+v", lo)
+	}
+	if len(lo.Addresses) == 0 || lo.Addresses[0] != "127.0.0.1/8" {
+		t.Errorf("expected loopback addresses, got %v", lo.Addresses)
+	}
+	if status[1].Error == "" {
+		t.Errorf("expected an error for a missing interface, got %+v", status[1])
+	}
+}
+
+func TestAnnotateNetworkStatus(t *testing.T) {
+	nsPath := nettest.NewNS(t).Path
+	s := &specs.State{Annotations: map[string]string{"foo": "bar"}}
+	if err := annotateNetworkStatus(s, nsPath, []*configs.Network{{Type: "loopback"}}); err != nil {
+		t.Fatal(err)
+	}
+	if s.Annotations["foo"] != "bar" {
+		t.Errorf("expected the annotations to be kept, got %v", s.Annotations)
+	}
+	var interfaces []NetworkInterfaceStatus
+	if err := json.Unmarshal([]byte(s.Annotations[NetworkAnnotation]), &interfaces); err != nil {
+		t.Fatal(err)
+	}
+	if len(interfaces) != 1 || interfaces[0].Name != "lo" || interfaces[0].Index == 0 {
+		t.Errorf("unexpected interfaces: %+v", interfaces)
+	}
+
+	s = &specs.State{}
+	if err := annotateNetworkStatus(s, nsPath, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.Annotations[NetworkAnnotation]; ok {
+		t.Error("expected no annotation without networks")
+	}
+}
+
+func TestResolvedNetwork(t *testing.T) {
+	n := &network{Network: configs.Network{Type: "veth", Name: "eth0", Address: "10.0.0.2/24"}}
+	resolved := &ResolvedNetwork{
+		IPv6Address: "fd00::2/64",
+		Gateway:     "10.0.0.1",
+		Routes:      []*configs.Route{{Destination: "192.168.0.0/16", Gateway: "10.0.0.254"}},
+		Neighbors:   []*configs.Neighbor{{IP: "10.0.0.3", MacAddress: "02:00:00:00:00:03"}},
+	}
+	if err := resolved.apply(n); err != nil {
+		t.Fatal(err)
+	}
+	if n.Address != "10.0.0.2/24" || n.IPv6Address != "fd00::2/64" || n.Gateway != "10.0.0.1" {
+		t.Errorf("unexpected resolved network: %+v", n.Network)
+	}
+	if len(n.Routes) != 1 || n.Routes[0].InterfaceName != "eth0" {
+		t.Errorf("expected the route to use eth0, got %+v", n.Routes)
+	}
+	if resolved.Routes[0].InterfaceName != "" {
+		t.Error("the resolved route was modified")
+	}
+	if len(n.Neighbors) != 1 || n.Neighbors[0].IP != "10.0.0.3" {
+		t.Errorf("expected the resolved neighbor, got %+v", n.Neighbors)
+	}
+
+	for _, r := range []*ResolvedNetwork{
+		{MacAddress: "invalid"},
+		{Address: "10.0.0.2"},
+		{Address: "fd00::2/64"},
+		{IPv6Address: "10.0.0.2/24"},
+		{Gateway: "10.0.0.1/24"},
+		{IPv6Gateway: "10.0.0.1"},
+		{Routes: []*configs.Route{{Destination: "invalid"}}},
+		{Neighbors: []*configs.Neighbor{{IP: "10.0.0.3"}}},
+	} {
+		if err := r.apply(&network{}); err == nil {
+			t.Errorf("%+v: expected error, got nil", r)
+		}
+	}
+	standby := &network{Network: configs.Network{Name: "eth1", Standby: true}}
+	if err := (&ResolvedNetwork{Routes: []*configs.Route{{Destination: "10.1.0.0/16"}}}).apply(standby); err == nil {
+		t.Error("expected error resolving a route through a standby network")
+	}
+}
+
+func TestMoveLink(t *testing.T) {
+	host := nettest.NewNS(t)
+	ctr := nettest.NewNS(t)
+	host.AddVeth(t, "host0", "peer0", nil)
+	nsFd := ctr.Fd(t)
+
+	mac, _ := net.ParseMAC("02:42:ac:11:00:02")
+	m := &linkMove{
+		name:     "eth0",
+		mac:      mac,
+		mtu:      1400,
+		altNames: []string{"container-uplink-interface"},
+		raw: []*configs.LinkAttribute{
+			{Type: unix.IFLA_IFALIAS, Value: []byte("moved by runc\x00")},
+		},
+	}
+	host.Do(t, func() error {
+		link, err := netlink.LinkByName("peer0")
+		if err != nil {
+			return err
+		}
+		return moveLink(link, nsFd, m)
+	})
+	if host.Link(t, "peer0") != nil {
+		t.Fatal("expected peer0 to be moved out of the host")
+	}
+	link := ctr.Link(t, "eth0")
+	if link == nil {
+		t.Fatal("expected eth0 in the container")
+	}
+	attrs := link.Attrs()
+	if attrs.MTU != 1400 || attrs.HardwareAddr.String() != mac.String() {
+		t.Errorf("settings not applied: mtu %d, mac %s", attrs.MTU, attrs.HardwareAddr)
+	}
+	if attrs.Alias != "moved by runc" {
+		t.Errorf("raw attributes not applied: alias %q", attrs.Alias)
+	}
+	ctr.Do(t, func() error {
+		// Look the link up by its alternative name.
+		req := nl.NewNetlinkRequest(unix.RTM_GETLINK, unix.NLM_F_ACK)
+		req.AddData(nl.NewIfInfomsg(unix.AF_UNSPEC))
+		req.AddData(nl.NewRtAttr(unix.IFLA_ALT_IFNAME, nl.ZeroTerminated("container-uplink-interface")))
+		msgs, err := req.Execute(unix.NETLINK_ROUTE, unix.RTM_NEWLINK)
+		if err != nil {
+			t.Errorf("expected the link to be found by its alternative name: %v", err)
+			return nil
+		}
+		if index := nl.DeserializeIfInfomsg(msgs[0]).Index; int(index) != attrs.Index {
+			t.Errorf("alternative name resolved to index %d, expected %d", index, attrs.Index)
+		}
+		return nil
+	})
+
+	// The alternative names are removed and the link set up again if the
+	// move fails, here because the namespace is not a network namespace.
+	host.AddVeth(t, "host1", "peer1", nil)
+	host.Do(t, func() error {
+		return netlink.LinkSetUp(&netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: "peer1"}})
+	})
+	m.altNames = []string{"second-uplink-interface"}
+	m.down = true
+	devNull, err := os.Open("/dev/null")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+	err = host.Run(func() error {
+		link, err := netlink.LinkByName("peer1")
+		if err != nil {
+			return err
+		}
+		return moveLink(link, int(devNull.Fd()), m)
+	})
+	if err == nil {
+		t.Fatal("expected error moving a link to an invalid namespace")
+	}
+	host.Do(t, func() error {
+		req := nl.NewNetlinkRequest(unix.RTM_GETLINK, unix.NLM_F_ACK)
+		req.AddData(nl.NewIfInfomsg(unix.AF_UNSPEC))
+		req.AddData(nl.NewRtAttr(unix.IFLA_ALT_IFNAME, nl.ZeroTerminated("second-uplink-interface")))
+		if _, err := req.Execute(unix.NETLINK_ROUTE, unix.RTM_NEWLINK); err == nil {
+			t.Error("expected the alternative name to be removed after the failed move")
+		}
+		return nil
+	})
+	if host.Link(t, "peer1").Attrs().Flags&net.FlagUp == 0 {
+		t.Error("expected the link to be up again after the failed move")
+	}
+}
+
+func TestSampleNetworkStats(t *testing.T) {
+	ctr := nettest.NewNS(t)
+	ctr.AddVeth(t, "eth0", "peer0", nil)
+	networks := []*configs.Network{
+		{Type: "loopback"},
+		{Type: "veth", Name: "eth0"},
+		{Type: "veth", Name: "missing"},
+	}
+	// Generate some traffic on the loopback interface.
+	ctr.Do(t, func() error {
+		conn, err := net.Dial("udp", "127.0.0.1:9")
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		_, err = conn.Write([]byte("ping"))
+		return err
+	})
+	sample, err := sampleNetworkStats(ctr.Path, networks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sample.Interfaces) != 2 {
+		t.Fatalf("expected 2 interfaces, got %+v", sample.Interfaces)
+	}
+	lo := sample.Interfaces[0]
+	if lo.Name != "lo" || lo.TxPackets == 0 || lo.RxPackets == 0 {
+		t.Errorf("expected traffic on lo, got %+v", lo)
+	}
+	if sample.Interfaces[1].Name != "eth0" {
+		t.Errorf("expected eth0, got %+v", sample.Interfaces[1])
+	}
+}
+
+func TestSetupGatewayNeighbors(t *testing.T) {
+	gw := nettest.NewNS(t)
+	ctr := nettest.NewNS(t)
+	gw.AddVeth(t, "gw0", "eth0", ctr)
+	setup := func(ns *nettest.NS, name, addr string) {
+		ns.Do(t, func() error {
+			link, err := netlink.LinkByName(name)
+			if err != nil {
+				return err
+			}
+			ipNet, err := netlink.ParseIPNet(addr)
+			if err != nil {
+				return err
+			}
+			if err := netlink.AddrAdd(link, &netlink.Addr{IPNet: ipNet}); err != nil {
+				return err
+			}
+			return netlink.LinkSetUp(link)
+		})
+	}
+	setup(gw, "gw0", "10.0.0.1/24")
+	setup(ctr, "eth0", "10.0.0.2/24")
+	gwMac := gw.Link(t, "gw0").Attrs().HardwareAddr
+
+	pinned := func() net.HardwareAddr {
+		var mac net.HardwareAddr
+		ctr.Do(t, func() error {
+			neighs, err := netlink.NeighList(0, netlink.FAMILY_V4)
+			if err != nil {
+				return err
+			}
+			for _, n := range neighs {
+				if n.IP.String() == "10.0.0.1" && n.State == netlink.NUD_PERMANENT {
+					mac = n.HardwareAddr
+				}
+			}
+			return nil
+		})
+		return mac
+	}
+
+	n := &configs.Network{Type: "veth", Name: "eth0", Gateway: "10.0.0.1", PinGateway: true}
+	ctr.Do(t, func() error { return setupGatewayNeighbors(context.Background(), n) })
+	if mac := pinned(); mac.String() != gwMac.String() {
+		t.Errorf("expected the gateway to be pinned to %s, got %s", gwMac, mac)
+	}
+
+	n.GatewayMacAddress = "02:00:00:00:00:01"
+	ctr.Do(t, func() error { return setupGatewayNeighbors(context.Background(), n) })
+	if mac := pinned(); mac.String() != n.GatewayMacAddress {
+		t.Errorf("expected the gateway to be pinned to %s, got %s", n.GatewayMacAddress, mac)
+	}
+
+	// An unreachable gateway can not be resolved.
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	n = &configs.Network{Type: "veth", Name: "eth0", Gateway: "10.0.0.3", PinGateway: true}
+	if err := ctr.Run(func() error { return setupGatewayNeighbors(ctx, n) }); err == nil {
+		t.Error("expected error pinning an unreachable gateway")
+	}
+}
+
+func TestSetupNeighbors(t *testing.T) {
+	ctr := nettest.NewNS(t)
+	ctr.AddVeth(t, "eth0", "peer0", nil)
+	n := &configs.Network{
+		Type: "veth",
+		Name: "eth0",
+		Neighbors: []*configs.Neighbor{
+			{IP: "10.0.0.3", MacAddress: "02:00:00:00:00:03"},
+			{IP: "fd00::3", MacAddress: "02:00:00:00:00:04"},
+		},
+	}
+	ctr.Do(t, func() error {
+		link, err := netlink.LinkByName("eth0")
+		if err != nil {
+			return err
+		}
+		if err := netlink.LinkSetUp(link); err != nil {
+			return err
+		}
+		if err := setupNeighbors(n); err != nil {
+			return err
+		}
+		neighs, err := netlink.NeighList(link.Attrs().Index, netlink.FAMILY_ALL)
+		if err != nil {
+			return err
+		}
+		found := map[string]string{}
+		for _, neigh := range neighs {
+			if neigh.State == netlink.NUD_STALE {
+				found[neigh.IP.String()] = neigh.HardwareAddr.String()
+			}
+		}
+		if found["10.0.0.3"] != "02:00:00:00:00:03" || found["fd00::3"] != "02:00:00:00:00:04" {
+			t.Errorf("expected stale entries for the neighbors, got %v", found)
+		}
+		return nil
+	})
+}
+
+func TestAddAddressConflict(t *testing.T) {
+	ctr := nettest.NewNS(t)
+	ctr.AddVeth(t, "eth0", "peer0", nil)
+	ctr.Do(t, func() error {
+		link, err := netlink.LinkByName("eth0")
+		if err != nil {
+			return err
+		}
+		addr, err := netlink.ParseAddr("192.0.2.2/24")
+		if err != nil {
+			return err
+		}
+		if err := addAddress(link, addr, ""); err != nil {
+			return err
+		}
+		if err := addAddress(link, addr, configs.AddressConflictFail); !errors.Is(err, unix.EEXIST) {
+			t.Errorf("expected EEXIST with the fail policy, got %v", err)
+		}
+		if err := addAddress(link, addr, configs.AddressConflictIgnore); err != nil {
+			t.Errorf("unexpected error with the ignore policy: %v", err)
+		}
+		if err := addAddress(link, addr, configs.AddressConflictReplace); err != nil {
+			t.Errorf("unexpected error with the replace policy: %v", err)
+		}
+		return nil
+	})
+	if addrs := ctr.Addrs(t, "eth0"); len(addrs) != 1 || addrs[0] != "192.0.2.2/24" {
+		t.Errorf("expected a single address, got %v", addrs)
+	}
+}
+
+func TestConfigureLinkPreUpHook(t *testing.T) {
+	ctr := nettest.NewNS(t)
+	ctr.AddVeth(t, "eth0", "peer0", nil)
+	input := filepath.Join(t.TempDir(), "input")
+	n := &configs.Network{
+		Name:    "eth0",
+		Address: "192.0.2.2/24",
+		// The address is added again by the second setup.
+		AddressConflict: configs.AddressConflictIgnore,
+		PreUpHook:       &configs.Command{Path: "/bin/false", Args: []string{"false"}},
+	}
+	ctr.Do(t, func() error {
+		link, err := netlink.LinkByName("eth0")
+		if err != nil {
+			return err
+		}
+		if err := configureLink(link, n); err == nil {
+			t.Error("expected error from a failing pre-up hook")
+		}
+		return nil
+	})
+	if ctr.Link(t, "eth0").Attrs().Flags&net.FlagUp != 0 {
+		t.Error("expected the link to be kept down after a failing pre-up hook")
+	}
+
+	n.PreUpHook = &configs.Command{Path: "/bin/sh", Args: []string{"sh", "-c", "cat > " + input}}
+	ctr.Do(t, func() error {
+		link, err := netlink.LinkByName("eth0")
+		if err != nil {
+			return err
+		}
+		return configureLink(link, n)
+	})
+	data, err := os.ReadFile(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got configs.Network
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "eth0" || got.Address != "192.0.2.2/24" {
+		t.Errorf("unexpected hook input: %s", data)
+	}
+	if ctr.Link(t, "eth0").Attrs().Flags&net.FlagUp == 0 {
+		t.Error("expected the link to be up")
+	}
+}
+
+func TestFlushInterface(t *testing.T) {
+	ctr := nettest.NewNS(t)
+	ctr.AddVeth(t, "eth0", "peer0", nil)
+	n := &configs.Network{Name: "eth0", Address: "192.0.2.2/24", Gateway: "192.0.2.1", IPv6Address: "2001:db8::2/64"}
+	ctr.Do(t, func() error {
+		link, err := netlink.LinkByName("eth0")
+		if err != nil {
+			return err
+		}
+		if err := configureLink(link, n); err != nil {
+			return err
+		}
+		if err := flushInterface("eth0"); err != nil {
+			return err
+		}
+		routes, err := netlink.RouteList(link, netlink.FAMILY_V4)
+		if err != nil {
+			return err
+		}
+		if len(routes) != 0 {
+			t.Errorf("expected no IPv4 routes, got %+v", routes)
+		}
+		return nil
+	})
+	for _, a := range ctr.Addrs(t, "eth0") {
+		if !strings.HasPrefix(a, "fe80:") {
+			t.Errorf("expected only link-local addresses, got %s", a)
+		}
+	}
+}
+
+// releasingStrategy records the networks released, and fails to release
+// those listed in fail.
+type releasingStrategy struct {
+	loopback
+	released []string
+	fail     map[string]bool
+}
+
+func (r *releasingStrategy) release(n *network) error {
+	r.released = append(r.released, n.Name)
+	if r.fail[n.Name] {
+		return errors.New("device busy")
+	}
+	return nil
+}
+
+func TestTeardownNetworks(t *testing.T) {
+	r := &releasingStrategy{fail: map[string]bool{"eth1": true}}
+	strategies["releasing"] = r
+	t.Cleanup(func() { delete(strategies, "releasing") })
+	c := &Container{
+		stateDir: t.TempDir(),
+		config: &configs.Config{Networks: []*configs.Network{
+			{Type: "releasing", Name: "eth0"},
+			{Type: "releasing", Name: "eth1"},
+			{Type: "loopback"},
+			{Type: "releasing", Name: "eth2"},
+		}},
+	}
+	err := teardownNetworks(c)
+	var report *networkTeardownError
+	if !errors.As(err, &report) {
+		t.Fatalf("expected a teardown report, got %v", err)
+	}
+	if strings.Join(r.released, ",") != "eth0,eth1,eth2" {
+		t.Errorf("expected all the networks to be released, got %v", r.released)
+	}
+	if strings.Join(report.Released, ",") != "eth0,lo,eth2" || len(report.Failed) != 1 || report.Failed["eth1"] == nil {
+		t.Errorf("unexpected teardown report: %+v", report)
+	}
+
+	r.fail = nil
+	if err := teardownNetworks(c); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestRunNetworkSetupDeadline(t *testing.T) {
+	// A setup ignoring its context is abandoned at the deadline.
+	block := make(chan struct{})
+	defer close(block)
+	start := time.Now()
+	err := runNetworkSetup(context.Background(), start.Add(100*time.Millisecond), func(context.Context) error {
+		<-block
+		return nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the deadline to be exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("the setup was not abandoned at the deadline, took %s", elapsed)
+	}
+
+	expected := errors.New("setup failed")
+	err = runNetworkSetup(context.Background(), time.Now().Add(time.Minute), func(context.Context) error { return expected })
+	if err != expected {
+		t.Errorf("expected the setup error, got %v", err)
+	}
+	if err := runNetworkSetup(context.Background(), time.Time{}, func(ctx context.Context) error {
+		if _, ok := ctx.Deadline(); ok {
+			t.Error("unexpected deadline without timeout")
+		}
+		return nil
+	}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGetWirelessDevice(t *testing.T) {
+	ns := nettest.NewNS(t)
+	ns.AddVeth(t, "host0", "peer0", nil)
+	ns.Do(t, func() error {
+		link, err := netlink.LinkByName("host0")
+		if err != nil {
+			return err
+		}
+		dev, err := getWirelessDevice(link.Attrs().Index)
+		if err != nil {
+			return err
+		}
+		if dev != nil {
+			t.Errorf("expected host0 not to be a wireless interface, got %+v", dev)
+		}
+		return nil
+	})
+}
+
+func TestConfigureLink(t *testing.T) {
+	ctr := nettest.NewNS(t)
+	ctr.AddVeth(t, "eth0", "peer0", nil)
+	n := &configs.Network{
+		Name:        "eth0",
+		Mtu:         1400,
+		TxQueueLen:  100,
+		Address:     "192.0.2.2/24",
+		Gateway:     "192.0.2.1",
+		IPv6Address: "2001:db8::2/64",
+	}
+	ctr.Do(t, func() error {
+		link, err := netlink.LinkByName("eth0")
+		if err != nil {
+			return err
+		}
+		if err := configureLink(link, n); err != nil {
+			return err
+		}
+		routes, err := netlink.RouteList(link, netlink.FAMILY_V4)
+		if err != nil {
+			return err
+		}
+		for _, r := range routes {
+			if r.Dst == nil && r.Gw.Equal(net.ParseIP("192.0.2.1")) {
+				return nil
+			}
+		}
+		t.Errorf("expected a default route through the gateway, got %+v", routes)
+		return nil
+	})
+	link := ctr.Link(t, "eth0")
+	if attrs := link.Attrs(); attrs.MTU != 1400 || attrs.TxQLen != 100 || attrs.Flags&net.FlagUp == 0 {
+		t.Errorf("unexpected link attributes: mtu %d, txqlen %d, flags %v", attrs.MTU, attrs.TxQLen, attrs.Flags)
+	}
+	addrs := ctr.Addrs(t, "eth0")
+	found := 0
+	for _, a := range addrs {
+		if a == "192.0.2.2/24" || a == "2001:db8::2/64" {
+			found++
+		}
+	}
+	if found != 2 {
+		t.Errorf("expected both addresses, got %+v", addrs)
+	}
+}
+
+func TestConfigureLinkGatewayMetric(t *testing.T) {
+	ctr := nettest.NewNS(t)
+	ctr.AddVeth(t, "eth0", "peer0", nil)
+	ctr.AddVeth(t, "eth1", "peer1", nil)
+	networks := []*configs.Network{
+		{Name: "eth0", Address: "192.0.2.2/24", Gateway: "192.0.2.1", GatewayMetric: 100},
+		{Name: "eth1", Address: "198.51.100.2/24", Gateway: "198.51.100.1", GatewayMetric: 200},
+	}
+	ctr.Do(t, func() error {
+		for _, n := range networks {
+			link, err := netlink.LinkByName(n.Name)
+			if err != nil {
+				return err
+			}
+			if err := configureLink(link, n); err != nil {
+				return err
+			}
+		}
+		routes, err := netlink.RouteList(nil, netlink.FAMILY_V4)
+		if err != nil {
+			return err
+		}
+		metrics := map[string]int{}
+		for _, r := range routes {
+			if r.Dst == nil {
+				metrics[r.Gw.String()] = r.Priority
+			}
+		}
+		if metrics["192.0.2.1"] != 100 || metrics["198.51.100.1"] != 200 || len(metrics) != 2 {
+			t.Errorf("expected a default route through each gateway with its metric, got %v", metrics)
+		}
+		return nil
+	})
+}
+
+func TestAssignInterfaceNames(t *testing.T) {
+	networks := []*configs.Network{
+		{Type: "loopback"},
+		{Type: "veth", HostInterfaceName: "veth0"},
+		{Type: "veth", HostInterfaceName: "veth1"},
+		{Type: "veth", Name: "eth0", HostInterfaceName: "veth2"},
+		{Type: "vcan"},
+	}
+	named := assignInterfaceNames("ctr1", networks)
+	if networks[1].Name != "" {
+		t.Errorf("expected the given networks to be left untouched, got %q", networks[1].Name)
+	}
+	networks = named
+	if networks[0].Name != "" || networks[3].Name != "eth0" || networks[4].Name != "" {
+		t.Errorf("expected only the networks with a host interface and no name to be named, got %q, %q and %q",
+			networks[0].Name, networks[3].Name, networks[4].Name)
+	}
+	first, second := networks[1].Name, networks[2].Name
+	if first == "" || first == second {
+		t.Errorf("expected distinct names, got %q and %q", first, second)
+	}
+	if len(first) >= unix.IFNAMSIZ {
+		t.Errorf("generated name %q is too long", first)
+	}
+	if name := hashedInterfaceName("ctr1", "veth0"); name != first {
+		t.Errorf("expected the same name for the same container, got %q and %q", first, name)
+	}
+	if name := hashedInterfaceName("ctr2", "veth0"); name == first {
+		t.Errorf("expected a different name for another container, got %q", name)
+	}
+}
+
+func TestOptionalNetworks(t *testing.T) {
+	host := nettest.NewNS(t)
+	ctr := nettest.NewNS(t)
+	nsFd := ctr.Fd(t)
+	n := &configs.Network{Type: "vxlan", Name: "vxlan0", Parent: "missing0", VXLAN: &configs.VXLANSettings{VNI: 42}}
+	err := host.Run(func() error { return createVxlan(n, nsFd) })
+	if !unavailableDevice(err) {
+		t.Errorf("expected a missing parent to be an unavailable device, got %v", err)
+	}
+	if !unavailableDevice(fmt.Errorf("open: %w", unix.EBUSY)) || unavailableDevice(unix.EINVAL) {
+		t.Error("expected only busy devices to be unavailable")
+	}
+
+	config := &configs.Config{
+		Networks: []*configs.Network{{Type: "loopback"}, n, {Type: "veth", Name: "eth0"}},
+		Routes: []*configs.Route{
+			{Destination: "10.1.0.0/16", InterfaceName: "vxlan0"},
+			{Destination: "10.2.0.0/16", InterfaceName: "eth0"},
+		},
+	}
+	removed, routes := removeNetworks(config, map[*configs.Network]bool{n: true})
+	if len(removed) != 1 || removed[0] != n {
+		t.Errorf("expected the vxlan network to be returned, got %+v", removed)
+	}
+	if len(routes) != 1 || routes[0].InterfaceName != "vxlan0" {
+		t.Errorf("expected the routes through vxlan0 to be returned, got %+v", routes)
+	}
+	if len(config.Networks) != 2 || config.Networks[1].Name != "eth0" {
+		t.Errorf("expected the vxlan network to be removed, got %+v", config.Networks)
+	}
+	if len(config.Routes) != 1 || config.Routes[0].InterfaceName != "eth0" {
+		t.Errorf("expected the routes through vxlan0 to be removed, got %+v", config.Routes)
+	}
+}
+
+type recordingStrategy struct {
+	calls []string
+}
+
+func (r *recordingStrategy) Create(ctx context.Context, n *configs.Network, nspid int) error {
+	r.calls = append(r.calls, fmt.Sprintf("create %s %d", n.Name, nspid))
+	n.Name = "renamed0"
+	return nil
+}
+
+func (r *recordingStrategy) Initialize(ctx context.Context, n *configs.Network) error {
+	r.calls = append(r.calls, "initialize "+n.Name)
+	return nil
+}
+
+func (r *recordingStrategy) Detach(n *configs.Network) error {
+	r.calls = append(r.calls, "detach "+n.Name)
+	return nil
+}
+
+func (r *recordingStrategy) Attach(n *configs.Network) error {
+	r.calls = append(r.calls, "attach "+n.Name)
+	return nil
+}
+
+func (r *recordingStrategy) Release(n *configs.Network) error {
+	r.calls = append(r.calls, "release "+n.Name)
+	return nil
+}
+
+func TestRegisterNetworkStrategy(t *testing.T) {
+	r := &recordingStrategy{}
+	RegisterNetworkStrategy("custom", r)
+	t.Cleanup(func() { delete(strategies, "custom") })
+
+	found := false
+	for _, tpe := range KnownNetworkTypes() {
+		found = found || tpe == "custom"
+	}
+	if !found {
+		t.Errorf("expected the registered type in %v", KnownNetworkTypes())
+	}
+	strategy, err := getStrategy("custom")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := &network{Network: configs.Network{Type: "custom", Name: "eth0"}}
+	if err := strategy.create(context.Background(), n, 42); err != nil {
+		t.Fatal(err)
+	}
+	if err := strategy.initialize(context.Background(), n); err != nil {
+		t.Fatal(err)
+	}
+	if err := strategy.detach(&n.Network); err != nil {
+		t.Fatal(err)
+	}
+	if err := strategy.attach(&n.Network); err != nil {
+		t.Fatal(err)
+	}
+	if err := strategy.(networkReleaser).release(n); err != nil {
+		t.Fatal(err)
+	}
+	expected := "create eth0 42,initialize renamed0,detach renamed0,attach renamed0,release renamed0"
+	if calls := strings.Join(r.calls, ","); calls != expected {
+		t.Errorf("expected calls %q, got %q", expected, calls)
+	}
+
+	for _, name := range []string{"custom", "veth", ""} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected registering %q to panic", name)
+				}
+			}()
+			RegisterNetworkStrategy(name, &recordingStrategy{})
+		}()
+	}
+}
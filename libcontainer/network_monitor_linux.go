@@ -0,0 +1,102 @@
+package libcontainer
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// networkMonitorPrefix prefixes the name of the files of the container state
+// directory holding the status of the network monitors, followed by the
+// name of the monitor.
+const networkMonitorPrefix = "network-monitor-"
+
+// NetworkMonitorStatus is the status of a monitor of the container network,
+// run by the runc process in the foreground of the container, such as the
+// sampling of its stats history or its LLDP announcements.
+type NetworkMonitorStatus struct {
+	// Name is the name of the monitor, "stats_history", "lldp" or "quota".
+	Name string `json:"name"`
+	// Suspended is set while the container is paused, the monitor then not
+	// probing the container network, so a frozen container does not look
+	// like a broken link.
+	Suspended bool `json:"suspended"`
+	// Since is the time the monitor was started, suspended or resumed.
+	Since time.Time `json:"since"`
+}
+
+// networkMonitor tracks the status of a monitor of the network of c, and
+// records it in the container state directory when it changes.
+type networkMonitor struct {
+	c        *Container
+	status   NetworkMonitorStatus
+	recorded bool
+}
+
+func newNetworkMonitor(c *Container, name string) *networkMonitor {
+	return &networkMonitor{c: c, status: NetworkMonitorStatus{Name: name}}
+}
+
+// check returns the path of the container network namespace, and whether
+// the probes of the monitor are suspended as the container is paused. An
+// error wrapping ErrNotRunning is returned once the container has stopped.
+func (m *networkMonitor) check() (string, bool, error) {
+	m.c.m.Lock()
+	nsPath, err := m.c.netNSPath()
+	paused := err == nil && m.c.state.status() == Paused
+	m.c.m.Unlock()
+	if err != nil {
+		return "", false, err
+	}
+	if !m.recorded || paused != m.status.Suspended {
+		m.status.Suspended = paused
+		m.status.Since = time.Now()
+		if err := m.c.writeStateFile(m.filename(), &m.status); err != nil {
+			return "", false, err
+		}
+		m.recorded = true
+	}
+	return nsPath, paused, nil
+}
+
+// stop removes the status of the monitor from the container state
+// directory.
+func (m *networkMonitor) stop() {
+	if m.recorded {
+		_ = os.Remove(filepath.Join(m.c.stateDir, m.filename()))
+	}
+}
+
+func (m *networkMonitor) filename() string {
+	return networkMonitorPrefix + m.status.Name + ".json"
+}
+
+// NetworkMonitors returns the status of the network monitors running for the
+// container, sorted by name.
+func (c *Container) NetworkMonitors() ([]NetworkMonitorStatus, error) {
+	files, err := filepath.Glob(filepath.Join(c.stateDir, networkMonitorPrefix+"*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var monitors []NetworkMonitorStatus
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			// The monitor stopped in the meantime.
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		var s NetworkMonitorStatus
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, err
+		}
+		monitors = append(monitors, s)
+	}
+	sort.Slice(monitors, func(i, j int) bool { return monitors[i].Name < monitors[j].Name })
+	return monitors, nil
+}
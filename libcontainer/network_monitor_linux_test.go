@@ -0,0 +1,67 @@
+package libcontainer
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/runc/internal/testutil/nettest"
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+func TestNetworkMonitor(t *testing.T) {
+	ctr := nettest.NewNS(t)
+	c := runningNetNSContainer(t, "myid", ctr)
+	m := newNetworkMonitor(c, "lldp")
+	if _, suspended, err := m.check(); err != nil || suspended {
+		t.Fatalf("expected the monitor to run, got suspended %v, error %v", suspended, err)
+	}
+	monitors, err := c.NetworkMonitors()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(monitors) != 1 || monitors[0].Name != "lldp" || monitors[0].Suspended {
+		t.Fatalf("expected the lldp monitor to be running, got %+v", monitors)
+	}
+
+	if err := c.cgroupManager.Freeze(configs.Frozen); err != nil {
+		t.Fatal(err)
+	}
+	if _, suspended, err := m.check(); err != nil || !suspended {
+		t.Fatalf("expected the monitor to be suspended, got suspended %v, error %v", suspended, err)
+	}
+	monitors, err = c.NetworkMonitors()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(monitors) != 1 || !monitors[0].Suspended || !monitors[0].Since.After(time.Time{}) {
+		t.Fatalf("expected the lldp monitor to be suspended, got %+v", monitors)
+	}
+
+	m.stop()
+	if monitors, err := c.NetworkMonitors(); err != nil || len(monitors) != 0 {
+		t.Errorf("expected no monitor once stopped, got %+v, error %v", monitors, err)
+	}
+}
+
+func TestSampleNetworkStatsPaused(t *testing.T) {
+	ctr := nettest.NewNS(t)
+	c := runningNetNSContainer(t, "myid", ctr)
+	c.config.Networks = []*configs.Network{{Type: "loopback", Name: "lo"}}
+	c.config.NetworkOptions = &configs.NetworkOptions{
+		StatsHistory: &configs.StatsHistory{Interval: time.Hour, Samples: 10},
+	}
+	if err := c.cgroupManager.Freeze(configs.Frozen); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := c.SampleNetworkStats(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.NetworkStatsHistory(); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected no sample while the container is paused, got %v", err)
+	}
+}
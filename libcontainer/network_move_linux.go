@@ -0,0 +1,204 @@
+package libcontainer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/opencontainers/runc/libcontainer/configs/validate"
+	"github.com/vishvananda/netlink"
+)
+
+// NetworkMove holds the settings rewritten when a network is moved from a
+// container to another. Empty fields keep the settings of the network.
+type NetworkMove struct {
+	// Name is the name of the interface in the destination container.
+	Name string `json:"name,omitempty"`
+	// Address is the IPv4 address and mask of the interface.
+	Address string `json:"address,omitempty"`
+	// Gateway is the IPv4 gateway of the interface.
+	Gateway string `json:"gateway,omitempty"`
+	// IPv6Address is the IPv6 address and mask of the interface.
+	IPv6Address string `json:"ipv6_address,omitempty"`
+	// IPv6Gateway is the IPv6 gateway of the interface.
+	IPv6Gateway string `json:"ipv6_gateway,omitempty"`
+}
+
+// MoveNetwork moves the network whose interface is named name from the
+// running container srcID to the running container dstID, whose states are
+// found in root, without going through the host network namespace. The
+// interface loses its addresses and routes in the move, and is set up again
+// in the destination container with the settings of the network, rewritten
+// with m if it is not nil. The network locks of both containers are held, in
+// the order of their ids so that concurrent moves can not deadlock.
+//
+// Networks holding host resources claimed for the source container, such as
+// SR-IOV virtual functions, can not be moved.
+func MoveNetwork(ctx context.Context, root, srcID, dstID, name string, m *NetworkMove) error {
+	if srcID == dstID {
+		return errors.New("source and destination containers are the same")
+	}
+	firstID, secondID := srcID, dstID
+	if secondID < firstID {
+		firstID, secondID = secondID, firstID
+	}
+	return withNetworkLock(root, firstID, func(first *Container) error {
+		return withNetworkLock(root, secondID, func(second *Container) error {
+			if first.id == srcID {
+				return moveNetwork(ctx, first, second, name, m)
+			}
+			return moveNetwork(ctx, second, first, name, m)
+		})
+	})
+}
+
+// moveNetwork moves the network whose interface is named name from src to
+// dst, and updates the state of both containers. If the interface can not be
+// set up in dst, it is moved back to src.
+func moveNetwork(ctx context.Context, src, dst *Container, name string, m *NetworkMove) error {
+	i := -1
+	for j, n := range src.config.Networks {
+		if containerInterfaceName(n) == name {
+			i = j
+			break
+		}
+	}
+	if i == -1 {
+		return fmt.Errorf("network %q is not attached", name)
+	}
+	n := *src.config.Networks[i]
+	if n.Type == "loopback" {
+		return errors.New("loopback networks can not be moved")
+	}
+	strategy, err := getStrategy(n.Type)
+	if err != nil {
+		return err
+	}
+	if _, ok := strategy.(networkReleaser); ok {
+		return fmt.Errorf("%s networks hold host resources and can not be moved", n.Type)
+	}
+	if _, ok := strategy.(*macvtap); ok {
+		return errors.New("macvtap networks have a device node in the container and can not be moved")
+	}
+	if m != nil {
+		m.apply(&n)
+	}
+	dstName := containerInterfaceName(&n)
+	for _, existing := range dst.config.Networks {
+		if containerInterfaceName(existing) == dstName {
+			return fmt.Errorf("network %q is already attached to container %s", dstName, dst.id)
+		}
+	}
+	config := *dst.config
+	config.Networks = append(config.Networks[:len(config.Networks):len(config.Networks)], &n)
+	if err := validate.Validate(&config); err != nil {
+		return fmt.Errorf("invalid network %q: %w", dstName, err)
+	}
+
+	srcPath, err := src.netNSPath()
+	if err != nil {
+		return err
+	}
+	dstPath, err := dst.netNSPath()
+	if err != nil {
+		return err
+	}
+	// Keep both namespaces open for the whole move, so the interface can
+	// still be moved back if the destination container exits meanwhile.
+	srcNS, err := os.Open(srcPath)
+	if err != nil {
+		return src.namespaceError(err)
+	}
+	defer srcNS.Close()
+	dstNS, err := os.Open(dstPath)
+	if err != nil {
+		return dst.namespaceError(err)
+	}
+	defer dstNS.Close()
+	srcPath = "/proc/self/fd/" + strconv.Itoa(int(srcNS.Fd()))
+	dstPath = "/proc/self/fd/" + strconv.Itoa(int(dstNS.Fd()))
+
+	if err := moveInterface(srcPath, int(dstNS.Fd()), name, &linkMove{name: dstName, down: n.DownBeforeMove}); err != nil {
+		return src.namespaceError(err)
+	}
+	nw := &network{Network: n, stateDir: dst.stateDir}
+	var skipped []SkippedNetworkSetting
+	err = doInNetNSWithCaps(dstPath, networkCapabilities(&n), func() (err error) {
+		skipped, err = initializeNetwork(ctx, strategy, nw)
+		return err
+	})
+	if err == nil {
+		// The counters of the interface do not survive the move.
+		err = prepareNetworkStats(dst.config.NetworkOptions, dstPath, []*configs.Network{&n})
+	}
+	if err != nil {
+		err = dst.namespaceError(err)
+		if rerr := moveInterface(dstPath, int(srcNS.Fd()), dstName, &linkMove{name: name, down: n.DownBeforeMove}); rerr != nil {
+			return fmt.Errorf("%w (unable to move %s back: %w)", err, dstName, rerr)
+		}
+		back := &network{Network: *src.config.Networks[i], stateDir: src.stateDir}
+		_ = doInNetNSWithCaps(srcPath, networkCapabilities(&back.Network), func() error {
+			_, err := initializeNetwork(ctx, strategy, back)
+			return err
+		})
+		_ = prepareNetworkStats(src.config.NetworkOptions, srcPath, []*configs.Network{&back.Network})
+		return err
+	}
+
+	networks := make([]*configs.Network, 0, len(src.config.Networks)-1)
+	networks = append(networks, src.config.Networks[:i]...)
+	src.config.Networks = append(networks, src.config.Networks[i+1:]...)
+	kept := src.skippedNetwork[:0]
+	for _, s := range src.skippedNetwork {
+		if s.Interface != name {
+			kept = append(kept, s)
+		}
+	}
+	src.skippedNetwork = kept
+	dst.config.Networks = config.Networks
+	dst.skippedNetwork = append(dst.skippedNetwork, skipped...)
+	for _, c := range []*Container{src, dst} {
+		state, err := c.currentState()
+		if err != nil {
+			return err
+		}
+		if err := c.saveState(state); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apply rewrites the settings of n.
+func (m *NetworkMove) apply(n *configs.Network) {
+	if m.Name != "" {
+		n.Name = m.Name
+	}
+	if m.Address != "" {
+		n.Address = m.Address
+	}
+	if m.Gateway != "" {
+		n.Gateway = m.Gateway
+	}
+	if m.IPv6Address != "" {
+		n.IPv6Address = m.IPv6Address
+	}
+	if m.IPv6Gateway != "" {
+		n.IPv6Gateway = m.IPv6Gateway
+	}
+}
+
+// moveInterface moves the interface named name from the network namespace at
+// nsPath to the network namespace nsFd, as set by m.
+func moveInterface(nsPath string, nsFd int, name string, m *linkMove) error {
+	return doInNetNS(nsPath, func() error {
+		link, err := netlink.LinkByName(name)
+		if err != nil {
+			return fmt.Errorf("unable to find %s: %w", name, err)
+		}
+		return moveLink(link, nsFd, m)
+	})
+}
@@ -0,0 +1,87 @@
+package libcontainer
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+
+	"github.com/opencontainers/runc/internal/testutil/nettest"
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/opencontainers/runc/libcontainer/system"
+)
+
+// runningNetNSContainer returns a running container whose init process is
+// started in the network namespace ns.
+func runningNetNSContainer(t *testing.T, id string, ns *nettest.NS) *Container {
+	cmd := exec.Command("sleep", "60")
+	ns.Do(t, cmd.Start)
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+	stat, err := system.Stat(cmd.Process.Pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Container{
+		id:       id,
+		stateDir: t.TempDir(),
+		config: &configs.Config{
+			Rootfs:     "/var",
+			Namespaces: []configs.Namespace{{Type: configs.NEWNET}},
+		},
+		initProcess:          &mockProcess{_pid: cmd.Process.Pid, started: stat.StartTime},
+		initProcessStartTime: stat.StartTime,
+		cgroupManager:        &mockCgroupManager{},
+	}
+	c.state = &runningState{c: c}
+	return c
+}
+
+func TestMoveNetwork(t *testing.T) {
+	srcNS := nettest.NewNS(t)
+	dstNS := nettest.NewNS(t)
+	srcNS.AddVeth(t, "eth0", "peer0", nil)
+	src := runningNetNSContainer(t, "src", srcNS)
+	dst := runningNetNSContainer(t, "dst", dstNS)
+	src.config.Networks = []*configs.Network{
+		{Type: "loopback"},
+		{Type: "veth", Name: "eth0", HostInterfaceName: "peer0", Address: "10.0.0.2/24"},
+	}
+	src.skippedNetwork = []SkippedNetworkSetting{{Interface: "eth0", Setting: "net.ipv4.conf.eth0.unsupported"}}
+	dst.config.Networks = []*configs.Network{{Type: "loopback"}}
+
+	ctx := context.Background()
+	if err := moveNetwork(ctx, src, dst, "lo", nil); err == nil {
+		t.Error("expected error moving a loopback network")
+	}
+	if err := moveNetwork(ctx, src, dst, "eth1", nil); err == nil {
+		t.Error("expected error moving a network which is not attached")
+	}
+	if err := moveNetwork(ctx, src, dst, "eth0", &NetworkMove{Address: "10.0.0.300/24"}); err == nil {
+		t.Error("expected error with an invalid address")
+	}
+	if err := moveNetwork(ctx, src, dst, "eth0", &NetworkMove{Name: "net1", Address: "10.0.1.2/24"}); err != nil {
+		t.Fatal(err)
+	}
+	if srcNS.Link(t, "eth0") != nil {
+		t.Error("expected the interface to be moved out of the source container")
+	}
+	if addrs := dstNS.Addrs(t, "net1"); len(addrs) != 1 || addrs[0] != "10.0.1.2/24" {
+		t.Errorf("expected the rewritten address in the destination container, got %v", addrs)
+	}
+
+	state, err := loadState(src.stateDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Config.Networks) != 1 || len(state.SkippedNetworkSettings) != 0 {
+		t.Errorf("expected only the loopback network in the source state, got %+v", state.Config.Networks)
+	}
+	if state, err = loadState(dst.stateDir); err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Config.Networks) != 2 || state.Config.Networks[1].Name != "net1" {
+		t.Errorf("expected the moved network in the destination state, got %+v", state.Config.Networks)
+	}
+}
@@ -0,0 +1,166 @@
+package libcontainer
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+)
+
+// MTUMismatch is a path of the container network where packets larger than
+// the MTU of the next device are sent, and are fragmented or dropped.
+type MTUMismatch struct {
+	// Interface is the name of the interface inside the container.
+	Interface string `json:"interface"`
+	// Route is the destination of the route through Interface whose MTU is
+	// larger than the MTU of the interface, if the mismatch is on a route.
+	Route string `json:"route,omitempty"`
+	// Mtu is the MTU of the interface, or of the route if Route is set.
+	Mtu int `json:"mtu"`
+	// Device is the name of the device with the lower MTU, in the host for
+	// the host side and parent interfaces.
+	Device string `json:"device"`
+	// DeviceMtu is the MTU the device can carry for Interface, after the
+	// encapsulation overhead if any.
+	DeviceMtu int `json:"device_mtu"`
+}
+
+func (m MTUMismatch) String() string {
+	if m.Route != "" {
+		return fmt.Sprintf("route to %s through %s has mtu %d, larger than the mtu %d of %s", m.Route, m.Interface, m.Mtu, m.DeviceMtu, m.Device)
+	}
+	return fmt.Sprintf("interface %s has mtu %d, larger than the mtu %d of %s", m.Interface, m.Mtu, m.DeviceMtu, m.Device)
+}
+
+// vxlanOverhead returns the bytes VXLAN adds to the packets of n, with the
+// outer IP, UDP and VXLAN headers and the inner ethernet header.
+func vxlanOverhead(n *configs.Network) int {
+	if s := n.VXLAN; s != nil {
+		for _, addr := range []string{s.Local, s.Remote} {
+			if ip := net.ParseIP(addr); ip != nil && ip.To4() == nil {
+				return 70
+			}
+		}
+	}
+	return 50
+}
+
+// hostMTU returns the name and the MTU available to n of the host device the
+// packets of its interface go through, if there is one.
+func hostMTU(n *configs.Network) (string, int, error) {
+	name, overhead := n.HostInterfaceName, 0
+	switch n.Type {
+	case "veth":
+	case "macvlan", "macvtap", "ipvlan", "vlan":
+		name = n.Parent
+	case "vxlan":
+		name, overhead = n.Parent, vxlanOverhead(n)
+	default:
+		return "", 0, nil
+	}
+	if name == "" {
+		return "", 0, nil
+	}
+	link, err := netlink.LinkByName(name)
+	if err != nil {
+		var notFound netlink.LinkNotFoundError
+		if errors.As(err, &notFound) {
+			return "", 0, nil
+		}
+		return "", 0, err
+	}
+	return name, link.Attrs().MTU - overhead, nil
+}
+
+// checkNetworkMTU returns the MTU mismatches of the given networks in the
+// network namespace at nsPath: interfaces with a larger MTU than their host
+// side or parent interface, and routes of the container with a larger MTU
+// than the interface they go through.
+func checkNetworkMTU(nsPath string, networks []*configs.Network) ([]MTUMismatch, error) {
+	type lower struct {
+		name string
+		mtu  int
+	}
+	lowers := make(map[string]lower)
+	for _, n := range networks {
+		name, mtu, err := hostMTU(n)
+		if err != nil {
+			return nil, err
+		}
+		if name != "" {
+			lowers[containerInterfaceName(n)] = lower{name: name, mtu: mtu}
+		}
+	}
+
+	var mismatches []MTUMismatch
+	err := doInNetNS(nsPath, func() error {
+		for _, n := range networks {
+			name := containerInterfaceName(n)
+			l, ok := lowers[name]
+			if !ok {
+				continue
+			}
+			link, err := netlink.LinkByName(name)
+			if err != nil {
+				return err
+			}
+			if mtu := link.Attrs().MTU; mtu > l.mtu {
+				mismatches = append(mismatches, MTUMismatch{Interface: name, Mtu: mtu, Device: l.name, DeviceMtu: l.mtu})
+			}
+		}
+		routes, err := netlink.RouteList(nil, netlink.FAMILY_ALL)
+		if err != nil {
+			return err
+		}
+		for _, route := range routes {
+			if route.MTU == 0 || route.LinkIndex == 0 {
+				continue
+			}
+			link, err := netlink.LinkByIndex(route.LinkIndex)
+			if err != nil {
+				return err
+			}
+			attrs := link.Attrs()
+			if route.MTU <= attrs.MTU {
+				continue
+			}
+			dst := "default"
+			if route.Dst != nil {
+				dst = route.Dst.String()
+			}
+			mismatches = append(mismatches, MTUMismatch{Interface: attrs.Name, Route: dst, Mtu: route.MTU, Device: attrs.Name, DeviceMtu: attrs.MTU})
+		}
+		return nil
+	})
+	return mismatches, err
+}
+
+// CheckNetworkMTU returns the paths of the network of the running container
+// where packets are fragmented or dropped because the MTU of a device is
+// lower than the MTU of the interface or route sending them.
+func (c *Container) CheckNetworkMTU() ([]MTUMismatch, error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	nsPath, err := c.netNSPath()
+	if err != nil {
+		return nil, err
+	}
+	return checkNetworkMTU(nsPath, c.config.Networks)
+}
+
+// warnNetworkMTU logs the MTU mismatches of the given networks in the network
+// namespace at nsPath. The check is best effort, and does not fail the
+// network setup.
+func warnNetworkMTU(nsPath string, networks []*configs.Network) {
+	mismatches, err := checkNetworkMTU(nsPath, networks)
+	if err != nil {
+		logrus.Warnf("unable to check the network mtu: %v", err)
+		return
+	}
+	for _, m := range mismatches {
+		logrus.Warnf("network mtu mismatch: %s", m)
+	}
+}
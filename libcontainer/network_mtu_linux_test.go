@@ -0,0 +1,86 @@
+package libcontainer
+
+import (
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/opencontainers/runc/internal/testutil/nettest"
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+)
+
+func TestCheckNetworkMTU(t *testing.T) {
+	host := nettest.NewNS(t)
+	ctr := nettest.NewNS(t)
+	host.AddVeth(t, "rveth0", "eth0", ctr)
+	networks := []*configs.Network{
+		{Type: "loopback"},
+		{Type: "veth", Name: "eth0", HostInterfaceName: "rveth0"},
+	}
+	check := func() []MTUMismatch {
+		var mismatches []MTUMismatch
+		host.Do(t, func() error {
+			var err error
+			mismatches, err = checkNetworkMTU(ctr.Path, networks)
+			return err
+		})
+		return mismatches
+	}
+	if mismatches := check(); len(mismatches) != 0 {
+		t.Fatalf("expected no mismatch, got %v", mismatches)
+	}
+
+	host.Do(t, func() error {
+		return netlink.LinkSetMTU(host.Link(t, "rveth0"), 1400)
+	})
+	ctr.Do(t, func() error {
+		link := ctr.Link(t, "eth0")
+		if err := netlink.LinkSetUp(link); err != nil {
+			return err
+		}
+		return netlink.RouteAdd(&netlink.Route{
+			LinkIndex: link.Attrs().Index,
+			Scope:     netlink.SCOPE_LINK,
+			Dst:       &net.IPNet{IP: net.IPv4(10, 0, 0, 0), Mask: net.CIDRMask(24, 32)},
+			MTU:       9000,
+		})
+	})
+	expected := []MTUMismatch{
+		{Interface: "eth0", Mtu: 1500, Device: "rveth0", DeviceMtu: 1400},
+		{Interface: "eth0", Route: "10.0.0.0/24", Mtu: 9000, Device: "eth0", DeviceMtu: 1500},
+	}
+	if mismatches := check(); !reflect.DeepEqual(mismatches, expected) {
+		t.Errorf("expected %v, got %v", expected, mismatches)
+	}
+}
+
+func TestCheckNetworkMTUVxlan(t *testing.T) {
+	host := nettest.NewNS(t)
+	ctr := nettest.NewNS(t)
+	peer := nettest.NewNS(t)
+	host.AddVeth(t, "underlay0", "eth0", peer)
+	n := &configs.Network{
+		Type:   "vxlan",
+		Name:   "vxlan0",
+		Parent: "underlay0",
+		VXLAN:  &configs.VXLANSettings{VNI: 42, Remote: "192.0.2.1"},
+	}
+	nsFd := ctr.Fd(t)
+	host.Do(t, func() error { return createVxlan(n, nsFd) })
+
+	// The MTU of the interface is not lowered with the MTU of its parent.
+	host.Do(t, func() error {
+		return netlink.LinkSetMTU(host.Link(t, "underlay0"), 1400)
+	})
+	var mismatches []MTUMismatch
+	host.Do(t, func() error {
+		var err error
+		mismatches, err = checkNetworkMTU(ctr.Path, []*configs.Network{n})
+		return err
+	})
+	expected := []MTUMismatch{{Interface: "vxlan0", Mtu: 1450, Device: "underlay0", DeviceMtu: 1350}}
+	if !reflect.DeepEqual(mismatches, expected) {
+		t.Errorf("expected %v, got %v", expected, mismatches)
+	}
+}
@@ -0,0 +1,425 @@
+package libcontainer
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"os/exec"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/runc/internal/testutil/nettest"
+	"github.com/opencontainers/runc/libcontainer/configs"
+	statsv1 "github.com/opencontainers/runc/types/stats/v1"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// TestNetworkSetup exercises the network setup end to end, using a
+// throwaway namespace standing in for the host and another one for the
+// container.
+func TestNetworkSetup(t *testing.T) {
+	host := nettest.NewNS(t)
+	ctr := nettest.NewNS(t)
+	host.AddVeth(t, "host0", "eth0", ctr)
+
+	loose := 2
+	config := &configs.Config{
+		Namespaces:     configs.Namespaces([]configs.Namespace{{Type: configs.NEWNET}}),
+		NetworkOptions: &configs.NetworkOptions{RPFilter: &loose},
+		Networks: []*configs.Network{
+			{Type: "loopback", RPFilter: &loose},
+		},
+	}
+	// Host side settings are not tied to a strategy, exercise them on the
+	// host end of the veth pair.
+	hostSide := &configs.Network{
+		Type:              "veth",
+		Name:              "eth0",
+		HostInterfaceName: "host0",
+		HostShaping:       &configs.Shaping{EgressRate: 1 << 20, EgressBurst: 1 << 16},
+	}
+
+	host.Do(t, func() error {
+		_, err := setupHostInterface(context.Background(), hostSide)
+		return err
+	})
+	if host.Link(t, hostIfbName("host0")) == nil {
+		t.Fatal("expected the ifb device to be created in the host")
+	}
+
+	initConfig := &initConfig{Config: config}
+	for _, n := range config.Networks {
+		initConfig.Networks = append(initConfig.Networks, &network{Network: *n})
+	}
+	ctr.Do(t, func() error {
+		_, err := setupNetwork(context.Background(), initConfig)
+		return err
+	})
+	if got := readNetworkSysctl(t, ctr.Path, "/proc/sys/net/ipv4/conf/all/rp_filter"); got != "2" {
+		t.Errorf("expected namespace rp_filter 2, got %s", got)
+	}
+
+	status, err := networkStatus(ctr.Path, append(config.Networks, hostSide))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status) != 2 || !status[0].Up || status[1].Error != "" || status[1].Up {
+		t.Errorf("unexpected interfaces status: %+v", status)
+	}
+
+	host.Do(t, func() error { return teardownHostInterface(hostSide) })
+	if host.Link(t, hostIfbName("host0")) != nil {
+		t.Error("expected the ifb device to be removed from the host")
+	}
+	// Tearing down twice must not fail.
+	host.Do(t, func() error { return teardownHostShaping(hostSide) })
+}
+
+func TestNetworkProgress(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("joining network namespaces requires root")
+	}
+	var stages []NetworkStage
+	p := &initProcess{
+		// The loopback interface of the test network namespace is used.
+		cmd:       &exec.Cmd{Process: &os.Process{Pid: os.Getpid()}},
+		container: &Container{},
+		config: &initConfig{Config: &configs.Config{
+			Networks: []*configs.Network{{Type: "loopback"}},
+		}},
+		process: &Process{NetworkProgress: func(p NetworkProgress) {
+			if p.Err != nil {
+				t.Errorf("stage %s: unexpected error: %v", p.Stage, p.Err)
+			}
+			if p.Stage == NetworkUp && (p.Status == nil || !p.Status.Up) {
+				t.Errorf("expected the interface to be up, got %+v", p.Status)
+			}
+			stages = append(stages, p.Stage)
+		}},
+	}
+	if err := p.createNetworkInterfaces(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.notifyNetworkReady(); err != nil {
+		t.Fatal(err)
+	}
+	want := []NetworkStage{NetworkCreated, NetworkConfigured, NetworkUp}
+	if !reflect.DeepEqual(stages, want) {
+		t.Errorf("expected stages %v, got %v", want, stages)
+	}
+
+	p.config.Config.Networks = []*configs.Network{{Type: "unknown"}}
+	p.config.Networks = nil
+	stages = nil
+	if err := p.createNetworkInterfaces(); err == nil {
+		t.Fatal("expected error for an unknown network type")
+	}
+	if len(stages) != 0 {
+		t.Errorf("expected no progress for an unknown network type, got %v", stages)
+	}
+}
+
+func TestProcessExitContext(t *testing.T) {
+	cmd := exec.Command("sleep", "60")
+	if err := cmd.Start(); err != nil {
+		t.Skip(err)
+	}
+	ctx, cancel := processExitContext(cmd.Process.Pid)
+	defer cancel()
+	select {
+	case <-ctx.Done():
+		t.Fatal("context cancelled while the process is running")
+	case <-time.After(50 * time.Millisecond):
+	}
+	_ = cmd.Process.Kill()
+	_ = cmd.Wait()
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("context not cancelled after the process exited")
+	}
+
+	ctx, cancel = processExitContext(os.Getpid())
+	cancel()
+	<-ctx.Done()
+}
+
+// BenchmarkSetupNetwork measures the container side setup, cycling through a
+// few namespaces. The setup is on the start path of every container with a
+// private network namespace, and is expected to stay well below a
+// millisecond per container.
+func BenchmarkSetupNetwork(b *testing.B) {
+	const count = 8
+	loose := 2
+	config := &configs.Config{
+		NetworkOptions: &configs.NetworkOptions{RPFilter: &loose},
+		Networks:       []*configs.Network{{Type: "loopback"}},
+	}
+	initConfig := &initConfig{Config: config, Networks: []*network{{Network: *config.Networks[0]}}}
+	namespaces := make([]*nettest.NS, count)
+	for i := range namespaces {
+		namespaces[i] = nettest.NewNS(b)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		namespaces[i%count].Do(b, func() error {
+			_, err := setupNetwork(context.Background(), initConfig)
+			return err
+		})
+	}
+}
+
+// BenchmarkHostInterface measures the lifetime of a host side interface,
+// from its creation to its removal, with shaping in both directions. This
+// runs for every interface of every container, and a full cycle is expected
+// to stay below 50ms, most of which is spent by the kernel creating and
+// removing the devices.
+func BenchmarkHostInterface(b *testing.B) {
+	host := nettest.NewNS(b)
+	n := &configs.Network{
+		Type:              "veth",
+		HostInterfaceName: "host0",
+		HostShaping: &configs.Shaping{
+			IngressRate: 1 << 20, IngressBurst: 1 << 16,
+			EgressRate: 1 << 20, EgressBurst: 1 << 16,
+		},
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		host.Do(b, func() error {
+			veth := &netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: "host0"}, PeerName: "peer0"}
+			if err := netlink.LinkAdd(veth); err != nil {
+				return err
+			}
+			if _, err := setupHostInterface(context.Background(), n); err != nil {
+				return err
+			}
+			if err := teardownHostInterface(n); err != nil {
+				return err
+			}
+			return netlink.LinkDel(veth)
+		})
+	}
+}
+
+func TestNetworkEnv(t *testing.T) {
+	networks := []*network{
+		{Network: configs.Network{Type: "loopback"}},
+		{Network: configs.Network{Type: "sriov", Name: "net1.100", Address: "10.0.0.2/24", IPv6Address: "fd00::2/64"}},
+	}
+	expected := []string{
+		"RUNC_NETDEV_NET1_100_IPV4=10.0.0.2",
+		"RUNC_NETDEV_NET1_100_IPV4_CIDR=10.0.0.2/24",
+		"RUNC_NETDEV_NET1_100_IPV6=fd00::2",
+		"RUNC_NETDEV_NET1_100_IPV6_CIDR=fd00::2/64",
+	}
+	if env := networkEnv(networks); !reflect.DeepEqual(env, expected) {
+		t.Errorf("expected %v, got %v", expected, env)
+	}
+}
+
+// creatingStrategy counts the networks it creates.
+type creatingStrategy struct {
+	loopback
+	created atomic.Int32
+}
+
+func (s *creatingStrategy) create(ctx context.Context, n *network, nspid int) error {
+	s.created.Add(1)
+	return nil
+}
+
+func TestCreateNetworkInterfacesAbandoned(t *testing.T) {
+	s := &creatingStrategy{}
+	strategies["creating"] = s
+	t.Cleanup(func() { delete(strategies, "creating") })
+	resolved := make(chan struct{})
+	p := &initProcess{
+		cmd:       &exec.Cmd{Process: &os.Process{Pid: os.Getpid()}},
+		container: &Container{},
+		config: &initConfig{Config: &configs.Config{
+			Networks:       []*configs.Network{{Type: "creating", Name: "eth0"}},
+			NetworkOptions: &configs.NetworkOptions{SetupTimeout: 50 * time.Millisecond},
+		}},
+		process: &Process{ResolveNetwork: func(ctx context.Context, n *configs.Network) (*ResolvedNetwork, error) {
+			// The context is ignored, so the setup is abandoned while
+			// the network is resolved.
+			defer close(resolved)
+			time.Sleep(200 * time.Millisecond)
+			return &ResolvedNetwork{}, nil
+		}},
+	}
+	if err := p.createNetworkInterfaces(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the deadline to be exceeded, got %v", err)
+	}
+	<-resolved
+	time.Sleep(50 * time.Millisecond)
+	if created := s.created.Load(); created != 0 {
+		t.Errorf("expected no network to be created once the setup is abandoned, got %d", created)
+	}
+}
+
+// slowStrategy creates its networks once released, ignoring the context.
+type slowStrategy struct {
+	loopback
+	release chan struct{}
+	created chan struct{}
+}
+
+func (s *slowStrategy) create(ctx context.Context, n *network, nspid int) error {
+	<-s.release
+	close(s.created)
+	return nil
+}
+
+func TestNetworkProgressAbandoned(t *testing.T) {
+	s := &slowStrategy{release: make(chan struct{}), created: make(chan struct{})}
+	strategies["slow"] = s
+	t.Cleanup(func() { delete(strategies, "slow") })
+	var returned atomic.Bool
+	p := &initProcess{
+		cmd:       &exec.Cmd{Process: &os.Process{Pid: os.Getpid()}},
+		container: &Container{},
+		config: &initConfig{Config: &configs.Config{
+			Networks:       []*configs.Network{{Type: "slow", Name: "eth0"}},
+			NetworkOptions: &configs.NetworkOptions{SetupTimeout: 50 * time.Millisecond},
+		}},
+		process: &Process{NetworkProgress: func(p NetworkProgress) {
+			if returned.Load() {
+				t.Errorf("unexpected progress once the setup was abandoned: %s", p.Stage)
+			}
+		}},
+	}
+	if err := p.createNetworkInterfaces(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the deadline to be exceeded, got %v", err)
+	}
+	returned.Store(true)
+	close(s.release)
+	<-s.created
+	// Leave time for the abandoned setup to report the creation.
+	time.Sleep(50 * time.Millisecond)
+}
+
+// TestNetworkAttachDetach exercises attaching a network to a running
+// container end to end, including the counters of its interface, with a
+// throwaway namespace standing in for the host and another one for the
+// container.
+func TestNetworkAttachDetach(t *testing.T) {
+	host := nettest.NewNS(t)
+	ctr := nettest.NewNS(t)
+	c := runningNetNSContainer(t, "myid", ctr)
+	n := &configs.Network{Type: "veth", Name: "eth0", HostInterfaceName: "veth-myid", Address: "10.0.0.2/24"}
+	host.Do(t, func() error { return c.attachNetwork(context.Background(), n, nil) })
+	if addrs := ctr.Addrs(t, "eth0"); len(addrs) == 0 || addrs[0] != "10.0.0.2/24" {
+		t.Errorf("expected eth0 to have 10.0.0.2/24, got %v", addrs)
+	}
+
+	// Traffic sent from the host is counted by the container interface.
+	host.Do(t, func() error {
+		link, err := netlink.LinkByName("veth-myid")
+		if err != nil {
+			return err
+		}
+		addr, _ := netlink.ParseAddr("10.0.0.1/24")
+		if err := netlink.AddrAdd(link, addr); err != nil {
+			return err
+		}
+		conn, err := net.Dial("udp", "10.0.0.2:9")
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		_, err = conn.Write([]byte("ping"))
+		return err
+	})
+	var stats []*statsv1.NetworkInterface
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		var err error
+		if stats, err = (&netlinkStats{}).interfaceStats(ctr.Path, c.config.Networks); err != nil {
+			t.Fatal(err)
+		}
+		if len(stats) == 1 && stats[0].RxPackets > 0 {
+			break
+		}
+	}
+	if len(stats) != 1 || stats[0].Name != "eth0" || stats[0].RxPackets == 0 {
+		t.Errorf("expected the packets received by eth0 to be counted, got %+v", stats)
+	}
+
+	host.Do(t, func() error { return c.detachNetwork("eth0") })
+	if ctr.Link(t, "eth0") != nil || host.Link(t, "veth-myid") != nil {
+		t.Error("expected the veth pair to be removed")
+	}
+	if len(c.config.Networks) != 0 {
+		t.Errorf("expected no network left, got %+v", c.config.Networks)
+	}
+}
+
+// TestNetworkAttachRenameCollision checks that a network whose interface
+// name is taken in the container is rolled back, leaving the interface of
+// the container alone.
+func TestNetworkAttachRenameCollision(t *testing.T) {
+	host := nettest.NewNS(t)
+	ctr := nettest.NewNS(t)
+	ctr.AddVeth(t, "eth0", "peer0", nil)
+	index := ctr.Link(t, "eth0").Attrs().Index
+	c := runningNetNSContainer(t, "myid", ctr)
+	n := &configs.Network{Type: "veth", Name: "eth0", HostInterfaceName: "veth-myid"}
+	err := host.Run(func() error { return c.attachNetwork(context.Background(), n, nil) })
+	if !errors.Is(err, unix.EEXIST) {
+		t.Fatalf("expected EEXIST attaching a network named after an existing interface, got %v", err)
+	}
+	if host.Link(t, "veth-myid") != nil {
+		t.Error("expected the veth pair to be removed from the host")
+	}
+	if link := ctr.Link(t, "eth0"); link == nil || link.Attrs().Index != index {
+		t.Error("expected the container interface to be left alone")
+	}
+	ctr.Do(t, func() error {
+		links, err := netlink.LinkList()
+		if len(links) != 3 {
+			t.Errorf("expected only lo, eth0 and peer0 in the container, got %d links", len(links))
+		}
+		return err
+	})
+	if len(c.config.Networks) != 0 {
+		t.Errorf("expected no network attached, got %+v", c.config.Networks)
+	}
+}
+
+// TestNetworkAttachRollback checks that a network failing to be set up in
+// the container once created is removed from both sides, so it can be
+// attached again.
+func TestNetworkAttachRollback(t *testing.T) {
+	host := nettest.NewNS(t)
+	ctr := nettest.NewNS(t)
+	c := runningNetNSContainer(t, "myid", ctr)
+	n := &configs.Network{
+		Type:              "veth",
+		Name:              "eth0",
+		HostInterfaceName: "veth-myid",
+		Address:           "10.0.0.2/24",
+		PreUpHook:         &configs.Command{Path: "/bin/false"},
+	}
+	if err := host.Run(func() error { return c.attachNetwork(context.Background(), n, nil) }); err == nil {
+		t.Fatal("expected error attaching a network whose pre-up hook fails")
+	}
+	if host.Link(t, "veth-myid") != nil || ctr.Link(t, "eth0") != nil {
+		t.Error("expected the veth pair to be removed")
+	}
+	if state, err := loadState(c.stateDir); err == nil && len(state.Config.Networks) != 0 {
+		t.Errorf("expected no network in the saved state, got %+v", state.Config.Networks)
+	}
+
+	n.PreUpHook = nil
+	host.Do(t, func() error { return c.attachNetwork(context.Background(), n, nil) })
+	if ctr.Link(t, "eth0") == nil {
+		t.Error("expected eth0 to be attached once fixed")
+	}
+}
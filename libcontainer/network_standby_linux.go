@@ -0,0 +1,106 @@
+package libcontainer
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+)
+
+// ActivateNetwork activates the standby network whose interface is named name
+// in the running container id, whose state is found in root: the interface is
+// brought up and its gateways replace the default routes of the container.
+// The networks whose gateways are all replaced are put on standby, so they
+// can be activated back, but their interfaces are kept up. See AttachNetwork.
+func ActivateNetwork(root, id, name string) error {
+	return withNetworkLock(root, id, func(c *Container) error {
+		return c.activateNetwork(name)
+	})
+}
+
+// activateNetwork activates the standby network whose interface is named
+// name, and updates the container state.
+func (c *Container) activateNetwork(name string) error {
+	nsPath, err := c.netNSPath()
+	if err != nil {
+		return err
+	}
+	var n *configs.Network
+	for _, existing := range c.config.Networks {
+		if containerInterfaceName(existing) == name {
+			n = existing
+			break
+		}
+	}
+	if n == nil {
+		return fmt.Errorf("network %q is not attached", name)
+	}
+	if !n.Standby {
+		return fmt.Errorf("network %q is not on standby", name)
+	}
+	var routes []*configs.Route
+	for _, r := range c.config.Routes {
+		if r.InterfaceName == name {
+			routes = append(routes, r)
+		}
+	}
+	if err := doInNetNS(nsPath, func() error { return activateLink(n, routes) }); err != nil {
+		return c.namespaceError(fmt.Errorf("unable to activate %s: %w", name, err))
+	}
+
+	networks := make([]*configs.Network, 0, len(c.config.Networks))
+	for _, existing := range c.config.Networks {
+		updated := *existing
+		switch {
+		case existing == n:
+			updated.Standby = false
+		case (existing.Gateway != "" || existing.IPv6Gateway != "") &&
+			(existing.Gateway == "" || n.Gateway != "") &&
+			(existing.IPv6Gateway == "" || n.IPv6Gateway != ""):
+			updated.Standby = true
+		}
+		networks = append(networks, &updated)
+	}
+	c.config.Networks = networks
+	state, err := c.currentState()
+	if err != nil {
+		return err
+	}
+	return c.saveState(state)
+}
+
+// activateLink brings the interface of the standby network n up, replaces
+// the default routes with those through its gateways, and adds its static
+// routes and the container routes through it.
+func activateLink(n *configs.Network, routes []*configs.Route) error {
+	link, err := netlink.LinkByName(containerInterfaceName(n))
+	if err != nil {
+		return err
+	}
+	if err := netlink.LinkSetUp(link); err != nil {
+		return err
+	}
+	for _, gateway := range []string{n.Gateway, n.IPv6Gateway} {
+		if gateway == "" {
+			continue
+		}
+		route := &netlink.Route{
+			Scope:     netlink.SCOPE_UNIVERSE,
+			LinkIndex: link.Attrs().Index,
+			Gw:        net.ParseIP(gateway),
+			Priority:  n.GatewayMetric,
+		}
+		// The default route of another interface, if any, is replaced
+		// atomically.
+		if err := netlink.RouteReplace(route); err != nil {
+			return fmt.Errorf("unable to replace the default route with one through %s: %w", gateway, err)
+		}
+	}
+	if err := addStaticRoutes(link, n.StaticRoutes, netlink.RouteReplace); err != nil {
+		return err
+	}
+	// The routes are kept when the network is put back on standby, so they
+	// may already be there.
+	return addRoutes(routes, netlink.RouteReplace)
+}
@@ -0,0 +1,82 @@
+package libcontainer
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/opencontainers/runc/internal/testutil/nettest"
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+)
+
+func TestActivateNetwork(t *testing.T) {
+	ctr := nettest.NewNS(t)
+	ctr.AddVeth(t, "eth0", "peer0", nil)
+	ctr.AddVeth(t, "eth1", "peer1", nil)
+	c := runningNetNSContainer(t, "myid", ctr)
+	c.config.Networks = []*configs.Network{
+		{Type: "veth", Name: "eth0", Address: "192.0.2.2/24", Gateway: "192.0.2.1"},
+		{Type: "veth", Name: "eth1", Address: "198.51.100.2/24", Gateway: "198.51.100.1", Standby: true},
+	}
+	// The route can only be added once the interface is up.
+	c.config.Routes = []*configs.Route{
+		{Destination: "203.0.113.0/24", Source: "198.51.100.2", Gateway: "198.51.100.1", InterfaceName: "eth1"},
+	}
+	for _, n := range c.config.Networks {
+		n := &network{Network: *n}
+		ctr.Do(t, func() error { return (&veth{}).initialize(context.Background(), n) })
+	}
+	if ctr.Link(t, "eth1").Attrs().Flags&net.FlagUp != 0 {
+		t.Fatal("expected the standby interface to be left down")
+	}
+
+	if err := c.activateNetwork("eth0"); err == nil {
+		t.Error("expected error activating a network which is not on standby")
+	}
+	if err := c.activateNetwork("eth1"); err != nil {
+		t.Fatal(err)
+	}
+	if ctr.Link(t, "eth1").Attrs().Flags&net.FlagUp == 0 {
+		t.Error("expected the activated interface to be up")
+	}
+	var routes []netlink.Route
+	ctr.Do(t, func() (err error) {
+		routes, err = netlink.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{Dst: nil}, 0)
+		return err
+	})
+	var gateways []string
+	for _, r := range routes {
+		if r.Dst == nil {
+			gateways = append(gateways, r.Gw.String())
+		}
+	}
+	if len(gateways) != 1 || gateways[0] != "198.51.100.1" {
+		t.Errorf("expected a single default route through the activated gateway, got %v", gateways)
+	}
+	var found bool
+	for _, r := range routes {
+		if r.Dst != nil && r.Dst.String() == "203.0.113.0/24" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected the route through the activated interface to be added")
+	}
+
+	state, err := loadState(c.stateDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if networks := state.Config.Networks; !networks[0].Standby || networks[1].Standby {
+		t.Errorf("expected the networks to be swapped, got standby %v and %v", networks[0].Standby, networks[1].Standby)
+	}
+	// The previous network can be activated back, and the routes of the
+	// first one are kept when it is activated again.
+	if err := c.activateNetwork("eth0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.activateNetwork("eth1"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,364 @@
+package libcontainer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/asm"
+	"github.com/opencontainers/runc/libcontainer/configs"
+	statsv1 "github.com/opencontainers/runc/types/stats/v1"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+// networkStatsBackend collects the counters of the container interfaces.
+type networkStatsBackend interface {
+	// interfaceStats returns the counters of the interfaces of networks,
+	// in the network namespace at nsPath.
+	interfaceStats(nsPath string, networks []*configs.Network) ([]*statsv1.NetworkInterface, error)
+}
+
+// networkStatsPreparer is implemented by the stats backends which need to
+// set up the interfaces of the networks, in the network namespace at nsPath,
+// before their counters can be collected.
+type networkStatsPreparer interface {
+	prepare(nsPath string, networks []*configs.Network) error
+}
+
+var statsBackends = map[configs.NetworkStatsBackend]networkStatsBackend{
+	configs.NetworkStatsSysfs:   &sysfsStats{},
+	configs.NetworkStatsNetlink: &netlinkStats{},
+	configs.NetworkStatsEBPF:    &ebpfStats{},
+}
+
+// getStatsBackend returns the stats backend selected in the network options,
+// sysfs by default.
+func getStatsBackend(opts *configs.NetworkOptions) (networkStatsBackend, error) {
+	name := configs.NetworkStatsSysfs
+	if opts != nil && opts.StatsBackend != "" {
+		name = opts.StatsBackend
+	}
+	b, ok := statsBackends[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown stats backend %q", name)
+	}
+	return b, nil
+}
+
+// prepareNetworkStats sets up the interfaces of networks, in the network
+// namespace at nsPath, for the stats backend selected in opts.
+func prepareNetworkStats(opts *configs.NetworkOptions, nsPath string, networks []*configs.Network) error {
+	b, err := getStatsBackend(opts)
+	if err != nil {
+		return err
+	}
+	if p, ok := b.(networkStatsPreparer); ok {
+		return p.prepare(nsPath, networks)
+	}
+	return nil
+}
+
+// sysfsStats reads the counters of the host side of veth pairs from sysfs.
+// The interfaces of the other network types are not reported.
+type sysfsStats struct{}
+
+func (*sysfsStats) interfaceStats(_ string, networks []*configs.Network) ([]*statsv1.NetworkInterface, error) {
+	var interfaces []*statsv1.NetworkInterface
+	for _, n := range networks {
+		if n.Type != "veth" {
+			continue
+		}
+		istats, err := getNetworkInterfaceStats(n.HostInterfaceName)
+		if err != nil {
+			return nil, fmt.Errorf("interface %q: %w", n.HostInterfaceName, err)
+		}
+		interfaces = append(interfaces, istats)
+	}
+	return interfaces, nil
+}
+
+// netlinkStats reads the counters of the container interfaces from inside
+// the container network namespace.
+type netlinkStats struct{}
+
+func (*netlinkStats) interfaceStats(nsPath string, networks []*configs.Network) ([]*statsv1.NetworkInterface, error) {
+	sample, err := sampleNetworkStats(nsPath, networks)
+	if err != nil {
+		return nil, err
+	}
+	return sample.Interfaces, nil
+}
+
+// ebpfStats counts the packets of the container interfaces with programs
+// attached to the ingress and egress hooks of their clsact qdisc. The
+// programs share an array map holding the counters of both directions, and
+// are looked up through the filters of the interface, so nothing needs to
+// be pinned or cleaned up: the programs and the map go away with the
+// interface.
+type ebpfStats struct{}
+
+// statsCounterName is the name of the counter programs and of their filters.
+const statsCounterName = "runc_stats"
+
+const (
+	statsCounterRx uint32 = iota
+	statsCounterTx
+)
+
+// statsCounter is the value of the counter map, for a direction.
+type statsCounter struct {
+	Bytes   uint64
+	Packets uint64
+}
+
+func (*ebpfStats) prepare(nsPath string, networks []*configs.Network) error {
+	return doInNetNS(nsPath, func() error {
+		for _, n := range networks {
+			name := containerInterfaceName(n)
+			link, err := netlink.LinkByName(name)
+			if err != nil {
+				return fmt.Errorf("unable to find %s: %w", name, err)
+			}
+			if err := attachStatsCounters(link); err != nil {
+				return fmt.Errorf("unable to attach counters to %s: %w", name, err)
+			}
+		}
+		return nil
+	})
+}
+
+func (*ebpfStats) interfaceStats(nsPath string, networks []*configs.Network) ([]*statsv1.NetworkInterface, error) {
+	var interfaces []*statsv1.NetworkInterface
+	err := doInNetNS(nsPath, func() error {
+		for _, n := range networks {
+			name := containerInterfaceName(n)
+			link, err := netlink.LinkByName(name)
+			if err != nil {
+				var notFound netlink.LinkNotFoundError
+				if errors.As(err, &notFound) {
+					continue
+				}
+				return err
+			}
+			iface, err := readStatsCounters(link)
+			if err != nil {
+				return fmt.Errorf("unable to read the counters of %s: %w", name, err)
+			}
+			interfaces = append(interfaces, iface)
+		}
+		return nil
+	})
+	return interfaces, err
+}
+
+// attachStatsCounters attaches the counter programs to link, adding a clsact
+// qdisc if it has none. The programs are attached with the highest priority,
+// and let the packets go through the other filters.
+func attachStatsCounters(link netlink.Link) error {
+	qdisc := &netlink.GenericQdisc{
+		QdiscAttrs: netlink.QdiscAttrs{
+			LinkIndex: link.Attrs().Index,
+			Handle:    netlink.MakeHandle(0xffff, 0),
+			Parent:    netlink.HANDLE_CLSACT,
+		},
+		QdiscType: "clsact",
+	}
+	if err := netlink.QdiscAdd(qdisc); err != nil && !errors.Is(err, unix.EEXIST) {
+		return fmt.Errorf("unable to add clsact qdisc: %w", err)
+	}
+	counters, err := ebpf.NewMap(&ebpf.MapSpec{
+		Name:       statsCounterName,
+		Type:       ebpf.Array,
+		KeySize:    4,
+		ValueSize:  16,
+		MaxEntries: 2,
+	})
+	if err != nil {
+		return err
+	}
+	// The filters hold the programs, which hold the map.
+	defer counters.Close()
+	for parent, key := range map[uint32]uint32{
+		netlink.HANDLE_MIN_INGRESS: statsCounterRx,
+		netlink.HANDLE_MIN_EGRESS:  statsCounterTx,
+	} {
+		prog, err := ebpf.NewProgram(&ebpf.ProgramSpec{
+			Name:         statsCounterName,
+			Type:         ebpf.SchedCLS,
+			License:      "Apache",
+			Instructions: statsCounterProgram(counters, key),
+		})
+		if err != nil {
+			return err
+		}
+		err = netlink.FilterAdd(&netlink.BpfFilter{
+			FilterAttrs: netlink.FilterAttrs{
+				LinkIndex: link.Attrs().Index,
+				Parent:    parent,
+				Handle:    1,
+				Priority:  1,
+				Protocol:  unix.ETH_P_ALL,
+			},
+			Fd:           prog.FD(),
+			Name:         statsCounterName,
+			DirectAction: true,
+		})
+		prog.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// statsCounterProgram returns a program adding the length of the packet and
+// one to the bytes and packets of the counters at key.
+func statsCounterProgram(counters *ebpf.Map, key uint32) asm.Instructions {
+	const tcActUnspec = -1
+	return asm.Instructions{
+		asm.Mov.Reg(asm.R6, asm.R1),
+		asm.StoreImm(asm.RFP, -4, int64(key), asm.Word),
+		asm.Mov.Reg(asm.R2, asm.RFP),
+		asm.Add.Imm(asm.R2, -4),
+		asm.LoadMapPtr(asm.R1, counters.FD()),
+		asm.FnMapLookupElem.Call(),
+		asm.JEq.Imm(asm.R0, 0, "out"),
+		// struct __sk_buff starts with the length of the packet.
+		asm.LoadMem(asm.R1, asm.R6, 0, asm.Word),
+		asm.StoreXAdd(asm.R0, asm.R1, asm.DWord),
+		asm.Mov.Imm(asm.R1, 1),
+		asm.Instruction{OpCode: asm.StoreXAddOp(asm.DWord), Dst: asm.R0, Src: asm.R1, Offset: 8},
+		// Let the next filter classify the packet.
+		asm.Mov.Imm(asm.R0, tcActUnspec).WithSymbol("out"),
+		asm.Return(),
+	}
+}
+
+// readStatsCounters reads the counters of the programs attached to link by
+// attachStatsCounters.
+func readStatsCounters(link netlink.Link) (*statsv1.NetworkInterface, error) {
+	filters, err := netlink.FilterList(link, netlink.HANDLE_MIN_INGRESS)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range filters {
+		bpf, ok := f.(*netlink.BpfFilter)
+		if !ok || bpf.Name != statsCounterName {
+			continue
+		}
+		prog, err := ebpf.NewProgramFromID(ebpf.ProgramID(bpf.Id))
+		if err != nil {
+			return nil, err
+		}
+		info, err := prog.Info()
+		prog.Close()
+		if err != nil {
+			return nil, err
+		}
+		ids, ok := info.MapIDs()
+		if !ok || len(ids) != 1 {
+			return nil, errors.New("counter map not found")
+		}
+		counters, err := ebpf.NewMapFromID(ids[0])
+		if err != nil {
+			return nil, err
+		}
+		defer counters.Close()
+		var rx, tx statsCounter
+		if err := counters.Lookup(statsCounterRx, &rx); err != nil {
+			return nil, err
+		}
+		if err := counters.Lookup(statsCounterTx, &tx); err != nil {
+			return nil, err
+		}
+		return &statsv1.NetworkInterface{
+			Name:      link.Attrs().Name,
+			RxBytes:   rx.Bytes,
+			RxPackets: rx.Packets,
+			TxBytes:   tx.Bytes,
+			TxPackets: tx.Packets,
+			// The programs do not see the errors and drops.
+			Supported: statsv1.CounterRxBytes | statsv1.CounterRxPackets | statsv1.CounterTxBytes | statsv1.CounterTxPackets,
+		}, nil
+	}
+	return nil, errors.New("no counters attached")
+}
+
+// dumpLinkStats reads the counters of the interfaces named in names, in the
+// current network namespace. The counters of all the interfaces come from a
+// single dump of the links, rather than a request per interface, and only
+// their names and counters are decoded, which matters for containers with
+// hundreds of interfaces. Interfaces which do not exist are not returned.
+func dumpLinkStats(names map[string]bool) (map[string]*statsv1.NetworkInterface, error) {
+	req := nl.NewNetlinkRequest(unix.RTM_GETLINK, unix.NLM_F_DUMP)
+	req.AddData(nl.NewIfInfomsg(unix.AF_UNSPEC))
+	msgs, err := req.Execute(unix.NETLINK_ROUTE, unix.RTM_NEWLINK)
+	if err != nil {
+		return nil, err
+	}
+	stats := make(map[string]*statsv1.NetworkInterface, len(names))
+	for _, m := range msgs {
+		if len(m) < unix.SizeofIfInfomsg {
+			continue
+		}
+		if iface := decodeLinkStats(m[unix.SizeofIfInfomsg:], names); iface != nil {
+			stats[iface.Name] = iface
+		}
+	}
+	return stats, nil
+}
+
+// decodeLinkStats decodes the attributes of a RTM_NEWLINK message, and
+// returns the counters of the interface if it is named in names. The 64 bit
+// counters are preferred to the 32 bit ones, which can wrap.
+func decodeLinkStats(attrs []byte, names map[string]bool) *statsv1.NetworkInterface {
+	var name string
+	var stats32, stats64 []byte
+	for len(attrs) >= unix.SizeofRtAttr {
+		size := int(nl.NativeEndian().Uint16(attrs[0:2]))
+		if size < unix.SizeofRtAttr || size > len(attrs) {
+			break
+		}
+		data := attrs[unix.SizeofRtAttr:size]
+		switch nl.NativeEndian().Uint16(attrs[2:4]) &^ (unix.NLA_F_NESTED | unix.NLA_F_NET_BYTEORDER) {
+		case unix.IFLA_IFNAME:
+			name = string(bytes.TrimRight(data, "\x00"))
+		case unix.IFLA_STATS:
+			stats32 = data
+		case unix.IFLA_STATS64:
+			stats64 = data
+		}
+		size = (size + unix.NLA_ALIGNTO - 1) &^ (unix.NLA_ALIGNTO - 1)
+		if size > len(attrs) {
+			break
+		}
+		attrs = attrs[size:]
+	}
+	if !names[name] {
+		return nil
+	}
+	iface := &statsv1.NetworkInterface{Name: name}
+	// Both follow struct rtnl_link_stats, with counters of 64 or 32 bits.
+	counters := []*uint64{
+		&iface.RxPackets, &iface.TxPackets,
+		&iface.RxBytes, &iface.TxBytes,
+		&iface.RxErrors, &iface.TxErrors,
+		&iface.RxDropped, &iface.TxDropped,
+	}
+	switch {
+	case len(stats64) >= len(counters)*8:
+		for i, c := range counters {
+			*c = nl.NativeEndian().Uint64(stats64[i*8:])
+		}
+		iface.Supported = statsv1.AllCounters
+	case len(stats32) >= len(counters)*4:
+		for i, c := range counters {
+			*c = uint64(nl.NativeEndian().Uint32(stats32[i*4:]))
+		}
+		iface.Supported = statsv1.AllCounters
+	}
+	return iface
+}
@@ -0,0 +1,159 @@
+package libcontainer
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/runc/internal/testutil/nettest"
+	"github.com/opencontainers/runc/libcontainer/configs"
+	statsv1 "github.com/opencontainers/runc/types/stats/v1"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+func TestNetworkStatsBackends(t *testing.T) {
+	ctr := nettest.NewNS(t)
+	networks := []*configs.Network{{Type: "loopback", Name: "lo"}}
+	ebpf := &ebpfStats{}
+	if err := ebpf.prepare(ctr.Path, networks); err != nil {
+		t.Skipf("unable to attach eBPF counters: %v", err)
+	}
+	ctr.Do(t, func() error {
+		conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		for i := 0; i < 3; i++ {
+			if _, err := conn.WriteTo(make([]byte, 100), conn.LocalAddr()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	for name, backend := range statsBackends {
+		interfaces, err := backend.interfaceStats(ctr.Path, networks)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if name == configs.NetworkStatsSysfs {
+			if len(interfaces) != 0 {
+				t.Errorf("%s: expected only veth networks to be reported, got %+v", name, interfaces)
+			}
+			continue
+		}
+		if len(interfaces) != 1 || interfaces[0].Name != "lo" {
+			t.Fatalf("%s: expected the loopback interface, got %+v", name, interfaces)
+		}
+		// Each datagram is both sent and received by the loopback interface.
+		if i := interfaces[0]; i.TxPackets < 3 || i.RxPackets < 3 || i.TxBytes < 300 || i.RxBytes < 300 {
+			t.Errorf("%s: expected the datagrams to be counted, got %+v", name, i)
+		}
+	}
+
+	if _, err := getStatsBackend(&configs.NetworkOptions{StatsBackend: "procfs"}); err == nil {
+		t.Error("expected error for an unknown backend")
+	}
+}
+
+// BenchmarkSampleNetworkStats compares reading the counters of 500
+// interfaces with a request per interface and with a single dump.
+func BenchmarkSampleNetworkStats(b *testing.B) {
+	ctr := nettest.NewNS(b)
+	var networks []*configs.Network
+	for i := 0; i < 250; i++ {
+		name, peer := fmt.Sprintf("eth%d", i), fmt.Sprintf("peer%d", i)
+		ctr.AddVeth(b, name, peer, nil)
+		networks = append(networks, &configs.Network{Type: "veth", Name: name}, &configs.Network{Type: "veth", Name: peer})
+	}
+
+	b.Run("per-link", func(b *testing.B) {
+		ctr.Do(b, func() error {
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for _, n := range networks {
+					if _, err := netlink.LinkByName(n.Name); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		})
+	})
+	b.Run("dump", func(b *testing.B) {
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			sample, err := sampleNetworkStats(ctr.Path, networks)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if len(sample.Interfaces) != len(networks) {
+				b.Fatalf("expected %d interfaces, got %d", len(networks), len(sample.Interfaces))
+			}
+		}
+	})
+}
+
+func TestDecodeLinkStats(t *testing.T) {
+	stats32 := make([]byte, 4*8)
+	for i := 0; i < 8; i++ {
+		nl.NativeEndian().PutUint32(stats32[i*4:], uint32(i+1))
+	}
+	var attrs []byte
+	attrs = append(attrs, nl.NewRtAttr(unix.IFLA_IFNAME, nl.ZeroTerminated("eth0")).Serialize()...)
+	attrs = append(attrs, nl.NewRtAttr(unix.IFLA_STATS, stats32).Serialize()...)
+
+	if iface := decodeLinkStats(attrs, map[string]bool{"eth1": true}); iface != nil {
+		t.Errorf("expected eth0 to be skipped, got %+v", iface)
+	}
+	iface := decodeLinkStats(attrs, map[string]bool{"eth0": true})
+	if iface == nil {
+		t.Fatal("expected eth0 to be decoded")
+	}
+	if iface.RxPackets != 1 || iface.TxPackets != 2 || iface.RxBytes != 3 || iface.TxDropped != 8 || iface.Supported != statsv1.AllCounters {
+		t.Errorf("unexpected counters %+v", iface)
+	}
+	// A truncated attribute ends the decoding.
+	if iface := decodeLinkStats(attrs[:6], map[string]bool{"eth0": true}); iface != nil {
+		t.Errorf("expected nothing for truncated attributes, got %+v", iface)
+	}
+}
+
+func TestGetNetworkInterfaceStats(t *testing.T) {
+	dir := t.TempDir()
+	defer func(orig string) { sysfsNet = orig }(sysfsNet)
+	sysfsNet = dir
+	stats := filepath.Join(dir, "veth0", "statistics")
+	if err := os.MkdirAll(stats, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// The driver has no error counters, and a broken drop counter.
+	for file, value := range map[string]string{
+		"rx_bytes":   "100\n",
+		"rx_packets": "2\n",
+		"tx_bytes":   "300\n",
+		"tx_packets": "4\n",
+		"tx_dropped": "n/a\n",
+	} {
+		if err := os.WriteFile(filepath.Join(stats, file), []byte(value), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	iface, err := getNetworkInterfaceStats("veth0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The counters of the host side are swapped.
+	supported := statsv1.CounterRxBytes | statsv1.CounterRxPackets | statsv1.CounterTxBytes | statsv1.CounterTxPackets
+	if iface.RxBytes != 300 || iface.RxPackets != 4 || iface.TxBytes != 100 || iface.TxPackets != 2 || iface.Supported != supported {
+		t.Errorf("unexpected stats %+v", iface)
+	}
+	if _, err := getNetworkInterfaceStats("veth1"); err == nil {
+		t.Error("expected error for an interface without counters")
+	}
+}
@@ -0,0 +1,317 @@
+package libcontainer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/vishvananda/netlink"
+)
+
+// NetworkAnnotation is the annotation of the state given to the prestart,
+// createRuntime and poststart hooks holding the status of the container
+// interfaces, as a JSON list of NetworkInterfaceStatus.
+const NetworkAnnotation = "org.opencontainers.runc.network"
+
+// NetworkInterfaceStatus describes a network interface of the container as
+// found in the container network namespace after the network setup.
+type NetworkInterfaceStatus struct {
+	// Name is the name of the interface inside the container.
+	Name string `json:"name"`
+	// Type is the type of the network the interface belongs to.
+	Type string `json:"type"`
+	// HostInterfaceName is the name of the host side interface, if any.
+	HostInterfaceName string `json:"host_interface_name,omitempty"`
+	// Index is the interface index inside the container.
+	Index int `json:"index,omitempty"`
+	// MacAddress is the hardware address of the interface.
+	MacAddress string `json:"mac_address,omitempty"`
+	// Mtu is the MTU of the interface.
+	Mtu int `json:"mtu,omitempty"`
+	// Addresses are the addresses assigned to the interface, in CIDR form.
+	Addresses []string `json:"addresses,omitempty"`
+	// Up reports whether the interface is administratively up.
+	Up bool `json:"up"`
+	// Error is set if the interface could not be inspected.
+	Error string `json:"error,omitempty"`
+}
+
+// NetworkReady is the readiness notification written once the network setup
+// of a container is completed.
+type NetworkReady struct {
+	Interfaces []NetworkInterfaceStatus `json:"interfaces"`
+}
+
+// NetworkStage is a stage of the setup of a container network.
+type NetworkStage string
+
+const (
+	// NetworkCreated is reported once the interface of a network has been
+	// created and moved to the container network namespace.
+	NetworkCreated NetworkStage = "created"
+	// NetworkConfigured is reported once the host side settings of a network
+	// have been applied.
+	NetworkConfigured NetworkStage = "configured"
+	// NetworkUp is reported once the container has finished setting up the
+	// interface of a network.
+	NetworkUp NetworkStage = "up"
+)
+
+// NetworkProgress reports the progress of the setup of a container network.
+type NetworkProgress struct {
+	// Network is the configuration of the network.
+	Network *configs.Network
+	// Stage is the stage the network has reached, or failed to reach if Err
+	// is set. No further stage is reported after an error.
+	Stage NetworkStage
+	// Err is set if the stage failed.
+	Err error
+	// Status is the status of the container interface, only set for the
+	// NetworkUp stage.
+	Status *NetworkInterfaceStatus
+}
+
+// networkProgressReporter calls the NetworkProgress callback of a process
+// for the network setup, which may run in another goroutine. Once stopped,
+// the progress is no longer reported, so that the callback is not called
+// after the start of the container failed, when the setup is abandoned at
+// its deadline.
+type networkProgressReporter struct {
+	mu      sync.Mutex
+	fn      func(NetworkProgress)
+	stopped bool
+}
+
+// report calls the callback, if any and unless stopped, and returns err.
+func (r *networkProgressReporter) report(n *configs.Network, stage NetworkStage, err error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.fn != nil && !r.stopped {
+		r.fn(NetworkProgress{Network: n, Stage: stage, Err: err})
+	}
+	return err
+}
+
+// stop waits for the callback in progress, if any, and stops reporting.
+func (r *networkProgressReporter) stop() {
+	r.mu.Lock()
+	r.stopped = true
+	r.mu.Unlock()
+}
+
+// containerInterfaceName returns the name of the interface of network n
+// inside the container.
+func containerInterfaceName(n *configs.Network) string {
+	if n.Type == "loopback" && n.Name == "" {
+		return "lo"
+	}
+	return n.Name
+}
+
+// assignInterfaceNames returns the networks of the container id, where those
+// which have a host interface but no name are replaced by copies with a name
+// derived from both, so that it is the same every time the container is
+// created, and the interfaces of several networks do not collide in the
+// container. The given networks are left untouched.
+func assignInterfaceNames(id string, networks []*configs.Network) []*configs.Network {
+	named := make([]*configs.Network, 0, len(networks))
+	for _, n := range networks {
+		if n.Name == "" && n.HostInterfaceName != "" && n.Type != "loopback" {
+			n := *n
+			n.Name = hashedInterfaceName(id, n.HostInterfaceName)
+			named = append(named, &n)
+			continue
+		}
+		named = append(named, n)
+	}
+	return named
+}
+
+// hashedInterfaceName returns an interface name derived from the container id
+// and the host interface name, which fits in IFNAMSIZ.
+func hashedInterfaceName(id, hostName string) string {
+	sum := sha256.Sum256([]byte(id + "\x00" + hostName))
+	return "net" + hex.EncodeToString(sum[:5])
+}
+
+// networkStatus inspects the interfaces of the given networks in the network
+// namespace at nsPath.
+func networkStatus(nsPath string, networks []*configs.Network) ([]NetworkInterfaceStatus, error) {
+	status := make([]NetworkInterfaceStatus, 0, len(networks))
+	err := doInNetNS(nsPath, func() error {
+		for _, n := range networks {
+			s := NetworkInterfaceStatus{
+				Name:              containerInterfaceName(n),
+				Type:              n.Type,
+				HostInterfaceName: n.HostInterfaceName,
+			}
+			if err := inspectInterface(&s); err != nil {
+				s.Error = err.Error()
+			}
+			status = append(status, s)
+		}
+		return nil
+	})
+	return status, err
+}
+
+func inspectInterface(s *NetworkInterfaceStatus) error {
+	link, err := netlink.LinkByName(s.Name)
+	if err != nil {
+		return err
+	}
+	attrs := link.Attrs()
+	s.Index = attrs.Index
+	s.MacAddress = attrs.HardwareAddr.String()
+	s.Mtu = attrs.MTU
+	s.Up = attrs.Flags&net.FlagUp != 0
+	addrs, err := netlink.AddrList(link, netlink.FAMILY_ALL)
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		s.Addresses = append(s.Addresses, addr.IPNet.String())
+	}
+	return nil
+}
+
+// annotateNetworkStatus adds the status of the interfaces of the given
+// networks in the network namespace at nsPath to the annotations of s, so
+// hooks do not have to inspect the container network namespace themselves.
+func annotateNetworkStatus(s *specs.State, nsPath string, networks []*configs.Network) error {
+	if len(networks) == 0 {
+		return nil
+	}
+	interfaces, err := networkStatus(nsPath, networks)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(interfaces)
+	if err != nil {
+		return err
+	}
+	if s.Annotations == nil {
+		s.Annotations = make(map[string]string)
+	}
+	s.Annotations[NetworkAnnotation] = string(data)
+	return nil
+}
+
+// notifyNetworkReady reports that the network setup of the container has
+// completed, to the NetworkProgress callback of the process and as configured
+// in the container network options.
+func (p *initProcess) notifyNetworkReady() error {
+	opts := p.config.Config.NetworkOptions
+	notify := opts != nil && (opts.ReadyFile || opts.ReadySocket != "")
+	if !notify && p.process.NetworkProgress == nil {
+		return nil
+	}
+	networks := p.config.Config.Networks
+	interfaces, err := networkStatus(fmt.Sprintf("/proc/%d/ns/net", p.pid()), networks)
+	if err != nil {
+		return err
+	}
+	if p.process.NetworkProgress != nil {
+		for i := range interfaces {
+			progress := NetworkProgress{Network: networks[i], Stage: NetworkUp, Status: &interfaces[i]}
+			if interfaces[i].Error != "" {
+				progress.Err = errors.New(interfaces[i].Error)
+			}
+			p.process.NetworkProgress(progress)
+		}
+	}
+	if !notify {
+		return nil
+	}
+	ready := &NetworkReady{Interfaces: interfaces}
+	if opts.ReadyFile {
+		if err := p.container.writeStateFile(networkReadyFilename, ready); err != nil {
+			return err
+		}
+	}
+	if opts.ReadySocket != "" {
+		data, err := json.Marshal(ready)
+		if err != nil {
+			return err
+		}
+		conn, err := net.Dial("unixgram", opts.ReadySocket)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		if _, err := conn.Write([]byte("NETWORK_READY=1\nNETWORK_STATUS=" + string(data) + "\n")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NetworkReady returns the readiness notification written once the network
+// setup of the container completed. An error wrapping os.ErrNotExist is
+// returned if the network setup has not completed, or ReadyFile is not set
+// in the container network options.
+func (c *Container) NetworkReady() (*NetworkReady, error) {
+	f, err := os.Open(filepath.Join(c.stateDir, networkReadyFilename))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var ready NetworkReady
+	if err := json.NewDecoder(f).Decode(&ready); err != nil {
+		return nil, errors.New("invalid network readiness file: " + err.Error())
+	}
+	return &ready, nil
+}
+
+// NetworkInspection describes a network of the container.
+type NetworkInspection struct {
+	// Network is the configuration of the network.
+	Network *configs.Network `json:"network"`
+	// Status is the status of the container interface, only set if the
+	// container is running.
+	Status *NetworkInterfaceStatus `json:"status,omitempty"`
+	// Skipped lists the optional settings of the network which were not
+	// applied.
+	Skipped []SkippedNetworkSetting `json:"skipped,omitempty"`
+}
+
+// InspectNetworks returns the configuration of the container networks,
+// together with the current status of their interface if the container is
+// running, and the settings which were skipped when setting them up.
+func (c *Container) InspectNetworks() ([]NetworkInspection, error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	var status []NetworkInterfaceStatus
+	nsPath, err := c.netNSPath()
+	if err == nil {
+		if status, err = networkStatus(nsPath, c.config.Networks); err != nil {
+			return nil, err
+		}
+	} else if !errors.Is(err, ErrNotRunning) {
+		return nil, err
+	}
+
+	result := make([]NetworkInspection, 0, len(c.config.Networks))
+	for i, n := range c.config.Networks {
+		inspection := NetworkInspection{Network: n}
+		if status != nil {
+			inspection.Status = &status[i]
+		}
+		name := containerInterfaceName(n)
+		for _, s := range c.skippedNetwork {
+			if s.Interface == name {
+				inspection.Skipped = append(inspection.Skipped, s)
+			}
+		}
+		result = append(result, inspection)
+	}
+	return result, nil
+}
@@ -0,0 +1,74 @@
+package libcontainer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// nftApply loads ruleset using nft(8). If nsPath is not empty, nft is run in
+// the network namespace at nsPath instead of the current one. nft is killed
+// if ctx is cancelled before it completes.
+func nftApply(ctx context.Context, nsPath, ruleset string) error {
+	var out bytes.Buffer
+	cmd := exec.CommandContext(ctx, "nft", "-f", "-")
+	cmd.Stdin = strings.NewReader(ruleset)
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	var err error
+	if nsPath == "" {
+		err = cmd.Run()
+	} else {
+		err = doInNetNS(nsPath, cmd.Run)
+	}
+	if err != nil {
+		return fmt.Errorf("nft: %w: %s", err, bytes.TrimSpace(out.Bytes()))
+	}
+	return nil
+}
+
+// nftList runs nft(8) with args, in the network namespace at nsPath if not
+// empty, and returns its output.
+func nftList(ctx context.Context, nsPath string, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "nft", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	var err error
+	if nsPath == "" {
+		err = cmd.Run()
+	} else {
+		err = doInNetNS(nsPath, cmd.Run)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("nft: %w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// nftReplaceTable returns a ruleset atomically replacing the given table,
+// creating it first if needed so the deletion never fails.
+func nftReplaceTable(family, table, body string) string {
+	return fmt.Sprintf("table %[1]s %[2]s {}\ndelete table %[1]s %[2]s\ntable %[1]s %[2]s {\n%[3]s}\n", family, table, body)
+}
+
+// nftDeleteTable returns a ruleset deleting the given table, if it exists.
+func nftDeleteTable(family, table string) string {
+	return fmt.Sprintf("table %[1]s %[2]s {}\ndelete table %[1]s %[2]s\n", family, table)
+}
+
+// hostInterfaceTable is the name of the nftables table holding the rules
+// runc installs in the host for the host side interface ifName.
+func hostInterfaceTable(ifName string) string {
+	return "runc-" + ifName
+}
+
+// containerInterfaceTable is the name of the nftables table holding the
+// rules runc installs in the container network namespace for the interface
+// ifName. Its prefix differs from the one of hostInterfaceTable, so the
+// tables of both sides can not be mistaken for one another.
+func containerInterfaceTable(ifName string) string {
+	return "runc-ctr-" + ifName
+}
@@ -0,0 +1,104 @@
+package libcontainer
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/vishvananda/netlink"
+)
+
+// passthruRegistryFilename is the file, in the root directory of the
+// containers, recording the parent devices given to containers by passthru
+// macvlan interfaces.
+const passthruRegistryFilename = "macvlan-passthru.json"
+
+// passthruClaim records a parent device used by the passthru macvlan of a
+// container, and its state beforehand. A passthru macvlan takes over the MAC
+// address of its parent and sets it promiscuous, so the parent state is
+// restored when the container is destroyed.
+type passthruClaim struct {
+	// Parent is the name of the parent device.
+	Parent string `json:"parent"`
+	// Owner is the ID of the container the parent device is given to.
+	Owner string `json:"owner"`
+	// MacAddress is the MAC address of the parent device.
+	MacAddress string `json:"mac_address"`
+	// Promisc is whether the parent device was promiscuous.
+	Promisc bool `json:"promisc"`
+}
+
+// claimPassthruParent records parent as used by the passthru macvlan of
+// owner, with its current state. Only one passthru macvlan can be created on
+// a parent device, so claims from other containers are refused.
+func claimPassthruParent(registry, parent, owner string) error {
+	var claims []passthruClaim
+	return updateLockedJSON(registry, &claims, func() error {
+		for _, c := range claims {
+			if c.Parent == parent {
+				return fmt.Errorf("%s is already used by the passthru macvlan of container %s", parent, c.Owner)
+			}
+		}
+		link, err := netlink.LinkByName(parent)
+		if err != nil {
+			return err
+		}
+		claims = append(claims, passthruClaim{
+			Parent:     parent,
+			Owner:      owner,
+			MacAddress: link.Attrs().HardwareAddr.String(),
+			Promisc:    link.Attrs().Promisc != 0,
+		})
+		return nil
+	})
+}
+
+// releasePassthruParent removes the claim of owner on parent from registry,
+// and restores the state of parent.
+func releasePassthruParent(registry, owner, parent string) error {
+	var (
+		released *passthruClaim
+		claims   []passthruClaim
+	)
+	err := updateLockedJSON(registry, &claims, func() error {
+		kept := claims[:0]
+		for _, c := range claims {
+			if c.Owner == owner && c.Parent == parent {
+				c := c
+				released = &c
+			} else {
+				kept = append(kept, c)
+			}
+		}
+		claims = kept
+		return nil
+	})
+	if err != nil || released == nil {
+		return err
+	}
+	return restorePassthruParent(released)
+}
+
+// restorePassthruParent restores the MAC address and promiscuous mode of the
+// parent device of c.
+func restorePassthruParent(c *passthruClaim) error {
+	link, err := netlink.LinkByName(c.Parent)
+	if err != nil {
+		return err
+	}
+	mac, err := net.ParseMAC(c.MacAddress)
+	if err != nil {
+		return err
+	}
+	if err := netlink.LinkSetHardwareAddr(link, mac); err != nil {
+		return fmt.Errorf("unable to restore the MAC address of %s: %w", c.Parent, err)
+	}
+	if c.Promisc {
+		err = netlink.SetPromiscOn(link)
+	} else {
+		err = netlink.SetPromiscOff(link)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to restore the promiscuous mode of %s: %w", c.Parent, err)
+	}
+	return nil
+}
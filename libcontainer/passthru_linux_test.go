@@ -0,0 +1,46 @@
+package libcontainer
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/runc/internal/testutil/nettest"
+	"github.com/vishvananda/netlink"
+)
+
+func TestClaimPassthruParent(t *testing.T) {
+	host := nettest.NewNS(t)
+	host.AddVeth(t, "eth0", "peer0", nil)
+	registry := filepath.Join(t.TempDir(), passthruRegistryFilename)
+	mac := host.Link(t, "eth0").Attrs().HardwareAddr.String()
+
+	host.Do(t, func() error {
+		if err := claimPassthruParent(registry, "eth0", "ctr1"); err != nil {
+			return err
+		}
+		if err := claimPassthruParent(registry, "eth0", "ctr2"); err == nil {
+			t.Error("expected error claiming a parent used by another container")
+		}
+		// What a passthru macvlan does to its parent.
+		link, err := netlink.LinkByName("eth0")
+		if err != nil {
+			return err
+		}
+		if err := netlink.LinkSetHardwareAddr(link, net.HardwareAddr{0x02, 0, 0, 0, 0, 1}); err != nil {
+			return err
+		}
+		if err := netlink.SetPromiscOn(link); err != nil {
+			return err
+		}
+		if err := releasePassthruParent(registry, "ctr1", "eth0"); err != nil {
+			return err
+		}
+		// The parent can be claimed again once released.
+		return claimPassthruParent(registry, "eth0", "ctr2")
+	})
+	attrs := host.Link(t, "eth0").Attrs()
+	if attrs.HardwareAddr.String() != mac || attrs.Promisc != 0 {
+		t.Errorf("expected the parent state to be restored, got mac %s, promisc %d", attrs.HardwareAddr, attrs.Promisc)
+	}
+}
@@ -0,0 +1,181 @@
+package libcontainer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+)
+
+// pluginResultPrefix prefixes the name of the files of the container state
+// directory holding the results of the plugins, followed by the name of the
+// interface.
+const pluginResultPrefix = "network-plugin-"
+
+// pluginRequest is the request passed to the program of a plugin network,
+// see configs.PluginSettings.
+type pluginRequest struct {
+	Command     string          `json:"command"`
+	ContainerID string          `json:"container_id"`
+	NetNS       string          `json:"netns,omitempty"`
+	Interface   string          `json:"interface"`
+	Config      json.RawMessage `json:"config,omitempty"`
+	Result      *pluginResult   `json:"result,omitempty"`
+}
+
+// pluginResult is the result of the "add" command of a plugin network.
+type pluginResult struct {
+	MacAddress  string           `json:"mac_address,omitempty"`
+	Address     string           `json:"address,omitempty"`
+	Gateway     string           `json:"gateway,omitempty"`
+	IPv6Address string           `json:"ipv6_address,omitempty"`
+	IPv6Gateway string           `json:"ipv6_gateway,omitempty"`
+	Routes      []*configs.Route `json:"routes,omitempty"`
+}
+
+// plugin is a network strategy that delegates the creation of the interface
+// to an external program, in the manner of a CNI plugin, and configures it
+// with the settings the program returns. The result is kept in the container
+// state directory, and passed back to the program when the network is
+// released.
+type plugin struct{}
+
+func (p *plugin) create(ctx context.Context, n *network, nspid int) error {
+	if n.stateDir == "" {
+		return errors.New("plugin networks require the container state directory")
+	}
+	req := &pluginRequest{
+		Command:     "add",
+		ContainerID: filepath.Base(n.stateDir),
+		NetNS:       "/proc/" + strconv.Itoa(nspid) + "/ns/net",
+		Interface:   n.Name,
+		Config:      n.Plugin.Config,
+	}
+	out, err := runPlugin(ctx, n.Plugin.Command, req)
+	if err != nil {
+		return fmt.Errorf("plugin of network %s failed: %w", n.Name, err)
+	}
+	var result pluginResult
+	if len(bytes.TrimSpace(out)) > 0 {
+		if err := json.Unmarshal(out, &result); err != nil {
+			return fmt.Errorf("invalid result of the plugin of network %s: %w", n.Name, err)
+		}
+	}
+	// The result is saved first, so the program is given it back when
+	// the network is released, even if it is not valid.
+	data, err := json.Marshal(&result)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(pluginResultPath(n), data, 0o600); err != nil {
+		return err
+	}
+	resolved := &ResolvedNetwork{
+		MacAddress:  result.MacAddress,
+		Address:     result.Address,
+		Gateway:     result.Gateway,
+		IPv6Address: result.IPv6Address,
+		IPv6Gateway: result.IPv6Gateway,
+		Routes:      result.Routes,
+	}
+	if err := resolved.apply(n); err != nil {
+		return fmt.Errorf("invalid result of the plugin of network %s: %w", n.Name, err)
+	}
+	return nil
+}
+
+func (p *plugin) initialize(ctx context.Context, n *network) error {
+	link, err := netlink.LinkByName(n.Name)
+	if err != nil {
+		return fmt.Errorf("plugin did not create interface %s: %w", n.Name, err)
+	}
+	if n.MacAddress != "" {
+		mac, err := net.ParseMAC(n.MacAddress)
+		if err != nil {
+			return err
+		}
+		if err := netlink.LinkSetHardwareAddr(link, mac); err != nil {
+			return err
+		}
+	}
+	return configureLink(link, &n.Network)
+}
+
+// release runs the "del" command of the program of the network, with the
+// result of the "add" command.
+func (p *plugin) release(n *network) error {
+	req := &pluginRequest{
+		Command:     "del",
+		ContainerID: filepath.Base(n.stateDir),
+		Interface:   n.Name,
+		Config:      n.Plugin.Config,
+	}
+	path := pluginResultPath(n)
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		req.Result = &pluginResult{}
+		if err := json.Unmarshal(data, req.Result); err != nil {
+			return fmt.Errorf("invalid plugin result %s: %w", path, err)
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return err
+	}
+	if _, err := runPlugin(context.Background(), n.Plugin.Command, req); err != nil {
+		return fmt.Errorf("plugin of network %s failed: %w", n.Name, err)
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (p *plugin) attach(n *configs.Network) error {
+	return nil
+}
+
+func (p *plugin) detach(n *configs.Network) error {
+	return nil
+}
+
+func pluginResultPath(n *network) string {
+	return filepath.Join(n.stateDir, pluginResultPrefix+n.Name+".json")
+}
+
+// runPlugin runs cmd with req as its standard input, and returns its standard
+// output.
+func runPlugin(ctx context.Context, cmd *configs.Command, req *pluginRequest) ([]byte, error) {
+	input, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	if cmd.Timeout != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *cmd.Timeout)
+		defer cancel()
+	}
+	var stdout, stderr bytes.Buffer
+	c := exec.CommandContext(ctx, cmd.Path)
+	c.Args = cmd.Args
+	c.Env = cmd.Env
+	c.Dir = cmd.Dir
+	c.Stdin = bytes.NewReader(input)
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+	if err := c.Run(); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			err = ctxErr
+		}
+		return nil, fmt.Errorf("%w, stderr: %s", err, stderr.String())
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,106 @@
+package libcontainer
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/runc/internal/testutil/nettest"
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+// writePlugin writes a plugin program appending its requests to a log file,
+// and printing result on add.
+func writePlugin(t *testing.T, result string) (string, string) {
+	dir := t.TempDir()
+	log := filepath.Join(dir, "requests")
+	path := filepath.Join(dir, "plugin")
+	script := "#!/bin/sh\nreq=$(cat)\necho \"$req\" >> " + log + "\ncase \"$req\" in *'\"command\":\"add\"'*) echo '" + result + "';; esac\n"
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path, log
+}
+
+func TestPluginNetwork(t *testing.T) {
+	path, log := writePlugin(t, `{"address":"10.0.0.2/24","gateway":"10.0.0.1","routes":[{"destination":"10.1.0.0/16","gateway":"10.0.0.254"}]}`)
+	stateDir := filepath.Join(t.TempDir(), "ctr0")
+	if err := os.Mkdir(stateDir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	n := &network{
+		Network: configs.Network{
+			Type:   "plugin",
+			Name:   "eth0",
+			Plugin: &configs.PluginSettings{Command: &configs.Command{Path: path}, Config: json.RawMessage(`{"pool":"a"}`)},
+		},
+		stateDir: stateDir,
+	}
+	if err := (&plugin{}).create(context.Background(), n, 1234); err != nil {
+		t.Fatal(err)
+	}
+	if n.Address != "10.0.0.2/24" || n.Gateway != "10.0.0.1" || len(n.Routes) != 1 || n.Routes[0].InterfaceName != "eth0" {
+		t.Errorf("expected the plugin result to be applied, got %+v, routes %v", n.Network, n.Routes)
+	}
+
+	host := nettest.NewNS(t)
+	ctr := nettest.NewNS(t)
+	host.AddVeth(t, "veth0", "eth0", ctr)
+	ctr.Do(t, func() error { return (&plugin{}).initialize(context.Background(), n) })
+	if addrs := strings.Join(ctr.Addrs(t, "eth0"), " "); !strings.Contains(addrs, "10.0.0.2/24") {
+		t.Errorf("expected the address of the plugin result, got %v", addrs)
+	}
+
+	if err := (&plugin{}).release(n); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected an add and a del request, got %q", data)
+	}
+	var add, del pluginRequest
+	if err := json.Unmarshal([]byte(lines[0]), &add); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &del); err != nil {
+		t.Fatal(err)
+	}
+	if add.Command != "add" || add.ContainerID != "ctr0" || add.NetNS != "/proc/1234/ns/net" || add.Interface != "eth0" || string(add.Config) != `{"pool":"a"}` {
+		t.Errorf("unexpected add request %+v", add)
+	}
+	if del.Command != "del" || del.Result == nil || del.Result.Address != "10.0.0.2/24" {
+		t.Errorf("expected the del request to hold the add result, got %+v", del)
+	}
+	if _, err := os.Stat(pluginResultPath(n)); !os.IsNotExist(err) {
+		t.Errorf("expected the plugin result to be removed, got %v", err)
+	}
+}
+
+func TestPluginNetworkFailure(t *testing.T) {
+	path, _ := writePlugin(t, `{"address":"not an address"}`)
+	n := &network{
+		Network:  configs.Network{Type: "plugin", Name: "eth0", Plugin: &configs.PluginSettings{Command: &configs.Command{Path: path}}},
+		stateDir: t.TempDir(),
+	}
+	if err := (&plugin{}).create(context.Background(), n, 1234); err == nil {
+		t.Error("expected error for an invalid plugin result")
+	}
+	// The result is kept for the del command.
+	if _, err := os.Stat(pluginResultPath(n)); err != nil {
+		t.Error(err)
+	}
+
+	timeout := 100 * time.Millisecond
+	n.Plugin.Command = &configs.Command{Path: "/bin/sleep", Args: []string{"sleep", "10"}, Timeout: &timeout}
+	if err := (&plugin{}).create(context.Background(), n, 1234); err == nil {
+		t.Error("expected error for a plugin running past its timeout")
+	}
+}
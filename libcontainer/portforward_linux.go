@@ -0,0 +1,155 @@
+package libcontainer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/sirupsen/logrus"
+)
+
+// ForwardLoopbackPorts proxies TCP connections accepted on the host loopback
+// interface to the container loopback interface. If no forwards are given,
+// the ones set on the container's loopback network are used. It blocks until
+// ctx is cancelled, closing the proxied connections, or returns an error if
+// any of the host listeners can not be created or the container network
+// namespace can not be joined.
+func (c *Container) ForwardLoopbackPorts(ctx context.Context, forwards ...*configs.PortForward) error {
+	if len(forwards) == 0 {
+		for _, n := range c.config.Networks {
+			if n.Type == "loopback" {
+				forwards = append(forwards, n.PortForwards...)
+			}
+		}
+	}
+	if len(forwards) == 0 {
+		return errors.New("no port forwards configured")
+	}
+	c.m.Lock()
+	nsPath, err := c.netNSPath()
+	c.m.Unlock()
+	if err != nil {
+		return err
+	}
+
+	var listeners []net.Listener
+	defer func() {
+		for _, l := range listeners {
+			l.Close()
+		}
+	}()
+	for _, f := range forwards {
+		l, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(int(f.HostPort))))
+		if err != nil {
+			return fmt.Errorf("unable to forward port %d: %w", f.HostPort, err)
+		}
+		listeners = append(listeners, l)
+	}
+
+	// The connections to the container are made by a single thread, which
+	// stays in the container network namespace while the ports are
+	// forwarded, instead of a thread per connection. A socket belongs to
+	// the namespace it is created in for its whole lifetime.
+	dials := make(chan *dialRequest)
+	ready := make(chan error, 1)
+	go func() {
+		err := doInNetNS(nsPath, func() error {
+			ready <- nil
+			for req := range dials {
+				req.conn, req.err = net.Dial("tcp", req.target)
+				close(req.done)
+			}
+			return nil
+		})
+		if err != nil {
+			ready <- err
+		}
+	}()
+	if err := <-ready; err != nil {
+		return c.namespaceError(err)
+	}
+	defer close(dials)
+
+	var wg sync.WaitGroup
+	for i, f := range forwards {
+		wg.Add(1)
+		go func(l net.Listener, target string) {
+			defer wg.Done()
+			for {
+				conn, err := l.Accept()
+				if err != nil {
+					if !errors.Is(err, net.ErrClosed) {
+						logrus.WithError(err).Warnf("port forward %s: accept failed", l.Addr())
+					}
+					return
+				}
+				req := &dialRequest{target: target, done: make(chan struct{})}
+				dials <- req
+				<-req.done
+				if req.err != nil {
+					logrus.WithError(req.err).Warnf("port forward: unable to connect to %s in the container", target)
+					conn.Close()
+					continue
+				}
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					proxy(ctx, conn, req.conn)
+				}()
+			}
+		}(listeners[i], net.JoinHostPort("127.0.0.1", strconv.Itoa(int(f.ContainerPort))))
+	}
+	<-ctx.Done()
+	for _, l := range listeners {
+		l.Close()
+	}
+	listeners = nil
+	wg.Wait()
+	return nil
+}
+
+// dialRequest asks the thread in the container network namespace to connect
+// to target. done is closed once conn or err is set.
+type dialRequest struct {
+	target string
+	conn   net.Conn
+	err    error
+	done   chan struct{}
+}
+
+// proxy copies data between conn and upstream until both sides are done, or
+// ctx is cancelled.
+func proxy(ctx context.Context, conn, upstream net.Conn) {
+	defer conn.Close()
+	defer upstream.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			// Unblock the copies.
+			conn.Close()
+			upstream.Close()
+		case <-done:
+		}
+	}()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	pipe := func(dst, src net.Conn) {
+		defer wg.Done()
+		_, _ = io.Copy(dst, src)
+		if tcp, ok := dst.(*net.TCPConn); ok {
+			_ = tcp.CloseWrite()
+		}
+	}
+	go pipe(upstream, conn)
+	go pipe(conn, upstream)
+	wg.Wait()
+}
@@ -0,0 +1,106 @@
+package libcontainer
+
+import (
+	"context"
+	"io"
+	"net"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/runc/internal/testutil/nettest"
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/opencontainers/runc/libcontainer/system"
+)
+
+func TestForwardLoopbackPorts(t *testing.T) {
+	ctr := nettest.NewNS(t)
+	// A process in the namespace stands for the container init process.
+	cmd := exec.Command("sleep", "60")
+	ctr.Do(t, cmd.Start)
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+	stat, err := system.Stat(cmd.Process.Pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Container{
+		id:       "myid",
+		stateDir: t.TempDir(),
+		config: &configs.Config{
+			Rootfs:     "/var",
+			Namespaces: []configs.Namespace{{Type: configs.NEWNET}},
+		},
+		initProcess:          &mockProcess{_pid: cmd.Process.Pid, started: stat.StartTime},
+		initProcessStartTime: stat.StartTime,
+		cgroupManager:        &mockCgroupManager{},
+	}
+	c.state = &runningState{c: c}
+
+	// An echo server in the container.
+	var server net.Listener
+	ctr.Do(t, func() (err error) {
+		server, err = net.Listen("tcp", "127.0.0.1:0")
+		return err
+	})
+	defer server.Close()
+	go func() {
+		for {
+			conn, err := server.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, _ = io.Copy(conn, conn)
+			}()
+		}
+	}()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostPort := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- c.ForwardLoopbackPorts(ctx, &configs.PortForward{
+			HostPort:      uint16(hostPort),
+			ContainerPort: uint16(server.Addr().(*net.TCPAddr).Port),
+		})
+	}()
+	var conn net.Conn
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if conn, err = net.Dial("tcp", l.Addr().String()); err == nil || time.Now().After(deadline) {
+			break
+		}
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 4)
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadFull(conn, buf); err != nil || string(buf) != "ping" {
+		t.Fatalf("expected the data to be echoed through the forward, got %q (%v)", buf, err)
+	}
+
+	// The proxied connections are closed along with the listeners.
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Read(buf); err != io.EOF {
+		t.Errorf("expected the connection to be closed, got %v", err)
+	}
+}
@@ -0,0 +1,452 @@
+package libcontainer
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// DHCPv6 message types and options, from RFC 8415.
+const (
+	dhcp6Solicit   = 1
+	dhcp6Advertise = 2
+	dhcp6Request   = 3
+	dhcp6Reply     = 7
+
+	dhcp6OptClientID    = 1
+	dhcp6OptServerID    = 2
+	dhcp6OptElapsedTime = 8
+	dhcp6OptStatusCode  = 13
+	dhcp6OptIAPD        = 25
+	dhcp6OptIAPrefix    = 26
+
+	dhcp6ClientPort = 546
+	dhcp6ServerPort = 547
+)
+
+// dhcp6IAID is the identifier of the identity association the prefix is
+// delegated to. A single prefix is requested per interface.
+const dhcp6IAID = 1
+
+// allDHCPRelayAgentsAndServers is the multicast address the client messages
+// are sent to.
+var allDHCPRelayAgentsAndServers = net.ParseIP("ff02::1:2")
+
+// dhcp6Timing holds the retransmission timing of the DHCPv6 client, see RFC
+// 8415 section 15.
+type dhcp6Timing struct {
+	// initial is the time before the first retransmission of a message.
+	initial time.Duration
+	// max bounds the time between retransmissions, which doubles every
+	// time.
+	max time.Duration
+}
+
+// rfc8415Timing is the timing of Solicit messages defined by RFC 8415,
+// without the random jitter. It can be overridden by tests.
+var rfc8415Timing = dhcp6Timing{
+	initial: time.Second,
+	max:     time.Hour,
+}
+
+// defaultPrefixDelegationTimeout bounds the time waiting for a delegated
+// prefix if the network does not set it.
+const defaultPrefixDelegationTimeout = 10 * time.Second
+
+// dhcp6Option is a DHCPv6 option.
+type dhcp6Option struct {
+	code uint16
+	data []byte
+}
+
+// dhcp6Message is a DHCPv6 client or server message.
+type dhcp6Message struct {
+	typ     uint8
+	xid     [3]byte
+	options []dhcp6Option
+}
+
+func (m *dhcp6Message) marshal() []byte {
+	b := append([]byte{m.typ}, m.xid[:]...)
+	return append(b, marshalDHCP6Options(m.options)...)
+}
+
+// option returns the data of the first option with the given code.
+func (m *dhcp6Message) option(code uint16) ([]byte, bool) {
+	return findDHCP6Option(m.options, code)
+}
+
+func parseDHCP6Message(b []byte) (*dhcp6Message, error) {
+	if len(b) < 4 {
+		return nil, errors.New("short DHCPv6 message")
+	}
+	m := &dhcp6Message{typ: b[0]}
+	copy(m.xid[:], b[1:4])
+	options, err := parseDHCP6Options(b[4:])
+	if err != nil {
+		return nil, err
+	}
+	m.options = options
+	return m, nil
+}
+
+func marshalDHCP6Options(options []dhcp6Option) []byte {
+	var b []byte
+	for _, o := range options {
+		b = binary.BigEndian.AppendUint16(b, o.code)
+		b = binary.BigEndian.AppendUint16(b, uint16(len(o.data)))
+		b = append(b, o.data...)
+	}
+	return b
+}
+
+func parseDHCP6Options(b []byte) ([]dhcp6Option, error) {
+	var options []dhcp6Option
+	for len(b) > 0 {
+		if len(b) < 4 {
+			return nil, errors.New("truncated DHCPv6 option")
+		}
+		code, size := binary.BigEndian.Uint16(b), int(binary.BigEndian.Uint16(b[2:]))
+		if len(b) < 4+size {
+			return nil, fmt.Errorf("truncated DHCPv6 option %d", code)
+		}
+		options = append(options, dhcp6Option{code: code, data: b[4 : 4+size]})
+		b = b[4+size:]
+	}
+	return options, nil
+}
+
+func findDHCP6Option(options []dhcp6Option, code uint16) ([]byte, bool) {
+	for _, o := range options {
+		if o.code == code {
+			return o.data, true
+		}
+	}
+	return nil, false
+}
+
+// dhcp6StatusError returns an error if options hold a status code other
+// than success.
+func dhcp6StatusError(options []dhcp6Option) error {
+	status, ok := findDHCP6Option(options, dhcp6OptStatusCode)
+	if !ok || len(status) < 2 {
+		return nil
+	}
+	if code := binary.BigEndian.Uint16(status); code != 0 {
+		return fmt.Errorf("DHCPv6 server returned status %d: %q", code, status[2:])
+	}
+	return nil
+}
+
+// dhcp6IAPD returns an IA_PD option holding the given options.
+func dhcp6IAPD(options []dhcp6Option) dhcp6Option {
+	// T1 and T2 are left to the server.
+	data := make([]byte, 12)
+	binary.BigEndian.PutUint32(data, dhcp6IAID)
+	return dhcp6Option{code: dhcp6OptIAPD, data: append(data, marshalDHCP6Options(options)...)}
+}
+
+// dhcp6Prefix is a prefix delegated by a DHCPv6 server.
+type dhcp6Prefix struct {
+	prefix    *net.IPNet
+	preferred uint32
+	valid     uint32
+}
+
+// delegatedPrefix returns the prefix delegated in the IA_PD option of m.
+func delegatedPrefix(m *dhcp6Message) (*dhcp6Prefix, error) {
+	if err := dhcp6StatusError(m.options); err != nil {
+		return nil, err
+	}
+	for _, o := range m.options {
+		if o.code != dhcp6OptIAPD || len(o.data) < 12 || binary.BigEndian.Uint32(o.data) != dhcp6IAID {
+			continue
+		}
+		options, err := parseDHCP6Options(o.data[12:])
+		if err != nil {
+			return nil, err
+		}
+		if err := dhcp6StatusError(options); err != nil {
+			return nil, err
+		}
+		for _, p := range options {
+			if p.code != dhcp6OptIAPrefix || len(p.data) < 25 {
+				continue
+			}
+			preferred, valid, length := binary.BigEndian.Uint32(p.data), binary.BigEndian.Uint32(p.data[4:]), int(p.data[8])
+			if valid == 0 || length > 128 {
+				continue
+			}
+			if preferred > valid {
+				preferred = valid
+			}
+			ip := net.IP(append([]byte(nil), p.data[9:25]...))
+			return &dhcp6Prefix{
+				prefix:    &net.IPNet{IP: ip.Mask(net.CIDRMask(length, 128)), Mask: net.CIDRMask(length, 128)},
+				preferred: preferred,
+				valid:     valid,
+			}, nil
+		}
+	}
+	return nil, errors.New("no prefix delegated")
+}
+
+// delegatedSubnet returns the sub-prefix of prefix of the given length and
+// index.
+func delegatedSubnet(prefix *net.IPNet, length int, id uint64) (*net.IPNet, error) {
+	ones, _ := prefix.Mask.Size()
+	if length < ones || length > 128 {
+		return nil, fmt.Errorf("invalid subnet length %d for delegated prefix %s", length, prefix)
+	}
+	if bits := length - ones; bits < 64 && id>>bits != 0 {
+		return nil, fmt.Errorf("subnet %d does not fit in delegated prefix %s with length %d", id, prefix, length)
+	}
+	ip := new(big.Int).SetBytes(prefix.IP.To16())
+	ip.Or(ip, new(big.Int).Lsh(new(big.Int).SetUint64(id), uint(128-length)))
+	return &net.IPNet{IP: ip.FillBytes(make(net.IP, net.IPv6len)), Mask: net.CIDRMask(length, 128)}, nil
+}
+
+// setupPrefixDelegation requests a prefix with DHCPv6 on the interface of n,
+// and assigns a sub-prefix of it to the interface set in its prefix
+// delegation settings. The interface of n must be up. It must be called in
+// the container network namespace.
+func setupPrefixDelegation(ctx context.Context, n *configs.Network) error {
+	pd := n.PrefixDelegation
+	if pd == nil {
+		return nil
+	}
+	timeout := pd.Timeout
+	if timeout == 0 {
+		timeout = defaultPrefixDelegationTimeout
+	}
+	pdCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	name := containerInterfaceName(n)
+	link, err := netlink.LinkByName(name)
+	if err != nil {
+		return err
+	}
+	prefix, err := requestPrefix(pdCtx, link, pd.PrefixLength)
+	if err != nil {
+		if ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("no prefix delegated in %s", timeout)
+		}
+		return fmt.Errorf("unable to get a delegated prefix on %s: %w", name, err)
+	}
+	length := pd.SubnetLength
+	if length == 0 {
+		length = 64
+	}
+	subnet, err := delegatedSubnet(prefix.prefix, length, pd.SubnetID)
+	if err != nil {
+		return err
+	}
+	target, err := netlink.LinkByName(pd.Interface)
+	if err != nil {
+		return err
+	}
+	ip := subnet.IP
+	if length < 128 {
+		ip = append(net.IP(nil), ip...)
+		ip[15] |= 1
+	}
+	addr := &netlink.Addr{
+		IPNet:       &net.IPNet{IP: ip, Mask: subnet.Mask},
+		PreferedLft: int(prefix.preferred),
+		ValidLft:    int(prefix.valid),
+	}
+	if err := netlink.AddrAdd(target, addr); err != nil {
+		return fmt.Errorf("unable to add delegated address %s to %s: %w", addr.IPNet, pd.Interface, err)
+	}
+	if pd.Interface == "lo" {
+		// The loopback interface is only brought up by loopback networks.
+		return netlink.LinkSetUp(target)
+	}
+	return nil
+}
+
+// requestPrefix runs a DHCPv6 exchange on link to get a delegated prefix,
+// hinting the server with the given length if it is not zero.
+func requestPrefix(ctx context.Context, link netlink.Link, length int) (*dhcp6Prefix, error) {
+	attrs := link.Attrs()
+	clientID, err := dhcp6DUID(attrs.HardwareAddr)
+	if err != nil {
+		return nil, err
+	}
+	// Messages can not be sent before the interface has a link-local
+	// address, which is only usable once duplicate address detection is
+	// done.
+	if err := waitLinkLocal(ctx, link); err != nil {
+		return nil, err
+	}
+	conn, err := listenDHCP6(ctx, attrs.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var hint []dhcp6Option
+	if length != 0 {
+		data := make([]byte, 25)
+		data[8] = byte(length)
+		hint = append(hint, dhcp6Option{code: dhcp6OptIAPrefix, data: data})
+	}
+	solicit := &dhcp6Message{typ: dhcp6Solicit, options: []dhcp6Option{
+		{code: dhcp6OptClientID, data: clientID},
+		dhcp6IAPD(hint),
+	}}
+	advertise, err := dhcp6Exchange(ctx, conn, solicit, dhcp6Advertise)
+	if err != nil {
+		return nil, err
+	}
+	serverID, _ := advertise.option(dhcp6OptServerID)
+	iapd, _ := advertise.option(dhcp6OptIAPD)
+	request := &dhcp6Message{typ: dhcp6Request, options: []dhcp6Option{
+		{code: dhcp6OptClientID, data: clientID},
+		{code: dhcp6OptServerID, data: serverID},
+		{code: dhcp6OptIAPD, data: iapd},
+	}}
+	reply, err := dhcp6Exchange(ctx, conn, request, dhcp6Reply)
+	if err != nil {
+		return nil, err
+	}
+	return delegatedPrefix(reply)
+}
+
+// dhcp6DUID returns the link-layer address based DUID (DUID-LL) of an
+// interface with the hardware address mac.
+func dhcp6DUID(mac net.HardwareAddr) ([]byte, error) {
+	var hwType uint16
+	switch len(mac) {
+	case 6:
+		hwType = unix.ARPHRD_ETHER
+	case 20:
+		hwType = unix.ARPHRD_INFINIBAND
+	default:
+		return nil, errors.New("prefix delegation requires an ethernet or infiniband interface")
+	}
+	duid := []byte{0, 3}
+	duid = binary.BigEndian.AppendUint16(duid, hwType)
+	return append(duid, mac...), nil
+}
+
+// waitLinkLocal waits until link has a usable IPv6 link-local address.
+func waitLinkLocal(ctx context.Context, link netlink.Link) error {
+	for {
+		addrs, err := netlink.AddrList(link, netlink.FAMILY_V6)
+		if err != nil {
+			return err
+		}
+		for _, addr := range addrs {
+			if addr.IP.IsLinkLocalUnicast() && addr.Flags&(unix.IFA_F_TENTATIVE|unix.IFA_F_DADFAILED) == 0 {
+				return nil
+			}
+		}
+		if err := sleepCtx(ctx, 100*time.Millisecond); err != nil {
+			return fmt.Errorf("no IPv6 link-local address on %s: %w", link.Attrs().Name, err)
+		}
+	}
+}
+
+// listenDHCP6 opens the DHCPv6 client socket of the interface name.
+func listenDHCP6(ctx context.Context, name string) (net.PacketConn, error) {
+	lc := net.ListenConfig{Control: func(network, address string, c syscall.RawConn) error {
+		var sockErr error
+		err := c.Control(func(fd uintptr) {
+			// Several interfaces may request a prefix.
+			if sockErr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEADDR, 1); sockErr != nil {
+				return
+			}
+			sockErr = unix.BindToDevice(int(fd), name)
+		})
+		if err != nil {
+			return err
+		}
+		return sockErr
+	}}
+	conn, err := lc.ListenPacket(ctx, "udp6", fmt.Sprintf("[::]:%d", dhcp6ClientPort))
+	if err != nil {
+		return nil, fmt.Errorf("unable to open DHCPv6 socket: %w", err)
+	}
+	return conn, nil
+}
+
+// dhcp6Exchange sends m to the DHCPv6 servers on the link of conn,
+// retransmitting it until a reply of the given type is received.
+func dhcp6Exchange(ctx context.Context, conn net.PacketConn, m *dhcp6Message, want uint8) (*dhcp6Message, error) {
+	if _, err := rand.Read(m.xid[:]); err != nil {
+		return nil, err
+	}
+	// The zone is not set, as the net package resolves zones in a process
+	// wide cache, which does not account for network namespaces. The
+	// messages are sent on the interface the socket is bound to.
+	dst := &net.UDPAddr{IP: allDHCPRelayAgentsAndServers, Port: dhcp6ServerPort}
+	start := time.Now()
+	timing := rfc8415Timing
+	rt := timing.initial
+	buf := make([]byte, 65536)
+	for {
+		// The elapsed time is in hundredths of a second.
+		elapsed := make([]byte, 2)
+		if cs := time.Since(start).Milliseconds() / 10; cs < 0xffff {
+			binary.BigEndian.PutUint16(elapsed, uint16(cs))
+		} else {
+			binary.BigEndian.PutUint16(elapsed, 0xffff)
+		}
+		msg := *m
+		msg.options = append([]dhcp6Option{{code: dhcp6OptElapsedTime, data: elapsed}}, m.options...)
+		if _, err := conn.WriteTo(msg.marshal(), dst); err != nil {
+			return nil, fmt.Errorf("unable to send DHCPv6 message: %w", err)
+		}
+		deadline := time.Now().Add(rt)
+		for {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			wait := time.Until(deadline)
+			if wait <= 0 {
+				break
+			}
+			// Wake up regularly to check ctx.
+			if wait > 100*time.Millisecond {
+				wait = 100 * time.Millisecond
+			}
+			if err := conn.SetReadDeadline(time.Now().Add(wait)); err != nil {
+				return nil, err
+			}
+			size, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				if errors.Is(err, os.ErrDeadlineExceeded) {
+					continue
+				}
+				return nil, err
+			}
+			reply, err := parseDHCP6Message(buf[:size])
+			if err != nil || reply.typ != want || reply.xid != m.xid {
+				continue
+			}
+			// Servers which have no prefix to delegate still advertise.
+			if want == dhcp6Advertise {
+				if _, err := delegatedPrefix(reply); err != nil {
+					continue
+				}
+			}
+			return reply, nil
+		}
+		if rt *= 2; rt > timing.max {
+			rt = timing.max
+		}
+	}
+}
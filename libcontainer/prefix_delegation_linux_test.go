@@ -0,0 +1,212 @@
+package libcontainer
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/runc/internal/testutil/nettest"
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+func TestDelegatedSubnet(t *testing.T) {
+	_, prefix, _ := net.ParseCIDR("2001:db8:ab00::/56")
+	testCases := []struct {
+		length int
+		id     uint64
+		subnet string
+	}{
+		{length: 64, id: 0, subnet: "2001:db8:ab00::/64"},
+		{length: 64, id: 3, subnet: "2001:db8:ab00:3::/64"},
+		{length: 64, id: 255, subnet: "2001:db8:ab00:ff::/64"},
+		{length: 60, id: 1, subnet: "2001:db8:ab00:10::/60"},
+		{length: 56, id: 0, subnet: "2001:db8:ab00::/56"},
+		{length: 128, id: 1, subnet: "2001:db8:ab00::1/128"},
+		{length: 64, id: 256},
+		{length: 56, id: 1},
+		{length: 48},
+	}
+	for _, tc := range testCases {
+		subnet, err := delegatedSubnet(prefix, tc.length, tc.id)
+		if tc.subnet == "" {
+			if err == nil {
+				t.Errorf("subnet %d/%d: expected error, got %s", tc.id, tc.length, subnet)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("subnet %d/%d: unexpected error: %v", tc.id, tc.length, err)
+		} else if subnet.String() != tc.subnet {
+			t.Errorf("subnet %d/%d: expected %s, got %s", tc.id, tc.length, tc.subnet, subnet)
+		}
+	}
+}
+
+func TestDelegatedPrefix(t *testing.T) {
+	reply := &dhcp6Message{typ: dhcp6Reply, options: []dhcp6Option{
+		dhcp6IAPD([]dhcp6Option{iaPrefix("2001:db8:ab00::/56", 7200, 3600)}),
+	}}
+	parsed, err := parseDHCP6Message(reply.marshal())
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := delegatedPrefix(parsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The preferred lifetime is capped by the valid one.
+	if p.prefix.String() != "2001:db8:ab00::/56" || p.preferred != 3600 || p.valid != 3600 {
+		t.Errorf("unexpected delegated prefix %s, preferred %d, valid %d", p.prefix, p.preferred, p.valid)
+	}
+
+	noPrefix := &dhcp6Message{typ: dhcp6Reply, options: []dhcp6Option{
+		dhcp6IAPD([]dhcp6Option{{code: dhcp6OptStatusCode, data: append([]byte{0, 6}, "no prefix"...)}}),
+	}}
+	if _, err := delegatedPrefix(noPrefix); err == nil {
+		t.Error("expected error for a NoPrefixAvail status")
+	}
+	if _, err := parseDHCP6Message([]byte{dhcp6Reply, 0, 0, 0, 0, dhcp6OptIAPD, 0, 40}); err == nil {
+		t.Error("expected error for a truncated option")
+	}
+}
+
+func TestSetupPrefixDelegation(t *testing.T) {
+	timing := rfc8415Timing
+	t.Cleanup(func() { rfc8415Timing = timing })
+	rfc8415Timing = dhcp6Timing{initial: 200 * time.Millisecond, max: time.Second}
+
+	router := nettest.NewNS(t)
+	ctr := nettest.NewNS(t)
+	router.AddVeth(t, "peer0", "eth0", ctr)
+	router.AddVeth(t, "peer1", "lan0", ctr)
+	router.Do(t, func() error {
+		return netlink.LinkSetUp(&netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: "peer0"}})
+	})
+	ctr.Do(t, func() error {
+		return netlink.LinkSetUp(&netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: "eth0"}})
+	})
+
+	var conn net.PacketConn
+	router.Do(t, func() error {
+		var err error
+		conn, err = listenDHCP6Server("peer0")
+		return err
+	})
+	defer conn.Close()
+	go serveDHCP6(conn, "2001:db8:ab00::/56")
+
+	n := &configs.Network{
+		Type: "veth",
+		Name: "eth0",
+		PrefixDelegation: &configs.PrefixDelegationSettings{
+			Interface:    "lan0",
+			PrefixLength: 56,
+			SubnetID:     2,
+		},
+	}
+	ctr.Do(t, func() error { return setupPrefixDelegation(context.Background(), n) })
+	found := false
+	for _, addr := range ctr.Addrs(t, "lan0") {
+		if addr == "2001:db8:ab00:2::1/64" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected the delegated address on lan0, got %v", ctr.Addrs(t, "lan0"))
+	}
+}
+
+func TestSetupPrefixDelegationTimeout(t *testing.T) {
+	host := nettest.NewNS(t)
+	ctr := nettest.NewNS(t)
+	host.AddVeth(t, "peer0", "eth0", ctr)
+	n := &configs.Network{
+		Type: "veth",
+		Name: "eth0",
+		PrefixDelegation: &configs.PrefixDelegationSettings{
+			Interface: "lo",
+			Timeout:   300 * time.Millisecond,
+		},
+	}
+	// The interface is down, so it never gets a link-local address.
+	err := ctr.Run(func() error { return setupPrefixDelegation(context.Background(), n) })
+	if err == nil {
+		t.Fatal("expected error without a DHCPv6 server")
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the timeout of the network to be reported, got %v", err)
+	}
+}
+
+func iaPrefix(cidr string, preferred, valid uint32) dhcp6Option {
+	_, prefix, _ := net.ParseCIDR(cidr)
+	ones, _ := prefix.Mask.Size()
+	data := binary.BigEndian.AppendUint32(nil, preferred)
+	data = binary.BigEndian.AppendUint32(data, valid)
+	data = append(data, byte(ones))
+	return dhcp6Option{code: dhcp6OptIAPrefix, data: append(data, prefix.IP.To16()...)}
+}
+
+// listenDHCP6Server opens a DHCPv6 server socket on the interface name. It
+// must be called in the network namespace of the interface.
+func listenDHCP6Server(name string) (net.PacketConn, error) {
+	ifi, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, err
+	}
+	lc := net.ListenConfig{Control: func(network, address string, c syscall.RawConn) error {
+		var sockErr error
+		err := c.Control(func(fd uintptr) {
+			mreq := &unix.IPv6Mreq{Interface: uint32(ifi.Index)}
+			copy(mreq.Multiaddr[:], allDHCPRelayAgentsAndServers)
+			sockErr = unix.SetsockoptIPv6Mreq(int(fd), unix.IPPROTO_IPV6, unix.IPV6_JOIN_GROUP, mreq)
+		})
+		if err != nil {
+			return err
+		}
+		return sockErr
+	}}
+	return lc.ListenPacket(context.Background(), "udp6", fmt.Sprintf("[::]:%d", dhcp6ServerPort))
+}
+
+// serveDHCP6 delegates prefix to every client, until conn is closed.
+func serveDHCP6(conn net.PacketConn, prefix string) {
+	buf := make([]byte, 65536)
+	for {
+		size, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, os.ErrClosed) {
+				return
+			}
+			continue
+		}
+		m, err := parseDHCP6Message(buf[:size])
+		if err != nil {
+			continue
+		}
+		reply := &dhcp6Message{xid: m.xid}
+		switch m.typ {
+		case dhcp6Solicit:
+			reply.typ = dhcp6Advertise
+		case dhcp6Request:
+			reply.typ = dhcp6Reply
+		default:
+			continue
+		}
+		clientID, _ := m.option(dhcp6OptClientID)
+		reply.options = []dhcp6Option{
+			{code: dhcp6OptClientID, data: clientID},
+			{code: dhcp6OptServerID, data: []byte{0, 3, 0, 1, 2, 0, 0, 0, 0, 1}},
+			dhcp6IAPD([]dhcp6Option{iaPrefix(prefix, 3600, 7200)}),
+		}
+		_, _ = conn.WriteTo(reply.marshal(), addr)
+	}
+}
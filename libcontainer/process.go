@@ -1,6 +1,7 @@
 package libcontainer
 
 import (
+	"context"
 	"errors"
 	"io"
 	"math"
@@ -100,6 +101,20 @@ type Process struct {
 	SubCgroupPaths map[string]string
 
 	Scheduler *configs.Scheduler
+
+	// NetworkProgress, if set, is called as each network of the container
+	// goes through the stages of its setup. It is only used for the init
+	// process. It is called from the goroutine starting the container,
+	// except with a NetworkOptions.SetupTimeout, where the networks are
+	// created in another goroutine. It is not called anymore once the
+	// timeout expires, and never after Start returned.
+	NetworkProgress func(NetworkProgress)
+
+	// ResolveNetwork, if set, is called for each network of the container
+	// before its interface is created, to supply settings which are not
+	// known in advance, such as addresses allocated by an IPAM. It is only
+	// used for the init process.
+	ResolveNetwork func(context.Context, *configs.Network) (*ResolvedNetwork, error)
 }
 
 // Wait waits for the process to exit.
@@ -61,6 +61,9 @@ type processComm struct {
 	// Used for log forwarding from "runc init" to the parent.
 	logPipeParent *os.File
 	logPipeChild  *os.File
+	// Placeholder for the file descriptors reserved for the activation
+	// sockets created by "runc init", if any.
+	activationPlaceholder *os.File
 }
 
 func newProcessComm() (*processComm, error) {
@@ -87,6 +90,9 @@ func (c *processComm) closeChild() {
 	_ = c.initSockChild.Close()
 	_ = c.syncSockChild.Close()
 	_ = c.logPipeChild.Close()
+	if c.activationPlaceholder != nil {
+		_ = c.activationPlaceholder.Close()
+	}
 }
 
 func (c *processComm) closeParent() {
@@ -699,6 +705,18 @@ func (p *initProcess) start() (retErr error) {
 				containerProcessState, seccompFd); err != nil {
 				return err
 			}
+		case procNetworkSkipped:
+			var skipped []SkippedNetworkSetting
+			if sync.Arg == nil {
+				return fmt.Errorf("sync %q is missing an argument", sync.Type)
+			}
+			if err := json.Unmarshal(*sync.Arg, &skipped); err != nil {
+				return fmt.Errorf("sync %q passed invalid argument: %w", sync.Type, err)
+			}
+			for _, s := range skipped {
+				logrus.Warnf("network setting %s of %s was skipped: %s", s.Setting, s.Interface, s.Reason)
+			}
+			p.container.skippedNetwork = append(p.container.skippedNetwork, skipped...)
 		case procReady:
 			seenProcReady = true
 			// set rlimits, this has to be done here because we lose permissions
@@ -728,6 +746,18 @@ func (p *initProcess) start() (retErr error) {
 			}
 			p.container.initProcessStartTime = state.InitProcessStartTime
 
+			// The network has been set up by the child before it got ready.
+			if len(p.config.Config.Networks) > 0 {
+				nsPath := fmt.Sprintf("/proc/%d/ns/net", p.pid())
+				if err := prepareNetworkStats(p.config.Config.NetworkOptions, nsPath, p.config.Config.Networks); err != nil {
+					return fmt.Errorf("unable to prepare network stats: %w", err)
+				}
+				warnNetworkMTU(nsPath, p.config.Config.Networks)
+			}
+			if err := p.notifyNetworkReady(); err != nil {
+				return fmt.Errorf("unable to notify network readiness: %w", err)
+			}
+
 			// Sync with child.
 			if err := writeSync(p.comm.syncSockParent, procRun); err != nil {
 				return err
@@ -751,6 +781,11 @@ func (p *initProcess) start() (retErr error) {
 				s.Pid = p.cmd.Process.Pid
 				s.Status = specs.StateCreating
 				hooks := p.config.Config.Hooks
+				if len(hooks[configs.Prestart]) != 0 || len(hooks[configs.CreateRuntime]) != 0 {
+					if err := annotateNetworkStatus(s, fmt.Sprintf("/proc/%d/ns/net", s.Pid), p.config.Config.Networks); err != nil {
+						return fmt.Errorf("unable to inspect network interfaces for hooks: %w", err)
+					}
+				}
 
 				if err := hooks.Run(configs.Prestart, s); err != nil {
 					return err
@@ -813,18 +848,109 @@ func (p *initProcess) updateSpecState() error {
 }
 
 func (p *initProcess) createNetworkInterfaces() error {
-	for _, config := range p.config.Config.Networks {
-		strategy, err := getStrategy(config.Type)
-		if err != nil {
-			return err
+	if len(p.config.Config.Networks) == 0 {
+		return nil
+	}
+	// Stop waiting on the network setup if runc init dies.
+	ctx, cancel := processExitContext(p.pid())
+	defer cancel()
+	if opts := p.config.Config.NetworkOptions; opts != nil && opts.SetupTimeout > 0 {
+		// The deadline covers the setup in the container too.
+		p.config.NetworkDeadline = time.Now().Add(opts.SetupTimeout)
+	}
+	// The results are only kept once the setup completes, as it may be
+	// abandoned while still running when the deadline expires.
+	progress := &networkProgressReporter{fn: p.process.NetworkProgress}
+	var (
+		networks    []*network
+		skipped     []SkippedNetworkSetting
+		unavailable = make(map[*configs.Network]bool)
+	)
+	setup := func(ctx context.Context) error {
+		for _, config := range p.config.Config.Networks {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			strategy, err := getStrategy(config.Type)
+			if err != nil {
+				return err
+			}
+			n := &network{
+				Network:  *config,
+				stateDir: p.container.stateDir,
+			}
+			applyNetworkProfile(&n.Network)
+			if p.process.ResolveNetwork != nil {
+				resolved, err := p.process.ResolveNetwork(ctx, config)
+				if err != nil {
+					return fmt.Errorf("unable to resolve network %q: %w", config.Name, err)
+				}
+				if err := resolved.apply(n); err != nil {
+					return fmt.Errorf("invalid settings resolved for network %q: %w", config.Name, err)
+				}
+			}
+			if err := waitNetlinkToken(ctx, filepath.Dir(p.container.stateDir), netlinkRateLimit(p.config.Config)); err != nil {
+				return err
+			}
+			// Resolving the network may have outlasted the deadline.
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := strategy.create(ctx, n, p.pid()); err != nil {
+				if !config.Optional || !unavailableDevice(err) {
+					return progress.report(config, NetworkCreated, err)
+				}
+				_ = progress.report(config, NetworkCreated, err)
+				logrus.Warnf("optional network %s was skipped: %v", containerInterfaceName(config), err)
+				skipped = append(skipped, SkippedNetworkSetting{Interface: containerInterfaceName(config), Setting: "device", Reason: err.Error()})
+				unavailable[config] = true
+				continue
+			}
+			_ = progress.report(config, NetworkCreated, nil)
+			s, err := setupHostInterface(ctx, &n.Network)
+			if err != nil {
+				return progress.report(config, NetworkConfigured, err)
+			}
+			skipped = append(skipped, s...)
+			_ = progress.report(config, NetworkConfigured, nil)
+			networks = append(networks, n)
 		}
-		n := &network{
-			Network: *config,
+		return nil
+	}
+	if opts := p.config.Config.NetworkOptions; opts != nil && opts.IsolateMounts {
+		hostSetup := setup
+		setup = func(ctx context.Context) error {
+			return doInMountNS(func() error { return hostSetup(ctx) })
 		}
-		if err := strategy.create(n, p.pid()); err != nil {
-			return err
+	}
+	err := runNetworkSetup(ctx, p.config.NetworkDeadline, setup)
+	// An abandoned setup may still be running.
+	progress.stop()
+	if err != nil {
+		return err
+	}
+	p.container.skippedNetwork = append(p.container.skippedNetwork, skipped...)
+	p.config.Networks = append(p.config.Networks, networks...)
+	if len(unavailable) > 0 {
+		// The later steps and the network commands ignore the skipped
+		// networks, until they are reconciled.
+		networks, routes := removeNetworks(p.config.Config, unavailable)
+		p.container.pendingNetworks = append(p.container.pendingNetworks, networks...)
+		p.container.pendingRoutes = append(p.container.pendingRoutes, routes...)
+	}
+	for _, n := range networks {
+		// The config is sent to runc init afterwards, which creates the
+		// device nodes with the rootfs.
+		for _, d := range n.devices {
+			p.config.Config.Devices = append(p.config.Config.Devices, d)
+			if p.config.Config.Cgroups != nil && p.config.Config.Cgroups.Resources != nil {
+				p.config.Config.Cgroups.Resources.Devices = append(p.config.Config.Cgroups.Resources.Devices, &d.Rule)
+			}
 		}
-		p.config.Networks = append(p.config.Networks, n)
+	}
+	// The limits are applied along with the other resources.
+	if p.config.Config.Cgroups != nil && p.config.Config.Cgroups.Resources != nil {
+		p.container.networkRdma = addNetworkRdmaLimits(p.config.Config.Cgroups.Resources, networks)
 	}
 	return nil
 }
@@ -845,6 +971,44 @@ func (p *initProcess) forwardChildLogs() chan error {
 	return logs.ForwardLogs(p.comm.logPipeParent)
 }
 
+// processExitContext returns a context which is cancelled once the process
+// with the given pid exits. If pidfd_open(2) is not supported, the context is
+// only cancelled by calling the returned function.
+func processExitContext(pid int) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	pidFd, err := unix.PidfdOpen(pid, 0)
+	if err != nil {
+		return ctx, cancel
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		unix.Close(pidFd)
+		return ctx, cancel
+	}
+	go func() {
+		defer unix.Close(pidFd)
+		defer r.Close()
+		fds := []unix.PollFd{
+			{Fd: int32(pidFd), Events: unix.POLLIN},
+			{Fd: int32(r.Fd()), Events: unix.POLLIN},
+		}
+		for {
+			_, err := unix.Poll(fds, -1)
+			if err == unix.EINTR {
+				continue
+			}
+			// Either the process exited, or the write end of the pipe was
+			// closed by cancel.
+			cancel()
+			return
+		}
+	}()
+	return ctx, func() {
+		cancel()
+		w.Close()
+	}
+}
+
 func pidGetFd(pid, srcFd int) (*os.File, error) {
 	pidFd, err := unix.PidfdOpen(pid, 0)
 	if err != nil {
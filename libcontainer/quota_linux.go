@@ -0,0 +1,275 @@
+package libcontainer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+)
+
+// quotaExceededFilename is the file of the container state directory
+// recording the exceeded quotas.
+const quotaExceededFilename = "network-quotas.json"
+
+// quotaObject is the name of the nftables quota object of an interface.
+const quotaObject = "traffic"
+
+// quotaCounter is the name of the nftables counter object counting the
+// packets of an interface with a quota of packets.
+const quotaCounter = "traffic_packets"
+
+// quotaCheckInterval is the interval between two checks of the quotas by
+// EnforceNetworkQuotas.
+var quotaCheckInterval = 5 * time.Second
+
+// QuotaExceeded reports that the quota of an interface was exceeded.
+type QuotaExceeded struct {
+	// Interface is the name of the interface inside the container.
+	Interface string `json:"interface"`
+	// Bytes is the quota of bytes of the interface.
+	Bytes uint64 `json:"bytes,omitempty"`
+	// Packets is the quota of packets of the interface.
+	Packets uint64 `json:"packets,omitempty"`
+	// Action is the action applied to the interface.
+	Action configs.QuotaAction `json:"action"`
+	// Time is the time the quota was found exceeded.
+	Time time.Time `json:"time"`
+}
+
+// quotaTable is the name of the nftables table holding the quota of the
+// interface ifName in the container network namespace.
+func quotaTable(ifName string) string {
+	return "runc-quota-" + ifName
+}
+
+func quotaAction(q *configs.InterfaceQuota) configs.QuotaAction {
+	if q.Action == "" {
+		return configs.QuotaDropNew
+	}
+	return q.Action
+}
+
+// quotaRuleset returns the body of the table of the quota of the interface
+// name. The quota object counts the bytes of the interface in every hook it
+// goes through, and matches once exceeded, while the counter object counts
+// its packets for EnforceNetworkQuotas. Once exceeded is set, the verdict of
+// the action applies to all the traffic of the interface.
+func quotaRuleset(name string, q *configs.InterfaceQuota, exceeded bool) string {
+	var verdict string
+	switch quotaAction(q) {
+	case configs.QuotaDropNew:
+		verdict = " ct state new drop"
+	case configs.QuotaDrop:
+		verdict = " drop"
+	}
+	match := func(dir string) string {
+		rule := fmt.Sprintf("%sname %q", dir, name)
+		if q.Packets != 0 {
+			rule += fmt.Sprintf(" counter name %q", quotaCounter)
+		}
+		if exceeded {
+			rule += verdict
+		} else if q.Bytes != 0 {
+			rule += fmt.Sprintf(" quota name %q%s", quotaObject, verdict)
+		}
+		return "\t\t" + rule + "\n"
+	}
+	var b strings.Builder
+	if q.Bytes != 0 && !exceeded {
+		fmt.Fprintf(&b, "\tquota %s {\n\t\tover %d bytes\n\t}\n", quotaObject, q.Bytes)
+	}
+	if q.Packets != 0 {
+		fmt.Fprintf(&b, "\tcounter %s {\n\t}\n", quotaCounter)
+	}
+	for _, c := range []struct {
+		hook string
+		dirs []string
+	}{
+		{"input", []string{"iif"}},
+		{"output", []string{"oif"}},
+		{"forward", []string{"iif", "oif"}},
+	} {
+		fmt.Fprintf(&b, "\tchain %s {\n\t\ttype filter hook %s priority -150;\n", c.hook, c.hook)
+		for _, dir := range c.dirs {
+			b.WriteString(match(dir))
+		}
+		b.WriteString("\t}\n")
+	}
+	return b.String()
+}
+
+// setupQuota installs the quota of the interface of n. It must be called in
+// the container network namespace.
+func setupQuota(ctx context.Context, n *configs.Network) error {
+	if n.Quota == nil {
+		return nil
+	}
+	name := containerInterfaceName(n)
+	if err := nftApply(ctx, "", nftReplaceTable("inet", quotaTable(name), quotaRuleset(name, n.Quota, false))); err != nil {
+		return fmt.Errorf("unable to set the quota of %s: %w", name, err)
+	}
+	return nil
+}
+
+// quotaUsed returns the number of bytes and packets counted by the quota
+// of the interface name, in the network namespace at nsPath.
+func quotaUsed(ctx context.Context, nsPath, name string) (bytes, packets uint64, _ error) {
+	out, err := nftList(ctx, nsPath, "-j", "list", "table", "inet", quotaTable(name))
+	if err != nil {
+		return 0, 0, err
+	}
+	var list struct {
+		Nftables []struct {
+			Quota *struct {
+				Name string `json:"name"`
+				Used uint64 `json:"used"`
+			} `json:"quota"`
+			Counter *struct {
+				Name    string `json:"name"`
+				Packets uint64 `json:"packets"`
+			} `json:"counter"`
+		} `json:"nftables"`
+	}
+	if err := json.Unmarshal(out, &list); err != nil {
+		return 0, 0, fmt.Errorf("invalid nft output: %w", err)
+	}
+	found := false
+	for _, o := range list.Nftables {
+		switch {
+		case o.Quota != nil && o.Quota.Name == quotaObject:
+			bytes, found = o.Quota.Used, true
+		case o.Counter != nil && o.Counter.Name == quotaCounter:
+			packets, found = o.Counter.Packets, true
+		}
+	}
+	if !found {
+		return 0, 0, fmt.Errorf("quota of %s not found", name)
+	}
+	return bytes, packets, nil
+}
+
+// quotaExceeded reports whether the bytes or packets counted for the
+// interface exceed its quota q.
+func quotaExceeded(q *configs.InterfaceQuota, bytes, packets uint64) bool {
+	return (q.Bytes != 0 && bytes > q.Bytes) || (q.Packets != 0 && packets > q.Packets)
+}
+
+// EnforceNetworkQuotas periodically checks the quotas of the container
+// interfaces, recording those exceeded in the container state directory, as
+// returned by NetworkQuotasExceeded, and bringing the interfaces with the
+// QuotaLinkDown action down. The actions of the quotas of packets, which the
+// kernel can not apply, are applied as well. The quotas are not checked while the container
+// is paused. It returns once ctx is cancelled or the container has stopped.
+func (c *Container) EnforceNetworkQuotas(ctx context.Context) error {
+	var networks []*configs.Network
+	for _, n := range c.config.Networks {
+		if n.Quota != nil {
+			networks = append(networks, n)
+		}
+	}
+	if len(networks) == 0 {
+		return errors.New("no network has a quota")
+	}
+	exceeded, err := c.NetworkQuotasExceeded()
+	if err != nil {
+		return err
+	}
+	done := make(map[string]bool, len(exceeded))
+	for _, e := range exceeded {
+		done[e.Interface] = true
+	}
+	monitor := newNetworkMonitor(c, "quota")
+	defer monitor.stop()
+	ticker := time.NewTicker(quotaCheckInterval)
+	defer ticker.Stop()
+	for {
+		nsPath, suspended, err := monitor.check()
+		if err != nil {
+			if errors.Is(err, ErrNotRunning) {
+				return nil
+			}
+			return err
+		}
+		changed := false
+		for _, n := range networks {
+			name := containerInterfaceName(n)
+			if suspended || done[name] {
+				continue
+			}
+			bytes, packets, err := quotaUsed(ctx, nsPath, name)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				logrus.Warnf("unable to check the quota of %s: %v", name, err)
+				continue
+			}
+			if !quotaExceeded(n.Quota, bytes, packets) {
+				continue
+			}
+			e := QuotaExceeded{Interface: name, Bytes: n.Quota.Bytes, Packets: n.Quota.Packets, Action: quotaAction(n.Quota), Time: time.Now()}
+			logrus.Warnf("quota of %s exceeded with %d bytes and %d packets, action %s", name, bytes, packets, e.Action)
+			switch e.Action {
+			case configs.QuotaLinkDown:
+				if err := doInNetNS(nsPath, func() error { return setLinkDown(name) }); err != nil {
+					logrus.Warnf("unable to bring %s down: %v", name, err)
+				}
+			case configs.QuotaDropNew, configs.QuotaDrop:
+				// The kernel applies the action once the quota of bytes
+				// is exceeded, but not the quota of packets.
+				if n.Quota.Packets != 0 && packets > n.Quota.Packets {
+					ruleset := nftReplaceTable("inet", quotaTable(name), quotaRuleset(name, n.Quota, true))
+					if err := nftApply(ctx, nsPath, ruleset); err != nil {
+						logrus.Warnf("unable to apply the quota of %s: %v", name, err)
+					}
+				}
+			}
+			exceeded = append(exceeded, e)
+			done[name] = true
+			changed = true
+		}
+		if changed {
+			if err := c.writeStateFile(quotaExceededFilename, exceeded); err != nil {
+				return err
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func setLinkDown(name string) error {
+	link, err := netlink.LinkByName(name)
+	if err != nil {
+		return err
+	}
+	return netlink.LinkSetDown(link)
+}
+
+// NetworkQuotasExceeded returns the quotas of the container interfaces
+// found exceeded by EnforceNetworkQuotas.
+func (c *Container) NetworkQuotasExceeded() ([]QuotaExceeded, error) {
+	data, err := os.ReadFile(filepath.Join(c.stateDir, quotaExceededFilename))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var exceeded []QuotaExceeded
+	if err := json.Unmarshal(data, &exceeded); err != nil {
+		return nil, err
+	}
+	return exceeded, nil
+}
@@ -0,0 +1,144 @@
+package libcontainer
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/runc/internal/testutil/nettest"
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+)
+
+func TestQuotaRuleset(t *testing.T) {
+	expected := `	quota traffic {
+		over 1000 bytes
+	}
+	chain input {
+		type filter hook input priority -150;
+		iifname "eth0" quota name "traffic" ct state new drop
+	}
+	chain output {
+		type filter hook output priority -150;
+		oifname "eth0" quota name "traffic" ct state new drop
+	}
+	chain forward {
+		type filter hook forward priority -150;
+		iifname "eth0" quota name "traffic" ct state new drop
+		oifname "eth0" quota name "traffic" ct state new drop
+	}
+`
+	if got := quotaRuleset("eth0", &configs.InterfaceQuota{Bytes: 1000}, false); got != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+	// The quota only counts the traffic for the actions applied by runc.
+	got := quotaRuleset("eth0", &configs.InterfaceQuota{Bytes: 1000, Action: configs.QuotaEvent}, false)
+	if want := "\t\tiifname \"eth0\" quota name \"traffic\"\n"; !strings.Contains(got, want) {
+		t.Errorf("expected %q in:\n%s", want, got)
+	}
+}
+
+func TestQuotaRulesetPackets(t *testing.T) {
+	q := &configs.InterfaceQuota{Bytes: 1000, Packets: 10, Action: configs.QuotaDrop}
+	got := quotaRuleset("eth0", q, false)
+	for _, want := range []string{
+		"\tquota traffic {\n\t\tover 1000 bytes\n\t}\n",
+		"\tcounter traffic_packets {\n\t}\n",
+		"\t\toifname \"eth0\" counter name \"traffic_packets\" quota name \"traffic\" drop\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in:\n%s", want, got)
+		}
+	}
+	// Without a quota of bytes, the packets are only counted.
+	got = quotaRuleset("eth0", &configs.InterfaceQuota{Packets: 10, Action: configs.QuotaDrop}, false)
+	if strings.Contains(got, "quota") || strings.Contains(got, "drop") {
+		t.Errorf("expected no quota object nor verdict in:\n%s", got)
+	}
+	// Once exceeded, all the traffic gets the verdict of the action.
+	got = quotaRuleset("eth0", q, true)
+	if strings.Contains(got, "quota") {
+		t.Errorf("expected no quota object in:\n%s", got)
+	}
+	if want := "\t\tiifname \"eth0\" counter name \"traffic_packets\" drop\n"; !strings.Contains(got, want) {
+		t.Errorf("expected %q in:\n%s", want, got)
+	}
+}
+
+func TestEnforceNetworkQuotas(t *testing.T) {
+	// nft reports 2000 bytes and 20 packets used by the quota of every
+	// interface, and records the rulesets applied.
+	bin := t.TempDir()
+	applied := filepath.Join(bin, "applied")
+	script := "#!/bin/sh\n" +
+		"if [ \"$1\" = -f ]; then cat >> " + applied + "; exit 0; fi\n" +
+		"echo '{\"nftables\": [{\"metainfo\": {}}, {\"quota\": {\"name\": \"traffic\", \"bytes\": 1000, \"used\": 2000}}, " +
+		"{\"counter\": {\"name\": \"traffic_packets\", \"packets\": 20, \"bytes\": 2000}}]}'\n"
+	if err := os.WriteFile(filepath.Join(bin, "nft"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+":"+os.Getenv("PATH"))
+	interval := quotaCheckInterval
+	t.Cleanup(func() { quotaCheckInterval = interval })
+	quotaCheckInterval = 10 * time.Millisecond
+
+	ctr := nettest.NewNS(t)
+	ctr.AddVeth(t, "eth0", "peer0", nil)
+	ctr.AddVeth(t, "eth1", "peer1", nil)
+	ctr.AddVeth(t, "eth2", "peer2", nil)
+	ctr.AddVeth(t, "eth3", "peer3", nil)
+	ctr.Do(t, func() error {
+		for _, name := range []string{"eth0", "eth1", "eth2", "eth3"} {
+			if err := netlink.LinkSetUp(&netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: name}}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	c := runningNetNSContainer(t, "myid", ctr)
+	c.config.Networks = []*configs.Network{
+		{Type: "veth", Name: "eth0", Quota: &configs.InterfaceQuota{Bytes: 1000, Action: configs.QuotaLinkDown}},
+		{Type: "veth", Name: "eth1", Quota: &configs.InterfaceQuota{Bytes: 1500, Action: configs.QuotaEvent}},
+		{Type: "veth", Name: "eth2", Quota: &configs.InterfaceQuota{Packets: 10, Action: configs.QuotaDrop}},
+		{Type: "veth", Name: "eth3", Quota: &configs.InterfaceQuota{Bytes: 5000, Packets: 50}},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- c.EnforceNetworkQuotas(ctx) }()
+	var exceeded []QuotaExceeded
+	for deadline := time.Now().Add(5 * time.Second); len(exceeded) < 3 && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+		var err error
+		if exceeded, err = c.NetworkQuotasExceeded(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if len(exceeded) != 3 || exceeded[0].Interface != "eth0" || exceeded[1].Action != configs.QuotaEvent ||
+		exceeded[2].Interface != "eth2" || exceeded[2].Packets != 10 {
+		t.Fatalf("expected the quotas of eth0, eth1 and eth2 to be exceeded, got %+v", exceeded)
+	}
+	if ctr.Link(t, "eth0").Attrs().Flags&net.FlagUp != 0 {
+		t.Error("expected eth0 to be brought down")
+	}
+	if ctr.Link(t, "eth1").Attrs().Flags&net.FlagUp == 0 {
+		t.Error("expected eth1 to be left up")
+	}
+	// The kernel can not drop the traffic once the quota of packets of eth2
+	// is exceeded, so runc does.
+	data, err := os.ReadFile(applied)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "\t\tiifname \"eth2\" counter name \"traffic_packets\" drop\n"; !strings.Contains(string(data), want) {
+		t.Errorf("expected %q in the applied rulesets:\n%s", want, data)
+	}
+}
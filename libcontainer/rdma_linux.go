@@ -0,0 +1,61 @@
+package libcontainer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/opencontainers/runc/libcontainer/cgroups"
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+// rdmaDevice returns the name of the RDMA device backing the network
+// interface name, as found in sysfs, the sysfs directory of the network
+// interfaces.
+func rdmaDevice(sysfs, name string) (string, error) {
+	entries, err := os.ReadDir(filepath.Join(sysfs, name, "device", "infiniband"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	if len(entries) == 0 {
+		return "", fmt.Errorf("interface %s has no rdma device", name)
+	}
+	return entries[0].Name(), nil
+}
+
+// addNetworkRdmaLimits adds the RDMA limits of the networks to r, for the
+// RDMA devices found when the networks were created. The limits already set
+// in r for a device are kept. It returns the devices whose limits were added,
+// by container interface name.
+func addNetworkRdmaLimits(r *configs.Resources, networks []*network) map[string]string {
+	var added map[string]string
+	for _, n := range networks {
+		if n.RDMA == nil || n.rdmaDevice == "" {
+			continue
+		}
+		if _, ok := r.Rdma[n.rdmaDevice]; ok {
+			continue
+		}
+		if r.Rdma == nil {
+			r.Rdma = make(map[string]configs.LinuxRdma)
+		}
+		r.Rdma[n.rdmaDevice] = *n.RDMA
+		if added == nil {
+			added = make(map[string]string)
+		}
+		added[containerInterfaceName(&n.Network)] = n.rdmaDevice
+	}
+	return added
+}
+
+// removeNetworkRdmaLimits removes the RDMA limits of device, added for a
+// network, from r, and lifts them in the rdma cgroup managed by m.
+func removeNetworkRdmaLimits(m cgroups.Manager, r *configs.Resources, device string) error {
+	delete(r.Rdma, device)
+	path := m.Path("rdma")
+	if path == "" {
+		return nil
+	}
+	return cgroups.WriteFile(path, "rdma.max", device+" hca_handle=max hca_object=max")
+}
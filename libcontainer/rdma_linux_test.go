@@ -0,0 +1,58 @@
+package libcontainer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+func TestRdmaDevice(t *testing.T) {
+	sysfs := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(sysfs, "ib0", "device", "infiniband", "mlx5_0"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(sysfs, "eth0", "device"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	dev, err := rdmaDevice(sysfs, "ib0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dev != "mlx5_0" {
+		t.Errorf("expected rdma device mlx5_0, got %q", dev)
+	}
+	for _, name := range []string{"eth0", "eth1"} {
+		if _, err := rdmaDevice(sysfs, name); err == nil {
+			t.Errorf("expected error for %s without rdma device", name)
+		}
+	}
+}
+
+func TestAddNetworkRdmaLimits(t *testing.T) {
+	handles, objects := uint32(4), uint32(1000)
+	explicit := configs.LinuxRdma{HcaHandles: &handles}
+	r := &configs.Resources{Rdma: map[string]configs.LinuxRdma{"mlx5_1": explicit}}
+	networks := []*network{
+		{Network: configs.Network{Type: "sriov", Name: "eth0", RDMA: &configs.LinuxRdma{HcaObjects: &objects}}, rdmaDevice: "mlx5_0"},
+		// The limits of the cgroup resources take precedence.
+		{Network: configs.Network{Type: "ipoib", RDMA: &configs.LinuxRdma{HcaObjects: &objects}}, rdmaDevice: "mlx5_1"},
+		{Network: configs.Network{Type: "sriov"}, rdmaDevice: "mlx5_2"},
+	}
+	added := addNetworkRdmaLimits(r, networks)
+	if !reflect.DeepEqual(added, map[string]string{"eth0": "mlx5_0"}) {
+		t.Errorf("expected the limits of mlx5_0 to be added, got %v", added)
+	}
+	expected := map[string]configs.LinuxRdma{
+		"mlx5_0": {HcaObjects: &objects},
+		"mlx5_1": explicit,
+	}
+	if !reflect.DeepEqual(r.Rdma, expected) {
+		t.Errorf("expected rdma limits %+v, got %+v", expected, r.Rdma)
+	}
+	if added := addNetworkRdmaLimits(r, networks); added != nil {
+		t.Errorf("expected the resources to be unchanged, got %v added", added)
+	}
+}
@@ -0,0 +1,68 @@
+package libcontainer
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+)
+
+// setupRoutingRules adds the policy routing rules of the network namespace.
+// It must be called in the container network namespace.
+func setupRoutingRules(rules []*configs.RoutingRule) error {
+	for _, r := range rules {
+		nlRules, err := routingRules(r)
+		if err != nil {
+			return err
+		}
+		for _, rule := range nlRules {
+			if err := netlink.RuleAdd(rule); err != nil {
+				return fmt.Errorf("unable to add routing rule to table %d: %w", r.Table, err)
+			}
+		}
+	}
+	return nil
+}
+
+// routingRules returns the netlink rules implementing r, one per address
+// family it applies to.
+func routingRules(r *configs.RoutingRule) ([]*netlink.Rule, error) {
+	rule := netlink.NewRule()
+	rule.Table = r.Table
+	rule.IifName = r.Iif
+	rule.OifName = r.Oif
+	if r.Priority != 0 {
+		rule.Priority = r.Priority
+	}
+	if r.Mark != 0 || r.Mask != 0 {
+		rule.Mark = int(r.Mark)
+		if r.Mask != 0 {
+			rule.Mask = int(r.Mask)
+		}
+	}
+	for _, p := range []struct {
+		prefix string
+		dst    **net.IPNet
+	}{{r.From, &rule.Src}, {r.To, &rule.Dst}} {
+		if p.prefix == "" {
+			continue
+		}
+		_, ipnet, err := net.ParseCIDR(p.prefix)
+		if err != nil {
+			return nil, err
+		}
+		*p.dst = ipnet
+		rule.Family = netlink.FAMILY_V6
+		if ipnet.IP.To4() != nil {
+			rule.Family = netlink.FAMILY_V4
+		}
+	}
+	if rule.Family != 0 {
+		return []*netlink.Rule{rule}, nil
+	}
+	v4, v6 := *rule, *rule
+	v4.Family = netlink.FAMILY_V4
+	v6.Family = netlink.FAMILY_V6
+	return []*netlink.Rule{&v4, &v6}, nil
+}
@@ -0,0 +1,43 @@
+package libcontainer
+
+import (
+	"testing"
+
+	"github.com/opencontainers/runc/internal/testutil/nettest"
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+)
+
+func TestSetupRoutingRules(t *testing.T) {
+	ctr := nettest.NewNS(t)
+	rules := []*configs.RoutingRule{
+		{Priority: 100, From: "192.0.2.0/24", Table: 100},
+		{Priority: 101, Mark: 0x1, Mask: 0xff, Table: 101},
+		{Priority: 102, Iif: "eth0", To: "2001:db8::/32", Table: 102},
+	}
+	ctr.Do(t, func() error { return setupRoutingRules(rules) })
+
+	byTable := map[int][]netlink.Rule{}
+	ctr.Do(t, func() error {
+		for _, family := range []int{netlink.FAMILY_V4, netlink.FAMILY_V6} {
+			list, err := netlink.RuleList(family)
+			if err != nil {
+				return err
+			}
+			for _, r := range list {
+				byTable[r.Table] = append(byTable[r.Table], r)
+			}
+		}
+		return nil
+	})
+	if r := byTable[100]; len(r) != 1 || r[0].Priority != 100 || r[0].Src == nil || r[0].Src.String() != "192.0.2.0/24" {
+		t.Errorf("expected an IPv4 source rule to table 100, got %+v", r)
+	}
+	// A rule without addresses applies to both families.
+	if r := byTable[101]; len(r) != 2 || r[0].Mark != 0x1 || r[0].Mask != 0xff {
+		t.Errorf("expected a mark rule to table 101 for each family, got %+v", r)
+	}
+	if r := byTable[102]; len(r) != 1 || r[0].IifName != "eth0" || r[0].Dst == nil || r[0].Dst.String() != "2001:db8::/32" {
+		t.Errorf("expected an IPv6 rule to table 102, got %+v", r)
+	}
+}
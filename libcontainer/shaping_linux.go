@@ -0,0 +1,127 @@
+package libcontainer
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"time"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// shapingLatency is the maximum amount of time a packet can sit in the token
+// bucket queue before being dropped.
+const shapingLatency = 25 * time.Millisecond
+
+// hostIfbName returns the name of the ifb device used to shape the traffic
+// received by the host side interface hostIf. The name is derived from a
+// hash since the interface name limit leaves no room for a prefix.
+func hostIfbName(hostIf string) string {
+	return fmt.Sprintf("rifb%08x", crc32.ChecksumIEEE([]byte(hostIf)))
+}
+
+// addTBF installs a token bucket filter as the root qdisc of link, limiting
+// its egress traffic to rate bytes per second with the given burst in bytes.
+func addTBF(link netlink.Link, rate uint64, burst uint32) error {
+	units := float64(netlink.TIME_UNITS_PER_SEC)
+	buffer := uint32(float64(burst) * units / float64(rate) * netlink.TickInUsec())
+	limit := uint32(float64(rate)*shapingLatency.Seconds()) + burst
+	qdisc := &netlink.Tbf{
+		QdiscAttrs: netlink.QdiscAttrs{
+			LinkIndex: link.Attrs().Index,
+			Handle:    netlink.MakeHandle(1, 0),
+			Parent:    netlink.HANDLE_ROOT,
+		},
+		Rate:   rate,
+		Limit:  limit,
+		Buffer: buffer,
+	}
+	if err := netlink.QdiscAdd(qdisc); err != nil {
+		return fmt.Errorf("unable to add tbf qdisc to %s: %w", link.Attrs().Name, err)
+	}
+	return nil
+}
+
+// setupHostShaping limits the bandwidth of the host side interface of a
+// network. The traffic received by the container is shaped on egress of the
+// host side interface. Since only egress traffic can be shaped, the traffic
+// sent by the container is redirected from the host side interface ingress
+// to an ifb device, and shaped there.
+func setupHostShaping(n *configs.Network) error {
+	s := n.HostShaping
+	if n.HostInterfaceName == "" || s == nil {
+		return nil
+	}
+	link, err := netlink.LinkByName(n.HostInterfaceName)
+	if err != nil {
+		return err
+	}
+	if s.IngressRate > 0 {
+		if err := addTBF(link, s.IngressRate, s.IngressBurst); err != nil {
+			return err
+		}
+	}
+	if s.EgressRate == 0 {
+		return nil
+	}
+
+	ifb := &netlink.Ifb{LinkAttrs: netlink.LinkAttrs{
+		Name:   hostIfbName(n.HostInterfaceName),
+		MTU:    link.Attrs().MTU,
+		TxQLen: link.Attrs().TxQLen,
+	}}
+	if err := netlink.LinkAdd(ifb); err != nil {
+		return fmt.Errorf("unable to create ifb device: %w", err)
+	}
+	if err := netlink.LinkSetUp(ifb); err != nil {
+		return err
+	}
+	if err := addTBF(ifb, s.EgressRate, s.EgressBurst); err != nil {
+		return err
+	}
+	ingress := &netlink.Ingress{
+		QdiscAttrs: netlink.QdiscAttrs{
+			LinkIndex: link.Attrs().Index,
+			Handle:    netlink.MakeHandle(0xffff, 0),
+			Parent:    netlink.HANDLE_INGRESS,
+		},
+	}
+	if err := netlink.QdiscAdd(ingress); err != nil {
+		return fmt.Errorf("unable to add ingress qdisc to %s: %w", link.Attrs().Name, err)
+	}
+	redirect := netlink.NewMirredAction(ifb.Attrs().Index)
+	redirect.MirredAction = netlink.TCA_EGRESS_REDIR
+	// An u32 filter without selector matches all packets.
+	filter := &netlink.U32{
+		FilterAttrs: netlink.FilterAttrs{
+			LinkIndex: link.Attrs().Index,
+			Parent:    ingress.Handle,
+			Priority:  1,
+			Protocol:  unix.ETH_P_ALL,
+		},
+		Actions: []netlink.Action{redirect},
+	}
+	if err := netlink.FilterAdd(filter); err != nil {
+		return fmt.Errorf("unable to redirect %s ingress traffic: %w", link.Attrs().Name, err)
+	}
+	return nil
+}
+
+// teardownHostShaping removes the ifb device created by setupHostShaping.
+// The qdiscs of the host side interface go away together with it.
+func teardownHostShaping(n *configs.Network) error {
+	if n.HostInterfaceName == "" || n.HostShaping == nil || n.HostShaping.EgressRate == 0 {
+		return nil
+	}
+	ifb, err := netlink.LinkByName(hostIfbName(n.HostInterfaceName))
+	if err != nil {
+		var notFound netlink.LinkNotFoundError
+		if errors.As(err, &notFound) {
+			return nil
+		}
+		return err
+	}
+	return netlink.LinkDel(ifb)
+}
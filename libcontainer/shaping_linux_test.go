@@ -0,0 +1,68 @@
+package libcontainer
+
+import (
+	"testing"
+
+	"github.com/opencontainers/runc/internal/testutil/nettest"
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+)
+
+func TestHostShaping(t *testing.T) {
+	host := nettest.NewNS(t)
+	host.AddVeth(t, "host0", "peer0", nil)
+	n := &configs.Network{
+		Type:              "veth",
+		HostInterfaceName: "host0",
+		HostShaping: &configs.Shaping{
+			IngressRate:  1 << 20,
+			IngressBurst: 1 << 16,
+			EgressRate:   1 << 20,
+			EgressBurst:  1 << 16,
+		},
+	}
+	host.Do(t, func() error {
+		if err := setupHostShaping(n); err != nil {
+			return err
+		}
+
+		host, err := netlink.LinkByName(n.HostInterfaceName)
+		if err != nil {
+			return err
+		}
+		qdiscs, err := netlink.QdiscList(host)
+		if err != nil {
+			return err
+		}
+		var hasTbf, hasIngress bool
+		for _, q := range qdiscs {
+			switch q.Type() {
+			case "tbf":
+				hasTbf = true
+			case "ingress":
+				hasIngress = true
+			}
+		}
+		if !hasTbf || !hasIngress {
+			t.Errorf("expected tbf and ingress qdiscs on %s, got %v", n.HostInterfaceName, qdiscs)
+		}
+		ifb, err := netlink.LinkByName(hostIfbName(n.HostInterfaceName))
+		if err != nil {
+			return err
+		}
+		if qdiscs, err = netlink.QdiscList(ifb); err != nil {
+			return err
+		}
+		if len(qdiscs) == 0 || qdiscs[0].Type() != "tbf" {
+			t.Errorf("expected a tbf qdisc on the ifb device, got %v", qdiscs)
+		}
+
+		if err := teardownHostShaping(n); err != nil {
+			return err
+		}
+		if _, err := netlink.LinkByName(ifb.Attrs().Name); err == nil {
+			t.Error("expected the ifb device to be removed")
+		}
+		return nil
+	})
+}
@@ -0,0 +1,81 @@
+package libcontainer
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"golang.org/x/sys/unix"
+)
+
+// setupActivationSockets creates the activation sockets inside the container
+// and places them over the file descriptors reserved for them right after
+// stdio, following the systemd socket activation protocol.
+func setupActivationSockets(config *initConfig) error {
+	sockets := config.Config.ActivationSockets
+	if len(sockets) == 0 {
+		return nil
+	}
+	if os.Getenv("LISTEN_FDS") != "" {
+		return errors.New("activation sockets can not be used together with inherited LISTEN_FDS")
+	}
+	names := make([]string, 0, len(sockets))
+	for i, s := range sockets {
+		f, err := listenActivationSocket(s)
+		if err != nil {
+			return fmt.Errorf("unable to create activation socket %s %s: %w", s.Network, s.Address, err)
+		}
+		// No O_CLOEXEC, the socket has to be inherited by the container process.
+		err = unix.Dup3(int(f.Fd()), 3+i, 0)
+		f.Close()
+		if err != nil {
+			return os.NewSyscallError("dup3", err)
+		}
+		name := s.Name
+		if name == "" {
+			// Same default as systemd.
+			name = "unknown"
+		}
+		names = append(names, name)
+	}
+	for k, v := range map[string]string{
+		"LISTEN_FDS":     strconv.Itoa(len(sockets)),
+		"LISTEN_PID":     strconv.Itoa(unix.Getpid()),
+		"LISTEN_FDNAMES": strings.Join(names, ":"),
+	} {
+		if err := os.Setenv(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listenActivationSocket creates a listening socket in the network namespace
+// of the calling process and returns its file.
+func listenActivationSocket(s *configs.ActivationSocket) (*os.File, error) {
+	switch s.Network {
+	case "tcp", "tcp4", "tcp6", "unix":
+		l, err := net.Listen(s.Network, s.Address)
+		if err != nil {
+			return nil, err
+		}
+		if ul, ok := l.(*net.UnixListener); ok {
+			// The socket file must outlive the listener closed below.
+			ul.SetUnlinkOnClose(false)
+		}
+		defer l.Close()
+		return l.(interface{ File() (*os.File, error) }).File()
+	case "udp", "udp4", "udp6", "unixgram":
+		c, err := net.ListenPacket(s.Network, s.Address)
+		if err != nil {
+			return nil, err
+		}
+		defer c.Close()
+		return c.(interface{ File() (*os.File, error) }).File()
+	}
+	return nil, fmt.Errorf("unsupported network %q", s.Network)
+}
@@ -0,0 +1,39 @@
+package libcontainer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"golang.org/x/sys/unix"
+)
+
+func TestListenActivationSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.sock")
+	for _, s := range []*configs.ActivationSocket{
+		{Network: "tcp", Address: "127.0.0.1:0"},
+		{Network: "udp", Address: "127.0.0.1:0"},
+		{Network: "unix", Address: path},
+	} {
+		f, err := listenActivationSocket(s)
+		if err != nil {
+			t.Fatalf("%+v: %v", s, err)
+		}
+		flags, err := unix.FcntlInt(f.Fd(), unix.F_GETFD, 0)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if flags&unix.FD_CLOEXEC == 0 {
+			t.Errorf("%+v: expected the socket file to be O_CLOEXEC until placed", s)
+		}
+	}
+	// The unix socket must survive the listener being closed.
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("unix socket was removed: %v", err)
+	}
+	if _, err := listenActivationSocket(&configs.ActivationSocket{Network: "sctp", Address: ":0"}); err == nil {
+		t.Fatal("expected an error for an unsupported network")
+	}
+}
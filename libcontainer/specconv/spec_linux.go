@@ -325,6 +325,10 @@ type CreateOpts struct {
 	Spec             *specs.Spec
 	RootlessEUID     bool
 	RootlessCgroups  bool
+	// NetworkSysctlDefaults are set in the network namespace created for
+	// the container, unless the spec sets them. They are not used when the
+	// container joins an existing network namespace.
+	NetworkSysctlDefaults map[string]string
 }
 
 // getwd is a wrapper similar to os.Getwd, except it always gets
@@ -444,6 +448,16 @@ func CreateLibcontainerConfig(opts *CreateOpts) (*configs.Config, error) {
 		config.ReadonlyPaths = spec.Linux.ReadonlyPaths
 		config.MountLabel = spec.Linux.MountLabel
 		config.Sysctl = spec.Linux.Sysctl
+		if len(opts.NetworkSysctlDefaults) > 0 && config.Namespaces.Contains(configs.NEWNET) && config.Namespaces.PathOf(configs.NEWNET) == "" {
+			sysctl := make(map[string]string, len(spec.Linux.Sysctl)+len(opts.NetworkSysctlDefaults))
+			for k, v := range opts.NetworkSysctlDefaults {
+				sysctl[k] = v
+			}
+			for k, v := range spec.Linux.Sysctl {
+				sysctl[k] = v
+			}
+			config.Sysctl = sysctl
+		}
 		config.TimeOffsets = spec.Linux.TimeOffsets
 		if spec.Linux.Seccomp != nil {
 			seccomp, err := SetupSeccomp(spec.Linux.Seccomp)
@@ -628,6 +642,48 @@ func getLinuxPersonalityFromStr(domain string) (int, error) {
 	return -1, fmt.Errorf("invalid personality domain %s", domain)
 }
 
+// ReadNetworkSysctlDefaults parses a file in the sysctl.d(5) format holding
+// defaults for the network namespaces created for containers. Only network
+// namespace sysctls (net.*) are allowed. Keys may use "/" as the separator,
+// and the "-" prefix, which makes systemd ignore failures, is accepted but
+// has no effect: all the settings must be applied.
+func ReadNetworkSysctlDefaults(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	sysctl := map[string]string{}
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: missing '='", path, i+1)
+		}
+		key = strings.TrimPrefix(strings.TrimSpace(key), "-")
+		// As in sysctl.d, if the first separator is a slash, the slashes
+		// and dots are swapped.
+		if j := strings.IndexAny(key, "./"); j != -1 && key[j] == '/' {
+			key = strings.Map(func(r rune) rune {
+				switch r {
+				case '/':
+					return '.'
+				case '.':
+					return '/'
+				}
+				return r
+			}, key)
+		}
+		if !strings.HasPrefix(key, "net.") {
+			return nil, fmt.Errorf("%s:%d: %q is not a network namespace sysctl", path, i+1, key)
+		}
+		sysctl[key] = strings.TrimSpace(value)
+	}
+	return sysctl, nil
+}
+
 // Some systemd properties are documented as having "Sec" suffix
 // (e.g. TimeoutStopSec) but are expected to have "USec" suffix
 // here, so let's provide conversion to improve compatibility.
@@ -1204,6 +1260,69 @@ func SetupSeccomp(config *specs.LinuxSeccomp) (*configs.Seccomp, error) {
 	return newConfig, nil
 }
 
+// networkSyscalls are the syscalls needed to manage network devices over
+// netlink, as done by ip(8) and similar tools.
+var networkSyscalls = []string{
+	"bind",
+	"getsockname",
+	"getsockopt",
+	"recvfrom",
+	"recvmsg",
+	"sendmsg",
+	"sendto",
+	"setsockopt",
+	"socket",
+}
+
+// AddNetworkSeccompRules allows the container to manage its network devices
+// over netlink in the seccomp profile of config, if the container has network
+// devices other than its loopback interface, or CAP_NET_ADMIN. Only the
+// syscalls which have no rule in the profile are allowed, and socket(2) only
+// for the AF_NETLINK family, so that no rule chosen by the user is changed.
+// The profile is not changed if its default action already allows them. It
+// reports whether the profile was changed.
+func AddNetworkSeccompRules(config *configs.Config) bool {
+	s := config.Seccomp
+	if s == nil || s.DefaultAction == configs.Allow || s.DefaultAction == configs.Log {
+		return false
+	}
+	needed := false
+	for _, n := range config.Networks {
+		if n.Type != "loopback" {
+			needed = true
+			break
+		}
+	}
+	if !needed && config.Capabilities != nil {
+		for _, c := range config.Capabilities.Effective {
+			if c == "CAP_NET_ADMIN" {
+				needed = true
+				break
+			}
+		}
+	}
+	if !needed {
+		return false
+	}
+	ruled := make(map[string]bool, len(s.Syscalls))
+	for _, call := range s.Syscalls {
+		ruled[call.Name] = true
+	}
+	changed := false
+	for _, name := range networkSyscalls {
+		if ruled[name] {
+			continue
+		}
+		call := &configs.Syscall{Name: name, Action: configs.Allow, Args: []*configs.Arg{}}
+		if name == "socket" {
+			call.Args = append(call.Args, &configs.Arg{Index: 0, Value: unix.AF_NETLINK, Op: configs.EqualTo})
+		}
+		s.Syscalls = append(s.Syscalls, call)
+		changed = true
+	}
+	return changed
+}
+
 func createHooks(rspec *specs.Spec, config *configs.Config) {
 	config.Hooks = configs.Hooks{}
 	if rspec.Hooks != nil {
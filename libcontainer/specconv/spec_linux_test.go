@@ -2,6 +2,8 @@ package specconv
 
 import (
 	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -338,6 +340,51 @@ func TestSetupSeccomp(t *testing.T) {
 	}
 }
 
+func TestAddNetworkSeccompRules(t *testing.T) {
+	config := &configs.Config{
+		Seccomp: &configs.Seccomp{
+			DefaultAction: configs.Errno,
+			Syscalls:      []*configs.Syscall{{Name: "sendmsg", Action: configs.Errno}},
+		},
+		Networks: []*configs.Network{{Type: "loopback"}},
+	}
+	if AddNetworkSeccompRules(config) {
+		t.Fatal("expected no change without network devices")
+	}
+	config.Capabilities = &configs.Capabilities{Effective: []string{"CAP_NET_ADMIN"}}
+	if !AddNetworkSeccompRules(config) {
+		t.Fatal("expected the profile to be changed with CAP_NET_ADMIN")
+	}
+	rules := map[string]*configs.Syscall{}
+	for _, call := range config.Seccomp.Syscalls {
+		if rules[call.Name] != nil {
+			t.Errorf("duplicated rule for %s", call.Name)
+		}
+		rules[call.Name] = call
+	}
+	if rules["sendmsg"].Action != configs.Errno {
+		t.Error("expected the existing rule to be kept")
+	}
+	socket := rules["socket"]
+	if socket == nil || socket.Action != configs.Allow || len(socket.Args) != 1 || socket.Args[0].Value != unix.AF_NETLINK {
+		t.Errorf("expected socket to be allowed for AF_NETLINK, got %+v", socket)
+	}
+	if rules["recvmsg"] == nil || rules["recvmsg"].Action != configs.Allow {
+		t.Error("expected recvmsg to be allowed")
+	}
+	if AddNetworkSeccompRules(config) {
+		t.Error("expected no change once the rules are added")
+	}
+
+	config = &configs.Config{
+		Seccomp:  &configs.Seccomp{DefaultAction: configs.Allow},
+		Networks: []*configs.Network{{Type: "veth", Name: "eth0"}},
+	}
+	if AddNetworkSeccompRules(config) || len(config.Seccomp.Syscalls) != 0 {
+		t.Error("expected no change with an allowing default action")
+	}
+}
+
 func TestLinuxCgroupWithMemoryResource(t *testing.T) {
 	cgroupsPath := "/user/cgroups/path/id"
 
@@ -956,3 +1003,75 @@ func TestCreateDevices(t *testing.T) {
 		t.Errorf("device /dev/ram0 not found in config devices; got %v", conf.Devices)
 	}
 }
+
+func TestReadNetworkSysctlDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "netns.conf")
+	content := `# Fleet wide defaults.
+net.ipv6.conf.default.accept_ra = 0
+; Slashes are accepted as separators.
+-net/ipv4/conf/all/rp_filter=2
+
+net.core.somaxconn  =  4096
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	sysctl, err := ReadNetworkSysctlDefaults(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"net.ipv6.conf.default.accept_ra": "0",
+		"net.ipv4.conf.all.rp_filter":     "2",
+		"net.core.somaxconn":              "4096",
+	}
+	if !reflect.DeepEqual(sysctl, expected) {
+		t.Errorf("expected %v, got %v", expected, sysctl)
+	}
+
+	for _, bad := range []string{"kernel.pid_max = 1000\n", "net.core.somaxconn\n"} {
+		if err := os.WriteFile(path, []byte(bad), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ReadNetworkSysctlDefaults(path); err == nil {
+			t.Errorf("%q: expected error, got nil", bad)
+		}
+	}
+}
+
+func TestNetworkSysctlDefaults(t *testing.T) {
+	defaults := map[string]string{
+		"net.ipv6.conf.default.accept_ra": "0",
+		"net.core.somaxconn":              "4096",
+	}
+	spec := Example()
+	spec.Linux.Sysctl = map[string]string{"net.core.somaxconn": "1024"}
+	config, err := CreateLibcontainerConfig(&CreateOpts{Spec: spec, NetworkSysctlDefaults: defaults})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"net.ipv6.conf.default.accept_ra": "0",
+		"net.core.somaxconn":              "1024",
+	}
+	if !reflect.DeepEqual(config.Sysctl, expected) {
+		t.Errorf("expected %v, got %v", expected, config.Sysctl)
+	}
+	if len(spec.Linux.Sysctl) != 1 {
+		t.Errorf("the spec sysctls must not be modified, got %v", spec.Linux.Sysctl)
+	}
+
+	// The defaults do not apply to joined network namespaces.
+	for i, ns := range spec.Linux.Namespaces {
+		if ns.Type == specs.NetworkNamespace {
+			spec.Linux.Namespaces[i].Path = "/var/run/netns/test"
+		}
+	}
+	config, err = CreateLibcontainerConfig(&CreateOpts{Spec: spec, NetworkSysctlDefaults: defaults})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := config.Sysctl["net.ipv6.conf.default.accept_ra"]; ok {
+		t.Errorf("expected no defaults in a joined network namespace, got %v", config.Sysctl)
+	}
+}
@@ -0,0 +1,607 @@
+package libcontainer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/opencontainers/runc/libcontainer/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+// vfRegistryFilename is the file, in the root directory of the containers,
+// recording the SR-IOV virtual functions claimed by each container.
+const vfRegistryFilename = "sriov-vfs.json"
+
+// sysfsNet is the sysfs directory of the host network interfaces.
+var sysfsNet = "/sys/class/net"
+
+// sysfsPCI is the sysfs directory of the host PCI devices.
+var sysfsPCI = "/sys/bus/pci/devices"
+
+// vfClaim records a virtual function given to a container.
+type vfClaim struct {
+	// PF is the name of the physical function.
+	PF string `json:"pf"`
+	// VF is the index of the virtual function on PF.
+	VF int `json:"vf"`
+	// Owner is the ID of the container the virtual function is given to.
+	Owner string `json:"owner"`
+	// TTL, if not zero, is the time the claim is valid for once renewed,
+	// and Expires the time it expires at unless it is renewed again.
+	TTL     time.Duration `json:"ttl,omitempty"`
+	Expires time.Time     `json:"expires,omitempty"`
+	// Settings are the administrative settings of the virtual function
+	// before it was given to the container, restored when it is released.
+	Settings *vfSettings `json:"settings,omitempty"`
+}
+
+// vfSettings are the administrative settings of a virtual function, which
+// are set on its physical function.
+type vfSettings struct {
+	Vlan      uint32 `json:"vlan"`
+	Qos       uint32 `json:"qos"`
+	Spoofchk  bool   `json:"spoofchk"`
+	Trust     bool   `json:"trust"`
+	MinTxRate uint32 `json:"min_tx_rate"`
+	MaxTxRate uint32 `json:"max_tx_rate"`
+	// MAC is only recorded when the administrative address is changed, as
+	// setting it prevents the virtual function from changing its address.
+	MAC string `json:"mac,omitempty"`
+}
+
+// sriov is a network strategy that moves a free virtual function of a
+// physical function to the container, once its administrative settings are
+// applied on the physical function.
+type sriov struct{}
+
+func (s *sriov) create(ctx context.Context, n *network, nspid int) error {
+	if n.stateDir == "" {
+		return errors.New("sriov networks require the container state directory")
+	}
+	registry := filepath.Join(filepath.Dir(n.stateDir), vfRegistryFilename)
+	owner := filepath.Base(n.stateDir)
+	pf, err := physicalFunction(sysfsPCI, &n.Network)
+	if err != nil {
+		return err
+	}
+	var (
+		numVFs int
+		ttl    time.Duration
+	)
+	if n.SRIOV != nil {
+		numVFs, ttl = n.SRIOV.NumVFs, n.SRIOV.ClaimTTL
+	}
+	vf, name, err := claimVF(registry, sysfsNet, pf, owner, numVFs, ttl)
+	if err != nil {
+		return err
+	}
+	err = func() error {
+		// The claim is rolled back if the setup was abandoned meanwhile.
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		settings, err := getVFSettings(pf, vf)
+		if err != nil {
+			return fmt.Errorf("unable to save the settings of the virtual function: %w", err)
+		}
+		if n.SRIOV == nil || !n.SRIOV.AdminMAC {
+			settings.MAC = ""
+		}
+		if err := saveVFSettings(registry, pf, vf, settings); err != nil {
+			return err
+		}
+		if err := configureVF(pf, vf, &n.Network); err != nil {
+			return err
+		}
+		if n.RDMA != nil {
+			// The device of the virtual function is only found in the
+			// host network namespace.
+			if n.rdmaDevice, err = rdmaDevice(sysfsNet, name); err != nil {
+				return err
+			}
+		}
+		link, err := netlink.LinkByName(name)
+		if err != nil {
+			return err
+		}
+		ns, err := os.Open("/proc/" + strconv.Itoa(nspid) + "/ns/net")
+		if err != nil {
+			return err
+		}
+		defer ns.Close()
+		return moveLink(link, int(ns.Fd()), &linkMove{
+			name:     n.Name,
+			mtu:      n.Mtu,
+			down:     n.DownBeforeMove,
+			altNames: n.AltNames,
+			raw:      n.RawLinkAttributes,
+		})
+	}()
+	if err != nil {
+		var restore *vfSettings
+		_ = updateVFRegistry(registry, func(claims []vfClaim) ([]vfClaim, error) {
+			return removeVFClaims(claims, func(c vfClaim) bool {
+				if c.PF == pf && c.VF == vf {
+					restore = c.Settings
+					return true
+				}
+				return false
+			}), nil
+		})
+		if restore != nil {
+			_ = restoreVFSettings(pf, vf, restore)
+		}
+		return fmt.Errorf("unable to move virtual function %d of %s: %w", vf, pf, err)
+	}
+	return nil
+}
+
+func (s *sriov) initialize(ctx context.Context, n *network) error {
+	link, err := netlink.LinkByName(n.Name)
+	if err != nil {
+		return err
+	}
+	if n.MacAddress != "" {
+		mac, err := net.ParseMAC(n.MacAddress)
+		if err != nil {
+			return err
+		}
+		if err := netlink.LinkSetHardwareAddr(link, mac); err != nil {
+			return err
+		}
+	}
+	return configureLink(link, &n.Network)
+}
+
+// release releases the virtual functions of Parent claimed by the container,
+// and restores their administrative settings. Once the container network
+// namespace is gone, the kernel moves the virtual functions back to the host.
+// When the network is detached from a running container, only the virtual
+// function moved back to the host is released, as the container may still
+// use other virtual functions of Parent.
+func (s *sriov) release(n *network) error {
+	registry := filepath.Join(filepath.Dir(n.stateDir), vfRegistryFilename)
+	pf, err := physicalFunction(sysfsPCI, &n.Network)
+	if err != nil {
+		return err
+	}
+	var released []vfClaim
+	if n.detached {
+		released, err = releaseHostVFs(registry, sysfsNet, filepath.Base(n.stateDir), pf)
+	} else {
+		released, err = releaseVFs(registry, filepath.Base(n.stateDir), pf)
+	}
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, claim := range released {
+		if claim.Settings != nil {
+			errs = append(errs, restoreVFSettings(claim.PF, claim.VF, claim.Settings))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *sriov) attach(n *configs.Network) error {
+	return nil
+}
+
+func (s *sriov) detach(n *configs.Network) error {
+	return nil
+}
+
+// physicalFunction returns the name of the physical function of the sriov
+// network n, looking it up in the PCI devices directory sysfs if n gives its
+// PCI address.
+func physicalFunction(sysfs string, n *configs.Network) (string, error) {
+	if n.SRIOV == nil || n.SRIOV.PCIAddress == "" {
+		return n.Parent, nil
+	}
+	entries, err := os.ReadDir(filepath.Join(sysfs, n.SRIOV.PCIAddress, "net"))
+	if err != nil {
+		return "", fmt.Errorf("no network interface for PCI device %s: %w", n.SRIOV.PCIAddress, err)
+	}
+	if len(entries) != 1 {
+		return "", fmt.Errorf("PCI device %s has %d network interfaces, expected one", n.SRIOV.PCIAddress, len(entries))
+	}
+	return entries[0].Name(), nil
+}
+
+// configureVF applies the administrative settings of the sriov network n to
+// the virtual function vf of pf. The settings not given are kept.
+func configureVF(pf string, vf int, n *configs.Network) error {
+	s := n.SRIOV
+	if s == nil || (s.Vlan == 0 && s.Spoofchk == nil && s.Trust == nil && !s.AdminMAC) {
+		return nil
+	}
+	link, err := netlink.LinkByName(pf)
+	if err != nil {
+		return err
+	}
+	if s.Vlan != 0 {
+		if err := netlink.LinkSetVfVlanQos(link, vf, s.Vlan, s.Qos); err != nil {
+			return fmt.Errorf("unable to set the vlan of virtual function %d: %w", vf, err)
+		}
+	}
+	if s.Spoofchk != nil {
+		if err := netlink.LinkSetVfSpoofchk(link, vf, *s.Spoofchk); err != nil {
+			return fmt.Errorf("unable to set the spoof checking of virtual function %d: %w", vf, err)
+		}
+	}
+	if s.Trust != nil {
+		if err := netlink.LinkSetVfTrust(link, vf, *s.Trust); err != nil {
+			return fmt.Errorf("unable to set the trust of virtual function %d: %w", vf, err)
+		}
+	}
+	if s.AdminMAC {
+		mac, err := net.ParseMAC(n.MacAddress)
+		if err != nil {
+			return err
+		}
+		if err := netlink.LinkSetVfHardwareAddr(link, vf, mac); err != nil {
+			return fmt.Errorf("unable to set the address of virtual function %d: %w", vf, err)
+		}
+	}
+	return nil
+}
+
+// claimVF records a free virtual function of pf as used by owner, for ttl if
+// not zero, and returns its index and interface name. If pf has no virtual
+// function enabled and numVFs is not zero, numVFs virtual functions are
+// enabled first. Expired claims are released once their virtual function is
+// back in the host.
+func claimVF(registry, sysfs, pf, owner string, numVFs int, ttl time.Duration) (vf int, name string, err error) {
+	device := filepath.Join(sysfs, pf, "device")
+	err = updateVFRegistry(registry, func(claims []vfClaim) ([]vfClaim, error) {
+		// The virtual functions are enabled with the registry locked, as
+		// the kernel refuses to change their number once enabled.
+		enabled, err := readSysfsInt(device, "sriov_numvfs")
+		if err != nil {
+			return nil, fmt.Errorf("%s is not an SR-IOV physical function: %w", pf, err)
+		}
+		if enabled == 0 {
+			if numVFs == 0 {
+				return nil, fmt.Errorf("no virtual functions are enabled on %s", pf)
+			}
+			if err := os.WriteFile(filepath.Join(device, "sriov_numvfs"), []byte(strconv.Itoa(numVFs)), 0); err != nil {
+				return nil, fmt.Errorf("unable to enable virtual functions on %s: %w", pf, err)
+			}
+			enabled = numVFs
+		}
+		now := time.Now()
+		claims = removeVFClaims(claims, func(c vfClaim) bool {
+			// The virtual function of an expired claim may still be in
+			// the container, whose runc process failed to renew it: it
+			// is only released once back in the host.
+			if !c.expired(now) || !vfInHost(sysfs, c.PF, c.VF) {
+				return false
+			}
+			logrus.Warnf("releasing the expired claim of container %s on virtual function %d of %s", c.Owner, c.VF, c.PF)
+			if c.Settings != nil {
+				_ = restoreVFSettings(c.PF, c.VF, c.Settings)
+			}
+			return true
+		})
+		used := map[int]bool{}
+		for _, c := range claims {
+			if c.PF == pf {
+				used[c.VF] = true
+			}
+		}
+		for i := 0; i < enabled; i++ {
+			if used[i] {
+				continue
+			}
+			// Virtual functions bound to a driver without a network
+			// interface, such as vfio-pci, are skipped.
+			entries, err := os.ReadDir(filepath.Join(device, "virtfn"+strconv.Itoa(i), "net"))
+			if err != nil || len(entries) == 0 {
+				continue
+			}
+			vf, name = i, entries[0].Name()
+			claim := vfClaim{PF: pf, VF: i, Owner: owner, TTL: ttl}
+			if ttl != 0 {
+				claim.Expires = now.Add(ttl)
+			}
+			return append(claims, claim), nil
+		}
+		return nil, fmt.Errorf("no free virtual function on %s", pf)
+	})
+	return vf, name, err
+}
+
+// RenewDeviceClaims periodically renews the claims of the container on the
+// virtual functions of its networks with a claim TTL, so they do not expire
+// while it runs. The claims are still renewed while the container is paused,
+// as it keeps the devices. It returns once ctx is cancelled or the container
+// has stopped.
+func (c *Container) RenewDeviceClaims(ctx context.Context) error {
+	var ttl time.Duration
+	for _, n := range c.config.Networks {
+		if n.SRIOV != nil && n.SRIOV.ClaimTTL != 0 && (ttl == 0 || n.SRIOV.ClaimTTL < ttl) {
+			ttl = n.SRIOV.ClaimTTL
+		}
+	}
+	if ttl == 0 {
+		return errors.New("no network device claim has a ttl")
+	}
+	registry := filepath.Join(filepath.Dir(c.stateDir), vfRegistryFilename)
+	for {
+		c.m.Lock()
+		_, err := c.netNSPath()
+		c.m.Unlock()
+		if err != nil {
+			if errors.Is(err, ErrNotRunning) {
+				return nil
+			}
+			return err
+		}
+		if err := renewVFClaims(registry, c.id); err != nil {
+			logrus.Warnf("unable to renew the device claims of container %s: %v", c.id, err)
+		}
+		timer := time.NewTimer(ttl / 3)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+	}
+}
+
+// expired reports whether c has expired at now.
+func (c *vfClaim) expired(now time.Time) bool {
+	return !c.Expires.IsZero() && now.After(c.Expires)
+}
+
+// renewVFClaims renews the claims of owner with a TTL in registry.
+func renewVFClaims(registry, owner string) error {
+	return updateVFRegistry(registry, func(claims []vfClaim) ([]vfClaim, error) {
+		now := time.Now()
+		for i := range claims {
+			if claims[i].Owner == owner && claims[i].TTL != 0 {
+				claims[i].Expires = now.Add(claims[i].TTL)
+			}
+		}
+		return claims, nil
+	})
+}
+
+// releaseVFs removes the virtual functions of pf claimed by owner from
+// registry, and returns their claims.
+func releaseVFs(registry, owner, pf string) ([]vfClaim, error) {
+	return releaseVFClaims(registry, func(c vfClaim) bool {
+		return c.Owner == owner && c.PF == pf
+	})
+}
+
+// releaseHostVFs is like releaseVFs, but only releases the virtual functions
+// whose interface is in the host network namespace, as seen in sysfs, the
+// sysfs directory of the host network interfaces.
+func releaseHostVFs(registry, sysfs, owner, pf string) ([]vfClaim, error) {
+	return releaseVFClaims(registry, func(c vfClaim) bool {
+		return c.Owner == owner && c.PF == pf && vfInHost(sysfs, c.PF, c.VF)
+	})
+}
+
+// releaseVFClaims removes the claims matched by match from registry, and
+// returns them.
+func releaseVFClaims(registry string, match func(vfClaim) bool) ([]vfClaim, error) {
+	var released []vfClaim
+	err := updateVFRegistry(registry, func(claims []vfClaim) ([]vfClaim, error) {
+		return removeVFClaims(claims, func(c vfClaim) bool {
+			if match(c) {
+				released = append(released, c)
+				return true
+			}
+			return false
+		}), nil
+	})
+	return released, err
+}
+
+// vfInHost reports whether the virtual function vf of pf has an interface in
+// the host network namespace, as seen in sysfs, the sysfs directory of the
+// host network interfaces. The interfaces of the virtual functions given to
+// a container are only listed once back in the host.
+func vfInHost(sysfs, pf string, vf int) bool {
+	entries, err := os.ReadDir(filepath.Join(sysfs, pf, "device", "virtfn"+strconv.Itoa(vf), "net"))
+	return err == nil && len(entries) > 0
+}
+
+// saveVFSettings records the settings of the claimed virtual function vf of
+// pf in registry.
+func saveVFSettings(registry, pf string, vf int, settings *vfSettings) error {
+	return updateVFRegistry(registry, func(claims []vfClaim) ([]vfClaim, error) {
+		for i := range claims {
+			if claims[i].PF == pf && claims[i].VF == vf {
+				claims[i].Settings = settings
+				return claims, nil
+			}
+		}
+		return nil, fmt.Errorf("virtual function %d of %s is not claimed", vf, pf)
+	})
+}
+
+// getVFSettings returns the administrative settings of the virtual function
+// vf of pf.
+func getVFSettings(pf string, vf int) (*vfSettings, error) {
+	req := nl.NewNetlinkRequest(unix.RTM_GETLINK, unix.NLM_F_ACK)
+	req.AddData(nl.NewIfInfomsg(unix.AF_UNSPEC))
+	req.AddData(nl.NewRtAttr(unix.IFLA_IFNAME, nl.ZeroTerminated(pf)))
+	req.AddData(nl.NewRtAttr(unix.IFLA_EXT_MASK, nl.Uint32Attr(nl.RTEXT_FILTER_VF)))
+	msgs, err := req.Execute(unix.NETLINK_ROUTE, unix.RTM_NEWLINK)
+	if err != nil {
+		return nil, err
+	}
+	attrs, err := nl.ParseRouteAttr(msgs[0][unix.SizeofIfInfomsg:])
+	if err != nil {
+		return nil, err
+	}
+	for _, attr := range attrs {
+		if attr.Attr.Type&^unix.NLA_F_NESTED == unix.IFLA_VFINFO_LIST {
+			return parseVFSettings(attr.Value, vf)
+		}
+	}
+	return nil, fmt.Errorf("%s reports no virtual functions", pf)
+}
+
+// parseVFSettings returns the settings of the virtual function vf from the
+// value of an IFLA_VFINFO_LIST attribute. Each IFLA_VF_* attribute is a
+// structure starting with the virtual function index.
+func parseVFSettings(list []byte, vf int) (*vfSettings, error) {
+	infos, err := nl.ParseRouteAttr(list)
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range infos {
+		attrs, err := nl.ParseRouteAttr(info.Value)
+		if err != nil {
+			return nil, err
+		}
+		var s vfSettings
+		found := false
+		for _, attr := range attrs {
+			v := attr.Value
+			if len(v) < 8 {
+				continue
+			}
+			if int(nl.NativeEndian().Uint32(v)) != vf {
+				break
+			}
+			found = true
+			u32 := func(i int) uint32 { return nl.NativeEndian().Uint32(v[4*i:]) }
+			switch attr.Attr.Type {
+			case unix.IFLA_VF_MAC:
+				if len(v) >= 10 {
+					s.MAC = net.HardwareAddr(v[4:10]).String()
+				}
+			case unix.IFLA_VF_VLAN:
+				if len(v) >= 12 {
+					s.Vlan, s.Qos = u32(1), u32(2)
+				}
+			case unix.IFLA_VF_SPOOFCHK:
+				s.Spoofchk = u32(1) != 0
+			case unix.IFLA_VF_TRUST:
+				s.Trust = u32(1) != 0
+			case unix.IFLA_VF_RATE:
+				if len(v) >= 12 {
+					s.MinTxRate, s.MaxTxRate = u32(1), u32(2)
+				}
+			}
+		}
+		if found {
+			return &s, nil
+		}
+	}
+	return nil, fmt.Errorf("virtual function %d not found", vf)
+}
+
+// restoreVFSettings sets the administrative settings of the virtual function
+// vf of pf.
+func restoreVFSettings(pf string, vf int, s *vfSettings) error {
+	link, err := netlink.LinkByName(pf)
+	if err != nil {
+		return err
+	}
+	errs := []error{
+		netlink.LinkSetVfVlanQos(link, vf, int(s.Vlan), int(s.Qos)),
+		netlink.LinkSetVfSpoofchk(link, vf, s.Spoofchk),
+		netlink.LinkSetVfTrust(link, vf, s.Trust),
+		netlink.LinkSetVfRate(link, vf, int(s.MinTxRate), int(s.MaxTxRate)),
+	}
+	if s.MAC != "" {
+		mac, err := net.ParseMAC(s.MAC)
+		if err != nil {
+			return err
+		}
+		errs = append(errs, netlink.LinkSetVfHardwareAddr(link, vf, mac))
+	}
+	err = errors.Join(errs...)
+	if err != nil {
+		return fmt.Errorf("unable to restore the settings of virtual function %d of %s: %w", vf, pf, err)
+	}
+	return nil
+}
+
+func removeVFClaims(claims []vfClaim, match func(vfClaim) bool) []vfClaim {
+	kept := make([]vfClaim, 0, len(claims))
+	for _, c := range claims {
+		if !match(c) {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+// updateVFRegistry calls fn with the claims recorded in registry, while
+// holding a lock on it, and saves the claims it returns unless it fails.
+func updateVFRegistry(registry string, fn func([]vfClaim) ([]vfClaim, error)) error {
+	var claims []vfClaim
+	return updateLockedJSON(registry, &claims, func() error {
+		updated, err := fn(claims)
+		if err != nil {
+			return err
+		}
+		claims = updated
+		return nil
+	})
+}
+
+// updateLockedJSON decodes the JSON file path, if it exists, into v and calls
+// fn, while holding a lock on the file path.lock. v is then saved back unless
+// fn fails. It is written to a temporary file renamed to path, so that a
+// crash while it is written does not leave a truncated file behind, which is
+// why the lock is held on a separate file.
+func updateLockedJSON(path string, v interface{}, fn func() error) (retErr error) {
+	lockPath := path + ".lock"
+	lock, err := os.OpenFile(lockPath, os.O_RDWR|os.O_CREATE|unix.O_CLOEXEC, 0o600)
+	if err != nil {
+		return err
+	}
+	defer lock.Close()
+	if err := unix.Flock(int(lock.Fd()), unix.LOCK_EX); err != nil {
+		return &os.PathError{Op: "flock", Path: lockPath, Err: err}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, v); err != nil {
+			return fmt.Errorf("invalid %s: %w", path, err)
+		}
+	}
+	if err := fn(); err != nil {
+		return err
+	}
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+"-")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if retErr != nil {
+			tmpFile.Close()
+			os.Remove(tmpFile.Name())
+		}
+	}()
+	if err := utils.WriteJSON(tmpFile, v); err != nil {
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile.Name(), path)
+}
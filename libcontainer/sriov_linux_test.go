@@ -0,0 +1,219 @@
+package libcontainer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+func TestClaimVF(t *testing.T) {
+	sysfs := t.TempDir()
+	device := filepath.Join(sysfs, "ens1f0", "device")
+	for _, dir := range []string{
+		filepath.Join(device, "virtfn0", "net", "ens1f0v0"),
+		// A virtual function bound to vfio-pci has no interface.
+		filepath.Join(device, "virtfn1"),
+		filepath.Join(device, "virtfn2", "net", "ens1f0v2"),
+	} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	numVFs := filepath.Join(device, "sriov_numvfs")
+	if err := os.WriteFile(numVFs, []byte("0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	registry := filepath.Join(t.TempDir(), vfRegistryFilename)
+
+	if _, _, err := claimVF(registry, sysfs, "ens1f0", "ctr1", 0, 0); err == nil {
+		t.Fatal("expected error without enabled virtual functions")
+	}
+	if _, _, err := claimVF(registry, sysfs, "eno1", "ctr1", 3, 0); err == nil {
+		t.Fatal("expected error for a device which is not a physical function")
+	}
+	vf, name, err := claimVF(registry, sysfs, "ens1f0", "ctr1", 3, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vf != 0 || name != "ens1f0v0" {
+		t.Errorf("expected the first virtual function, got %d (%s)", vf, name)
+	}
+	if data, _ := os.ReadFile(numVFs); string(data) != "3" {
+		t.Errorf("expected the virtual functions to be enabled, got %q", data)
+	}
+	if vf, name, err = claimVF(registry, sysfs, "ens1f0", "ctr2", 3, 0); err != nil {
+		t.Fatal(err)
+	}
+	if vf != 2 || name != "ens1f0v2" {
+		t.Errorf("expected the third virtual function, got %d (%s)", vf, name)
+	}
+	if _, _, err := claimVF(registry, sysfs, "ens1f0", "ctr3", 3, 0); err == nil {
+		t.Fatal("expected error without free virtual functions")
+	}
+
+	settings := &vfSettings{Vlan: 100, Spoofchk: true}
+	if err := saveVFSettings(registry, "ens1f0", 0, settings); err != nil {
+		t.Fatal(err)
+	}
+	// The interface of the second virtual function is in its container.
+	if err := os.RemoveAll(filepath.Join(device, "virtfn2", "net", "ens1f0v2")); err != nil {
+		t.Fatal(err)
+	}
+	released, err := releaseHostVFs(registry, sysfs, "ctr2", "ens1f0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(released) != 0 {
+		t.Errorf("expected the virtual function in the container to be kept, got %+v", released)
+	}
+	released, err = releaseVFs(registry, "ctr1", "ens1f0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(released) != 1 || released[0].VF != 0 || !reflect.DeepEqual(released[0].Settings, settings) {
+		t.Errorf("expected the first virtual function to be released with its settings, got %+v", released)
+	}
+	if vf, _, err = claimVF(registry, sysfs, "ens1f0", "ctr3", 3, 0); err != nil {
+		t.Fatal(err)
+	}
+	if vf != 0 {
+		t.Errorf("expected the released virtual function, got %d", vf)
+	}
+}
+
+func TestClaimVFExpiry(t *testing.T) {
+	sysfs := t.TempDir()
+	device := filepath.Join(sysfs, "ens1f0", "device")
+	if err := os.MkdirAll(filepath.Join(device, "virtfn0", "net", "ens1f0v0"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(device, "sriov_numvfs"), []byte("1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	registry := filepath.Join(t.TempDir(), vfRegistryFilename)
+
+	if _, _, err := claimVF(registry, sysfs, "ens1f0", "ctr1", 0, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := claimVF(registry, sysfs, "ens1f0", "ctr2", 0, 0); err == nil {
+		t.Fatal("expected error while the claim is valid")
+	}
+	// Expire the claim, as if its container had not renewed it.
+	err := updateVFRegistry(registry, func(claims []vfClaim) ([]vfClaim, error) {
+		claims[0].Expires = time.Now().Add(-time.Second)
+		return claims, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := renewVFClaims(registry, "ctr1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := claimVF(registry, sysfs, "ens1f0", "ctr2", 0, 0); err == nil {
+		t.Fatal("expected error once the claim is renewed")
+	}
+	err = updateVFRegistry(registry, func(claims []vfClaim) ([]vfClaim, error) {
+		claims[0].Expires = time.Now().Add(-time.Second)
+		return claims, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The interface of the virtual function is still in the container.
+	vfNet := filepath.Join(device, "virtfn0", "net", "ens1f0v0")
+	if err := os.Remove(vfNet); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := claimVF(registry, sysfs, "ens1f0", "ctr2", 0, 0); err == nil {
+		t.Fatal("expected error while the expired virtual function is in the container")
+	}
+	if err := os.Mkdir(vfNet, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	vf, _, err := claimVF(registry, sysfs, "ens1f0", "ctr2", 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vf != 0 {
+		t.Errorf("expected the expired virtual function, got %d", vf)
+	}
+	released, err := releaseVFs(registry, "ctr1", "ens1f0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(released) != 0 {
+		t.Errorf("expected the expired claim to be gone, got %+v", released)
+	}
+}
+
+func TestParseVFSettings(t *testing.T) {
+	vfAttr := func(typ int, vf uint32, values ...uint32) *nl.RtAttr {
+		data := make([]byte, 4*(len(values)+1))
+		nl.NativeEndian().PutUint32(data, vf)
+		for i, v := range values {
+			nl.NativeEndian().PutUint32(data[4*(i+1):], v)
+		}
+		return nl.NewRtAttr(typ, data)
+	}
+	list := nl.NewRtAttr(unix.IFLA_VFINFO_LIST, nil)
+	for vf := uint32(0); vf < 2; vf++ {
+		info := list.AddRtAttr(unix.IFLA_VF_INFO, nil)
+		mac := make([]byte, 36)
+		nl.NativeEndian().PutUint32(mac, vf)
+		copy(mac[4:], []byte{2, 0, 0, 0, 0, byte(vf)})
+		info.AddChild(nl.NewRtAttr(unix.IFLA_VF_MAC, mac))
+		info.AddChild(vfAttr(unix.IFLA_VF_VLAN, vf, 10*vf, vf))
+		info.AddChild(vfAttr(unix.IFLA_VF_SPOOFCHK, vf, vf))
+		info.AddChild(vfAttr(unix.IFLA_VF_TRUST, vf, 1-vf))
+		info.AddChild(vfAttr(unix.IFLA_VF_RATE, vf, 100*vf, 1000*vf))
+	}
+	value := list.Serialize()[unix.SizeofRtAttr:]
+
+	s, err := parseVFSettings(value, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := vfSettings{Vlan: 10, Qos: 1, Spoofchk: true, MinTxRate: 100, MaxTxRate: 1000, MAC: "02:00:00:00:00:01"}
+	if *s != expected {
+		t.Errorf("expected %+v, got %+v", expected, *s)
+	}
+	if _, err := parseVFSettings(value, 2); err == nil {
+		t.Error("expected error for a missing virtual function")
+	}
+}
+
+func TestPhysicalFunction(t *testing.T) {
+	sysfs := t.TempDir()
+	for _, dir := range []string{
+		filepath.Join(sysfs, "0000:65:00.0", "net", "ens1f0"),
+		filepath.Join(sysfs, "0000:65:00.1", "net", "ens1f1"),
+		filepath.Join(sysfs, "0000:65:00.1", "net", "ens1f1d1"),
+		// A physical function bound to a driver without network interface.
+		filepath.Join(sysfs, "0000:66:00.0"),
+	} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pciNetwork := func(addr string) *configs.Network {
+		return &configs.Network{Type: "sriov", SRIOV: &configs.SRIOVSettings{PCIAddress: addr}}
+	}
+
+	if pf, err := physicalFunction(sysfs, &configs.Network{Type: "sriov", Parent: "eno1"}); err != nil || pf != "eno1" {
+		t.Errorf("expected the parent interface, got %q (%v)", pf, err)
+	}
+	if pf, err := physicalFunction(sysfs, pciNetwork("0000:65:00.0")); err != nil || pf != "ens1f0" {
+		t.Errorf("expected the interface of the PCI device, got %q (%v)", pf, err)
+	}
+	for _, addr := range []string{"0000:65:00.1", "0000:66:00.0", "0000:67:00.0"} {
+		if _, err := physicalFunction(sysfs, pciNetwork(addr)); err == nil {
+			t.Errorf("%s: expected error", addr)
+		}
+	}
+}
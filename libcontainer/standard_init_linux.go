@@ -1,6 +1,7 @@
 package libcontainer
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -78,17 +79,30 @@ func (l *linuxStandardInit) Init() error {
 		}
 	}
 
-	if err := setupNetwork(l.config); err != nil {
+	// If the container creation is aborted, runc init is killed, so there
+	// is no need for the network setup to be cancellable here.
+	skipped, err := setupNetwork(context.Background(), l.config)
+	if err != nil {
 		return err
 	}
-	if err := setupRoute(l.config.Config); err != nil {
+	if len(skipped) > 0 {
+		if err := writeSyncArg(l.pipe, procNetworkSkipped, skipped); err != nil {
+			return err
+		}
+	}
+	if err := setupRoute(l.config); err != nil {
 		return err
 	}
+	if opts := l.config.Config.NetworkOptions; opts != nil && opts.ExportEnv {
+		if err := exportNetworkEnv(l.config.Networks); err != nil {
+			return fmt.Errorf("unable to export network environment: %w", err)
+		}
+	}
 
 	// initialises the labeling system
 	selinux.GetEnabled()
 
-	err := prepareRootfs(l.pipe, l.config)
+	err = prepareRootfs(l.pipe, l.config)
 	if err != nil {
 		return err
 	}
@@ -118,6 +132,11 @@ func (l *linuxStandardInit) Init() error {
 		}
 	}
 
+	// Unix socket paths are resolved against the final container rootfs.
+	if err := setupActivationSockets(l.config); err != nil {
+		return err
+	}
+
 	if hostname := l.config.Config.Hostname; hostname != "" {
 		if err := unix.Sethostname([]byte(hostname)); err != nil {
 			return &os.SyscallError{Syscall: "sethostname", Err: err}
@@ -1,12 +1,14 @@
 package libcontainer
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 
 	"github.com/opencontainers/runc/libcontainer/configs"
 	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
 	"golang.org/x/sys/unix"
 )
 
@@ -54,6 +56,14 @@ func destroy(c *Container) error {
 			return fmt.Errorf("unable to remove container's IntelRDT group: %w", err)
 		}
 	}
+	if err := teardownNetworks(c); err != nil {
+		var report *networkTeardownError
+		if errors.As(err, &report) {
+			logrus.WithError(err).WithField("released", report.Released).Warn("unable to release the host resources of some networks")
+		} else {
+			logrus.WithError(err).Warn("unable to release the host resources of the networks")
+		}
+	}
 	if err := os.RemoveAll(c.stateDir); err != nil {
 		return fmt.Errorf("unable to remove container state dir: %w", err)
 	}
@@ -0,0 +1,69 @@
+package libcontainer
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+)
+
+// setupStaticRoutes adds the static routes of n through its interface. It
+// must be called in the container network namespace, once the interface is
+// up and has its addresses. The routes of standby networks are added by
+// activateLink.
+func setupStaticRoutes(n *configs.Network) error {
+	if len(n.StaticRoutes) == 0 || n.Standby {
+		return nil
+	}
+	link, err := netlink.LinkByName(containerInterfaceName(n))
+	if err != nil {
+		return err
+	}
+	return addStaticRoutes(link, n.StaticRoutes, netlink.RouteAdd)
+}
+
+// addStaticRoutes adds routes through link with add, which is either
+// netlink.RouteAdd or netlink.RouteReplace.
+func addStaticRoutes(link netlink.Link, routes []*configs.NetworkRoute, add func(*netlink.Route) error) error {
+	for _, r := range routes {
+		route, err := staticRoute(link, r)
+		if err != nil {
+			return err
+		}
+		if err := add(route); err != nil {
+			return fmt.Errorf("unable to add static route %s: %w", r.Destination, err)
+		}
+	}
+	return nil
+}
+
+// staticRoute returns the route through link described by r.
+func staticRoute(link netlink.Link, r *configs.NetworkRoute) (*netlink.Route, error) {
+	_, dst, err := net.ParseCIDR(r.Destination)
+	if err != nil {
+		return nil, err
+	}
+	route := &netlink.Route{
+		LinkIndex: link.Attrs().Index,
+		Dst:       dst,
+		Priority:  r.Metric,
+		Scope:     netlink.SCOPE_LINK,
+		Table:     r.Table,
+	}
+	if r.Gateway != "" {
+		if route.Gw = net.ParseIP(r.Gateway); route.Gw == nil {
+			return nil, fmt.Errorf("invalid gateway %q", r.Gateway)
+		}
+		route.Scope = netlink.SCOPE_UNIVERSE
+	}
+	switch r.Scope {
+	case "universe":
+		route.Scope = netlink.SCOPE_UNIVERSE
+	case "link":
+		route.Scope = netlink.SCOPE_LINK
+	case "host":
+		route.Scope = netlink.SCOPE_HOST
+	}
+	return route, nil
+}
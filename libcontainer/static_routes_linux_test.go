@@ -0,0 +1,98 @@
+package libcontainer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/opencontainers/runc/internal/testutil/nettest"
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+)
+
+func TestSetupStaticRoutes(t *testing.T) {
+	ctr := nettest.NewNS(t)
+	ctr.AddVeth(t, "eth0", "peer0", nil)
+	n := &network{Network: configs.Network{
+		Type:    "veth",
+		Name:    "eth0",
+		Address: "192.0.2.2/24",
+		StaticRoutes: []*configs.NetworkRoute{
+			{Destination: "10.1.0.0/16", Gateway: "192.0.2.1", Metric: 100},
+			{Destination: "198.51.100.0/24"},
+			{Destination: "0.0.0.0/0", Gateway: "192.0.2.1", Table: 100},
+		},
+	}}
+	ctr.Do(t, func() error {
+		if err := (&veth{}).initialize(context.Background(), n); err != nil {
+			return err
+		}
+		return setupStaticRoutes(&n.Network)
+	})
+
+	var routes []netlink.Route
+	ctr.Do(t, func() (err error) {
+		routes, err = netlink.RouteList(nil, netlink.FAMILY_V4)
+		return err
+	})
+	found := map[string]netlink.Route{}
+	for _, r := range routes {
+		if r.Dst != nil {
+			found[r.Dst.String()] = r
+		}
+	}
+	if r, ok := found["10.1.0.0/16"]; !ok || r.Gw.String() != "192.0.2.1" || r.Priority != 100 || r.Scope != netlink.SCOPE_UNIVERSE {
+		t.Errorf("expected a route to 10.1.0.0/16 through 192.0.2.1 with metric 100, got %+v", r)
+	}
+	if r, ok := found["198.51.100.0/24"]; !ok || r.Gw != nil || r.Scope != netlink.SCOPE_LINK {
+		t.Errorf("expected a link route to 198.51.100.0/24, got %+v", r)
+	}
+
+	// The routes of other tables are not in the main table.
+	if r, ok := found["0.0.0.0/0"]; ok {
+		t.Errorf("expected no default route in the main table, got %+v", r)
+	}
+	var table []netlink.Route
+	ctr.Do(t, func() (err error) {
+		table, err = netlink.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{Table: 100}, netlink.RT_FILTER_TABLE)
+		return err
+	})
+	if len(table) != 1 || table[0].Gw.String() != "192.0.2.1" {
+		t.Errorf("expected a default route through 192.0.2.1 in table 100, got %+v", table)
+	}
+
+	// The routes of a standby network are added once it is activated.
+	ctr.AddVeth(t, "eth1", "peer1", nil)
+	standby := &network{Network: configs.Network{
+		Type:         "veth",
+		Name:         "eth1",
+		Address:      "203.0.113.2/24",
+		Standby:      true,
+		StaticRoutes: []*configs.NetworkRoute{{Destination: "10.2.0.0/16", Gateway: "203.0.113.1"}},
+	}}
+	ctr.Do(t, func() error {
+		if err := (&veth{}).initialize(context.Background(), standby); err != nil {
+			return err
+		}
+		return setupStaticRoutes(&standby.Network)
+	})
+	hasRoute := func() bool {
+		var found bool
+		ctr.Do(t, func() error {
+			routes, err := netlink.RouteList(nil, netlink.FAMILY_V4)
+			for _, r := range routes {
+				if r.Dst != nil && r.Dst.String() == "10.2.0.0/16" {
+					found = true
+				}
+			}
+			return err
+		})
+		return found
+	}
+	if hasRoute() {
+		t.Fatal("expected the routes of the standby network to wait for its activation")
+	}
+	ctr.Do(t, func() error { return activateLink(&standby.Network, nil) })
+	if !hasRoute() {
+		t.Error("expected the routes of the activated network to be added")
+	}
+}
@@ -3,11 +3,11 @@ package libcontainer
 import (
 	"github.com/opencontainers/runc/libcontainer/cgroups"
 	"github.com/opencontainers/runc/libcontainer/intelrdt"
-	"github.com/opencontainers/runc/types"
+	statsv1 "github.com/opencontainers/runc/types/stats/v1"
 )
 
 type Stats struct {
-	Interfaces    []*types.NetworkInterface
+	Interfaces    []*statsv1.NetworkInterface
 	CgroupStats   *cgroups.Stats
 	IntelRdtStats *intelrdt.Stats
 }
@@ -38,6 +38,10 @@ type syncType string
 //
 //	procSeccomp --> [grab seccomp fd with pidfd_getfd()]
 //	            <-- procSeccompDone
+//
+//	procNetworkSkipped --> [record the skipped network settings]
+//	  Arg: []SkippedNetworkSetting
+//	                   --- no return synchronisation
 const (
 	procError       syncType = "procError"
 	procReady       syncType = "procReady"
@@ -48,6 +52,8 @@ const (
 	procMountFd     syncType = "procMountFd"
 	procSeccomp     syncType = "procSeccomp"
 	procSeccompDone syncType = "procSeccompDone"
+
+	procNetworkSkipped syncType = "procNetworkSkipped"
 )
 
 type syncFlags int
@@ -0,0 +1,97 @@
+package libcontainer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// tap is a network strategy that creates a persistent tap device in the
+// container network namespace, for a virtual machine run in the container.
+type tap struct{}
+
+func (t *tap) create(ctx context.Context, n *network, nspid int) error {
+	// The tap device is created in the network namespace of the thread
+	// opening /dev/net/tun.
+	return doInNetNS("/proc/"+strconv.Itoa(nspid)+"/ns/net", func() error {
+		return createTap(&n.Network)
+	})
+}
+
+func (t *tap) initialize(ctx context.Context, n *network) error {
+	link, err := netlink.LinkByName(n.Name)
+	if err != nil {
+		return err
+	}
+	if n.MacAddress != "" {
+		mac, err := net.ParseMAC(n.MacAddress)
+		if err != nil {
+			return err
+		}
+		if err := netlink.LinkSetHardwareAddr(link, mac); err != nil {
+			return err
+		}
+	}
+	return configureLink(link, &n.Network)
+}
+
+func (t *tap) attach(n *configs.Network) error {
+	return nil
+}
+
+func (t *tap) detach(n *configs.Network) error {
+	return nil
+}
+
+// createTap creates the persistent tap device of n in the current network
+// namespace, owned by the user and group of its settings. The netlink library
+// does not support setting the owner, so the tun ioctls are used directly.
+func createTap(n *configs.Network) error {
+	s := n.Tap
+	if s == nil {
+		s = &configs.TapSettings{}
+	}
+	f, err := os.OpenFile("/dev/net/tun", os.O_RDWR|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fd := int(f.Fd())
+	ifr, err := unix.NewIfreq(n.Name)
+	if err != nil {
+		return err
+	}
+	// Without IFF_TUN_EXCL, an existing device would be attached to.
+	flags := uint16(unix.IFF_TAP | unix.IFF_NO_PI | unix.IFF_TUN_EXCL)
+	if s.MultiQueue {
+		flags |= unix.IFF_MULTI_QUEUE
+	}
+	if s.VnetHdr {
+		flags |= unix.IFF_VNET_HDR
+	}
+	ifr.SetUint16(flags)
+	if err := unix.IoctlIfreq(fd, unix.TUNSETIFF, ifr); err != nil {
+		return fmt.Errorf("unable to create tap device %s: %w", n.Name, err)
+	}
+	// The device is deleted once f is closed, until it is made persistent.
+	if s.Owner != nil {
+		if err := unix.IoctlSetInt(fd, unix.TUNSETOWNER, int(*s.Owner)); err != nil {
+			return fmt.Errorf("unable to set the owner of %s: %w", n.Name, err)
+		}
+	}
+	if s.Group != nil {
+		if err := unix.IoctlSetInt(fd, unix.TUNSETGROUP, int(*s.Group)); err != nil {
+			return fmt.Errorf("unable to set the group of %s: %w", n.Name, err)
+		}
+	}
+	if err := unix.IoctlSetInt(fd, unix.TUNSETPERSIST, 1); err != nil {
+		return fmt.Errorf("unable to make %s persistent: %w", n.Name, err)
+	}
+	return nil
+}
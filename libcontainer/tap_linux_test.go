@@ -0,0 +1,39 @@
+package libcontainer
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/opencontainers/runc/internal/testutil/nettest"
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+)
+
+func TestCreateTap(t *testing.T) {
+	ctr := nettest.NewNS(t)
+	owner, group := uint32(1000), uint32(1001)
+	n := &network{Network: configs.Network{
+		Type:       "tap",
+		Name:       "tap0",
+		MacAddress: "02:00:00:00:00:02",
+		Mtu:        1400,
+		Tap:        &configs.TapSettings{MultiQueue: true, VnetHdr: true, Owner: &owner, Group: &group},
+	}}
+	ctr.Do(t, func() error { return createTap(&n.Network) })
+	ctr.Do(t, func() error { return (&tap{}).initialize(context.Background(), n) })
+
+	link, ok := ctr.Link(t, "tap0").(*netlink.Tuntap)
+	if !ok {
+		t.Fatal("expected a tap device to be created")
+	}
+	if link.Mode != netlink.TUNTAP_MODE_TAP || link.NonPersist || link.Owner != owner || link.Group != group {
+		t.Errorf("unexpected tap device: mode %d, persistent %v, owner %d, group %d", link.Mode, !link.NonPersist, link.Owner, link.Group)
+	}
+	if attrs := link.Attrs(); attrs.HardwareAddr.String() != n.MacAddress || attrs.MTU != 1400 || attrs.Flags&net.FlagUp == 0 {
+		t.Errorf("unexpected tap device: mac %s, mtu %d, flags %v", attrs.HardwareAddr, attrs.MTU, attrs.Flags)
+	}
+	if err := ctr.Run(func() error { return createTap(&n.Network) }); err == nil {
+		t.Error("expected error creating the same tap device twice")
+	}
+}
@@ -0,0 +1,131 @@
+package libcontainer
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+// Generic netlink commands and attributes of the team family, from
+// linux/if_team.h.
+const (
+	teamCmdOptionsSet = 1
+
+	teamAttrTeamIfindex = 1
+	teamAttrListOption  = 2
+	teamAttrItemOption  = 1
+
+	teamAttrOptionName = 1
+	teamAttrOptionType = 3
+	teamAttrOptionData = 4
+)
+
+// Netlink attribute types of the team options, from include/net/netlink.h.
+const (
+	nlaU32    = 3
+	nlaString = 5
+)
+
+// team is a network strategy that creates a team interface inside the
+// container, and adds the interfaces of other networks of the container to it
+// as ports, with a runner of the kernel, so the container aggregates its links
+// without the NET_ADMIN capability.
+type team struct{}
+
+func (t *team) create(ctx context.Context, n *network, nspid int) error {
+	// The team is created by initialize, once its ports are in the
+	// container.
+	return nil
+}
+
+func (t *team) initialize(ctx context.Context, n *network) (retErr error) {
+	link, err := teamLink(&n.Network)
+	if err != nil {
+		return err
+	}
+	if err := netlink.LinkAdd(link); err != nil {
+		return fmt.Errorf("unable to create team %s: %w", n.Name, err)
+	}
+	master, err := netlink.LinkByName(n.Name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if retErr != nil {
+			_ = netlink.LinkDel(master)
+		}
+	}()
+	index := master.Attrs().Index
+	// The runner can only be changed while the team has no ports.
+	if err := setTeamOption(index, "mode", nlaString, nl.ZeroTerminated(n.Team.Runner)); err != nil {
+		return fmt.Errorf("unable to set the runner of team %s: %w", n.Name, err)
+	}
+	// The team brings its ports up, but they must be down to be added.
+	ports := make(map[string]int, len(n.Team.Ports))
+	for _, name := range n.Team.Ports {
+		port, err := netlink.LinkByName(name)
+		if err != nil {
+			return err
+		}
+		if err := netlink.LinkSetDown(port); err != nil {
+			return err
+		}
+		if err := netlink.LinkSetMasterByIndex(port, index); err != nil {
+			return fmt.Errorf("unable to add port %s to team %s: %w", name, n.Name, err)
+		}
+		ports[name] = port.Attrs().Index
+	}
+	if n.Team.Runner == configs.TeamRunnerActiveBackup {
+		active := n.Team.ActivePort
+		if active == "" {
+			active = n.Team.Ports[0]
+		}
+		if err := setTeamOption(index, "activeport", nlaU32, nl.Uint32Attr(uint32(ports[active]))); err != nil {
+			return fmt.Errorf("unable to set the active port of team %s: %w", n.Name, err)
+		}
+	}
+	return configureLink(master, &n.Network)
+}
+
+func (t *team) attach(n *configs.Network) error {
+	return nil
+}
+
+func (t *team) detach(n *configs.Network) error {
+	return nil
+}
+
+// teamLink returns the team link of the team network n.
+func teamLink(n *configs.Network) (*netlink.GenericLink, error) {
+	attrs := netlink.NewLinkAttrs()
+	attrs.Name = n.Name
+	attrs.MTU = n.Mtu
+	if n.TxQueueLen != 0 {
+		attrs.TxQLen = n.TxQueueLen
+	}
+	if n.MacAddress != "" {
+		mac, err := net.ParseMAC(n.MacAddress)
+		if err != nil {
+			return nil, err
+		}
+		attrs.HardwareAddr = mac
+	}
+	return &netlink.GenericLink{LinkAttrs: attrs, LinkType: "team"}, nil
+}
+
+// setTeamOption sets the team option name, of the netlink attribute type typ,
+// to data on the team interface with the given index.
+func setTeamOption(index int, name string, typ uint8, data []byte) error {
+	list := nl.NewRtAttr(unix.NLA_F_NESTED|teamAttrListOption, nil)
+	option := list.AddRtAttr(unix.NLA_F_NESTED|teamAttrItemOption, nil)
+	option.AddRtAttr(teamAttrOptionName, nl.ZeroTerminated(name))
+	option.AddRtAttr(teamAttrOptionType, nl.Uint8Attr(typ))
+	option.AddRtAttr(teamAttrOptionData, data)
+	_, err := genlExecute("team", teamCmdOptionsSet, 0, nl.NewRtAttr(teamAttrTeamIfindex, nl.Uint32Attr(uint32(index))), list)
+	return err
+}
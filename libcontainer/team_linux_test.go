@@ -0,0 +1,71 @@
+package libcontainer
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/runc/internal/testutil/nettest"
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"golang.org/x/sys/unix"
+)
+
+func TestTeam(t *testing.T) {
+	host := nettest.NewNS(t)
+	ctr := nettest.NewNS(t)
+	host.AddVeth(t, "rveth0", "eth0", ctr)
+	host.AddVeth(t, "rveth1", "eth1", ctr)
+	n := &network{Network: configs.Network{
+		Type:    "team",
+		Name:    "team0",
+		Address: "10.0.0.2/24",
+		Team: &configs.TeamSettings{
+			Runner:     configs.TeamRunnerActiveBackup,
+			Ports:      []string{"eth0", "eth1"},
+			ActivePort: "eth1",
+		},
+	}}
+	if err := ctr.Run(func() error { return (&team{}).initialize(context.Background(), n) }); err != nil {
+		if errors.Is(err, unix.EOPNOTSUPP) {
+			t.Skip("team is not supported by the kernel")
+		}
+		t.Fatal(err)
+	}
+	link := ctr.Link(t, "team0")
+	if link.Type() != "team" {
+		t.Fatalf("expected a team interface in the container, got %s", link.Type())
+	}
+	for _, name := range n.Team.Ports {
+		if master := ctr.Link(t, name).Attrs().MasterIndex; master != link.Attrs().Index {
+			t.Errorf("expected %s to be a port of team0, got master %d", name, master)
+		}
+	}
+	if addrs := strings.Join(ctr.Addrs(t, "team0"), " "); !strings.Contains(addrs, "10.0.0.2/24") {
+		t.Errorf("expected the address on the team, got %v", addrs)
+	}
+}
+
+func TestTeamLink(t *testing.T) {
+	n := &configs.Network{
+		Type:       "team",
+		Name:       "team0",
+		Mtu:        9000,
+		MacAddress: "02:42:ac:11:00:02",
+		Team: &configs.TeamSettings{
+			Runner: configs.TeamRunnerRoundRobin,
+			Ports:  []string{"eth0", "eth1"},
+		},
+	}
+	link, err := teamLink(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link.Type() != "team" || link.Name != "team0" || link.MTU != 9000 || link.HardwareAddr.String() != n.MacAddress {
+		t.Errorf("unexpected team link %+v", link)
+	}
+	n.MacAddress = "invalid"
+	if _, err := teamLink(n); err == nil {
+		t.Error("expected error for an invalid MAC address")
+	}
+}
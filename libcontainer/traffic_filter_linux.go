@@ -0,0 +1,355 @@
+package libcontainer
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+// trafficFilterPriority is the priority of the first traffic filter. The
+// lower priorities are left to the filters runc installs for itself, such as
+// the stats counters, which are evaluated first.
+const trafficFilterPriority = 100
+
+// ipProtocols are the IP protocols traffic filters can match.
+var ipProtocols = map[string]byte{
+	"tcp":    unix.IPPROTO_TCP,
+	"udp":    unix.IPPROTO_UDP,
+	"sctp":   unix.IPPROTO_SCTP,
+	"icmp":   unix.IPPROTO_ICMP,
+	"icmpv6": unix.IPPROTO_ICMPV6,
+}
+
+// Attributes of the flower classifier, from linux/pkt_cls.h, which the
+// vendored netlink version does not model.
+const (
+	tcaFlowerAct            = 3
+	tcaFlowerKeyEthType     = 8
+	tcaFlowerKeyIPProto     = 9
+	tcaFlowerKeyIPv4Src     = 10
+	tcaFlowerKeyIPv6Src     = 14
+	tcaFlowerKeyTCPSrc      = 18
+	tcaFlowerKeyTCPDst      = 19
+	tcaFlowerKeyUDPSrc      = 20
+	tcaFlowerKeyUDPDst      = 21
+	tcaFlowerKeySCTPSrc     = 41
+	tcaFlowerKeySCTPDst     = 42
+)
+
+// flowerPortKeys are the flower keys of the source and destination ports of
+// the IP protocols with ports.
+var flowerPortKeys = map[string][2]uint16{
+	"tcp":  {tcaFlowerKeyTCPSrc, tcaFlowerKeyTCPDst},
+	"udp":  {tcaFlowerKeyUDPSrc, tcaFlowerKeyUDPDst},
+	"sctp": {tcaFlowerKeySCTPSrc, tcaFlowerKeySCTPDst},
+}
+
+// u32Selector holds the keys of an u32 filter matching the packets of an
+// ethernet protocol. The key values and masks are in network byte order.
+type u32Selector struct {
+	protocol uint16
+	keys     []nl.TcU32Key
+}
+
+// addKey adds a key matching the 4 bytes at off in the network header with
+// mask, unless mask is zero.
+func (s *u32Selector) addKey(off int32, val, mask []byte) {
+	native := nl.NativeEndian()
+	if native.Uint32(mask) == 0 {
+		return
+	}
+	s.keys = append(s.keys, nl.TcU32Key{
+		Mask: native.Uint32(mask),
+		Val:  native.Uint32(val) & native.Uint32(mask),
+		Off:  off,
+	})
+}
+
+// addPrefix adds the keys matching the address prefix at off in the network
+// header.
+func (s *u32Selector) addPrefix(off int32, prefix *net.IPNet) {
+	ip := prefix.IP.To4()
+	if ip == nil {
+		ip = prefix.IP.To16()
+	}
+	for i := 0; i < len(ip); i += 4 {
+		s.addKey(off+int32(i), ip[i:i+4], prefix.Mask[i:i+4])
+	}
+}
+
+// trafficFilterSelectors returns the selectors of the u32 filters matching
+// the packets selected by f: one for all packets if f has no match field,
+// or one per IP family f can match otherwise.
+func trafficFilterSelectors(f *configs.TrafficFilter) ([]*u32Selector, error) {
+	var prefixes [2]*net.IPNet
+	for i, addr := range []string{f.Source, f.Destination} {
+		if addr == "" {
+			continue
+		}
+		_, prefix, err := net.ParseCIDR(addr)
+		if err != nil {
+			return nil, err
+		}
+		prefixes[i] = prefix
+	}
+	if prefixes[0] == nil && prefixes[1] == nil && f.Protocol == "" {
+		return []*u32Selector{{protocol: unix.ETH_P_ALL, keys: []nl.TcU32Key{{}}}}, nil
+	}
+	ip4, ip6 := true, true
+	for _, prefix := range prefixes {
+		if prefix != nil {
+			ip4, ip6 = prefix.IP.To4() != nil, prefix.IP.To4() == nil
+		}
+	}
+	switch f.Protocol {
+	case "icmp":
+		ip6 = false
+	case "icmpv6":
+		ip4 = false
+	}
+	proto, protoMask := ipProtocols[f.Protocol], byte(0)
+	if proto != 0 {
+		protoMask = 0xff
+	}
+	ports := []byte{byte(f.SourcePort >> 8), byte(f.SourcePort), byte(f.DestinationPort >> 8), byte(f.DestinationPort)}
+	portsMask := make([]byte, 4)
+	if f.SourcePort != 0 {
+		portsMask[0], portsMask[1] = 0xff, 0xff
+	}
+	if f.DestinationPort != 0 {
+		portsMask[2], portsMask[3] = 0xff, 0xff
+	}
+
+	var sels []*u32Selector
+	// The keys are relative to the network header: the protocol is at
+	// offset 9 of IPv4 headers and 6 of IPv6 headers, the addresses at
+	// offset 12 and 8, and the transport header follows the fixed size
+	// header.
+	if ip4 {
+		s := &u32Selector{protocol: unix.ETH_P_IP}
+		s.addKey(8, []byte{0, proto, 0, 0}, []byte{0, protoMask, 0, 0})
+		for i, prefix := range prefixes {
+			if prefix != nil {
+				s.addPrefix(12+int32(i)*4, prefix)
+			}
+		}
+		s.addKey(20, ports, portsMask)
+		sels = append(sels, s)
+	}
+	if ip6 {
+		s := &u32Selector{protocol: unix.ETH_P_IPV6}
+		s.addKey(4, []byte{0, 0, proto, 0}, []byte{0, 0, protoMask, 0})
+		for i, prefix := range prefixes {
+			if prefix != nil {
+				s.addPrefix(8+int32(i)*16, prefix)
+			}
+		}
+		s.addKey(40, ports, portsMask)
+		sels = append(sels, s)
+	}
+	for _, s := range sels {
+		// An u32 filter without key matches all packets.
+		if len(s.keys) == 0 {
+			s.keys = []nl.TcU32Key{{}}
+		}
+	}
+	return sels, nil
+}
+
+// flowerOptions returns the options of a flower filter matching the packets
+// of the ethernet protocol selected by f, as returned by
+// trafficFilterSelectors. The destination keys and masks follow the source
+// ones, and the keys without mask are matched exactly.
+func flowerOptions(f *configs.TrafficFilter, protocol uint16) (*nl.RtAttr, error) {
+	options := nl.NewRtAttr(nl.TCA_OPTIONS, nil)
+	if protocol == unix.ETH_P_ALL {
+		return options, nil
+	}
+	options.AddRtAttr(tcaFlowerKeyEthType, binary.BigEndian.AppendUint16(nil, protocol))
+	if proto, ok := ipProtocols[f.Protocol]; ok {
+		options.AddRtAttr(tcaFlowerKeyIPProto, []byte{proto})
+	}
+	for i, addr := range []string{f.Source, f.Destination} {
+		if addr == "" {
+			continue
+		}
+		_, prefix, err := net.ParseCIDR(addr)
+		if err != nil {
+			return nil, err
+		}
+		key, ip := uint16(tcaFlowerKeyIPv6Src), prefix.IP.To16()
+		if ip4 := prefix.IP.To4(); ip4 != nil {
+			key, ip = tcaFlowerKeyIPv4Src, ip4
+		}
+		off := uint16(2 * i)
+		options.AddRtAttr(int(key+off), ip)
+		options.AddRtAttr(int(key+off+1), []byte(prefix.Mask))
+	}
+	for i, port := range []uint16{f.SourcePort, f.DestinationPort} {
+		if port != 0 {
+			options.AddRtAttr(int(flowerPortKeys[f.Protocol][i]), binary.BigEndian.AppendUint16(nil, port))
+		}
+	}
+	return options, nil
+}
+
+// encodeTrafficFilterAction adds the action of f to the actions attribute of
+// an u32 or flower filter. target is the index of the interface the packets are
+// redirected to by the mirred action.
+func encodeTrafficFilterAction(attr *nl.RtAttr, f *configs.TrafficFilter, target int) error {
+	switch f.Action {
+	case configs.FilterDrop:
+		drop := &netlink.GenericAction{ActionAttrs: netlink.ActionAttrs{Action: netlink.TC_ACT_SHOT}}
+		return netlink.EncodeActions(attr, []netlink.Action{drop})
+	case configs.FilterMirred:
+		return netlink.EncodeActions(attr, []netlink.Action{netlink.NewMirredAction(target)})
+	case configs.FilterPolice:
+		// netlink.EncodeActions does not support the police action.
+		police := nl.TcPolice{
+			Action: int32(netlink.TC_POLICE_SHOT),
+			Rate:   nl.TcRateSpec{Rate: f.Rate},
+			Burst:  uint32(netlink.Xmittime(uint64(f.Rate), f.Burst)),
+		}
+		var rtab [256]uint32
+		netlink.CalcRtable(&police.Rate, rtab[:], -1, 0, nl.LINKLAYER_ETHERNET)
+		table := attr.AddRtAttr(nl.TCA_ACT_TAB, nil)
+		table.AddRtAttr(nl.TCA_ACT_KIND, nl.ZeroTerminated("police"))
+		options := table.AddRtAttr(nl.TCA_ACT_OPTIONS, nil)
+		options.AddRtAttr(nl.TCA_POLICE_TBF, police.Serialize())
+		options.AddRtAttr(nl.TCA_POLICE_RATE, netlink.SerializeRtab(rtab))
+		return nil
+	}
+	return fmt.Errorf("unknown traffic filter action %q", f.Action)
+}
+
+// addTrafficFilter adds the u32 or flower filters implementing f to the
+// clsact qdisc of link, the IPv4 or all packets one with priority prio, and
+// the IPv6 one with priority prio+1, as the filters of a priority must all be
+// of the same protocol. The request is built by hand since netlink.U32 can
+// not carry the police action, and netlink has no flower filter.
+func addTrafficFilter(link netlink.Link, prio uint16, f *configs.TrafficFilter) error {
+	parent := uint32(netlink.HANDLE_MIN_INGRESS)
+	if f.Direction == configs.FilterEgress {
+		parent = netlink.HANDLE_MIN_EGRESS
+	}
+	var target int
+	if f.Action == configs.FilterMirred {
+		t, err := netlink.LinkByName(f.Target)
+		if err != nil {
+			return err
+		}
+		target = t.Attrs().Index
+	}
+	sels, err := trafficFilterSelectors(f)
+	if err != nil {
+		return err
+	}
+	for _, s := range sels {
+		prio := prio
+		if s.protocol == unix.ETH_P_IPV6 {
+			prio++
+		}
+		req := nl.NewNetlinkRequest(unix.RTM_NEWTFILTER, unix.NLM_F_CREATE|unix.NLM_F_EXCL|unix.NLM_F_ACK)
+		req.AddData(&nl.TcMsg{
+			Family:  nl.FAMILY_ALL,
+			Ifindex: int32(link.Attrs().Index),
+			Parent:  parent,
+			Info:    netlink.MakeHandle(prio, nl.Swap16(s.protocol)),
+		})
+		var options, actions *nl.RtAttr
+		if f.Classifier == configs.FilterFlower {
+			req.AddData(nl.NewRtAttr(nl.TCA_KIND, nl.ZeroTerminated("flower")))
+			if options, err = flowerOptions(f, s.protocol); err != nil {
+				return err
+			}
+			actions = options.AddRtAttr(tcaFlowerAct, nil)
+		} else {
+			req.AddData(nl.NewRtAttr(nl.TCA_KIND, nl.ZeroTerminated("u32")))
+			options = nl.NewRtAttr(nl.TCA_OPTIONS, nil)
+			sel := nl.TcU32Sel{
+				Flags: nl.TC_U32_TERMINAL,
+				Nkeys: uint8(len(s.keys)),
+				Keys:  s.keys,
+			}
+			options.AddRtAttr(nl.TCA_U32_SEL, sel.Serialize())
+			actions = options.AddRtAttr(nl.TCA_U32_ACT, nil)
+		}
+		if err := encodeTrafficFilterAction(actions, f, target); err != nil {
+			return err
+		}
+		req.AddData(options)
+		if _, err := req.Execute(unix.NETLINK_ROUTE, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setupTrafficFilters installs the traffic filters of n on a clsact qdisc of
+// its interface. It must be called in the container network namespace, once
+// the targets of the filters are set up. The filters are evaluated in the
+// order listed, each one taking two priorities from trafficFilterPriority.
+func setupTrafficFilters(n *configs.Network) error {
+	if len(n.TrafficFilters) == 0 {
+		return nil
+	}
+	link, err := netlink.LinkByName(containerInterfaceName(n))
+	if err != nil {
+		return err
+	}
+	clsact := &netlink.GenericQdisc{
+		QdiscAttrs: netlink.QdiscAttrs{
+			LinkIndex: link.Attrs().Index,
+			Handle:    netlink.MakeHandle(0xffff, 0),
+			Parent:    netlink.HANDLE_CLSACT,
+		},
+		QdiscType: "clsact",
+	}
+	if err := netlink.QdiscAdd(clsact); err != nil && !errors.Is(err, unix.EEXIST) {
+		return fmt.Errorf("unable to add clsact qdisc to %s: %w", link.Attrs().Name, err)
+	}
+	for i, f := range n.TrafficFilters {
+		if err := addTrafficFilter(link, uint16(trafficFilterPriority+2*i), f); err != nil {
+			return fmt.Errorf("unable to add traffic filter %d to %s: %w", i, link.Attrs().Name, err)
+		}
+	}
+	return nil
+}
+
+// teardownTrafficFilters removes the traffic filters of the interface of n,
+// leaving the filters of lower priorities in place.
+func teardownTrafficFilters(n *configs.Network) error {
+	link, err := netlink.LinkByName(containerInterfaceName(n))
+	if err != nil {
+		return err
+	}
+	for _, parent := range []uint32{netlink.HANDLE_MIN_INGRESS, netlink.HANDLE_MIN_EGRESS} {
+		filters, err := netlink.FilterList(link, parent)
+		if err != nil {
+			return err
+		}
+		for _, f := range filters {
+			attrs := f.Attrs()
+			if attrs.Priority < trafficFilterPriority {
+				continue
+			}
+			// Without handle, all the filters of the priority are deleted.
+			err := netlink.FilterDel(&netlink.U32{FilterAttrs: netlink.FilterAttrs{
+				LinkIndex: attrs.LinkIndex,
+				Parent:    attrs.Parent,
+				Priority:  attrs.Priority,
+				Protocol:  attrs.Protocol,
+			}})
+			if err != nil && !errors.Is(err, unix.ENOENT) {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+package libcontainer
+
+import (
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/opencontainers/runc/internal/testutil/nettest"
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+// u32Key returns the u32 key matching val with mask at off, given in host
+// byte order.
+func u32Key(off int32, val, mask uint32) nl.TcU32Key {
+	native := nl.NativeEndian()
+	return nl.TcU32Key{
+		Mask: native.Uint32(binary.BigEndian.AppendUint32(nil, mask)),
+		Val:  native.Uint32(binary.BigEndian.AppendUint32(nil, val)),
+		Off:  off,
+	}
+}
+
+func TestTrafficFilterSelectors(t *testing.T) {
+	for _, tc := range []struct {
+		filter   configs.TrafficFilter
+		expected []*u32Selector
+	}{
+		{
+			filter:   configs.TrafficFilter{Action: configs.FilterDrop},
+			expected: []*u32Selector{{protocol: unix.ETH_P_ALL, keys: []nl.TcU32Key{{}}}},
+		},
+		{
+			filter: configs.TrafficFilter{Destination: "10.0.0.0/8", Protocol: "tcp", DestinationPort: 80},
+			expected: []*u32Selector{{protocol: unix.ETH_P_IP, keys: []nl.TcU32Key{
+				u32Key(8, 0x00060000, 0x00ff0000),
+				u32Key(16, 0x0a000000, 0xff000000),
+				u32Key(20, 80, 0x0000ffff),
+			}}},
+		},
+		{
+			filter: configs.TrafficFilter{Source: "192.0.2.1/32", SourcePort: 53, Protocol: "udp"},
+			expected: []*u32Selector{{protocol: unix.ETH_P_IP, keys: []nl.TcU32Key{
+				u32Key(8, 0x00110000, 0x00ff0000),
+				u32Key(12, 0xc0000201, 0xffffffff),
+				u32Key(20, 53<<16, 0xffff0000),
+			}}},
+		},
+		{
+			filter: configs.TrafficFilter{Destination: "2001:db8::/32"},
+			expected: []*u32Selector{{protocol: unix.ETH_P_IPV6, keys: []nl.TcU32Key{
+				u32Key(24, 0x20010db8, 0xffffffff),
+			}}},
+		},
+		{
+			filter: configs.TrafficFilter{Protocol: "icmpv6"},
+			expected: []*u32Selector{{protocol: unix.ETH_P_IPV6, keys: []nl.TcU32Key{
+				u32Key(4, 0x00003a00, 0x0000ff00),
+			}}},
+		},
+		{
+			// Both families are matched without addresses.
+			filter: configs.TrafficFilter{Protocol: "tcp", DestinationPort: 443},
+			expected: []*u32Selector{
+				{protocol: unix.ETH_P_IP, keys: []nl.TcU32Key{
+					u32Key(8, 0x00060000, 0x00ff0000),
+					u32Key(20, 443, 0x0000ffff),
+				}},
+				{protocol: unix.ETH_P_IPV6, keys: []nl.TcU32Key{
+					u32Key(4, 0x00000600, 0x0000ff00),
+					u32Key(40, 443, 0x0000ffff),
+				}},
+			},
+		},
+	} {
+		sels, err := trafficFilterSelectors(&tc.filter)
+		if err != nil {
+			t.Errorf("%+v: unexpected error: %v", tc.filter, err)
+			continue
+		}
+		if !reflect.DeepEqual(sels, tc.expected) {
+			t.Errorf("%+v: expected selectors %+v, got %+v", tc.filter, tc.expected, sels)
+		}
+	}
+}
+
+func TestFlowerOptions(t *testing.T) {
+	for _, tc := range []struct {
+		filter   configs.TrafficFilter
+		protocol uint16
+		expected map[uint16][]byte
+	}{
+		{
+			filter:   configs.TrafficFilter{Action: configs.FilterDrop},
+			protocol: unix.ETH_P_ALL,
+			expected: map[uint16][]byte{},
+		},
+		{
+			filter:   configs.TrafficFilter{Destination: "10.0.0.0/8", Protocol: "tcp", DestinationPort: 80},
+			protocol: unix.ETH_P_IP,
+			expected: map[uint16][]byte{
+				tcaFlowerKeyEthType:     {0x08, 0x00},
+				tcaFlowerKeyIPProto:     {unix.IPPROTO_TCP},
+				tcaFlowerKeyIPv4Src + 2: {10, 0, 0, 0},
+				tcaFlowerKeyIPv4Src + 3: {255, 0, 0, 0},
+				tcaFlowerKeyTCPDst:      {0, 80},
+			},
+		},
+		{
+			filter:   configs.TrafficFilter{Source: "2001:db8::/32", Protocol: "sctp", SourcePort: 5000},
+			protocol: unix.ETH_P_IPV6,
+			expected: map[uint16][]byte{
+				tcaFlowerKeyEthType:     {0x86, 0xdd},
+				tcaFlowerKeyIPProto:     {unix.IPPROTO_SCTP},
+				tcaFlowerKeyIPv6Src:     {0x20, 0x01, 0x0d, 0xb8, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+				tcaFlowerKeyIPv6Src + 1: {0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+				tcaFlowerKeySCTPSrc:     {0x13, 0x88},
+			},
+		},
+	} {
+		options, err := flowerOptions(&tc.filter, tc.protocol)
+		if err != nil {
+			t.Errorf("%+v: unexpected error: %v", tc.filter, err)
+			continue
+		}
+		attrs, err := nl.ParseRouteAttr(options.Serialize()[unix.SizeofRtAttr:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := make(map[uint16][]byte)
+		for _, attr := range attrs {
+			got[attr.Attr.Type] = attr.Value
+		}
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("%+v: expected attributes %v, got %v", tc.filter, tc.expected, got)
+		}
+	}
+}
+
+func TestSetupTrafficFilters(t *testing.T) {
+	ctr := nettest.NewNS(t)
+	ctr.AddVeth(t, "eth0", "peer0", nil)
+	ctr.AddVeth(t, "eth1", "peer1", nil)
+	n := &configs.Network{
+		Type: "veth",
+		Name: "eth1",
+		TrafficFilters: []*configs.TrafficFilter{
+			{Destination: "10.0.0.0/8", Protocol: "tcp", DestinationPort: 80, Action: configs.FilterMirred, Target: "eth0"},
+			{Direction: configs.FilterEgress, Action: configs.FilterMirred, Target: "eth0"},
+			// Without addresses, both IPv4 and IPv6 packets are matched.
+			{Protocol: "tcp", DestinationPort: 22, Action: configs.FilterMirred, Target: "eth0"},
+		},
+	}
+	link := ctr.Link(t, "eth1")
+	target := ctr.Link(t, "eth0").Attrs().Index
+	// Stands for the stats counters, attached at priority 1 on the same
+	// clsact qdisc.
+	counters := &netlink.U32{
+		FilterAttrs: netlink.FilterAttrs{
+			LinkIndex: link.Attrs().Index,
+			Parent:    netlink.HANDLE_MIN_INGRESS,
+			Priority:  1,
+			Protocol:  unix.ETH_P_ALL,
+		},
+	}
+	ctr.Do(t, func() error {
+		if err := netlink.QdiscAdd(&netlink.GenericQdisc{
+			QdiscAttrs: netlink.QdiscAttrs{
+				LinkIndex: link.Attrs().Index,
+				Handle:    netlink.MakeHandle(0xffff, 0),
+				Parent:    netlink.HANDLE_CLSACT,
+			},
+			QdiscType: "clsact",
+		}); err != nil {
+			return err
+		}
+		if err := netlink.FilterAdd(counters); err != nil {
+			return err
+		}
+		return setupTrafficFilters(n)
+	})
+	for _, parent := range []uint32{netlink.HANDLE_MIN_INGRESS, netlink.HANDLE_MIN_EGRESS} {
+		var filters []netlink.Filter
+		ctr.Do(t, func() (err error) {
+			filters, err = netlink.FilterList(link, parent)
+			return err
+		})
+		var found bool
+		for _, f := range filters {
+			if u32, ok := f.(*netlink.U32); ok && u32.Sel != nil && u32.RedirIndex == target {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("expected a filter redirecting to eth0 on parent %x, got %+v", parent, filters)
+		}
+	}
+
+	// Each family of a filter has its own priority, above the ones of the
+	// filters installed by runc.
+	var ingress []netlink.Filter
+	ctr.Do(t, func() (err error) {
+		ingress, err = netlink.FilterList(link, netlink.HANDLE_MIN_INGRESS)
+		return err
+	})
+	prios := map[uint16]uint16{}
+	for _, f := range ingress {
+		prios[f.Attrs().Priority] = f.Attrs().Protocol
+	}
+	expected := map[uint16]uint16{
+		1:                         unix.ETH_P_ALL,
+		trafficFilterPriority:     unix.ETH_P_IP,
+		trafficFilterPriority + 4: unix.ETH_P_IP,
+		trafficFilterPriority + 5: unix.ETH_P_IPV6,
+	}
+	if !reflect.DeepEqual(prios, expected) {
+		t.Errorf("expected ingress filters %v, got %v", expected, prios)
+	}
+
+	ctr.Do(t, func() error {
+		return teardownTrafficFilters(n)
+	})
+	for _, parent := range []uint32{netlink.HANDLE_MIN_INGRESS, netlink.HANDLE_MIN_EGRESS} {
+		var filters []netlink.Filter
+		ctr.Do(t, func() (err error) {
+			filters, err = netlink.FilterList(link, parent)
+			return err
+		})
+		for _, f := range filters {
+			if f.Attrs().Priority >= trafficFilterPriority {
+				t.Errorf("expected no traffic filters on parent %x after teardown, got %+v", parent, f)
+			}
+		}
+		if parent == netlink.HANDLE_MIN_INGRESS && len(filters) == 0 {
+			t.Error("expected the priority 1 filter to be kept after teardown")
+		}
+	}
+
+	// The drop and police actions require kernel modules which are not
+	// always available.
+	for _, f := range []*configs.TrafficFilter{
+		{Protocol: "udp", Action: configs.FilterDrop},
+		{Source: "192.0.2.0/24", Action: configs.FilterPolice, Rate: 125000, Burst: 10000},
+	} {
+		err := ctr.Run(func() error {
+			return addTrafficFilter(link, trafficFilterPriority, f)
+		})
+		if errors.Is(err, unix.ENOENT) {
+			t.Logf("%s action not supported: %v", f.Action, err)
+			continue
+		}
+		if err != nil {
+			t.Errorf("unable to add %s filter: %v", f.Action, err)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package libcontainer
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// ip6tnlEncapLimit is the tunnel encapsulation limit of the ip6tnl tunnels,
+// the default of the kernel and iproute2.
+const ip6tnlEncapLimit = 4
+
+// ipTunnel is a network strategy that creates an ipip, sit, gre, gretap or
+// ip6tnl tunnel interface directly in the container network namespace. The tunnel
+// is created from the host, so the kernel keeps the host namespace as the
+// one the encapsulated packets are routed in.
+type ipTunnel struct{}
+
+func (t *ipTunnel) create(ctx context.Context, n *network, nspid int) error {
+	link, err := tunnelLink(&n.Network)
+	if err != nil {
+		return err
+	}
+	link.Attrs().Namespace = netlink.NsPid(nspid)
+	if err := netlink.LinkAdd(link); err != nil {
+		return fmt.Errorf("unable to create %s tunnel %s: %w", n.Type, n.Name, err)
+	}
+	return nil
+}
+
+func (t *ipTunnel) initialize(ctx context.Context, n *network) error {
+	link, err := netlink.LinkByName(n.Name)
+	if err != nil {
+		return err
+	}
+	return configureLink(link, &n.Network)
+}
+
+func (t *ipTunnel) attach(n *configs.Network) error {
+	return nil
+}
+
+func (t *ipTunnel) detach(n *configs.Network) error {
+	return nil
+}
+
+// tunnelLink returns the tunnel link of the ipip, sit, gre, gretap or ip6tnl
+// network n.
+func tunnelLink(n *configs.Network) (netlink.Link, error) {
+	attrs := netlink.NewLinkAttrs()
+	attrs.Name = n.Name
+	remote := net.ParseIP(n.Tunnel.Remote)
+	if remote == nil {
+		return nil, fmt.Errorf("invalid tunnel remote address %q", n.Tunnel.Remote)
+	}
+	var local net.IP
+	if n.Tunnel.Local != "" {
+		if local = net.ParseIP(n.Tunnel.Local); local == nil {
+			return nil, fmt.Errorf("invalid tunnel local address %q", n.Tunnel.Local)
+		}
+	}
+	switch n.Type {
+	case "ipip":
+		return &netlink.Iptun{LinkAttrs: attrs, Local: local, Remote: remote, Ttl: n.Tunnel.TTL}, nil
+	case "sit":
+		return &netlink.Sittun{LinkAttrs: attrs, Local: local, Remote: remote, Ttl: n.Tunnel.TTL}, nil
+	case "ip6tnl":
+		link := &netlink.Ip6tnl{LinkAttrs: attrs, Local: local, Remote: remote, Ttl: n.Tunnel.TTL, EncapLimit: ip6tnlEncapLimit}
+		switch n.Tunnel.Mode {
+		case "ipip6":
+			link.Proto = unix.IPPROTO_IPIP
+		case "ip6ip6":
+			link.Proto = unix.IPPROTO_IPV6
+		}
+		return link, nil
+	}
+	// The gre links are IPv6 ones unless their local address is an IPv4
+	// one, the unspecified address stands for any.
+	if local == nil {
+		local = net.IPv4zero
+	}
+	switch n.Type {
+	case "gre":
+		return &netlink.Gretun{
+			LinkAttrs: attrs, Local: local, Remote: remote, Ttl: n.Tunnel.TTL,
+			IKey: n.Tunnel.InputKey, OKey: n.Tunnel.OutputKey,
+		}, nil
+	case "gretap":
+		if n.MacAddress != "" {
+			mac, err := net.ParseMAC(n.MacAddress)
+			if err != nil {
+				return nil, err
+			}
+			attrs.HardwareAddr = mac
+		}
+		return &netlink.Gretap{
+			LinkAttrs: attrs, Local: local, Remote: remote, Ttl: n.Tunnel.TTL,
+			IKey: n.Tunnel.InputKey, OKey: n.Tunnel.OutputKey,
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown tunnel type %q", n.Type)
+}
@@ -0,0 +1,77 @@
+package libcontainer
+
+import (
+	"net"
+	"testing"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+func TestTunnelLink(t *testing.T) {
+	n := &configs.Network{
+		Type:   "ipip",
+		Name:   "tun0",
+		Tunnel: &configs.TunnelSettings{Local: "198.51.100.1", Remote: "198.51.100.2", TTL: 64},
+	}
+	link, err := tunnelLink(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	iptun, ok := link.(*netlink.Iptun)
+	if !ok {
+		t.Fatalf("expected an ipip link, got %T", link)
+	}
+	if iptun.Name != "tun0" || !iptun.Local.Equal(net.ParseIP("198.51.100.1")) || !iptun.Remote.Equal(net.ParseIP("198.51.100.2")) || iptun.Ttl != 64 {
+		t.Errorf("unexpected ipip link %+v", iptun)
+	}
+
+	n.Type = "sit"
+	n.Tunnel.Local = ""
+	if link, err = tunnelLink(n); err != nil {
+		t.Fatal(err)
+	}
+	if sit, ok := link.(*netlink.Sittun); !ok || sit.Local != nil {
+		t.Errorf("expected a sit link without local address, got %+v", link)
+	}
+
+	// gre links without local address must still be IPv4 ones.
+	n.Type = "gre"
+	n.Tunnel.InputKey, n.Tunnel.OutputKey = 42, 43
+	if link, err = tunnelLink(n); err != nil {
+		t.Fatal(err)
+	}
+	if gre, ok := link.(*netlink.Gretun); !ok || gre.Type() != "gre" || gre.IKey != 42 || gre.OKey != 43 {
+		t.Errorf("expected a keyed gre link, got %+v", link)
+	}
+
+	n.Type = "gretap"
+	n.MacAddress = "02:00:00:00:00:01"
+	if link, err = tunnelLink(n); err != nil {
+		t.Fatal(err)
+	}
+	if gretap, ok := link.(*netlink.Gretap); !ok || gretap.Type() != "gretap" || gretap.HardwareAddr.String() != n.MacAddress {
+		t.Errorf("expected a gretap link with mac address, got %+v", link)
+	}
+
+	n = &configs.Network{
+		Type:   "ip6tnl",
+		Name:   "tun0",
+		Tunnel: &configs.TunnelSettings{Local: "2001:db8::1", Remote: "2001:db8::2", Mode: "ipip6"},
+	}
+	if link, err = tunnelLink(n); err != nil {
+		t.Fatal(err)
+	}
+	ip6tnl, ok := link.(*netlink.Ip6tnl)
+	if !ok || !ip6tnl.Remote.Equal(net.ParseIP("2001:db8::2")) || ip6tnl.Proto != unix.IPPROTO_IPIP || ip6tnl.EncapLimit != ip6tnlEncapLimit {
+		t.Errorf("expected an IPv4 over IPv6 ip6tnl link, got %+v", link)
+	}
+	n.Tunnel.Mode = ""
+	if link, err = tunnelLink(n); err != nil {
+		t.Fatal(err)
+	}
+	if ip6tnl, ok := link.(*netlink.Ip6tnl); !ok || ip6tnl.Proto != 0 {
+		t.Errorf("expected an ip6tnl link for any protocol, got %+v", link)
+	}
+}
@@ -0,0 +1,167 @@
+package libcontainer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+)
+
+// vdpa generic netlink commands and attributes, from linux/vdpa.h.
+const (
+	vdpaCmdDevNew = 3
+	vdpaCmdDevDel = 4
+
+	vdpaAttrMgmtdevBusName   = 1
+	vdpaAttrMgmtdevDevName   = 2
+	vdpaAttrDevName          = 4
+	vdpaAttrDevNetCfgMacaddr = 10
+	vdpaAttrDevNetCfgMTU     = 13
+)
+
+// virtioVDPADriver is the vdpa bus driver exposing vDPA devices as virtio
+// devices, and so as network interfaces.
+const virtioVDPADriver = "virtio_vdpa"
+
+// sysfsVDPA is the sysfs directory of the vdpa bus.
+var sysfsVDPA = "/sys/bus/vdpa"
+
+// vdpa is a network strategy that creates a vDPA device on a management
+// device, binds it to the virtio_vdpa driver, and moves the resulting virtio
+// interface to the container.
+type vdpa struct{}
+
+func (v *vdpa) create(ctx context.Context, n *network, nspid int) error {
+	if err := newVDPADevice(n.VDPA, n.MacAddress, n.Mtu); err != nil {
+		return err
+	}
+	err := func() error {
+		// The device is deleted if the setup was abandoned meanwhile.
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := bindVirtioVDPA(sysfsVDPA, n.VDPA.Device); err != nil {
+			return err
+		}
+		name, err := vdpaInterface(sysfsVDPA, n.VDPA.Device)
+		if err != nil {
+			return err
+		}
+		link, err := netlink.LinkByName(name)
+		if err != nil {
+			return err
+		}
+		ns, err := os.Open("/proc/" + strconv.Itoa(nspid) + "/ns/net")
+		if err != nil {
+			return err
+		}
+		defer ns.Close()
+		return moveLink(link, int(ns.Fd()), &linkMove{
+			name: n.Name,
+			raw:  n.RawLinkAttributes,
+		})
+	}()
+	if err != nil {
+		_ = deleteVDPADevice(n.VDPA.Device)
+		return fmt.Errorf("unable to attach vdpa device %s: %w", n.VDPA.Device, err)
+	}
+	return nil
+}
+
+func (v *vdpa) initialize(ctx context.Context, n *network) error {
+	link, err := netlink.LinkByName(n.Name)
+	if err != nil {
+		return err
+	}
+	return configureLink(link, &n.Network)
+}
+
+// release deletes the vDPA device of the network.
+func (v *vdpa) release(n *network) error {
+	return deleteVDPADevice(n.VDPA.Device)
+}
+
+func (v *vdpa) attach(n *configs.Network) error {
+	return nil
+}
+
+func (v *vdpa) detach(n *configs.Network) error {
+	return nil
+}
+
+// newVDPADevice creates the vDPA device of s, with the given MAC address and
+// MTU if set, which are part of the virtio device configuration.
+func newVDPADevice(s *configs.VDPASettings, mac string, mtu int) error {
+	attrs := []*nl.RtAttr{nl.NewRtAttr(vdpaAttrDevName, nl.ZeroTerminated(s.Device))}
+	if bus, dev, ok := strings.Cut(s.MgmtDev, "/"); ok {
+		attrs = append(attrs,
+			nl.NewRtAttr(vdpaAttrMgmtdevBusName, nl.ZeroTerminated(bus)),
+			nl.NewRtAttr(vdpaAttrMgmtdevDevName, nl.ZeroTerminated(dev)))
+	} else {
+		attrs = append(attrs, nl.NewRtAttr(vdpaAttrMgmtdevDevName, nl.ZeroTerminated(s.MgmtDev)))
+	}
+	if mac != "" {
+		hw, err := net.ParseMAC(mac)
+		if err != nil {
+			return err
+		}
+		attrs = append(attrs, nl.NewRtAttr(vdpaAttrDevNetCfgMacaddr, hw))
+	}
+	if mtu != 0 {
+		attrs = append(attrs, nl.NewRtAttr(vdpaAttrDevNetCfgMTU, nl.Uint16Attr(uint16(mtu))))
+	}
+	if _, err := genlExecute("vdpa", vdpaCmdDevNew, 0, attrs...); err != nil {
+		return fmt.Errorf("unable to create vdpa device %s on %s: %w", s.Device, s.MgmtDev, err)
+	}
+	return nil
+}
+
+// deleteVDPADevice deletes the vDPA device name.
+func deleteVDPADevice(name string) error {
+	if _, err := genlExecute("vdpa", vdpaCmdDevDel, 0, nl.NewRtAttr(vdpaAttrDevName, nl.ZeroTerminated(name))); err != nil {
+		return fmt.Errorf("unable to delete vdpa device %s: %w", name, err)
+	}
+	return nil
+}
+
+// bindVirtioVDPA binds the vDPA device name to virtio_vdpa, unbinding it
+// first from any other driver, such as vhost_vdpa, which would expose it as a
+// character device instead of a network interface.
+func bindVirtioVDPA(sysfs, name string) error {
+	driver, err := os.Readlink(filepath.Join(sysfs, "devices", name, "driver"))
+	if err == nil {
+		if filepath.Base(driver) == virtioVDPADriver {
+			return nil
+		}
+		if err := os.WriteFile(filepath.Join(sysfs, "devices", name, "driver", "unbind"), []byte(name), 0); err != nil {
+			return fmt.Errorf("unable to unbind vdpa device %s from %s: %w", name, filepath.Base(driver), err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(sysfs, "drivers", virtioVDPADriver, "bind"), []byte(name), 0); err != nil {
+		return fmt.Errorf("unable to bind vdpa device %s to %s: %w", name, virtioVDPADriver, err)
+	}
+	return nil
+}
+
+// vdpaInterface returns the name of the network interface of the virtio
+// device created for the vDPA device name.
+func vdpaInterface(sysfs, name string) (string, error) {
+	matches, err := filepath.Glob(filepath.Join(sysfs, "devices", name, "virtio*", "net", "*"))
+	if err != nil {
+		return "", err
+	}
+	if len(matches) == 0 {
+		return "", fmt.Errorf("vdpa device %s has no network interface", name)
+	}
+	return filepath.Base(matches[0]), nil
+}
@@ -0,0 +1,52 @@
+package libcontainer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBindVirtioVDPA(t *testing.T) {
+	sysfs := t.TempDir()
+	for _, dir := range []string{
+		filepath.Join(sysfs, "devices", "vdpa0", "virtio3", "net", "eth5"),
+		filepath.Join(sysfs, "drivers", "vhost_vdpa"),
+		filepath.Join(sysfs, "drivers", virtioVDPADriver),
+	} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, f := range []string{
+		filepath.Join(sysfs, "drivers", "vhost_vdpa", "unbind"),
+		filepath.Join(sysfs, "drivers", virtioVDPADriver, "bind"),
+	} {
+		if err := os.WriteFile(f, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("../../drivers/vhost_vdpa", filepath.Join(sysfs, "devices", "vdpa0", "driver")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := bindVirtioVDPA(sysfs, "vdpa0"); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(sysfs, "drivers", "vhost_vdpa", "unbind")); string(data) != "vdpa0" {
+		t.Errorf("expected the device to be unbound from vhost_vdpa, got %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(sysfs, "drivers", virtioVDPADriver, "bind")); string(data) != "vdpa0" {
+		t.Errorf("expected the device to be bound to %s, got %q", virtioVDPADriver, data)
+	}
+
+	name, err := vdpaInterface(sysfs, "vdpa0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "eth5" {
+		t.Errorf("expected eth5, got %s", name)
+	}
+	if _, err := vdpaInterface(sysfs, "vdpa1"); err == nil {
+		t.Error("expected error for a device without interface")
+	}
+}
@@ -0,0 +1,171 @@
+package libcontainer
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// veth is a network strategy that creates a veth pair, with the
+// HostInterfaceName end in the host, enslaved to Bridge if set, and the Name
+// end in the container.
+type veth struct{}
+
+func (v *veth) create(ctx context.Context, n *network, nspid int) error {
+	ns, err := os.Open("/proc/" + strconv.Itoa(nspid) + "/ns/net")
+	if err != nil {
+		return err
+	}
+	defer ns.Close()
+	return createVeth(n, int(ns.Fd()))
+}
+
+func (v *veth) initialize(ctx context.Context, n *network) error {
+	link, err := netlink.LinkByName(n.Name)
+	if err != nil {
+		return err
+	}
+	return configureLink(link, &n.Network)
+}
+
+// attach enslaves the host end of the pair to the bridge, if any, and brings
+// it up. The bridge is created first if it is missing and CreateBridge is set.
+func (v *veth) attach(n *configs.Network) error {
+	host, err := netlink.LinkByName(n.HostInterfaceName)
+	if err != nil {
+		return err
+	}
+	if n.Bridge != "" {
+		bridge, err := getBridge(n.Bridge, n.CreateBridge)
+		if err != nil {
+			return err
+		}
+		if err := netlink.LinkSetMaster(host, bridge); err != nil {
+			return fmt.Errorf("unable to add %s to bridge %s: %w", n.HostInterfaceName, n.Bridge, err)
+		}
+		if n.HairpinMode {
+			if err := netlink.LinkSetHairpin(host, true); err != nil {
+				return fmt.Errorf("unable to set hairpin mode on %s: %w", n.HostInterfaceName, err)
+			}
+		}
+	}
+	return netlink.LinkSetUp(host)
+}
+
+// detach removes the host end of the pair from the bridge, if any, which
+// disconnects the container without removing its interface.
+func (v *veth) detach(n *configs.Network) error {
+	if n.Bridge == "" {
+		return nil
+	}
+	host, err := netlink.LinkByName(n.HostInterfaceName)
+	if err != nil {
+		return err
+	}
+	return netlink.LinkSetNoMaster(host)
+}
+
+// getBridge returns the bridge named name, creating it and bringing it up if it
+// is missing and create is set. Containers created concurrently may race to
+// create the same bridge, so an existing bridge is not an error.
+func getBridge(name string, create bool) (netlink.Link, error) {
+	bridge, err := netlink.LinkByName(name)
+	if err == nil {
+		return bridge, nil
+	}
+	if !create || !errors.As(err, &netlink.LinkNotFoundError{}) {
+		return nil, fmt.Errorf("unable to find bridge %s: %w", name, err)
+	}
+	attrs := netlink.NewLinkAttrs()
+	attrs.Name = name
+	if err := netlink.LinkAdd(&netlink.Bridge{LinkAttrs: attrs}); err != nil && !errors.Is(err, unix.EEXIST) {
+		return nil, fmt.Errorf("unable to create bridge %s: %w", name, err)
+	}
+	if bridge, err = netlink.LinkByName(name); err != nil {
+		return nil, err
+	}
+	if bridge.Type() != "bridge" {
+		return nil, fmt.Errorf("%s is not a bridge", name)
+	}
+	if err := netlink.LinkSetUp(bridge); err != nil {
+		return nil, fmt.Errorf("unable to bring bridge %s up: %w", name, err)
+	}
+	return bridge, nil
+}
+
+// createVeth creates the veth pair of n, and moves its peer to the network
+// namespace nsFd. The peer is created with a temporary name, so it does not
+// conflict with the host interfaces, and renamed as part of the move.
+func createVeth(n *network, nsFd int) (retErr error) {
+	if n.HostInterfaceName == "" {
+		return errors.New("veth networks require a host interface name")
+	}
+	var mac net.HardwareAddr
+	if n.MacAddress != "" {
+		var err error
+		if mac, err = net.ParseMAC(n.MacAddress); err != nil {
+			return err
+		}
+	}
+	peer, err := tempVethPeerName()
+	if err != nil {
+		return err
+	}
+	n.TempVethPeerName = peer
+	attrs := netlink.NewLinkAttrs()
+	attrs.Name = n.HostInterfaceName
+	attrs.MTU = n.Mtu
+	if n.TxQueueLen != 0 {
+		attrs.TxQLen = n.TxQueueLen
+	}
+	link := &netlink.Veth{LinkAttrs: attrs, PeerName: peer}
+	if err := netlink.LinkAdd(link); err != nil {
+		return fmt.Errorf("unable to create veth pair %s: %w", n.HostInterfaceName, err)
+	}
+	defer func() {
+		if retErr != nil {
+			// Deleting either end deletes the pair.
+			_ = netlink.LinkDel(link)
+		}
+	}()
+	if err := (&veth{}).attach(&n.Network); err != nil {
+		return err
+	}
+	child, err := netlink.LinkByName(peer)
+	if err != nil {
+		return err
+	}
+	// The link is brought up by initialize, once configured.
+	return moveLink(child, nsFd, &linkMove{
+		name:     n.Name,
+		mac:      mac,
+		mtu:      n.Mtu,
+		altNames: n.AltNames,
+		raw:      n.RawLinkAttributes,
+	})
+}
+
+// tempVethPeerName returns a random name for the peer of a veth pair, used
+// until it is moved to the container.
+func tempVethPeerName() (string, error) {
+	return tempInterfaceName("veth")
+}
+
+// tempInterfaceName returns a random name starting with prefix, for an
+// interface created in the host until it is moved to the container.
+func tempInterfaceName(prefix string) (string, error) {
+	b := make([]byte, 5)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return prefix + hex.EncodeToString(b), nil
+}
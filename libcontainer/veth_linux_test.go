@@ -0,0 +1,82 @@
+package libcontainer
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/opencontainers/runc/internal/testutil/nettest"
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+)
+
+func TestCreateVeth(t *testing.T) {
+	host := nettest.NewNS(t)
+	ctr := nettest.NewNS(t)
+	host.Do(t, func() error {
+		return netlink.LinkAdd(&netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: "br0"}})
+	})
+	n := &network{Network: configs.Network{
+		Type:              "veth",
+		Name:              "eth0",
+		HostInterfaceName: "veth0",
+		Bridge:            "br0",
+		MacAddress:        "02:00:00:00:00:02",
+		Mtu:               1400,
+		Address:           "192.0.2.2/24",
+	}}
+	nsFd := ctr.Fd(t)
+	host.Do(t, func() error { return createVeth(n, nsFd) })
+
+	hostEnd := host.Link(t, "veth0")
+	if hostEnd == nil {
+		t.Fatal("expected the host end to be created")
+	}
+	if attrs := hostEnd.Attrs(); attrs.MasterIndex != host.Link(t, "br0").Attrs().Index || attrs.Flags&net.FlagUp == 0 || attrs.MTU != 1400 {
+		t.Errorf("unexpected host end: master %d, flags %v, mtu %d", attrs.MasterIndex, attrs.Flags, attrs.MTU)
+	}
+	if host.Link(t, n.TempVethPeerName) != nil {
+		t.Error("expected the peer to be moved to the container")
+	}
+	peer := ctr.Link(t, "eth0")
+	if peer == nil {
+		t.Fatal("expected the peer to be renamed in the container")
+	}
+	if attrs := peer.Attrs(); attrs.HardwareAddr.String() != n.MacAddress || attrs.MTU != 1400 {
+		t.Errorf("unexpected peer: mac %s, mtu %d", attrs.HardwareAddr, attrs.MTU)
+	}
+	ctr.Do(t, func() error { return (&veth{}).initialize(context.Background(), n) })
+	if addrs := ctr.Addrs(t, "eth0"); len(addrs) == 0 || addrs[0] != "192.0.2.2/24" {
+		t.Errorf("expected the address to be added, got %v", addrs)
+	}
+
+	host.Do(t, func() error { return (&veth{}).detach(&n.Network) })
+	if host.Link(t, "veth0").Attrs().MasterIndex != 0 {
+		t.Error("expected the host end to be removed from the bridge")
+	}
+
+	// A failed creation does not leave the pair behind.
+	n = &network{Network: configs.Network{Type: "veth", Name: "eth1", HostInterfaceName: "veth1", Bridge: "missing0"}}
+	if err := host.Run(func() error { return createVeth(n, nsFd) }); err == nil {
+		t.Fatal("expected error with a missing bridge")
+	}
+	if host.Link(t, "veth1") != nil {
+		t.Error("expected the veth pair to be deleted")
+	}
+
+	n = &network{Network: configs.Network{Type: "veth", Name: "eth2", HostInterfaceName: "veth2", Bridge: "br1", CreateBridge: true}}
+	host.Do(t, func() error { return createVeth(n, nsFd) })
+	bridge := host.Link(t, "br1")
+	if bridge == nil || bridge.Attrs().Flags&net.FlagUp == 0 {
+		t.Fatal("expected the bridge to be created and up")
+	}
+	if host.Link(t, "veth2").Attrs().MasterIndex != bridge.Attrs().Index {
+		t.Error("expected the host end to be added to the created bridge")
+	}
+	// The bridge created for a container is reused by the next ones.
+	n = &network{Network: configs.Network{Type: "veth", Name: "eth3", HostInterfaceName: "veth3", Bridge: "br1", CreateBridge: true}}
+	host.Do(t, func() error { return createVeth(n, nsFd) })
+	if host.Link(t, "veth3").Attrs().MasterIndex != bridge.Attrs().Index {
+		t.Error("expected the host end to be added to the existing bridge")
+	}
+}
@@ -0,0 +1,110 @@
+package libcontainer
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+// vlan is a network strategy that creates an 802.1Q subinterface of the
+// Parent device of the host directly in the container network namespace, so
+// each container can be given its own VLAN of a trunk.
+type vlan struct{}
+
+func (v *vlan) create(ctx context.Context, n *network, nspid int) error {
+	ns, err := os.Open("/proc/" + strconv.Itoa(nspid) + "/ns/net")
+	if err != nil {
+		return err
+	}
+	defer ns.Close()
+	return createVlan(&n.Network, int(ns.Fd()))
+}
+
+func (v *vlan) initialize(ctx context.Context, n *network) error {
+	link, err := netlink.LinkByName(n.Name)
+	if err != nil {
+		return err
+	}
+	return configureLink(link, &n.Network)
+}
+
+func (v *vlan) attach(n *configs.Network) error {
+	return nil
+}
+
+func (v *vlan) detach(n *configs.Network) error {
+	return nil
+}
+
+// createVlan creates the VLAN subinterface of n in the network namespace nsFd
+// with a single request, as the netlink library does not support the QoS
+// mappings. The interface is brought up by initialize.
+func createVlan(n *configs.Network, nsFd int) error {
+	parent, err := netlink.LinkByName(n.Parent)
+	if err != nil {
+		return fmt.Errorf("unable to find parent interface %s: %w", n.Parent, err)
+	}
+	req := nl.NewNetlinkRequest(unix.RTM_NEWLINK, unix.NLM_F_CREATE|unix.NLM_F_EXCL|unix.NLM_F_ACK)
+	req.AddData(nl.NewIfInfomsg(unix.AF_UNSPEC))
+	req.AddData(nl.NewRtAttr(unix.IFLA_IFNAME, nl.ZeroTerminated(n.Name)))
+	req.AddData(nl.NewRtAttr(unix.IFLA_LINK, nl.Uint32Attr(uint32(parent.Attrs().Index))))
+	req.AddData(nl.NewRtAttr(unix.IFLA_NET_NS_FD, nl.Uint32Attr(uint32(nsFd))))
+	if n.Mtu != 0 {
+		req.AddData(nl.NewRtAttr(unix.IFLA_MTU, nl.Uint32Attr(uint32(n.Mtu))))
+	}
+	if n.TxQueueLen != 0 {
+		req.AddData(nl.NewRtAttr(unix.IFLA_TXQLEN, nl.Uint32Attr(uint32(n.TxQueueLen))))
+	}
+	if n.MacAddress != "" {
+		mac, err := net.ParseMAC(n.MacAddress)
+		if err != nil {
+			return err
+		}
+		req.AddData(nl.NewRtAttr(unix.IFLA_ADDRESS, mac))
+	}
+	linkInfo := nl.NewRtAttr(unix.IFLA_LINKINFO, nil)
+	linkInfo.AddRtAttr(nl.IFLA_INFO_KIND, nl.NonZeroTerminated("vlan"))
+	data := linkInfo.AddRtAttr(nl.IFLA_INFO_DATA, nil)
+	data.AddRtAttr(nl.IFLA_VLAN_ID, nl.Uint16Attr(uint16(n.VLAN.ID)))
+	protocol := uint16(netlink.VLAN_PROTOCOL_8021Q)
+	if n.VLAN.Protocol == "802.1ad" {
+		protocol = uint16(netlink.VLAN_PROTOCOL_8021AD)
+	}
+	data.AddRtAttr(nl.IFLA_VLAN_PROTOCOL, binary.BigEndian.AppendUint16(nil, protocol))
+	addVlanQosMap(data, nl.IFLA_VLAN_INGRESS_QOS, n.VLAN.IngressQosMap)
+	addVlanQosMap(data, nl.IFLA_VLAN_EGRESS_QOS, n.VLAN.EgressQosMap)
+	req.AddData(linkInfo)
+	if _, err := req.Execute(unix.NETLINK_ROUTE, 0); err != nil {
+		return fmt.Errorf("unable to create vlan interface %s on %s: %w", n.Name, n.Parent, err)
+	}
+	return nil
+}
+
+// addVlanQosMap adds the priority mappings of m to data, as an attribute of
+// type typ holding a struct ifla_vlan_qos_mapping per mapping.
+func addVlanQosMap(data *nl.RtAttr, typ int, m map[uint32]uint32) {
+	if len(m) == 0 {
+		return
+	}
+	from := make([]uint32, 0, len(m))
+	for f := range m {
+		from = append(from, f)
+	}
+	sort.Slice(from, func(i, j int) bool { return from[i] < from[j] })
+	qos := data.AddRtAttr(typ, nil)
+	for _, f := range from {
+		mapping := make([]byte, 8)
+		nl.NativeEndian().PutUint32(mapping, f)
+		nl.NativeEndian().PutUint32(mapping[4:], m[f])
+		qos.AddRtAttr(unix.IFLA_VLAN_QOS_MAPPING, mapping)
+	}
+}
@@ -0,0 +1,56 @@
+package libcontainer
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/runc/internal/testutil/nettest"
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+func TestCreateVlan(t *testing.T) {
+	host := nettest.NewNS(t)
+	ctr := nettest.NewNS(t)
+	host.AddVeth(t, "eth0", "peer0", nil)
+	nsFd := ctr.Fd(t)
+	n := &configs.Network{
+		Type:   "vlan",
+		Name:   "vlan100",
+		Parent: "eth0",
+		Mtu:    1400,
+		VLAN: &configs.VLANSettings{
+			ID:            100,
+			Protocol:      "802.1ad",
+			IngressQosMap: map[uint32]uint32{5: 2},
+			EgressQosMap:  map[uint32]uint32{3: 7, 1: 4},
+		},
+	}
+	if err := host.Run(func() error { return createVlan(n, nsFd) }); err != nil {
+		if errors.Is(err, unix.EOPNOTSUPP) {
+			t.Skip("vlan is not supported by the kernel")
+		}
+		t.Fatal(err)
+	}
+	if host.Link(t, "vlan100") != nil {
+		t.Error("expected the vlan interface to be created in the container only")
+	}
+	link, ok := ctr.Link(t, "vlan100").(*netlink.Vlan)
+	if !ok {
+		t.Fatal("expected a vlan interface in the container")
+	}
+	if link.VlanId != 100 || link.VlanProtocol != netlink.VLAN_PROTOCOL_8021AD || link.Attrs().MTU != 1400 {
+		t.Errorf("unexpected vlan interface: id %d, protocol %s, mtu %d", link.VlanId, link.VlanProtocol, link.Attrs().MTU)
+	}
+	var info []byte
+	ctr.Do(t, func() (err error) {
+		info, err = os.ReadFile("/proc/thread-self/net/vlan/vlan100")
+		return err
+	})
+	if s := string(info); !strings.Contains(s, "5:2") || !strings.Contains(s, "1:4 3:7") {
+		t.Errorf("expected the qos mappings to be set, got:\n%s", s)
+	}
+}
@@ -0,0 +1,140 @@
+package libcontainer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+// vxlanPort is the IANA assigned VXLAN port, used unless a port is given, as
+// the kernel defaults to the port used before its assignment.
+const vxlanPort = 4789
+
+// vxlan is a network strategy that creates a VXLAN interface in the host and
+// moves it to the container. The UDP socket of the interface stays in the
+// host network namespace, so the container is given an overlay network
+// without being able to reach the underlay.
+type vxlan struct{}
+
+func (v *vxlan) create(ctx context.Context, n *network, nspid int) error {
+	ns, err := os.Open("/proc/" + strconv.Itoa(nspid) + "/ns/net")
+	if err != nil {
+		return err
+	}
+	defer ns.Close()
+	return createVxlan(&n.Network, int(ns.Fd()))
+}
+
+func (v *vxlan) initialize(ctx context.Context, n *network) error {
+	link, err := netlink.LinkByName(n.Name)
+	if err != nil {
+		return err
+	}
+	return configureLink(link, &n.Network)
+}
+
+func (v *vxlan) attach(n *configs.Network) error {
+	return nil
+}
+
+func (v *vxlan) detach(n *configs.Network) error {
+	return nil
+}
+
+// createVxlan creates the VXLAN interface of n in the host with a temporary
+// name, and moves it to the network namespace nsFd. The interface is brought
+// up by initialize.
+func createVxlan(n *configs.Network, nsFd int) (retErr error) {
+	name, err := tempInterfaceName("vxlan")
+	if err != nil {
+		return err
+	}
+	s := n.VXLAN
+	attrs := netlink.NewLinkAttrs()
+	attrs.Name = name
+	attrs.MTU = n.Mtu
+	if n.TxQueueLen != 0 {
+		attrs.TxQLen = n.TxQueueLen
+	}
+	if n.MacAddress != "" {
+		if attrs.HardwareAddr, err = net.ParseMAC(n.MacAddress); err != nil {
+			return err
+		}
+	}
+	link := &netlink.Vxlan{
+		LinkAttrs: attrs,
+		VxlanId:   int(s.VNI),
+		SrcAddr:   net.ParseIP(s.Local),
+		Group:     net.ParseIP(s.Remote),
+		TTL:       int(s.TTL),
+		TOS:       int(s.TOS),
+		Learning:  s.Learning,
+		UDPCSum:   s.UDPChecksum,
+		Port:      vxlanPort,
+		PortLow:   int(s.SrcPortLow),
+		PortHigh:  int(s.SrcPortHigh),
+	}
+	if s.Port != 0 {
+		link.Port = int(s.Port)
+	}
+	if n.Parent != "" {
+		parent, err := netlink.LinkByName(n.Parent)
+		if err != nil {
+			return fmt.Errorf("unable to find parent interface %s: %w", n.Parent, err)
+		}
+		link.VtepDevIndex = parent.Attrs().Index
+	}
+	if err := netlink.LinkAdd(link); err != nil {
+		return fmt.Errorf("unable to create vxlan interface %s: %w", n.Name, err)
+	}
+	defer func() {
+		if retErr != nil {
+			_ = netlink.LinkDel(link)
+		}
+	}()
+	host, err := netlink.LinkByName(name)
+	if err != nil {
+		return err
+	}
+	if s.DF != "" {
+		if err := setVxlanDF(host, s.DF); err != nil {
+			return fmt.Errorf("unable to set the df of vxlan interface %s: %w", n.Name, err)
+		}
+	}
+	return moveLink(host, nsFd, &linkMove{name: n.Name, altNames: n.AltNames, raw: n.RawLinkAttributes})
+}
+
+// setVxlanDF changes the don't fragment setting of the VXLAN interface link,
+// which the netlink package does not model.
+func setVxlanDF(link netlink.Link, df string) error {
+	req := nl.NewNetlinkRequest(unix.RTM_NEWLINK, unix.NLM_F_ACK)
+	msg := nl.NewIfInfomsg(unix.AF_UNSPEC)
+	msg.Index = int32(link.Attrs().Index)
+	req.AddData(msg)
+	linkInfo := nl.NewRtAttr(unix.IFLA_LINKINFO, nil)
+	linkInfo.AddRtAttr(nl.IFLA_INFO_KIND, nl.NonZeroTerminated("vxlan"))
+	data := linkInfo.AddRtAttr(nl.IFLA_INFO_DATA, nil)
+	data.AddRtAttr(unix.IFLA_VXLAN_DF, nl.Uint8Attr(tunnelDF(df)))
+	req.AddData(linkInfo)
+	_, err := req.Execute(unix.NETLINK_ROUTE, 0)
+	return err
+}
+
+// tunnelDF returns the kernel value of the don't fragment setting df of a
+// VXLAN or Geneve interface.
+func tunnelDF(df string) uint8 {
+	switch df {
+	case configs.TunnelDFSet:
+		return 1
+	case configs.TunnelDFInherit:
+		return 2
+	}
+	return 0
+}
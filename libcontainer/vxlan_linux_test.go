@@ -0,0 +1,80 @@
+package libcontainer
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/opencontainers/runc/internal/testutil/nettest"
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+func TestCreateVxlan(t *testing.T) {
+	host := nettest.NewNS(t)
+	ctr := nettest.NewNS(t)
+	peer := nettest.NewNS(t)
+	host.AddVeth(t, "underlay0", "eth0", peer)
+	nsFd := ctr.Fd(t)
+	n := &configs.Network{
+		Type:   "vxlan",
+		Name:   "vxlan0",
+		Parent: "underlay0",
+		Mtu:    1450,
+		VXLAN: &configs.VXLANSettings{
+			VNI:    42,
+			Remote: "192.0.2.1",
+		},
+	}
+	if err := host.Run(func() error { return createVxlan(n, nsFd) }); err != nil {
+		if errors.Is(err, unix.EOPNOTSUPP) {
+			t.Skip("vxlan is not supported by the kernel")
+		}
+		t.Fatal(err)
+	}
+	link, ok := ctr.Link(t, "vxlan0").(*netlink.Vxlan)
+	if !ok {
+		t.Fatal("expected a vxlan interface in the container")
+	}
+	if link.VxlanId != 42 || link.Port != vxlanPort || link.MTU != 1450 {
+		t.Errorf("expected vni 42, port %d and mtu 1450, got %d, %d and %d", vxlanPort, link.VxlanId, link.Port, link.MTU)
+	}
+	if !link.Group.Equal(net.ParseIP("192.0.2.1")) {
+		t.Errorf("expected remote 192.0.2.1, got %v", link.Group)
+	}
+	if link.Learning {
+		t.Error("expected learning to be disabled")
+	}
+
+	// The tuning of the outer headers is applied. The netlink package does
+	// not read back the source port range nor the df.
+	tuned := &configs.Network{
+		Type:   "vxlan",
+		Name:   "vxlan2",
+		Parent: "underlay0",
+		VXLAN: &configs.VXLANSettings{
+			VNI:          43,
+			Remote:       "192.0.2.1",
+			SrcPortLow:   49152,
+			SrcPortHigh:  50175,
+			TunnelTuning: configs.TunnelTuning{TOS: 1, DF: configs.TunnelDFInherit, UDPChecksum: true},
+		},
+	}
+	if err := host.Run(func() error { return createVxlan(tuned, nsFd) }); err != nil {
+		t.Fatal(err)
+	}
+	link, ok = ctr.Link(t, "vxlan2").(*netlink.Vxlan)
+	if !ok {
+		t.Fatal("expected a vxlan interface in the container")
+	}
+	if link.TOS != 1 || !link.UDPCSum {
+		t.Errorf("expected inherited tos and udp checksums, got tos %d and checksums %v", link.TOS, link.UDPCSum)
+	}
+
+	// A missing parent fails, and leaves nothing behind in the host.
+	n.Name, n.Parent = "vxlan1", "missing0"
+	if err := host.Run(func() error { return createVxlan(n, nsFd) }); err == nil {
+		t.Fatal("expected error for a missing parent")
+	}
+}
@@ -0,0 +1,183 @@
+package libcontainer
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+// wireguard is a network strategy that creates a WireGuard interface in the
+// host, configures its keys and peers, and moves it to the container. The
+// UDP socket of the interface stays in the host network namespace, so the
+// container gets an encrypted overlay without running an agent or being
+// able to change the keys.
+type wireguard struct{}
+
+func (w *wireguard) create(ctx context.Context, n *network, nspid int) error {
+	ns, err := os.Open("/proc/" + strconv.Itoa(nspid) + "/ns/net")
+	if err != nil {
+		return err
+	}
+	defer ns.Close()
+	return createWireGuard(&n.Network, int(ns.Fd()))
+}
+
+func (w *wireguard) initialize(ctx context.Context, n *network) error {
+	link, err := netlink.LinkByName(n.Name)
+	if err != nil {
+		return err
+	}
+	return configureLink(link, &n.Network)
+}
+
+func (w *wireguard) attach(n *configs.Network) error {
+	return nil
+}
+
+func (w *wireguard) detach(n *configs.Network) error {
+	return nil
+}
+
+// createWireGuard creates the WireGuard interface of n in the host with a
+// temporary name, configures it, and moves it to the network namespace nsFd.
+// The interface is brought up by initialize.
+func createWireGuard(n *configs.Network, nsFd int) (retErr error) {
+	name, err := tempInterfaceName("wg")
+	if err != nil {
+		return err
+	}
+	attrs := netlink.NewLinkAttrs()
+	attrs.Name = name
+	attrs.MTU = n.Mtu
+	if n.TxQueueLen != 0 {
+		attrs.TxQLen = n.TxQueueLen
+	}
+	link := &netlink.GenericLink{LinkAttrs: attrs, LinkType: "wireguard"}
+	if err := netlink.LinkAdd(link); err != nil {
+		return fmt.Errorf("unable to create wireguard interface %s: %w", n.Name, err)
+	}
+	defer func() {
+		if retErr != nil {
+			_ = netlink.LinkDel(link)
+		}
+	}()
+	host, err := netlink.LinkByName(name)
+	if err != nil {
+		return err
+	}
+	deviceAttrs, err := wireGuardDeviceAttrs(host.Attrs().Index, n.WireGuard)
+	if err != nil {
+		return err
+	}
+	if _, err := genlExecute("wireguard", unix.WG_CMD_SET_DEVICE, 0, deviceAttrs...); err != nil {
+		return fmt.Errorf("unable to configure wireguard interface %s: %w", n.Name, err)
+	}
+	return moveLink(host, nsFd, &linkMove{name: n.Name, altNames: n.AltNames, raw: n.RawLinkAttributes})
+}
+
+// wireGuardDeviceAttrs returns the attributes of the WG_CMD_SET_DEVICE
+// request setting up the interface with the given index as configured in s.
+// The peers of the interface are replaced, and the endpoints resolved.
+func wireGuardDeviceAttrs(index int, s *configs.WireGuardSettings) ([]*nl.RtAttr, error) {
+	privateKey, err := readWireGuardKey(s.PrivateKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	attrs := []*nl.RtAttr{
+		nl.NewRtAttr(unix.WGDEVICE_A_IFINDEX, nl.Uint32Attr(uint32(index))),
+		nl.NewRtAttr(unix.WGDEVICE_A_PRIVATE_KEY, privateKey),
+		nl.NewRtAttr(unix.WGDEVICE_A_FLAGS, nl.Uint32Attr(unix.WGDEVICE_F_REPLACE_PEERS)),
+	}
+	if s.ListenPort != 0 {
+		attrs = append(attrs, nl.NewRtAttr(unix.WGDEVICE_A_LISTEN_PORT, nl.Uint16Attr(s.ListenPort)))
+	}
+	if s.FirewallMark != 0 {
+		attrs = append(attrs, nl.NewRtAttr(unix.WGDEVICE_A_FWMARK, nl.Uint32Attr(s.FirewallMark)))
+	}
+	if len(s.Peers) == 0 {
+		return attrs, nil
+	}
+	peers := nl.NewRtAttr(unix.NLA_F_NESTED|unix.WGDEVICE_A_PEERS, nil)
+	for _, p := range s.Peers {
+		publicKey, err := base64.StdEncoding.DecodeString(p.PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key %q: %w", p.PublicKey, err)
+		}
+		peer := peers.AddRtAttr(unix.NLA_F_NESTED, nil)
+		peer.AddRtAttr(unix.WGPEER_A_PUBLIC_KEY, publicKey)
+		peer.AddRtAttr(unix.WGPEER_A_FLAGS, nl.Uint32Attr(unix.WGPEER_F_REPLACE_ALLOWEDIPS))
+		if p.PresharedKeyFile != "" {
+			key, err := readWireGuardKey(p.PresharedKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			peer.AddRtAttr(unix.WGPEER_A_PRESHARED_KEY, key)
+		}
+		if p.Endpoint != "" {
+			endpoint, err := net.ResolveUDPAddr("udp", p.Endpoint)
+			if err != nil {
+				return nil, fmt.Errorf("unable to resolve wireguard endpoint %s: %w", p.Endpoint, err)
+			}
+			peer.AddRtAttr(unix.WGPEER_A_ENDPOINT, sockaddr(endpoint))
+		}
+		if p.PersistentKeepalive != 0 {
+			peer.AddRtAttr(unix.WGPEER_A_PERSISTENT_KEEPALIVE_INTERVAL, nl.Uint16Attr(p.PersistentKeepalive))
+		}
+		allowed := peer.AddRtAttr(unix.NLA_F_NESTED|unix.WGPEER_A_ALLOWEDIPS, nil)
+		for _, cidr := range p.AllowedIPs {
+			_, ipNet, err := net.ParseCIDR(cidr)
+			if err != nil {
+				return nil, err
+			}
+			family, ip := uint16(unix.AF_INET), ipNet.IP.To4()
+			if ip == nil {
+				family, ip = unix.AF_INET6, ipNet.IP.To16()
+			}
+			ones, _ := ipNet.Mask.Size()
+			a := allowed.AddRtAttr(unix.NLA_F_NESTED, nil)
+			a.AddRtAttr(unix.WGALLOWEDIP_A_FAMILY, nl.Uint16Attr(family))
+			a.AddRtAttr(unix.WGALLOWEDIP_A_IPADDR, []byte(ip))
+			a.AddRtAttr(unix.WGALLOWEDIP_A_CIDR_MASK, nl.Uint8Attr(uint8(ones)))
+		}
+	}
+	return append(attrs, peers), nil
+}
+
+// readWireGuardKey reads the base64 encoded key in the file at path.
+func readWireGuardKey(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(data)))
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("invalid wireguard key in %s", path)
+	}
+	return key, nil
+}
+
+// sockaddr returns addr as a struct sockaddr_in or sockaddr_in6.
+func sockaddr(addr *net.UDPAddr) []byte {
+	if ip := addr.IP.To4(); ip != nil {
+		b := make([]byte, unix.SizeofSockaddrInet4)
+		nl.NativeEndian().PutUint16(b, unix.AF_INET)
+		binary.BigEndian.PutUint16(b[2:], uint16(addr.Port))
+		copy(b[4:], ip)
+		return b
+	}
+	b := make([]byte, unix.SizeofSockaddrInet6)
+	nl.NativeEndian().PutUint16(b, unix.AF_INET6)
+	binary.BigEndian.PutUint16(b[2:], uint16(addr.Port))
+	copy(b[8:], addr.IP.To16())
+	return b
+}
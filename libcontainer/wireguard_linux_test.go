@@ -0,0 +1,122 @@
+package libcontainer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/runc/internal/testutil/nettest"
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+// writeWireGuardKey writes a key made of b to a file, and returns its path.
+func writeWireGuardKey(t *testing.T, b byte) string {
+	path := filepath.Join(t.TempDir(), "key")
+	key := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, 32))
+	if err := os.WriteFile(path, []byte(key+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCreateWireGuard(t *testing.T) {
+	host := nettest.NewNS(t)
+	ctr := nettest.NewNS(t)
+	nsFd := ctr.Fd(t)
+	n := &configs.Network{
+		Type: "wireguard",
+		Name: "wg0",
+		Mtu:  1420,
+		WireGuard: &configs.WireGuardSettings{
+			PrivateKeyFile: writeWireGuardKey(t, 1),
+			ListenPort:     51820,
+			Peers: []*configs.WireGuardPeer{{
+				PublicKey:  base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{2}, 32)),
+				Endpoint:   "192.0.2.1:51820",
+				AllowedIPs: []string{"10.0.0.0/24"},
+			}},
+		},
+	}
+	if err := host.Run(func() error { return createWireGuard(n, nsFd) }); err != nil {
+		if errors.Is(err, unix.EOPNOTSUPP) {
+			t.Skip("wireguard is not supported by the kernel")
+		}
+		t.Fatal(err)
+	}
+	link := ctr.Link(t, "wg0")
+	if link == nil || link.Type() != "wireguard" {
+		t.Fatalf("expected a wireguard interface in the container, got %v", link)
+	}
+	if link.Attrs().MTU != 1420 {
+		t.Errorf("expected mtu 1420, got %d", link.Attrs().MTU)
+	}
+}
+
+func TestWireGuardDeviceAttrs(t *testing.T) {
+	peerKey := bytes.Repeat([]byte{2}, 32)
+	s := &configs.WireGuardSettings{
+		PrivateKeyFile: writeWireGuardKey(t, 1),
+		ListenPort:     51820,
+		Peers: []*configs.WireGuardPeer{{
+			PublicKey:           base64.StdEncoding.EncodeToString(peerKey),
+			PresharedKeyFile:    writeWireGuardKey(t, 3),
+			Endpoint:            "[2001:db8::1]:51821",
+			AllowedIPs:          []string{"10.0.0.0/24", "fd00::/64"},
+			PersistentKeepalive: 25,
+		}},
+	}
+	attrs, err := wireGuardDeviceAttrs(7, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	device := map[int][]byte{}
+	for _, a := range attrs {
+		device[int(a.Type)&^unix.NLA_F_NESTED] = a.Serialize()[unix.SizeofRtAttr:]
+	}
+	if index := nl.NativeEndian().Uint32(device[unix.WGDEVICE_A_IFINDEX]); index != 7 {
+		t.Errorf("expected interface index 7, got %d", index)
+	}
+	if !bytes.Equal(device[unix.WGDEVICE_A_PRIVATE_KEY], bytes.Repeat([]byte{1}, 32)) {
+		t.Error("unexpected private key")
+	}
+	if port := nl.NativeEndian().Uint16(device[unix.WGDEVICE_A_LISTEN_PORT]); port != 51820 {
+		t.Errorf("expected listen port 51820, got %d", port)
+	}
+
+	peers, err := nl.ParseRouteAttr(device[unix.WGDEVICE_A_PEERS])
+	if err != nil || len(peers) != 1 {
+		t.Fatalf("expected one peer, got %d (%v)", len(peers), err)
+	}
+	parsed, err := nl.ParseRouteAttr(peers[0].Value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	peer := map[int][]byte{}
+	for _, a := range parsed {
+		peer[int(a.Attr.Type)&^unix.NLA_F_NESTED] = a.Value
+	}
+	if !bytes.Equal(peer[unix.WGPEER_A_PUBLIC_KEY], peerKey) || !bytes.Equal(peer[unix.WGPEER_A_PRESHARED_KEY], bytes.Repeat([]byte{3}, 32)) {
+		t.Error("unexpected peer keys")
+	}
+	endpoint := peer[unix.WGPEER_A_ENDPOINT]
+	if len(endpoint) != unix.SizeofSockaddrInet6 || nl.NativeEndian().Uint16(endpoint) != unix.AF_INET6 || endpoint[2] != 0xca || endpoint[3] != 0x6d {
+		t.Errorf("unexpected endpoint %x", endpoint)
+	}
+	if keepalive := nl.NativeEndian().Uint16(peer[unix.WGPEER_A_PERSISTENT_KEEPALIVE_INTERVAL]); keepalive != 25 {
+		t.Errorf("expected keepalive 25, got %d", keepalive)
+	}
+	allowed, err := nl.ParseRouteAttr(peer[unix.WGPEER_A_ALLOWEDIPS])
+	if err != nil || len(allowed) != 2 {
+		t.Fatalf("expected two allowed IPs, got %d (%v)", len(allowed), err)
+	}
+
+	s.PrivateKeyFile = filepath.Join(t.TempDir(), "missing")
+	if _, err := wireGuardDeviceAttrs(7, s); err == nil {
+		t.Error("expected error for a missing private key")
+	}
+}
@@ -0,0 +1,111 @@
+package libcontainer
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+// wirelessDevice identifies the cfg80211 device behind a network interface.
+type wirelessDevice struct {
+	// wiphy is the index of the physical device the interface belongs to.
+	wiphy uint32
+	// wdev is the wireless device identifier, which unlike the interface
+	// index is kept when the device changes network namespace.
+	wdev uint64
+}
+
+// nl80211Execute sends an nl80211 request with the given attributes in the
+// current network namespace, returning the attributes of each reply.
+func nl80211Execute(cmd uint8, flags int, attrs ...*nl.RtAttr) ([]map[uint16][]byte, error) {
+	return genlExecute("nl80211", cmd, flags, attrs...)
+}
+
+// genlExecute sends a request to the generic netlink family name, returning
+// the attributes of each reply.
+func genlExecute(name string, cmd uint8, flags int, attrs ...*nl.RtAttr) ([]map[uint16][]byte, error) {
+	family, err := netlink.GenlFamilyGet(name)
+	if err != nil {
+		return nil, err
+	}
+	req := nl.NewNetlinkRequest(int(family.ID), unix.NLM_F_ACK|flags)
+	req.AddData(&nl.Genlmsg{Command: cmd, Version: 0})
+	for _, attr := range attrs {
+		req.AddData(attr)
+	}
+	msgs, err := req.Execute(unix.NETLINK_GENERIC, 0)
+	if err != nil {
+		return nil, err
+	}
+	replies := make([]map[uint16][]byte, 0, len(msgs))
+	for _, m := range msgs {
+		parsed, err := nl.ParseRouteAttr(m[nl.SizeofGenlmsg:])
+		if err != nil {
+			return nil, err
+		}
+		reply := make(map[uint16][]byte, len(parsed))
+		for _, a := range parsed {
+			reply[a.Attr.Type&^(unix.NLA_F_NESTED|unix.NLA_F_NET_BYTEORDER)] = a.Value
+		}
+		replies = append(replies, reply)
+	}
+	return replies, nil
+}
+
+// getWirelessDevice returns the cfg80211 device of the interface with the
+// given index, or nil if the interface is not a wireless one.
+func getWirelessDevice(index int) (*wirelessDevice, error) {
+	replies, err := nl80211Execute(unix.NL80211_CMD_GET_INTERFACE, 0,
+		nl.NewRtAttr(unix.NL80211_ATTR_IFINDEX, nl.Uint32Attr(uint32(index))))
+	if err != nil {
+		// The nl80211 family is missing when cfg80211 is not loaded,
+		// and interfaces which are not wireless are reported as ENODEV.
+		if errors.Is(err, unix.ENOENT) || errors.Is(err, unix.ENODEV) || errors.Is(err, unix.EOPNOTSUPP) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if len(replies) == 0 {
+		return nil, nil
+	}
+	wiphy, wdev := replies[0][unix.NL80211_ATTR_WIPHY], replies[0][unix.NL80211_ATTR_WDEV]
+	if len(wiphy) != 4 || len(wdev) != 8 {
+		return nil, errors.New("incomplete nl80211 interface attributes")
+	}
+	return &wirelessDevice{wiphy: nl.NativeEndian().Uint32(wiphy), wdev: nl.NativeEndian().Uint64(wdev)}, nil
+}
+
+// moveWirelessLink moves a wireless interface to the network namespace nsFd.
+// Wireless interfaces can not be moved with RTM_NEWLINK; the whole phy has to
+// be moved with nl80211, which also moves any other interface of the phy. The
+// phy keeps its name. The index of the interface in the container namespace
+// is returned, as it may differ from the host one.
+func moveWirelessLink(link netlink.Link, dev *wirelessDevice, nsFd int) (int, error) {
+	_, err := nl80211Execute(unix.NL80211_CMD_SET_WIPHY_NETNS, 0,
+		nl.NewRtAttr(unix.NL80211_ATTR_WIPHY, nl.Uint32Attr(dev.wiphy)),
+		nl.NewRtAttr(unix.NL80211_ATTR_NETNS_FD, nl.Uint32Attr(uint32(nsFd))))
+	if err != nil {
+		return 0, fmt.Errorf("unable to move the wireless phy of %s to the container: %w", link.Attrs().Name, err)
+	}
+	var index int
+	err = doInNetNS("/proc/self/fd/"+strconv.Itoa(nsFd), func() error {
+		replies, err := nl80211Execute(unix.NL80211_CMD_GET_INTERFACE, 0,
+			nl.NewRtAttr(unix.NL80211_ATTR_WDEV, nl.Uint64Attr(dev.wdev)))
+		if err != nil {
+			return err
+		}
+		if len(replies) == 0 || len(replies[0][unix.NL80211_ATTR_IFINDEX]) != 4 {
+			return errors.New("no interface index reported")
+		}
+		index = int(nl.NativeEndian().Uint32(replies[0][unix.NL80211_ATTR_IFINDEX]))
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("unable to find %s in the container after moving its phy: %w", link.Attrs().Name, err)
+	}
+	return index, nil
+}
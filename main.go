@@ -108,6 +108,14 @@ func main() {
 			Name:  "systemd-cgroup",
 			Usage: "enable systemd cgroup support, expects cgroupsPath to be of form \"slice:prefix:name\" for e.g. \"system.slice:runc:434234\"",
 		},
+		cli.StringFlag{
+			Name:  "netns-sysctl-defaults",
+			Usage: "path to a sysctl.d(5) file with defaults for the network namespaces created for containers",
+		},
+		cli.StringFlag{
+			Name:  "netlink-rate-limit",
+			Usage: "limit the rate at which container networks are created, as RATE[:BURST] networks per second",
+		},
 		cli.StringFlag{
 			Name:  "rootless",
 			Value: "auto",
@@ -122,7 +130,11 @@ func main() {
 		execCommand,
 		killCommand,
 		listCommand,
+		metadataCommand,
+		netdevCommand,
+		netstatCommand,
 		pauseCommand,
+		portForwardCommand,
 		psCommand,
 		restoreCommand,
 		resumeCommand,
@@ -0,0 +1,47 @@
+package main
+
+import (
+	gocontext "context"
+	"io"
+	"os"
+
+	"github.com/urfave/cli"
+)
+
+var metadataCommand = cli.Command{
+	Name:  "metadata",
+	Usage: "serve a metadata document on a link-local address inside the container",
+	ArgsUsage: `<container-id> <metadata-file>
+
+Where "<container-id>" is the name for the instance of the container and
+"<metadata-file>" is the path to a JSON document, or "-" to read it from
+standard input. The document is served over HTTP on 169.254.169.254:80 in the
+container network namespace. The command runs in the foreground until it is
+interrupted.
+
+EXAMPLE:
+       # runc metadata <container-id> meta-data.json`,
+	Action: func(context *cli.Context) error {
+		if err := checkArgs(context, 2, exactArgs); err != nil {
+			return err
+		}
+		doc, err := readMetadata(context.Args()[1])
+		if err != nil {
+			return err
+		}
+		container, err := getContainer(context)
+		if err != nil {
+			return err
+		}
+		return runUntilSignal(func(ctx gocontext.Context) error {
+			return container.ServeMetadata(ctx, doc)
+		})
+	},
+}
+
+func readMetadata(path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(path)
+}
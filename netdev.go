@@ -0,0 +1,384 @@
+package main
+
+import (
+	gocontext "context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/opencontainers/runc/libcontainer"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+var netdevCommand = cli.Command{
+	Name:  "netdev",
+	Usage: "manage the network devices of a container",
+	Subcommands: []cli.Command{
+		netdevActivateCommand,
+		netdevInspectCommand,
+		netdevListCommand,
+		netdevLLDPCommand,
+		netdevQuotaCommand,
+		netdevRenewCommand,
+		netdevMoveCommand,
+		netdevReconcileCommand,
+	},
+}
+
+var netdevActivateCommand = cli.Command{
+	Name:      "activate",
+	Usage:     "activate a standby network device of a container",
+	ArgsUsage: `<container-id> <device>`,
+	Description: `The activate command brings the standby network device named <device> of a
+running container up, and replaces the default routes of the container with
+those through its gateways. The devices whose gateways are all replaced are put
+on standby, but are kept up.`,
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "diff",
+			Usage: "print the changes made to the network devices of the container",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		if err := checkArgs(context, 2, exactArgs); err != nil {
+			return err
+		}
+		args := context.Args()
+		return reportNetworkChanges(context, []string{args[0]}, func() error {
+			return libcontainer.ActivateNetwork(context.GlobalString("root"), args[0], args[1])
+		})
+	},
+}
+
+var netdevInspectCommand = cli.Command{
+	Name:      "inspect",
+	Usage:     "display the network devices of a container",
+	ArgsUsage: `<container-id>`,
+	Description: `The inspect command displays the configuration of the network devices of a
+container, the status of their interfaces if the container is running, and the
+optional settings which were skipped because the kernel does not support them.`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "format, f",
+			Value: "table",
+			Usage: `select one of: ` + formatOptions,
+		},
+	},
+	Action: func(context *cli.Context) error {
+		if err := checkArgs(context, 1, exactArgs); err != nil {
+			return err
+		}
+		container, err := getContainer(context)
+		if err != nil {
+			return err
+		}
+		networks, err := container.InspectNetworks()
+		if err != nil {
+			return err
+		}
+		switch context.String("format") {
+		case "table":
+			w := tabwriter.NewWriter(os.Stdout, 12, 1, 3, ' ', 0)
+			fmt.Fprint(w, "NAME\tTYPE\tHOST INTERFACE\tSTATE\tADDRESSES\tSKIPPED\n")
+			for _, n := range networks {
+				addrs := "-"
+				if s := n.Status; s != nil && len(s.Addresses) > 0 {
+					addrs = strings.Join(s.Addresses, ",")
+				}
+				skipped := make([]string, 0, len(n.Skipped))
+				for _, s := range n.Skipped {
+					skipped = append(skipped, s.Setting)
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+					orDash(n.Network.Name), n.Network.Type, orDash(n.Network.HostInterfaceName),
+					orDash(n.State()), addrs, orDash(strings.Join(skipped, ",")))
+			}
+			return w.Flush()
+		case "json":
+			return json.NewEncoder(os.Stdout).Encode(networks)
+		default:
+			return errors.New("invalid format option")
+		}
+	},
+}
+
+var netdevListCommand = cli.Command{
+	Name:  "list",
+	Usage: "list the network devices of the host",
+	Description: `The list command lists the network interfaces of the host with their driver,
+speed, NUMA node and SR-IOV capabilities, and whether they are eligible to be
+attached to a container: only physical devices which are not enslaved to
+another interface are.`,
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "candidates",
+			Usage: "only list the devices eligible to be attached to a container",
+		},
+		cli.StringSliceFlag{
+			Name:  "allow",
+			Usage: "only consider eligible the devices matching this pattern (can be repeated)",
+		},
+		cli.StringFlag{
+			Name:  "format, f",
+			Value: "table",
+			Usage: `select one of: ` + formatOptions,
+		},
+	},
+	Action: func(context *cli.Context) error {
+		if err := checkArgs(context, 0, exactArgs); err != nil {
+			return err
+		}
+		devices, err := libcontainer.ListHostNetworkDevices(context.StringSlice("allow"))
+		if err != nil {
+			return err
+		}
+		if context.Bool("candidates") {
+			candidates := devices[:0]
+			for _, d := range devices {
+				if d.Eligible {
+					candidates = append(candidates, d)
+				}
+			}
+			devices = candidates
+		}
+		switch context.String("format") {
+		case "table":
+			w := tabwriter.NewWriter(os.Stdout, 12, 1, 3, ' ', 0)
+			fmt.Fprint(w, "NAME\tDRIVER\tSPEED\tNUMA\tVFS\tELIGIBLE\n")
+			for _, d := range devices {
+				speed, numa, vfs := "-", "-", "-"
+				if d.Speed > 0 {
+					speed = strconv.Itoa(d.Speed) + "Mb/s"
+				}
+				if d.NUMANode >= 0 {
+					numa = strconv.Itoa(d.NUMANode)
+				}
+				if d.SRIOVTotalVFs > 0 {
+					vfs = fmt.Sprintf("%d/%d", d.SRIOVNumVFs, d.SRIOVTotalVFs)
+				}
+				eligible := "yes"
+				if !d.Eligible {
+					eligible = "no: " + d.Reason
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", d.Name, orDash(d.Driver), speed, numa, vfs, eligible)
+			}
+			return w.Flush()
+		case "json":
+			return json.NewEncoder(os.Stdout).Encode(devices)
+		default:
+			return errors.New("invalid format option")
+		}
+	},
+}
+
+var netdevMoveCommand = cli.Command{
+	Name:      "move",
+	Usage:     "move a network device from a container to another",
+	ArgsUsage: `<src-id> <dst-id> <device>`,
+	Description: `The move command moves the network device named <device> from the running
+container <src-id> to the running container <dst-id>, without going through
+the host network namespace. The device is set up again in the destination
+container, with its name and addresses optionally rewritten.`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "name",
+			Usage: "name of the device in the destination container",
+		},
+		cli.StringFlag{
+			Name:  "ip",
+			Usage: "IPv4 address and mask of the device in the destination container",
+		},
+		cli.StringFlag{
+			Name:  "gateway",
+			Usage: "IPv4 gateway of the device in the destination container",
+		},
+		cli.StringFlag{
+			Name:  "ip6",
+			Usage: "IPv6 address and mask of the device in the destination container",
+		},
+		cli.StringFlag{
+			Name:  "gateway6",
+			Usage: "IPv6 gateway of the device in the destination container",
+		},
+		cli.BoolFlag{
+			Name:  "diff",
+			Usage: "print the changes made to the network devices of the container",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		if err := checkArgs(context, 3, exactArgs); err != nil {
+			return err
+		}
+		args := context.Args()
+		m := &libcontainer.NetworkMove{
+			Name:        context.String("name"),
+			Address:     context.String("ip"),
+			Gateway:     context.String("gateway"),
+			IPv6Address: context.String("ip6"),
+			IPv6Gateway: context.String("gateway6"),
+		}
+		return reportNetworkChanges(context, []string{args[0], args[1]}, func() error {
+			return runUntilSignal(func(ctx gocontext.Context) error {
+				return libcontainer.MoveNetwork(ctx, context.GlobalString("root"), args[0], args[1], args[2], m)
+			})
+		})
+	},
+}
+
+var netdevReconcileCommand = cli.Command{
+	Name:      "reconcile",
+	Usage:     "attach the optional network devices of a container which became available",
+	ArgsUsage: `<container-id>`,
+	Description: `The reconcile command attaches the optional network devices of a running
+container which were skipped at its creation because their device was missing
+or busy, and have become available since then, for example by being
+hot-plugged. The names of the attached devices are printed.`,
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "diff",
+			Usage: "print the changes made to the network devices of the container",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		if err := checkArgs(context, 1, exactArgs); err != nil {
+			return err
+		}
+		id := context.Args().First()
+		return reportNetworkChanges(context, []string{id}, func() error {
+			return runUntilSignal(func(ctx gocontext.Context) error {
+				attached, err := libcontainer.ReconcileNetworks(ctx, context.GlobalString("root"), id)
+				for _, name := range attached {
+					fmt.Println(name)
+				}
+				return err
+			})
+		})
+	},
+}
+
+var netdevLLDPCommand = cli.Command{
+	Name:      "lldp",
+	Usage:     "announce a container on the links of its network devices with LLDP",
+	ArgsUsage: `<container-id>`,
+	Description: `The lldp command periodically sends LLDP frames announcing a running
+container on the network devices with LLDP settings, until the container stops
+or runc is interrupted, when frames telling the neighbors to forget the
+container are sent.`,
+	Action: func(context *cli.Context) error {
+		if err := checkArgs(context, 1, exactArgs); err != nil {
+			return err
+		}
+		container, err := getContainer(context)
+		if err != nil {
+			return err
+		}
+		return runUntilSignal(container.AnnounceLLDP)
+	},
+}
+
+var netdevQuotaCommand = cli.Command{
+	Name:      "quota",
+	Usage:     "enforce the quotas of the network devices of a container",
+	ArgsUsage: `<container-id>`,
+	Description: `The quota command periodically checks the quotas of the network devices of a
+running container, until the container stops or runc is interrupted. Exceeded
+quotas are recorded, to be reported by runc events, and the devices whose
+quota has the link-down action are brought down. The actions of the quotas of
+packets, which the kernel can not apply, are applied as well. runc run
+enforces the quotas itself while it is attached to the container.`,
+	Action: func(context *cli.Context) error {
+		if err := checkArgs(context, 1, exactArgs); err != nil {
+			return err
+		}
+		container, err := getContainer(context)
+		if err != nil {
+			return err
+		}
+		return runUntilSignal(container.EnforceNetworkQuotas)
+	},
+}
+
+var netdevRenewCommand = cli.Command{
+	Name:      "renew",
+	Usage:     "renew the claims of a container on its network devices",
+	ArgsUsage: `<container-id>`,
+	Description: `The renew command periodically renews the claims of a running container on
+the virtual functions of its networks with a claim ttl, until the container
+stops or runc is interrupted. Claims which are not renewed expire, and their
+virtual functions can be given to other containers once back in the host.`,
+	Action: func(context *cli.Context) error {
+		if err := checkArgs(context, 1, exactArgs); err != nil {
+			return err
+		}
+		container, err := getContainer(context)
+		if err != nil {
+			return err
+		}
+		return runUntilSignal(container.RenewDeviceClaims)
+	},
+}
+
+// reportNetworkChanges runs update, which may change the network settings of
+// the containers ids, and logs the changes it made to their networks, which
+// are also printed if the diff flag is set. The changes are reported even if
+// update fails, as it may have applied some of them. The report is best
+// effort: if the networks of a container can not be inspected beforehand,
+// as when the caller can not enter its network namespace, its changes are
+// not reported, but update still runs.
+func reportNetworkChanges(context *cli.Context, ids []string, update func() error) error {
+	root := context.GlobalString("root")
+	before := make([][]libcontainer.NetworkInspection, len(ids))
+	inspected := make([]bool, len(ids))
+	for i, id := range ids {
+		c, err := libcontainer.Load(root, id)
+		if err == nil {
+			before[i], err = c.InspectNetworks()
+		}
+		if err != nil {
+			logrus.WithError(err).Warnf("unable to report the network changes of container %s", id)
+			continue
+		}
+		inspected[i] = true
+	}
+	err := update()
+	for i, id := range ids {
+		if !inspected[i] {
+			continue
+		}
+		c, lerr := libcontainer.Load(root, id)
+		if lerr != nil {
+			logrus.WithError(lerr).Warnf("unable to report the network changes of container %s", id)
+			continue
+		}
+		after, ierr := c.InspectNetworks()
+		if ierr != nil {
+			logrus.WithError(ierr).Warnf("unable to report the network changes of container %s", id)
+			continue
+		}
+		for _, change := range libcontainer.DiffNetworks(before[i], after) {
+			logrus.WithFields(logrus.Fields{
+				"id":        id,
+				"interface": change.Interface,
+				"field":     change.Field,
+				"before":    change.Before,
+				"after":     change.After,
+			}).Info("network changed")
+			if context.Bool("diff") {
+				fmt.Printf("%s %s\n", id, change)
+			}
+		}
+	}
+	return err
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
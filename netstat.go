@@ -0,0 +1,238 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/opencontainers/runc/libcontainer"
+	"github.com/urfave/cli"
+	"github.com/vishvananda/netlink/nl"
+)
+
+// socketEntry describes a single listening socket found in the container's
+// network namespace.
+type socketEntry struct {
+	// Proto is the protocol as named in /proc/net (tcp, tcp6, udp, udp6, unix).
+	Proto string `json:"proto"`
+	// Local is the local address of the socket (ip:port, or a path for unix).
+	Local string `json:"local"`
+	// Inode is the socket inode number.
+	Inode uint64 `json:"inode"`
+}
+
+// netstatSummary is a summary of the sockets in a container's network
+// namespace.
+type netstatSummary struct {
+	// Listening contains all sockets that accept connections or datagrams.
+	Listening []socketEntry `json:"listening"`
+	// Established is the number of established connections per protocol.
+	Established map[string]int `json:"established"`
+}
+
+var netstatCommand = cli.Command{
+	Name:      "netstat",
+	Usage:     "netstat displays the sockets open in a container's network namespace",
+	ArgsUsage: `<container-id>`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "format, f",
+			Value: "table",
+			Usage: `select one of: ` + formatOptions,
+		},
+	},
+	Action: func(context *cli.Context) error {
+		if err := checkArgs(context, 1, exactArgs); err != nil {
+			return err
+		}
+		container, err := getContainer(context)
+		if err != nil {
+			return err
+		}
+		status, err := container.Status()
+		if err != nil {
+			return err
+		}
+		if status == libcontainer.Stopped {
+			return errors.New("cannot inspect sockets of a stopped container")
+		}
+		state, err := container.State()
+		if err != nil {
+			return err
+		}
+		summary, err := readNetstat(filepath.Join("/proc", strconv.Itoa(state.InitProcessPid), "net"))
+		if err != nil {
+			return err
+		}
+
+		switch context.String("format") {
+		case "table":
+			w := tabwriter.NewWriter(os.Stdout, 12, 1, 3, ' ', 0)
+			fmt.Fprint(w, "PROTO\tLOCAL ADDRESS\tINODE\n")
+			for _, s := range summary.Listening {
+				fmt.Fprintf(w, "%s\t%s\t%d\n", s.Proto, s.Local, s.Inode)
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			fmt.Println()
+			for _, proto := range []string{"tcp", "tcp6"} {
+				fmt.Printf("established %s connections: %d\n", proto, summary.Established[proto])
+			}
+		case "json":
+			return json.NewEncoder(os.Stdout).Encode(summary)
+		default:
+			return errors.New("invalid format option")
+		}
+		return nil
+	},
+}
+
+const (
+	tcpEstablished = 0x01
+	tcpListen      = 0x0A
+	udpUnconnected = 0x07
+
+	// unixAcceptCon is the __SO_ACCEPTCON flag reported for listening
+	// unix sockets in /proc/net/unix.
+	unixAcceptCon = 0x10000
+)
+
+// readNetstat parses the socket tables found in the given /proc/<pid>/net
+// directory. Since /proc/<pid>/net reflects the network namespace of <pid>,
+// there is no need to join the namespace to read them.
+func readNetstat(dir string) (*netstatSummary, error) {
+	summary := &netstatSummary{
+		Listening:   []socketEntry{},
+		Established: map[string]int{},
+	}
+	for _, proto := range []string{"tcp", "tcp6", "udp", "udp6"} {
+		f, err := os.Open(filepath.Join(dir, proto))
+		if err != nil {
+			// IPv6 may be disabled in the kernel.
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		err = parseInetSockets(f, proto, summary)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse %s sockets: %w", proto, err)
+		}
+	}
+	f, err := os.Open(filepath.Join(dir, "unix"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if err := parseUnixSockets(f, summary); err != nil {
+		return nil, fmt.Errorf("unable to parse unix sockets: %w", err)
+	}
+	return summary, nil
+}
+
+// parseInetSockets parses the format of /proc/net/{tcp,tcp6,udp,udp6}.
+func parseInetSockets(r io.Reader, proto string, summary *netstatSummary) error {
+	s := bufio.NewScanner(r)
+	// Skip the header.
+	s.Scan()
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) < 10 {
+			return fmt.Errorf("malformed line %q", s.Text())
+		}
+		st, err := strconv.ParseUint(fields[3], 16, 8)
+		if err != nil {
+			return fmt.Errorf("invalid socket state %q: %w", fields[3], err)
+		}
+		listening := false
+		if strings.HasPrefix(proto, "tcp") {
+			switch st {
+			case tcpEstablished:
+				summary.Established[proto]++
+				continue
+			case tcpListen:
+				listening = true
+			}
+		} else {
+			listening = st == udpUnconnected
+		}
+		if !listening {
+			continue
+		}
+		local, err := parseHexAddr(fields[1])
+		if err != nil {
+			return err
+		}
+		inode, err := strconv.ParseUint(fields[9], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid inode %q: %w", fields[9], err)
+		}
+		summary.Listening = append(summary.Listening, socketEntry{Proto: proto, Local: local, Inode: inode})
+	}
+	return s.Err()
+}
+
+// parseUnixSockets parses the format of /proc/net/unix.
+func parseUnixSockets(r io.Reader, summary *netstatSummary) error {
+	s := bufio.NewScanner(r)
+	// Skip the header.
+	s.Scan()
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) < 7 {
+			return fmt.Errorf("malformed line %q", s.Text())
+		}
+		flags, err := strconv.ParseUint(fields[3], 16, 32)
+		if err != nil {
+			return fmt.Errorf("invalid socket flags %q: %w", fields[3], err)
+		}
+		if flags&unixAcceptCon == 0 {
+			continue
+		}
+		inode, err := strconv.ParseUint(fields[6], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid inode %q: %w", fields[6], err)
+		}
+		path := ""
+		if len(fields) > 7 {
+			path = fields[7]
+		}
+		summary.Listening = append(summary.Listening, socketEntry{Proto: "unix", Local: path, Inode: inode})
+	}
+	return s.Err()
+}
+
+// parseHexAddr converts an address in the "0100007F:0050" form used by
+// /proc/net into "127.0.0.1:80". The IP address is stored as a sequence of
+// 32-bit words printed in host byte order, the port in big-endian.
+func parseHexAddr(s string) (string, error) {
+	addr, port, ok := strings.Cut(s, ":")
+	if !ok {
+		return "", fmt.Errorf("invalid address %q", s)
+	}
+	raw, err := hex.DecodeString(addr)
+	if err != nil || (len(raw) != net.IPv4len && len(raw) != net.IPv6len) {
+		return "", fmt.Errorf("invalid address %q", s)
+	}
+	ip := make(net.IP, len(raw))
+	for i := 0; i < len(raw); i += 4 {
+		nl.NativeEndian().PutUint32(ip[i:], binary.BigEndian.Uint32(raw[i:]))
+	}
+	p, err := strconv.ParseUint(port, 16, 16)
+	if err != nil {
+		return "", fmt.Errorf("invalid port in address %q: %w", s, err)
+	}
+	return net.JoinHostPort(ip.String(), strconv.FormatUint(p, 10)), nil
+}
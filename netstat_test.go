@@ -0,0 +1,74 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseHexAddr(t *testing.T) {
+	for _, tc := range []struct {
+		in, out string
+	}{
+		{in: "0100007F:0050", out: "127.0.0.1:80"},
+		{in: "00000000:1F90", out: "0.0.0.0:8080"},
+		{in: "00000000000000000000000001000000:0016", out: "[::1]:22"},
+		{in: "0000000000000000FFFF00000100007F:01BB", out: "127.0.0.1:443"},
+	} {
+		got, err := parseHexAddr(tc.in)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.in, err)
+			continue
+		}
+		if got != tc.out {
+			t.Errorf("%s: expected %s, got %s", tc.in, tc.out, got)
+		}
+	}
+	for _, in := range []string{"", "0100007F", "zz00007F:0050", "0100007F:zz", "01007F:0050"} {
+		if _, err := parseHexAddr(in); err == nil {
+			t.Errorf("%q: expected error, got nil", in)
+		}
+	}
+}
+
+func TestParseSockets(t *testing.T) {
+	const tcp = `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 0100007F:0050 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1001 1 0000000000000000 100 0 0 10 0
+   1: 0100007F:0050 0100007F:C350 01 00000000:00000000 00:00000000 00000000     0        0 1002 1 0000000000000000 20 4 30 10 -1
+   2: 0100007F:0050 0100007F:C351 01 00000000:00000000 00:00000000 00000000     0        0 1003 1 0000000000000000 20 4 30 10 -1
+   3: 0100007F:0050 0100007F:C352 06 00000000:00000000 00:00000000 00000000     0        0 0 3 0000000000000000
+`
+	const udp = `   sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode ref pointer drops
+  10: 00000000:0035 00000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 2001 2 0000000000000000 0
+`
+	const unix = `Num       RefCount Protocol Flags    Type St Inode Path
+0000000000000000: 00000002 00000000 00010000 0001 01 3001 /run/app.sock
+0000000000000000: 00000003 00000000 00000000 0001 03 3002
+`
+	summary := &netstatSummary{Established: map[string]int{}}
+	if err := parseInetSockets(strings.NewReader(tcp), "tcp", summary); err != nil {
+		t.Fatal(err)
+	}
+	if err := parseInetSockets(strings.NewReader(udp), "udp", summary); err != nil {
+		t.Fatal(err)
+	}
+	if err := parseUnixSockets(strings.NewReader(unix), summary); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []socketEntry{
+		{Proto: "tcp", Local: "127.0.0.1:80", Inode: 1001},
+		{Proto: "udp", Local: "0.0.0.0:53", Inode: 2001},
+		{Proto: "unix", Local: "/run/app.sock", Inode: 3001},
+	}
+	if len(summary.Listening) != len(expected) {
+		t.Fatalf("expected %d listening sockets, got %+v", len(expected), summary.Listening)
+	}
+	for i := range expected {
+		if summary.Listening[i] != expected[i] {
+			t.Errorf("expected %+v, got %+v", expected[i], summary.Listening[i])
+		}
+	}
+	if n := summary.Established["tcp"]; n != 2 {
+		t.Errorf("expected 2 established tcp connections, got %d", n)
+	}
+}
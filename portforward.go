@@ -0,0 +1,64 @@
+package main
+
+import (
+	gocontext "context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/urfave/cli"
+)
+
+var portForwardCommand = cli.Command{
+	Name:  "port-forward",
+	Usage: "forward host loopback ports to the container loopback",
+	ArgsUsage: `<container-id> [<host-port>:<container-port> ...]
+
+Where "<container-id>" is the name for the instance of the container. If no
+ports are given, the port forwards from the container configuration are used.
+The command runs in the foreground until it is interrupted.
+
+EXAMPLE:
+To make a service listening on 127.0.0.1:8080 in the container reachable on
+127.0.0.1:18080 in the host:
+
+       # runc port-forward <container-id> 18080:8080`,
+	Action: func(context *cli.Context) error {
+		if err := checkArgs(context, 1, minArgs); err != nil {
+			return err
+		}
+		forwards, err := parsePortForwards(context.Args()[1:])
+		if err != nil {
+			return err
+		}
+		container, err := getContainer(context)
+		if err != nil {
+			return err
+		}
+		return runUntilSignal(func(ctx gocontext.Context) error {
+			return container.ForwardLoopbackPorts(ctx, forwards...)
+		})
+	},
+}
+
+// parsePortForwards parses a list of <host-port>:<container-port> pairs.
+func parsePortForwards(args []string) ([]*configs.PortForward, error) {
+	var forwards []*configs.PortForward
+	for _, arg := range args {
+		host, ctr, ok := strings.Cut(arg, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid port forward %q: expected <host-port>:<container-port>", arg)
+		}
+		hostPort, err := strconv.ParseUint(host, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid host port in %q: %w", arg, err)
+		}
+		ctrPort, err := strconv.ParseUint(ctr, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid container port in %q: %w", arg, err)
+		}
+		forwards = append(forwards, &configs.PortForward{HostPort: uint16(hostPort), ContainerPort: uint16(ctrPort)})
+	}
+	return forwards, nil
+}
@@ -3,6 +3,7 @@ package types
 import (
 	"github.com/opencontainers/runc/libcontainer/cgroups"
 	"github.com/opencontainers/runc/libcontainer/intelrdt"
+	v1 "github.com/opencontainers/runc/types/stats/v1"
 )
 
 // Event struct for encoding the event data to json.
@@ -150,16 +151,7 @@ type IntelRdt struct {
 	CMTStats *[]intelrdt.CMTNumaNodeStats `json:"cmt_stats,omitempty"`
 }
 
-type NetworkInterface struct {
-	// Name is the name of the network interface.
-	Name string
-
-	RxBytes   uint64
-	RxPackets uint64
-	RxErrors  uint64
-	RxDropped uint64
-	TxBytes   uint64
-	TxPackets uint64
-	TxErrors  uint64
-	TxDropped uint64
-}
+// NetworkInterface is the version 1 network interface statistics.
+//
+// Deprecated: use [v1.NetworkInterface], whose encoding is stable.
+type NetworkInterface = v1.NetworkInterface
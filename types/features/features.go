@@ -22,4 +22,20 @@ const (
 	// AnnotationLibseccompVersion is the version of libseccomp, e.g., "2.5.1".
 	// Note that the runtime MAY support seccomp even when this annotation is not present.
 	AnnotationLibseccompVersion = "io.github.seccomp.libseccomp.version"
+
+	// AnnotationNetlinkVersion is the version of the github.com/vishvananda/netlink
+	// library runc is built with, e.g., "v1.1.0", which bounds the netlink features
+	// runc can use. It is not present if runc is built without module information.
+	AnnotationNetlinkVersion = "com.github.vishvananda.netlink.version"
+
+	// AnnotationNetworkTypes is the comma-separated list of the network types runc
+	// can set up, e.g., "loopback,macvlan,veth".
+	AnnotationNetworkTypes = "org.opencontainers.runc.network.types"
+
+	// AnnotationNetworkSupportedTypes is the comma-separated list of the network
+	// types whose kernel module is available on the host, as probed when the
+	// features are requested. A supported type can still fail to be set up, for
+	// example if the device it needs is missing. It is not present if the kernel
+	// modules of the host can not be inspected.
+	AnnotationNetworkSupportedTypes = "org.opencontainers.runc.network.supported-types"
 )
@@ -0,0 +1,9 @@
+// Package v1 defines version 1 of the network statistics reported by runc,
+// as encoded in the output of "runc events" and in the network stats history.
+//
+// The JSON encoding of the types of this package is stable: fields are only
+// ever added, and existing fields are never renamed, removed or given a
+// different meaning or type. Changes which can not follow these rules are
+// made in a new version of the package, and the existing versions keep being
+// supported.
+package v1
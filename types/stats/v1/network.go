@@ -0,0 +1,57 @@
+package v1
+
+import "time"
+
+// Version is the version of the statistics of this package.
+const Version = "v1"
+
+// NetworkInterface holds the counters of a container network interface, as
+// seen from the container.
+//
+// The JSON keys are those of the unversioned types.NetworkInterface, which
+// had no struct tags, so the encoding is unchanged.
+type NetworkInterface struct {
+	// Name is the name of the network interface.
+	Name string `json:"Name"`
+
+	RxBytes   uint64 `json:"RxBytes"`
+	RxPackets uint64 `json:"RxPackets"`
+	RxErrors  uint64 `json:"RxErrors"`
+	RxDropped uint64 `json:"RxDropped"`
+	TxBytes   uint64 `json:"TxBytes"`
+	TxPackets uint64 `json:"TxPackets"`
+	TxErrors  uint64 `json:"TxErrors"`
+	TxDropped uint64 `json:"TxDropped"`
+
+	// Supported is the set of counters collected for the interface, the
+	// others being zero as the driver or the stats backend does not
+	// provide them. It is not set by versions of runc older than its
+	// introduction.
+	Supported Counter `json:"Supported,omitempty"`
+}
+
+// Counter identifies a counter of NetworkInterface, as a bit of its
+// Supported set.
+type Counter uint32
+
+const (
+	CounterRxBytes Counter = 1 << iota
+	CounterRxPackets
+	CounterRxErrors
+	CounterRxDropped
+	CounterTxBytes
+	CounterTxPackets
+	CounterTxErrors
+	CounterTxDropped
+
+	// AllCounters is the set of all the counters.
+	AllCounters = CounterRxBytes | CounterRxPackets | CounterRxErrors | CounterRxDropped |
+		CounterTxBytes | CounterTxPackets | CounterTxErrors | CounterTxDropped
+)
+
+// NetworkStatsSample holds the counters of the container interfaces, as
+// seen from inside the container, at a given time.
+type NetworkStatsSample struct {
+	Time       time.Time           `json:"time"`
+	Interfaces []*NetworkInterface `json:"interfaces"`
+}
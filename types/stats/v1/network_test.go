@@ -0,0 +1,39 @@
+package v1
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// TestNetworkStatsEncoding checks the JSON encoding of the statistics is
+// unchanged, as collectors depend on it.
+func TestNetworkStatsEncoding(t *testing.T) {
+	sample := &NetworkStatsSample{
+		Time: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Interfaces: []*NetworkInterface{{
+			Name:    "eth0",
+			RxBytes: 1, RxPackets: 2, RxErrors: 3, RxDropped: 4,
+			TxBytes: 5, TxPackets: 6, TxErrors: 7, TxDropped: 8,
+		}},
+	}
+	data, err := json.Marshal(sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const expected = `{"time":"2024-01-02T03:04:05Z","interfaces":[{"Name":"eth0","RxBytes":1,"RxPackets":2,"RxErrors":3,"RxDropped":4,"TxBytes":5,"TxPackets":6,"TxErrors":7,"TxDropped":8}]}`
+	if string(data) != expected {
+		t.Errorf("unexpected encoding:\n got: %s\nwant: %s", data, expected)
+	}
+}
+
+func TestNetworkInterfaceSupported(t *testing.T) {
+	data, err := json.Marshal(&NetworkInterface{Name: "eth0", Supported: CounterRxBytes | CounterTxBytes})
+	if err != nil {
+		t.Fatal(err)
+	}
+	const expected = `{"Name":"eth0","RxBytes":0,"RxPackets":0,"RxErrors":0,"RxDropped":0,"TxBytes":0,"TxPackets":0,"TxErrors":0,"TxDropped":0,"Supported":17}`
+	if string(data) != expected {
+		t.Errorf("unexpected encoding:\n got: %s\nwant: %s", data, expected)
+	}
+}
@@ -136,6 +136,10 @@ other options are ignored.
 			Name:  "mem-bw-schema",
 			Usage: "The string of Intel RDT/MBA memory bandwidth schema",
 		},
+		cli.BoolFlag{
+			Name:  "diff",
+			Usage: "print the changes made to the network devices of the container",
+		},
 	},
 	Action: func(context *cli.Context) error {
 		if err := checkArgs(context, 1, exactArgs); err != nil {
@@ -360,6 +364,8 @@ other options are ignored.
 		// Note this field is not saved into container's state.json.
 		config.Cgroups.SkipDevices = true
 
-		return container.Set(config)
+		return reportNetworkChanges(context, []string{container.ID()}, func() error {
+			return container.Set(config)
+		})
 	},
 }
@@ -1,12 +1,16 @@
 package main
 
 import (
+	gocontext "context"
 	"errors"
 	"fmt"
+	"math"
 	"net"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/coreos/go-systemd/v22/activation"
 	"github.com/opencontainers/runtime-spec/specs-go"
@@ -146,6 +150,11 @@ func createPidFile(path string, process *libcontainer.Process) error {
 	if err != nil {
 		return err
 	}
+	return writePidFile(path, pid)
+}
+
+// writePidFile atomically writes pid into the file at path.
+func writePidFile(path string, pid int) error {
 	var (
 		tmpDir  = filepath.Dir(path)
 		tmpName = filepath.Join(tmpDir, "."+filepath.Base(path))
@@ -162,28 +171,171 @@ func createPidFile(path string, process *libcontainer.Process) error {
 	return os.Rename(tmpName, path)
 }
 
+// runUntilSignal calls fn with a context which is cancelled once runc
+// receives SIGINT or SIGTERM.
+func runUntilSignal(fn func(gocontext.Context) error) error {
+	ctx, stop := signal.NotifyContext(gocontext.Background(), unix.SIGINT, unix.SIGTERM)
+	defer stop()
+	return fn(ctx)
+}
+
 func createContainer(context *cli.Context, id string, spec *specs.Spec) (*libcontainer.Container, error) {
 	rootlessCg, err := shouldUseRootlessCgroupManager(context)
 	if err != nil {
 		return nil, err
 	}
+	var sysctlDefaults map[string]string
+	if path := context.GlobalString("netns-sysctl-defaults"); path != "" {
+		if sysctlDefaults, err = specconv.ReadNetworkSysctlDefaults(path); err != nil {
+			return nil, fmt.Errorf("unable to read network sysctl defaults: %w", err)
+		}
+	}
 	config, err := specconv.CreateLibcontainerConfig(&specconv.CreateOpts{
-		CgroupName:       id,
-		UseSystemdCgroup: context.GlobalBool("systemd-cgroup"),
-		NoPivotRoot:      context.Bool("no-pivot"),
-		NoNewKeyring:     context.Bool("no-new-keyring"),
-		Spec:             spec,
-		RootlessEUID:     os.Geteuid() != 0,
-		RootlessCgroups:  rootlessCg,
+		CgroupName:            id,
+		UseSystemdCgroup:      context.GlobalBool("systemd-cgroup"),
+		NoPivotRoot:           context.Bool("no-pivot"),
+		NoNewKeyring:          context.Bool("no-new-keyring"),
+		Spec:                  spec,
+		RootlessEUID:          os.Geteuid() != 0,
+		RootlessCgroups:       rootlessCg,
+		NetworkSysctlDefaults: sysctlDefaults,
 	})
 	if err != nil {
 		return nil, err
 	}
+	if value := context.GlobalString("netlink-rate-limit"); value != "" {
+		limit, err := parseRateLimit(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid netlink rate limit: %w", err)
+		}
+		if config.NetworkOptions == nil {
+			config.NetworkOptions = &configs.NetworkOptions{}
+		}
+		if config.NetworkOptions.NetlinkRateLimit == nil {
+			config.NetworkOptions.NetlinkRateLimit = limit
+		}
+	}
+	if err := applyNetDevices(config, context.StringSlice("netdev")); err != nil {
+		return nil, err
+	}
+	if context.Bool("netdev-seccomp") {
+		specconv.AddNetworkSeccompRules(config)
+	}
 
 	root := context.GlobalString("root")
 	return libcontainer.Create(root, id, config)
 }
 
+// applyNetDevices adds the network devices given with --netdev to config. A
+// device named like a configured network overrides the given settings of it.
+func applyNetDevices(config *configs.Config, values []string) error {
+	for _, value := range values {
+		n := &configs.Network{}
+		if err := parseNetDevice(n, value); err != nil {
+			return fmt.Errorf("invalid network device %q: %w", value, err)
+		}
+		if n.Name == "" {
+			return fmt.Errorf("invalid network device %q: name is required", value)
+		}
+		found := false
+		for _, c := range config.Networks {
+			if c.Name == n.Name {
+				// Only the given settings are changed.
+				if err := parseNetDevice(c, value); err != nil {
+					return err
+				}
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+		if n.Type == "" {
+			return fmt.Errorf("invalid network device %q: type is required for a new device", value)
+		}
+		config.Networks = append(config.Networks, n)
+	}
+	return nil
+}
+
+// parseNetDevice sets the settings of n given as comma separated KEY=VALUE
+// pairs. The routes given replace the static routes of n.
+func parseNetDevice(n *configs.Network, value string) error {
+	var routes []*configs.NetworkRoute
+	for _, pair := range strings.Split(value, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok || v == "" {
+			return fmt.Errorf("expected KEY=VALUE, got %q", pair)
+		}
+		switch k {
+		case "name":
+			n.Name = v
+		case "type":
+			n.Type = v
+		case "ip":
+			n.Address = v
+		case "ip6":
+			n.IPv6Address = v
+		case "gateway":
+			n.Gateway = v
+		case "gateway6":
+			n.IPv6Gateway = v
+		case "metric":
+			metric, err := strconv.Atoi(v)
+			if err != nil || metric < 0 {
+				return fmt.Errorf("invalid metric %q", v)
+			}
+			n.GatewayMetric = metric
+		case "mac":
+			n.MacAddress = v
+		case "mtu":
+			mtu, err := strconv.Atoi(v)
+			if err != nil || mtu <= 0 {
+				return fmt.Errorf("invalid mtu %q", v)
+			}
+			n.Mtu = mtu
+		case "host":
+			n.HostInterfaceName = v
+		case "parent":
+			n.Parent = v
+		case "optional":
+			optional, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("invalid optional %q", v)
+			}
+			n.Optional = optional
+		case "route":
+			dst, gw, _ := strings.Cut(v, "@")
+			routes = append(routes, &configs.NetworkRoute{Destination: dst, Gateway: gw})
+		default:
+			return fmt.Errorf("unknown key %q", k)
+		}
+	}
+	if routes != nil {
+		n.StaticRoutes = routes
+	}
+	return nil
+}
+
+// parseRateLimit parses a rate limit given as RATE[:BURST], RATE being a
+// number of requests per second. The burst defaults to the rate, rounded up.
+func parseRateLimit(value string) (*configs.RateLimit, error) {
+	rate, burst, hasBurst := strings.Cut(value, ":")
+	limit := &configs.RateLimit{}
+	var err error
+	if limit.Rate, err = strconv.ParseFloat(rate, 64); err != nil || limit.Rate <= 0 || math.IsInf(limit.Rate, 0) {
+		return nil, fmt.Errorf("invalid rate %q", rate)
+	}
+	limit.Burst = int(math.Ceil(limit.Rate))
+	if hasBurst {
+		if limit.Burst, err = strconv.Atoi(burst); err != nil || limit.Burst < 1 {
+			return nil, fmt.Errorf("invalid burst %q", burst)
+		}
+	}
+	return limit, nil
+}
+
 type runner struct {
 	init            bool
 	enableSubreaper bool
@@ -284,7 +436,19 @@ func (r *runner) run(config *specs.Process) (int, error) {
 			return -1, err
 		}
 	}
+	// The network monitors only run while runc is attached to the container.
+	stopSampling, stopLLDP, stopRenewing, stopQuotas := func() {}, func() {}, func() {}, func() {}
+	if !detach {
+		stopSampling = r.sampleNetworkStats()
+		stopLLDP = r.announceLLDP()
+		stopRenewing = r.renewDeviceClaims()
+		stopQuotas = r.enforceNetworkQuotas()
+	}
 	status, err := handler.forward(process, tty, detach)
+	stopSampling()
+	stopLLDP()
+	stopRenewing()
+	stopQuotas()
 	if err != nil {
 		r.terminate(process)
 	}
@@ -297,6 +461,73 @@ func (r *runner) run(config *specs.Process) (int, error) {
 	return status, err
 }
 
+// sampleNetworkStats records the network stats history of the container, if
+// enabled in its configuration, until the returned function is called.
+func (r *runner) sampleNetworkStats() func() {
+	opts := r.container.Config().NetworkOptions
+	if opts == nil || opts.StatsHistory == nil {
+		return func() {}
+	}
+	return runInBackground(r.container.SampleNetworkStats, "unable to sample network stats")
+}
+
+// runInBackground runs fn until the returned function is called, which
+// cancels the context of fn and waits for it to return. The error returned
+// by fn is logged with msg.
+func runInBackground(fn func(gocontext.Context) error, msg string) func() {
+	ctx, cancel := gocontext.WithCancel(gocontext.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := fn(ctx); err != nil {
+			logrus.WithError(err).Warn(msg)
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// announceLLDP announces the container with LLDP on the networks with LLDP
+// settings, if any, until the returned function is called.
+func (r *runner) announceLLDP() func() {
+	enabled := false
+	for _, n := range r.container.Config().Networks {
+		enabled = enabled || n.LLDP != nil
+	}
+	if !enabled {
+		return func() {}
+	}
+	return runInBackground(r.container.AnnounceLLDP, "unable to announce the container with lldp")
+}
+
+// renewDeviceClaims renews the claims of the container on its network
+// devices, if any has a ttl, until the returned function is called.
+func (r *runner) renewDeviceClaims() func() {
+	enabled := false
+	for _, n := range r.container.Config().Networks {
+		enabled = enabled || (n.SRIOV != nil && n.SRIOV.ClaimTTL != 0)
+	}
+	if !enabled {
+		return func() {}
+	}
+	return runInBackground(r.container.RenewDeviceClaims, "unable to renew the network device claims")
+}
+
+// enforceNetworkQuotas checks the quotas of the network devices of the
+// container, if any has one, until the returned function is called.
+func (r *runner) enforceNetworkQuotas() func() {
+	enabled := false
+	for _, n := range r.container.Config().Networks {
+		enabled = enabled || n.Quota != nil
+	}
+	if !enabled {
+		return func() {}
+	}
+	return runInBackground(r.container.EnforceNetworkQuotas, "unable to enforce the network quotas")
+}
+
 func (r *runner) destroy() {
 	if r.shouldDestroy {
 		if err := r.container.Destroy(); err != nil {
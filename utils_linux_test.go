@@ -0,0 +1,75 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	for _, tc := range []struct {
+		value string
+		rate  float64
+		burst int
+	}{
+		{value: "50", rate: 50, burst: 50},
+		{value: "2.5", rate: 2.5, burst: 3},
+		{value: "10:100", rate: 10, burst: 100},
+	} {
+		limit, err := parseRateLimit(tc.value)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.value, err)
+			continue
+		}
+		if limit.Rate != tc.rate || limit.Burst != tc.burst {
+			t.Errorf("%s: expected %v:%d, got %v:%d", tc.value, tc.rate, tc.burst, limit.Rate, limit.Burst)
+		}
+	}
+	for _, value := range []string{"", "0", "-1", "fast", "10:0", "10:"} {
+		if _, err := parseRateLimit(value); err == nil {
+			t.Errorf("%q: expected error, got nil", value)
+		}
+	}
+}
+
+func TestApplyNetDevices(t *testing.T) {
+	config := &configs.Config{Networks: []*configs.Network{
+		{Type: "loopback"},
+		{Type: "sriov", Name: "eth0", Parent: "ens1f0", Address: "10.0.0.2/24"},
+	}}
+	err := applyNetDevices(config, []string{
+		"name=eth0,ip=10.0.1.2/24,mtu=9000,metric=50",
+		"name=eth1,type=vdpa,ip6=fd00::2/64,optional=true,route=fd01::/64@fd00::1,route=fd02::/64",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(config.Networks) != 3 {
+		t.Fatalf("expected 3 networks, got %d", len(config.Networks))
+	}
+	eth0 := config.Networks[1]
+	if eth0.Type != "sriov" || eth0.Parent != "ens1f0" || eth0.Address != "10.0.1.2/24" || eth0.Mtu != 9000 || eth0.GatewayMetric != 50 {
+		t.Errorf("unexpected overridden network: %+v", eth0)
+	}
+	eth1 := config.Networks[2]
+	if eth1.Name != "eth1" || eth1.Type != "vdpa" || eth1.IPv6Address != "fd00::2/64" || !eth1.Optional {
+		t.Errorf("unexpected added network: %+v", eth1)
+	}
+	if r := eth1.StaticRoutes; len(r) != 2 || r[0].Destination != "fd01::/64" || r[0].Gateway != "fd00::1" || r[1].Destination != "fd02::/64" || r[1].Gateway != "" {
+		t.Errorf("unexpected static routes: %+v", r)
+	}
+
+	for _, value := range []string{
+		"type=vdpa",
+		"name=eth2",
+		"name=eth2,type=vdpa,mtu=0",
+		"name=eth2,type=vdpa,metric=-1",
+		"name=eth2,type=vdpa,color=blue",
+		"name=eth2,type=vdpa,optional=maybe",
+		"name=eth2,type",
+	} {
+		if err := applyNetDevices(&configs.Config{}, []string{value}); err == nil {
+			t.Errorf("%q: expected error, got nil", value)
+		}
+	}
+}